@@ -31,6 +31,7 @@ import (
 	"github.com/inhere/kite-go/internal/cli/syscmd"
 	"github.com/inhere/kite-go/internal/cli/textcmd"
 	"github.com/inhere/kite-go/internal/cli/toolcmd"
+	"github.com/inhere/kite-go/internal/cli/x"
 	"github.com/inhere/kite-go/pkg/kiteext"
 	"github.com/inhere/kite-go/pkg/util/pacutil"
 )
@@ -60,7 +61,7 @@ func addCommands(cli *gcli.App) {
 		// extcmd.UserExtCmd,
 		textcmd.TextToolCmd,
 		jsoncmd.JSONToolCmd,
-		// extcmd.XFileCmd,
+		x.XFileCmd,
 		toolcmd.ToolsCmd,
 		toolcmd.RunAnyCmd,
 		// extcmd.PlugCmd,
@@ -2,13 +2,16 @@ package devcmd
 
 import (
 	"github.com/gookit/gcli/v3"
+	"github.com/gookit/goutil/fsutil"
 	"github.com/inhere/kite-go/pkg/proxysrv"
 )
 
 type pSrvOptions struct {
-	Port   int    `flag:"desc=proxy server port;default=8080"`
-	Rules  string `flag:"desc=proxy rules file path;default=proxy-rules.txt"`
-	Config string `flag:"desc=proxy config file path;default=.dev-proxy.yml"`
+	Port   int    `flag:"desc=proxy server port, default is 8090 or port in config file;shorts=P"`
+	Host   string `flag:"desc=proxy server host, default is 127.0.0.1"`
+	Rules  string `flag:"desc=proxy rules file path, will ignore on not exists;default=proxy-rules.txt"`
+	Config string `flag:"desc=proxy config file path, will ignore on not exists;default=.dev-proxy.yml;shorts=c"`
+	Quiet  bool   `flag:"desc=quiet mode, dont print request logs;shorts=q"`
 }
 
 // NewProxyServerCmd create a new command.
@@ -17,14 +20,59 @@ func NewProxyServerCmd() *gcli.Command {
 
 	return &gcli.Command{
 		Name:    "proxy-server",
-		Desc:    "Start a http proxy server for development",
+		Desc:    "Start a http proxy server for development, support whistle-style rules",
 		Aliases: []string{"proxy-s", "proxy-srv"},
 		Config: func(c *gcli.Command) {
 			c.MustFromStruct(&psOpts)
+			c.AddArg("rules", "inline proxy rules, format: 'pattern operation [operation ...]'", false, true)
 		},
+		Help: `
+<info>Rule Syntax</>:
+  # one rule per line, lines start with # are comments
+  pattern operation [operation ...]
+
+<info>Patterns</>: api.example.com, api.example.com:8080/v1, *.example.com, /v1/users, https://api.example.com
+<info>Operations</>:
+  127.0.0.1:8080, host://127.0.0.1    map the request to the host
+  http://127.0.0.1:8080/api           forward request to the URL
+  file://./mock.json, ./mocks         response local file, or file in the dir
+  statusCode://404                    response the status code directly
+  replaceStatus://500                 replace the upstream response status
+  redirect://https://example.com      response 302 redirect
+  reqHeaders://X-Env=dev&X-Debug=1    set request headers
+  resHeaders://{"X-Mock":"1"}         set response headers
+  reqDelay://1000, resDelay://1000    delay milliseconds
+
+<info>Config</> file(.dev-proxy.yml):
+  port: 8090
+  rules: ["api.example.com 127.0.0.1:8080"]
+  rule_files: [proxy-rules.txt]
+  rule_dirs: [proxy-rules]
+  global_vars: {local: "127.0.0.1:8080"} # use ${local} in rules
+
+<info>Examples</>:
+  {$fullCmd} 'api.example.com 127.0.0.1:8080' '/v1/users file://./mock.json'
+  {$fullCmd} -c .dev-proxy.yml
+`,
 		Func: func(c *gcli.Command, args []string) error {
 			ps := proxysrv.NewProxySrv()
-			// TODO
+			if fsutil.IsFile(psOpts.Config) {
+				if err := ps.LoadConfigFile(psOpts.Config); err != nil {
+					return err
+				}
+			}
+			if fsutil.IsFile(psOpts.Rules) {
+				ps.RuleFiles = append(ps.RuleFiles, psOpts.Rules)
+			}
+			ps.Config.Rules = append(ps.Config.Rules, c.Arg("rules").Strings()...)
+
+			if psOpts.Port > 0 {
+				ps.Port = psOpts.Port
+			}
+			if psOpts.Host != "" {
+				ps.Host = psOpts.Host
+			}
+			ps.Quiet = ps.Quiet || psOpts.Quiet
 			return ps.Start()
 		},
 	}
@@ -1,6 +1,12 @@
 package extcmd
 
-import "github.com/gookit/gcli/v3"
+import (
+	"github.com/gookit/cliui/show"
+	"github.com/gookit/gcli/v3"
+	"github.com/gookit/goutil/errorx"
+	"github.com/inhere/kite-go/internal/app"
+	"github.com/inhere/kite-go/pkg/kscript"
+)
 
 // TaskManageCmd instance
 var TaskManageCmd = &gcli.Command{
@@ -28,7 +34,34 @@ var TaskList = &gcli.Command{
 
 var TaskInfo = &gcli.Command{
 	Name: "info",
-	Desc: "show an Task information",
+	Desc: "show an Task information, includes the resolved vars",
+	Config: func(c *gcli.Command) {
+		c.AddArg("name", "the script task name", true)
+	},
+	Func: func(c *gcli.Command, _ []string) error {
+		if err := app.Scripts.InitLoad(); err != nil {
+			return err
+		}
+
+		name := c.Arg("name").String()
+		st, err := app.Scripts.LoadScriptTaskInfo(name)
+		if err != nil {
+			return err
+		}
+		if st == nil {
+			return errorx.Rawf("script task %q is not exists", name)
+		}
+
+		show.AList("Script Task Info", st)
+		if len(st.Vars) == 0 {
+			return nil
+		}
+
+		// resolve dynamic vars, show the values will be used on run.
+		vars, err := app.Scripts.ResolveTaskVars(st, &kscript.RunCtx{})
+		show.AList("Task Vars", vars)
+		return err
+	},
 }
 
 var TaskRun = &gcli.Command{
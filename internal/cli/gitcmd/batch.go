@@ -1,11 +1,22 @@
 package gitcmd
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/gookit/color/colorp"
 	"github.com/gookit/gcli/v3"
-	"github.com/gookit/goutil/dump"
+	"github.com/gookit/gcli/v3/gflag"
+	"github.com/gookit/goutil/arrutil"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/inhere/kite-go/internal/app"
 	"github.com/inhere/kite-go/internal/biz/cmdbiz"
+	"github.com/inhere/kite-go/pkg/gitx"
 )
 
 // NewBatchCmd command
@@ -13,7 +24,7 @@ func NewBatchCmd() *gcli.Command {
 	return &gcli.Command{
 		Name:    "batch",
 		Aliases: []string{"bat"},
-		Desc:    "provide some useful dev tools commands",
+		Desc:    "batch run git or shell commands in multi git repositories",
 		Subs: []*gcli.Command{
 			NewBatchRunCmd(),
 			NewBatchPullCmd(),
@@ -24,63 +35,219 @@ func NewBatchCmd() *gcli.Command {
 	}
 }
 
-var btrOpts = struct {
+// batchOpts common options for batch run and pull
+type batchOpts struct {
 	cmdbiz.CommonOpts
-	pDir string
-}{}
+	// name of the batch command. eg: run, pull
+	name    string
+	baseDir string
+	dirs    gcli.String
+	depth   int
+	// workspace name in config git.workspaces
+	workspace   string
+	concurrency int
+	onlyFailed  bool
+}
+
+func (o *batchOpts) bindFlags(c *gcli.Command) {
+	o.BindCommonFlags(c)
+
+	c.StrOpt2(&o.workspace, "workspace, ws", "use the named repo dir list in config <cyan>git.workspaces</>")
+	c.VarOpt(&o.dirs, "dirs", "", "limit run in the given repo dir names, multi by comma")
+	c.IntOpt2(&o.depth, "depth", "max depth for find git repos in the base dir", gflag.WithDefault(gitx.DefaultBatchDepth))
+	c.IntOpt2(&o.concurrency, "concurrency, c", "max number of repos to run at the same time", gflag.WithDefault(4))
+	c.BoolOpt2(&o.onlyFailed, "only-failed, failed", "only run in the failed repos of last batch run")
+}
+
+// failedFile for record failed repo dirs of last batch run, keyed by the command name
+func (o *batchOpts) failedFile() string {
+	return app.App().TmpPath("git-batch-" + o.name + "-failed.json")
+}
+
+// resolveDirs find the repo dirs for batch run
+func (o *batchOpts) resolveDirs() ([]string, error) {
+	if o.onlyFailed {
+		dirs, err := gitx.LoadFailedDirs(o.failedFile())
+		if err != nil {
+			return nil, err
+		}
+		if len(dirs) == 0 {
+			return nil, errorx.Raw("not found failed repos of the last batch run")
+		}
+		return dirs, nil
+	}
+
+	var repos []string
+	if o.workspace != "" {
+		dirs, ok := app.Gitx().WorkspaceDirs(o.workspace)
+		if !ok {
+			return nil, errorx.Rawf("the workspace %q is not found in config git.workspaces", o.workspace)
+		}
+
+		for _, dir := range dirs {
+			dir = fsutil.ExpandPath(dir)
+			if !gitx.IsRepoDir(dir) {
+				colorp.Warnf("skip the workspace dir %q, it is not a git repository\n", dir)
+				continue
+			}
+			repos = append(repos, dir)
+		}
+	} else {
+		baseDir, err := filepath.Abs(o.baseDir)
+		if err != nil {
+			return nil, err
+		}
+		if repos, err = gitx.FindRepos(baseDir, o.depth); err != nil {
+			return nil, err
+		}
+	}
+
+	// limit by dir names
+	if names := o.dirs.Split(","); len(names) > 0 {
+		repos = arrutil.Filter(repos, func(dir string) bool {
+			return arrutil.StringsHas(names, filepath.Base(dir))
+		})
+	}
+	return repos, nil
+}
+
+// run the git sub-command(or shell command) in the repos and print summary
+func (o *batchOpts) run(args []string, shell bool) error {
+	dirs, err := o.resolveDirs()
+	if err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		colorp.Infoln("Not found any git repository for batch run")
+		return nil
+	}
+
+	colorp.Infof("Will run %q in %d repositories(concurrency: %d)\n", strings.Join(args, " "), len(dirs), o.concurrency)
+	start := time.Now()
+
+	gbr := gitx.NewBatchRun(func(gbr *gitx.GitBatchRun) {
+		gbr.Dirs = dirs
+		gbr.ShellMode = shell
+		gbr.DryRun = o.DryRun
+		gbr.WithStatus = true
+		gbr.Concurrency = o.concurrency
+		gbr.DoneFn = printRepoResult
+	})
+
+	results, err := gbr.Run(args...)
+	if err != nil {
+		return err
+	}
+
+	// record failed repos for retry by --only-failed
+	failed := gitx.FailedDirs(results)
+	if !o.DryRun {
+		if err := gitx.SaveFailedDirs(o.failedFile(), failed); err != nil {
+			colorp.Warnf("save failed repos error: %s\n", err.Error())
+		}
+	}
+
+	printBatchSummary(results)
+	if len(failed) > 0 {
+		return errorx.Rawf("%d of %d repositories run failed, retry them by --only-failed", len(failed), len(results))
+	}
+
+	colorp.Successf("All done, total %d repositories, cost %s\n", len(results), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// printRepoResult print output grouped by repo
+func printRepoResult(r *gitx.RepoResult) {
+	if r.Failed() {
+		colorp.Redf("==> %s (%s) FAILED\n", r.Name, r.Dir)
+	} else {
+		colorp.Cyanf("==> %s (%s)\n", r.Name, r.Dir)
+	}
+
+	if out := strings.TrimSpace(r.Output); out != "" {
+		fmt.Println(out)
+	}
+	if r.Failed() {
+		colorp.Redln("ERROR:", r.Error.Error())
+	}
+	fmt.Println()
+}
+
+func printBatchSummary(results []*gitx.RepoResult) {
+	colorp.Infoln("Summary:")
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "REPO\tBRANCH\tAHEAD/BEHIND\tDIRTY\tRESULT\tCOST")
+	for _, r := range results {
+		branch, ab, dirty := "-", "-", "-"
+		if st := r.Status; st != nil {
+			branch, ab = st.Branch, st.AheadBehind()
+			if st.Dirty {
+				dirty = "yes"
+			} else {
+				dirty = "no"
+			}
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Name, branch, ab, dirty, r.ResultText(), r.Cost.Round(time.Millisecond))
+	}
+	_ = tw.Flush()
+}
+
+var btrOpts = struct {
+	batchOpts
+	shell bool
+}{batchOpts: batchOpts{name: "run"}}
 
+// NewBatchRunCmd instance
 func NewBatchRunCmd() *gcli.Command {
 	return &gcli.Command{
 		Name:    "run",
-		Desc:    "batch run git command in the given dirs",
+		Desc:    "batch run git command(or shell command) in multi git repositories",
 		Aliases: []string{"exec"},
 		Config: func(c *gcli.Command) {
-			btrOpts.BindCommonFlags(c)
+			btrOpts.bindFlags(c)
+			c.StrOpt2(&btrOpts.baseDir, "base, b", "base directory for find git repositories, default is work dir", gflag.WithDefault("./"))
+			c.BoolOpt2(&btrOpts.shell, "shell, s", "run the arguments as shell command line, default run as git sub-command")
 
-			c.AddArg("dirs", "run command in the given dirs, if empty, run on all subdir")
+			c.AddArg("command", "the git sub-command and arguments, or shell command on --shell", true, true)
 		},
-		Func: func(c *gcli.Command, args []string) error {
-
-			return nil
+		Examples: `
+  {$fullCmd} status -sb
+  {$fullCmd} --ws services -- fetch --all --prune
+  {$fullCmd} --shell -- make test
+  {$fullCmd} --shell -- 'make test && echo ok'
+  {$fullCmd} --only-failed -- pull --rebase
+`,
+		Func: func(c *gcli.Command, _ []string) error {
+			return btrOpts.run(c.Arg("command").Strings(), btrOpts.shell)
 		},
 	}
 }
 
-var btpOpts = struct {
-	cmdbiz.CommonOpts
-	dirs gcli.String
-}{}
+var btpOpts = batchOpts{name: "pull"}
 
+// NewBatchPullCmd instance
 func NewBatchPullCmd() *gcli.Command {
 	return &gcli.Command{
 		Name:    "pull",
 		Desc:    "batch pull multi git directory by `git pull`",
 		Aliases: []string{"pul", "pl"},
 		Config: func(c *gcli.Command) {
+			btpOpts.bindFlags(c)
+
 			c.
 				AddArg("baseDir", "base directory for run batch pull, default is work dir").
 				WithValue("./")
-
-			c.VarOpt(&btpOpts.dirs, "dirs", "", "limit update the given dir names")
 		},
+		Examples: `
+  {$fullCmd} ~/workspace
+  {$fullCmd} --ws services -c 8
+  {$fullCmd} --only-failed
+`,
 		Func: func(c *gcli.Command, args []string) error {
-			baseDir := c.Arg("baseDir").String()
-			absDir, err := filepath.Abs(baseDir)
-			if err != nil {
-				return err
-			}
-
-			dirNames := btpOpts.dirs.Split(",")
-			// if len(dirNames) > 0 {
-			// 	for _, name := range dirNames {
-			// 		path := filepath.Join(absDir, name)
-			// 	}
-			// 	return nil
-			// }
-
-			dump.P(dirNames, baseDir, absDir)
-
-			return nil
+			btpOpts.baseDir = c.Arg("baseDir").String()
+			return btpOpts.run([]string{"pull"}, false)
 		},
 	}
 }
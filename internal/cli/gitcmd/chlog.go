@@ -1,24 +1,28 @@
 package gitcmd
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/gookit/color/colorp"
 	"github.com/gookit/gcli/v3"
-	"github.com/gookit/gitw"
-	"github.com/gookit/gitw/chlog"
-	"github.com/gookit/goutil/cflag"
-	"github.com/gookit/goutil/dump"
+	"github.com/gookit/goutil/arrutil"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/inhere/kite-go/internal/app"
+	"github.com/inhere/kite-go/pkg/gitx"
 )
 
 var chlogOpts = struct {
-	limit     cflag.String
 	sha1      string
 	sha2      string
 	style     string
+	version   string
 	repoUrl   string
 	dstFile   string
+	prepend   string
 	noMerges  bool
+	noAuthor  bool
 	unShallow bool
 	fetchTags bool
 }{}
@@ -27,12 +31,17 @@ var chlogOpts = struct {
 func NewChangelogCmd() *gcli.Command {
 	return &gcli.Command{
 		Name:    "chlog",
-		Desc:    "Generate changelog message for git repository",
+		Desc:    "Generate changelog message for git repository by conventional commits",
 		Aliases: []string{"cl", "clog", "changelog"},
+		Help: `
+Commits will parse by Conventional Commits(eg: "feat(scope)!: subject"), then group by
+the sections in config <cyan>git.changelog</>.
+`,
 		Examples: `
   {$binWithCmd} last head
   {$binWithCmd} last head --style gh-release --no-merges
-  {$binWithPath} v2.0.9 v2.0.10 --no-merges --style gh-release --exclude "cs-fixer,format codes"
+  {$binWithCmd} last head --version v2.1.0 --prepend CHANGELOG.md
+  {$binWithPath} v2.0.9 v2.0.10 --no-merges --style gh-release
 `,
 		Config: func(c *gcli.Command) {
 			c.AddArg("oldVersion", `The old version. eg: v1.0.2, 349238b
@@ -48,8 +57,9 @@ func NewChangelogCmd() *gcli.Command {
 					arg.Required = true
 				})
 
-			c.VarOpt(&chlogOpts.limit, "limit", "", "limit update the given dir names")
 			c.StrOpt2(&chlogOpts.dstFile, "file", "Export changelog message to the file, default dump to stdout")
+			c.StrOpt2(&chlogOpts.prepend, "prepend", "Insert the new version section to the changelog `FILE`. eg: CHANGELOG.md")
+			c.StrOpt2(&chlogOpts.version, "version", "The version title for changelog, default use the newVersion if it is a tag")
 			c.StrOpt2(&chlogOpts.repoUrl, "repo-url", `
 The git repo URL address. eg: https://github.com/inhere/kite
  default will auto use current git origin remote url
@@ -60,47 +70,99 @@ The style for generate for changelog.
 `)
 			c.BoolOpt2(&chlogOpts.fetchTags, "fetch-tags", "Update repo tags list by 'git fetch --tags'")
 			c.BoolOpt2(&chlogOpts.noMerges, "no-merges", "dont contains merge request logs")
+			c.BoolOpt2(&chlogOpts.noAuthor, "no-author", "dont credit authors for each commit")
 			c.BoolOpt2(&chlogOpts.unShallow, "unshallow", "Convert to a complete warehouse, useful on GitHub Action.")
 		},
 		Func: func(c *gcli.Command, args []string) error {
-			// baseDir := c.Arg("baseDir").String()
 			absDir, err := filepath.Abs(c.WorkDir())
 			if err != nil {
 				return err
 			}
 
-			repo := gitw.NewRepo(absDir)
-			colorp.Infoln("chlog options:")
-			dump.NoLoc(repo.DefaultRemoteInfo())
+			gx := app.Gitx()
+			gp := gx.LoadRepo(absDir)
+			if chlogOpts.unShallow {
+				if err := gp.Cmd("fetch", "--unshallow").Run(); err != nil {
+					return err
+				}
+			}
+			if chlogOpts.fetchTags {
+				if err := gp.Cmd("fetch", "--tags").Run(); err != nil {
+					return err
+				}
+			}
+
+			if chlogOpts.sha1, err = gitx.ResolveVersion(absDir, c.Arg("oldVersion").String()); err != nil {
+				return err
+			}
+			if chlogOpts.sha2, err = gitx.ResolveVersion(absDir, c.Arg("newVersion").String()); err != nil {
+				return err
+			}
+			if chlogOpts.sha2 == "" {
+				return c.NewErrf("not found the new version %q", c.Arg("newVersion").String())
+			}
+
+			cl := gitx.NewChangelog(gx.ChlogConfig())
+			cl.HostType = gx.HostType
+			cl.RepoURL = chlogOpts.repoUrl
+			if cl.RepoURL == "" && gp.HasDefaultRemote() {
+				rmt := gp.DefRemoteInfo()
+				cl.RepoURL = rmt.HTTPHost(gx.DisableHTTPS) + "/" + rmt.Path()
+			}
+			if chlogOpts.noAuthor {
+				cl.WithAuthor = false
+			}
 
-			cl := chlog.New()
-			cl.Formatter = &chlog.MarkdownFormatter{
-				RepoURL: "https://github.com/gookit/gitw",
+			cl.Version = chlogOpts.version
+			if cl.Version == "" && chlogOpts.sha2 != "HEAD" && isTagName(absDir, chlogOpts.sha2) {
+				cl.Version = chlogOpts.sha2
 			}
-			// with some settings ...
-			cl.WithConfigFn(func(c *chlog.Config) {
-				c.GroupPrefix = "\n### "
-				c.GroupSuffix = "\n"
-			})
 
-			chlogOpts.sha1 = c.Arg("oldVersion").String()
-			chlogOpts.sha2 = c.Arg("newVersion").String()
-			colorp.Infoln("chlog options:")
-			dump.NoLoc(chlogOpts)
+			colorp.Infof("Generate changelog for %s..%s, repo URL: %s\n", chlogOpts.sha1, chlogOpts.sha2, cl.RepoURL)
 
 			// fetch git log
-			cl.FetchGitLog(chlogOpts.sha1, chlogOpts.sha2, "--no-merges")
-			if cl.LogIsEmpty() {
+			if err := cl.FetchCommits(absDir, chlogOpts.sha1, chlogOpts.sha2, chlogOpts.noMerges); err != nil {
+				return err
+			}
+			if len(cl.Commits) == 0 {
 				colorp.Infoln("Not found change log in two hash version")
 				return nil
 			}
 
-			if err = cl.Generate(); err != nil {
-				return err
+			if chlogOpts.prepend != "" {
+				if err := cl.PrependToFile(chlogOpts.prepend); err != nil {
+					return err
+				}
+				colorp.Successf("OK, changelog of the version %q is prepend to %s\n", cl.Version, chlogOpts.prepend)
+				return nil
+			}
+
+			var text string
+			switch strings.ToLower(chlogOpts.style) {
+			case "simple":
+				text = cl.Text()
+			case "gh-release", "ghr":
+				text = cl.Markdown(false)
+			default: // markdown, md
+				text = cl.Markdown(true)
 			}
 
-			dump.P(cl.Changelog())
+			if chlogOpts.dstFile != "" {
+				if err := fsutil.WriteFile(chlogOpts.dstFile, text, fsutil.DefaultFilePerm); err != nil {
+					return err
+				}
+				colorp.Successf("OK, changelog is exported to %s\n", chlogOpts.dstFile)
+				return nil
+			}
+
+			fmt.Println(text)
 			return nil
 		},
 	}
 }
+
+// isTagName check the name is a tag in the repo
+func isTagName(repoDir, name string) bool {
+	tags, err := gitx.VersionTags(repoDir)
+	return err == nil && arrutil.StringsHas(tags, name)
+}
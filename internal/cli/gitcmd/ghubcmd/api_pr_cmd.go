@@ -0,0 +1,301 @@
+package ghubcmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gookit/cliui/show"
+	"github.com/gookit/color/colorp"
+	"github.com/gookit/gcli/v3"
+	"github.com/gookit/gitw/gitutil"
+	"github.com/gookit/goutil/strutil"
+	"github.com/inhere/kite-go/internal/app"
+	ghapi "github.com/inhere/kite-go/pkg/gitx/github"
+)
+
+// NewApiPrCmd groups github pull request api commands.
+func NewApiPrCmd() *gcli.Command {
+	return &gcli.Command{
+		Name:    "pr",
+		Desc:    "github pull request api commands",
+		Aliases: []string{"pull"},
+		Subs: []*gcli.Command{
+			NewApiPrListCmd(),
+			NewApiPrViewCmd(),
+			NewApiPrCommentCmd(),
+			NewApiPrChecksCmd(),
+			NewApiPrMergeCmd(),
+		},
+	}
+}
+
+// checkRepoPath check the repository path and github token
+func checkRepoPath(c *gcli.Command, repoPath string) (*ghapi.GitHub, error) {
+	if strings.TrimSpace(repoPath) == "" {
+		return nil, fmt.Errorf("the repository path is required, use --repo owner/repo")
+	}
+	if _, _, err := gitutil.SplitPath(repoPath); err != nil {
+		return nil, err
+	}
+
+	gh := app.Ghub()
+	if strutil.IsBlank(gh.Token) {
+		return nil, c.NewErr("github token is empty, please configure github.token or GITHUB_PA_TOKEN")
+	}
+	return gh, nil
+}
+
+// prNumber get the pull request number from argument
+func prNumber(c *gcli.Command) int {
+	return c.Arg("number").Int()
+}
+
+var apiPrListOpts = struct {
+	ghapi.PRListInput
+}{}
+
+// NewApiPrListCmd lists pull requests by github api.
+func NewApiPrListCmd() *gcli.Command {
+	return &gcli.Command{
+		Name:    "list",
+		Aliases: []string{"ls"},
+		Desc:    "list pull requests by github api",
+		Help: `
+# Examples:
+  {$fullCmd} -r owner/repo
+  {$fullCmd} -r owner/repo --state all --base main
+`,
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&apiPrListOpts.RepoPath, "repo, r", "repository path, format: owner/repo")
+			c.StrOpt2(&apiPrListOpts.State, "state, s", "filter by state, allow: open, closed, all")
+			c.StrOpt2(&apiPrListOpts.Base, "base, b", "filter by base branch name")
+			c.StrOpt2(&apiPrListOpts.Head, "head", "filter by head branch, format: owner:branch")
+			c.IntOpt2(&apiPrListOpts.Limit, "limit, l", "max pull requests count for listing")
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			gh, err := checkRepoPath(c, apiPrListOpts.RepoPath)
+			if err != nil {
+				return err
+			}
+
+			items, err := gh.ListPullRequests(apiPrListOpts.PRListInput)
+			if err != nil {
+				return err
+			}
+
+			if len(items) == 0 {
+				colorp.Infof("No pull requests found for the repository: %s\n", apiPrListOpts.RepoPath)
+				return nil
+			}
+
+			colorp.Infof("Pull requests for the repository %s:\n", apiPrListOpts.RepoPath)
+			for _, pr := range items {
+				draft := ""
+				if pr.Draft {
+					draft = " [draft]"
+				}
+				colorp.Cyanf("  #%-5d %s%s\n", pr.Number, pr.Title, draft)
+				fmt.Printf("         %s -> %s by %s\n", pr.Head.Label, pr.Base.Ref, pr.User.Login)
+			}
+			return nil
+		},
+	}
+}
+
+var apiPrViewOpts = struct {
+	RepoPath string
+}{}
+
+// NewApiPrViewCmd views pull request by github api.
+func NewApiPrViewCmd() *gcli.Command {
+	return &gcli.Command{
+		Name:    "view",
+		Aliases: []string{"get", "show"},
+		Desc:    "view pull request info by github api",
+		Help: `
+# Examples:
+  {$fullCmd} -r owner/repo 12
+`,
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&apiPrViewOpts.RepoPath, "repo, r", "repository path, format: owner/repo")
+			c.AddArg("number", "the pull request number", true)
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			gh, err := checkRepoPath(c, apiPrViewOpts.RepoPath)
+			if err != nil {
+				return err
+			}
+
+			pr, err := gh.GetPullRequest(apiPrViewOpts.RepoPath, prNumber(c))
+			if err != nil {
+				return err
+			}
+
+			labels := make([]string, 0, len(pr.Labels))
+			for _, label := range pr.Labels {
+				labels = append(labels, label.Name)
+			}
+
+			mergeable := "unknown"
+			if pr.Mergeable != nil {
+				mergeable = fmt.Sprint(*pr.Mergeable)
+			}
+
+			show.AList(fmt.Sprintf("pull request #%d", pr.Number), map[string]any{
+				"Title":     pr.Title,
+				"State":     pr.State,
+				"Draft":     pr.Draft,
+				"Author":    pr.User.Login,
+				"Head":      pr.Head.Label + " " + pr.Head.SHA,
+				"Base":      pr.Base.Ref,
+				"Labels":    strings.Join(labels, ","),
+				"Merged":    pr.Merged,
+				"Mergeable": mergeable + " " + pr.MergeableState,
+				"URL":       pr.HTMLURL,
+			})
+			return nil
+		},
+	}
+}
+
+var apiPrCommentOpts = struct {
+	RepoPath string
+	Message  string
+}{}
+
+// NewApiPrCommentCmd adds comment to pull request by github api.
+func NewApiPrCommentCmd() *gcli.Command {
+	return &gcli.Command{
+		Name: "comment",
+		Desc: "add comment to pull request by github api",
+		Help: `
+# Examples:
+  {$fullCmd} -r owner/repo -m "LGTM" 12
+`,
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&apiPrCommentOpts.RepoPath, "repo, r", "repository path, format: owner/repo")
+			c.StrOpt2(&apiPrCommentOpts.Message, "message, m", "the comment message")
+			c.AddArg("number", "the pull request number", true)
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			gh, err := checkRepoPath(c, apiPrCommentOpts.RepoPath)
+			if err != nil {
+				return err
+			}
+
+			comment, err := gh.CommentPullRequest(apiPrCommentOpts.RepoPath, prNumber(c), apiPrCommentOpts.Message)
+			if err != nil {
+				return err
+			}
+
+			colorp.Successf("Successful add comment: %s\n", comment.HTMLURL)
+			return nil
+		},
+	}
+}
+
+var apiPrChecksOpts = struct {
+	RepoPath string
+}{}
+
+// NewApiPrChecksCmd lists check runs status of pull request head.
+func NewApiPrChecksCmd() *gcli.Command {
+	return &gcli.Command{
+		Name:    "checks",
+		Aliases: []string{"check"},
+		Desc:    "list check runs status for the pull request head by github api",
+		Help: `
+# Examples:
+  {$fullCmd} -r owner/repo 12
+`,
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&apiPrChecksOpts.RepoPath, "repo, r", "repository path, format: owner/repo")
+			c.AddArg("number", "the pull request number", true)
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			gh, err := checkRepoPath(c, apiPrChecksOpts.RepoPath)
+			if err != nil {
+				return err
+			}
+
+			pr, err := gh.GetPullRequest(apiPrChecksOpts.RepoPath, prNumber(c))
+			if err != nil {
+				return err
+			}
+
+			list, err := gh.ListCheckRuns(apiPrChecksOpts.RepoPath, pr.Head.SHA)
+			if err != nil {
+				return err
+			}
+
+			colorp.Infof("Check runs for #%d head %s:\n", pr.Number, pr.Head.SHA)
+			for _, cr := range list.CheckRuns {
+				switch {
+				case !cr.Completed():
+					colorp.Yellowf("  ● %-30s %s\n", cr.Name, cr.Status)
+				case cr.Passed():
+					colorp.Greenf("  ✓ %-30s %s\n", cr.Name, cr.Conclusion)
+				default:
+					colorp.Redf("  ✗ %-30s %s\n", cr.Name, cr.Conclusion)
+				}
+			}
+			fmt.Printf("\nTotal: %d, pending: %d, failed: %d\n", list.TotalCount, list.Pending(), len(list.Failed()))
+			return nil
+		},
+	}
+}
+
+var apiPrMergeOpts = struct {
+	ghapi.PRMergeInput
+	noWait  bool
+	timeout string
+}{}
+
+// NewApiPrMergeCmd merges pull request by github api, will wait for check runs success.
+func NewApiPrMergeCmd() *gcli.Command {
+	return &gcli.Command{
+		Name: "merge",
+		Desc: "merge pull request by github api, will wait the check runs are success",
+		Help: `
+# Examples:
+  {$fullCmd} -r owner/repo 12
+  {$fullCmd} -r owner/repo --method squash --timeout 30m 12
+`,
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&apiPrMergeOpts.RepoPath, "repo, r", "repository path, format: owner/repo")
+			c.StrOpt2(&apiPrMergeOpts.Method, "method", "the merge method, allow: merge, squash, rebase")
+			c.StrOpt2(&apiPrMergeOpts.CommitTitle, "title", "custom the merge commit title")
+			c.BoolOpt2(&apiPrMergeOpts.noWait, "no-wait", "dont wait check runs, merge directly")
+			c.StrOpt2(&apiPrMergeOpts.timeout, "timeout", "timeout for wait check runs, default is 10m")
+			c.AddArg("number", "the pull request number", true)
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			gh, err := checkRepoPath(c, apiPrMergeOpts.RepoPath)
+			if err != nil {
+				return err
+			}
+
+			in := apiPrMergeOpts.PRMergeInput
+			in.Number = prNumber(c)
+			in.WaitChecks = !apiPrMergeOpts.noWait
+			if apiPrMergeOpts.timeout != "" {
+				if in.WaitTimeout, err = time.ParseDuration(apiPrMergeOpts.timeout); err != nil {
+					return err
+				}
+			}
+
+			if in.WaitChecks {
+				colorp.Infof("Waiting check runs of #%d are success ...\n", in.Number)
+			}
+
+			res, err := gh.MergePullRequest(in)
+			if err != nil {
+				return err
+			}
+
+			colorp.Successf("Successful merge pull request #%d: %s\n", in.Number, res.SHA)
+			return nil
+		},
+	}
+}
@@ -22,6 +22,7 @@ func NewApiCmd() *gcli.Command {
 		Subs: []*gcli.Command{
 			NewApiCommitCmd(),
 			NewApiTagCmd(),
+			NewApiPrCmd(),
 		},
 	}
 }
@@ -1,6 +1,8 @@
 package glabcmd
 
 import (
+	"strings"
+
 	"github.com/gookit/cliui/show"
 	"github.com/gookit/gcli/v3"
 	"github.com/gookit/gitw/gitutil"
@@ -22,6 +24,10 @@ var mrOpts = struct {
 	openBr string // same of target + openIt=true
 	// open link on browser
 	openIt bool
+	// create MR by gitlab api
+	api   bool
+	title string
+	desc  string
 	// auto search .git repo on parent dir
 	// findRepo bool
 }{}
@@ -39,6 +45,9 @@ func NewMergeRequestCmd() *gcli.Command {
 				"Open new PR page link on browser. eg: http://my.gitlab.com/group/repo/merge_requests/new",
 			)
 			c.BoolOpt2(&mrOpts.direct, "direct,d", "The PR is direct from fork to main repository")
+			c.BoolOpt2(&mrOpts.api, "api", "Create the PR by gitlab API and print the URL, require config gitlab.token")
+			c.StrOpt2(&mrOpts.title, "title", "The PR title on use --api, default is the last commit subject")
+			c.StrOpt2(&mrOpts.desc, "desc", "The PR description on use --api")
 			// c.BoolOpt2(&mrOpts.findRepo, "find-repo, find", "auto find repo .git dir on parent dirs")
 
 			c.StrOpt(&mrOpts.openBr, "open", "o", "Set target branch and open PR link on browser")
@@ -58,6 +67,7 @@ Special:
   {$binWithCmd} -o qa                 Will open PR link for main 'HEAD_BRANCH' to main 'qa' on browser
   {$binWithCmd} -t qa                 Will generate PR link for main 'HEAD_BRANCH' to main 'qa'
   {$binWithCmd} -t qa --direct        Will generate PR link for fork 'HEAD_BRANCH' to main 'qa'
+  {$binWithCmd} -t qa --direct --api  Will create PR for fork 'HEAD_BRANCH' to main 'qa' by gitlab API
   # Will generate PR link for 'group/repo', from 'dev' to 'qa' branch
   {binWithCmd} -o dev -t qa group/repo
 `,
@@ -140,6 +150,10 @@ func mergeRequestHandle(c *gcli.Command, _ []string) (err error) {
 
 	show.AList("Merge Request Info", mrInfo)
 
+	if mrOpts.api {
+		return createMRByApi(c, glp, mrInfo)
+	}
+
 	// link := glp.MargeRequestURL(mrInfo)
 	link := mrInfo.BuildURL(hostUrl)
 	c.Warnln("Merge Request Link:")
@@ -150,3 +164,43 @@ func mergeRequestHandle(c *gcli.Command, _ []string) (err error) {
 	}
 	return
 }
+
+// createMRByApi create the merge request by gitlab api v4
+func createMRByApi(c *gcli.Command, glp *gitlab.GlProject, mrInfo *gitlab.PRLinkQuery) error {
+	if strutil.IsBlank(glp.Token) {
+		return c.NewErr("gitlab token is empty, please configure gitlab.token or GITLAB_PA_TOKEN")
+	}
+
+	title := mrOpts.title
+	if title == "" && glp.IsGitRepo() {
+		title, _ = glp.GitLoc().Cmd("log", "-1", "--format=%s").Output()
+		title = strings.TrimSpace(title)
+	}
+
+	in := &gitlab.MRCreateInput{
+		SourceBranch: mrInfo.SourceBranch,
+		TargetBranch: mrInfo.TargetBranch,
+		Title:        strutil.OrElse(title, "Merge branch '"+mrInfo.SourceBranch+"' into '"+mrInfo.TargetBranch+"'"),
+		Description:  mrOpts.desc,
+	}
+
+	var err error
+	var mr *gitlab.MergeRequest
+	if mrInfo.SourceProjectId != "" && mrInfo.TargetProjectId == mrInfo.SourceProjectId {
+		// the project is given by argument
+		mr, err = glp.Api().CreateMR(mrInfo.SourceProjectId, in)
+	} else {
+		mr, err = glp.CreateMergeRequest(in, mrOpts.direct)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.Warnln("Merge Request Created:")
+	c.Println("  ", mr.WebURL)
+
+	if mrOpts.openIt {
+		return sysutil.OpenBrowser(mr.WebURL)
+	}
+	return nil
+}
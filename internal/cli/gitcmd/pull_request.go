@@ -12,6 +12,8 @@ import (
 	"github.com/gookit/goutil/sysutil"
 	"github.com/inhere/kite-go/internal/app"
 	"github.com/inhere/kite-go/internal/biz/cmdbiz"
+	"github.com/inhere/kite-go/pkg/gitx"
+	ghapi "github.com/inhere/kite-go/pkg/gitx/github"
 )
 
 var (
@@ -27,6 +29,14 @@ var (
 		openIt bool
 		// auto search .git repo on parent dir
 		// findRepo bool
+		// create PR by github api
+		api   bool
+		draft bool
+		title string
+		body  string
+		// reviewers and labels, multi split by comma
+		reviewers string
+		labels    string
 	}{}
 )
 
@@ -48,6 +58,13 @@ func NewPullRequestCmd() *gcli.Command {
 			c.StrOpt(&mrOpts.openBr, "open", "o", "Set target branch and open PR link on browser")
 			c.StrOpt(&mrOpts.source, "source", "s", "The source branch name, default is current `BRANCH`")
 			c.StrOpt(&mrOpts.target, "target", "t", "The target branch name, default is current `BRANCH`")
+
+			c.BoolOpt2(&mrOpts.api, "api", "Create the PR by github API and print the URL, require config github.token")
+			c.BoolOpt2(&mrOpts.draft, "draft", "Create as draft PR on use --api")
+			c.StrOpt2(&mrOpts.title, "title", "The PR title on use --api, default is the last commit subject")
+			c.StrOpt2(&mrOpts.body, "body", "The PR description body on use --api")
+			c.StrOpt2(&mrOpts.reviewers, "reviewers", "Request reviewers on use --api, multi split by comma")
+			c.StrOpt2(&mrOpts.labels, "labels", "Add labels on use --api, multi split by comma")
 			c.AddArg("repoPath", "The project name with path in self-host gitlab.\nif empty will fetch from workdir")
 		},
 		Help: `
@@ -62,6 +79,7 @@ Special:
   {$binWithCmd} -o qa                 Will open PR link for main 'HEAD_BRANCH' to main 'qa' on browser
   {$binWithCmd} -t qa                 Will generate PR link for main 'HEAD_BRANCH' to main 'qa'
   {$binWithCmd} -t qa --direct        Will generate PR link for fork 'HEAD_BRANCH' to main 'qa'
+  {$binWithCmd} -t qa --api --draft   Will create draft PR for fork 'HEAD_BRANCH' to main 'qa' by github API
   # Will generate PR link for 'group/repo', from 'dev' to 'qa' branch
   {$binWithCmd} -o dev -t qa group/repo
 `,
@@ -122,8 +140,13 @@ func mergeRequestHandle(c *gcli.Command, _ []string) (err error) {
 			mrOpts.target = mrOpts.source
 		}
 
-		dstPid = gp.SrcRemoteInfo().Path()
 		srcPid = gp.DefaultRemoteInfo().Path()
+		if gp.HasSourceRemote() {
+			dstPid = gp.SrcRemoteInfo().Path()
+		} else {
+			// not fork mode, PR into the default remote
+			dstPid = srcPid
+		}
 		if mrOpts.direct {
 			dstPid = gp.DefaultRemoteInfo().Path()
 		}
@@ -143,6 +166,10 @@ func mergeRequestHandle(c *gcli.Command, _ []string) (err error) {
 		"Into branch name":  mrOpts.target,
 	})
 
+	if mrOpts.api {
+		return createPRByApi(c, gp, srcPid, dstPid)
+	}
+
 	// http://git.your.com/GROUP/NAME/compare/TARGET_BRANCH...GROUP1/NAME:SOURCE_BRANCH
 	link := fmt.Sprintf(
 		"%s/%s/compare/%s...%s:%s",
@@ -156,3 +183,51 @@ func mergeRequestHandle(c *gcli.Command, _ []string) (err error) {
 	}
 	return
 }
+
+// createPRByApi create the pull request by github api
+func createPRByApi(c *gcli.Command, gp *gitx.GitLoc, srcPid, dstPid string) error {
+	gh := app.Ghub()
+	if strutil.IsBlank(gh.Token) {
+		return c.NewErr("github token is empty, please configure github.token or GITHUB_PA_TOKEN")
+	}
+
+	// cross-repository PR, head format: owner:branch
+	head := mrOpts.source
+	if srcPid != dstPid {
+		owner, _, err := gitutil.SplitPath(srcPid)
+		if err != nil {
+			return err
+		}
+		head = owner + ":" + head
+	}
+
+	title := mrOpts.title
+	if title == "" && gp.IsGitRepo() {
+		title, _ = gp.Cmd("log", "-1", "--format=%s").Output()
+		title = strings.TrimSpace(title)
+	}
+
+	pr, err := gh.CreatePullRequest(ghapi.PRCreateInput{
+		RepoPath:  dstPid,
+		Title:     strutil.OrElse(title, mrOpts.source),
+		Body:      mrOpts.body,
+		Head:      head,
+		Base:      mrOpts.target,
+		Draft:     mrOpts.draft,
+		Reviewers: strutil.Split(mrOpts.reviewers, ","),
+		Labels:    strutil.Split(mrOpts.labels, ","),
+	})
+	// PR maybe created, but request reviewers or add labels failed
+	if pr != nil && pr.HTMLURL != "" {
+		c.Warnln("Pull Request Created:")
+		c.Println("  ", pr.HTMLURL)
+	}
+	if err != nil {
+		return err
+	}
+
+	if mrOpts.openIt {
+		return sysutil.OpenBrowser(pr.HTMLURL)
+	}
+	return nil
+}
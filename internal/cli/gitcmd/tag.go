@@ -1,6 +1,7 @@
 package gitcmd
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/gookit/cliui/interact"
@@ -10,6 +11,11 @@ import (
 	"github.com/gookit/gcli/v3/gflag"
 	"github.com/gookit/gitw"
 	"github.com/gookit/gitw/gitutil"
+	"github.com/gookit/goutil/arrutil"
+	"github.com/gookit/goutil/strutil"
+	"github.com/inhere/kite-go/internal/app"
+	"github.com/inhere/kite-go/pkg/gitx"
+	ghapi "github.com/inhere/kite-go/pkg/gitx/github"
 )
 
 // NewTagCmd instance
@@ -21,6 +27,7 @@ func NewTagCmd() *gcli.Command {
 			NewTagListCmd(),
 			NewTagCreateCmd(),
 			NewTagDeleteCmd(),
+			NewTagBumpCmd(),
 		},
 	}
 }
@@ -204,3 +211,173 @@ func NewTagDeleteCmd() *gcli.Command {
 		},
 	}
 }
+
+var tagBumpOpts = struct {
+	PreId   string `flag:"the pre-release identifier for bump pre. eg: rc, beta;false;rc"`
+	NoPush  bool   `flag:"dont push the new tag to remote;false"`
+	Release bool   `flag:"create GitHub release for the new tag, require config github.token. cannot use with --no-push;false;;r"`
+	Draft   bool   `flag:"create the GitHub release as draft;false"`
+	Yes     bool   `flag:"dont confirm;false;;y"`
+}{}
+
+// NewTagBumpCmd instance
+func NewTagBumpCmd() *gcli.Command {
+	return &gcli.Command{
+		Name:    "bump",
+		Aliases: []string{"release"},
+		Desc:    "bump next semantic version, create annotated tag with changelog and push it",
+		Help: `
+Bump type:
+  major, minor, patch - bump the version part
+  pre                 - bump pre-release version. eg: v1.2.3 -> v1.2.4-rc.1, v1.2.4-rc.1 -> v1.2.4-rc.2
+  auto                - infer by conventional commits since latest tag. breaking: major, feat: minor, others: patch
+
+# Examples:
+  {$fullCmd}
+  {$fullCmd} minor --release
+  {$fullCmd} pre --pre-id beta --dry-run
+`,
+		Config: func(c *gcli.Command) {
+			c.MustFromStruct(&tagBumpOpts, gflag.TagRuleSimple)
+			c.AddArg("type", "the bump type, allow: major, minor, patch, pre, auto").WithValue("auto")
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			gx := app.Gitx()
+			gp := gx.LoadRepo(GitOpts.Workdir)
+			repoDir := strutil.OrElse(GitOpts.Workdir, c.WorkDir())
+
+			// check the release options before any side effect
+			var repoPath string
+			if tagBumpOpts.Release {
+				// GitHub will create the tag from default branch on it not pushed
+				if tagBumpOpts.NoPush {
+					return c.NewErr("the --release cannot be used with --no-push, the tag must be pushed before release")
+				}
+				if !gp.HasDefaultRemote() {
+					return c.NewErrf("the default remote %q is not exists", gx.DefaultRemote)
+				}
+				if strutil.IsBlank(app.Ghub().Token) {
+					return c.NewErr("github token is empty, please configure github.token or GITHUB_PA_TOKEN")
+				}
+				repoPath = gp.DefRemoteInfo().Path()
+			}
+
+			// fetch is read-only, also run on dry-run for get the latest tags
+			var steps []string
+			noPush := tagBumpOpts.NoPush
+			if gp.HasDefaultRemote() {
+				fetchArgs := []string{gx.DefaultRemote, "--tags"}
+				if err := gp.Cmd("fetch", fetchArgs...).Run(); err != nil {
+					colorp.Warnf("Fetch tags from remote %q error: %v, will use the local tags\n", gx.DefaultRemote, err)
+				} else {
+					steps = append(steps, "git fetch "+strings.Join(fetchArgs, " ")+" (done)")
+				}
+			} else {
+				colorp.Warnf("The default remote %q is not exists, skip fetch and push tags\n", gx.DefaultRemote)
+				noPush = true
+			}
+
+			tags, err := gitx.VersionTags(repoDir)
+			if err != nil {
+				return err
+			}
+
+			lastTag, lastVer := gitx.LatestSemverTag(tags)
+			if lastVer == nil {
+				lastVer = &gitx.SemVer{}
+				colorp.Warnln("Not found any semantic version tag, will bump from v0.0.0")
+			}
+
+			cl := gitx.NewChangelog(gx.ChlogConfig())
+			cl.HostType = gx.HostType
+			if gp.HasDefaultRemote() {
+				rmt := gp.DefRemoteInfo()
+				cl.RepoURL = rmt.HTTPHost(gx.DisableHTTPS) + "/" + rmt.Path()
+			}
+			if err := cl.FetchCommits(repoDir, lastTag, "HEAD", true); err != nil {
+				return err
+			}
+			if len(cl.Commits) == 0 {
+				return c.NewErrf("no new commits since the latest tag %s", lastTag)
+			}
+
+			bumpType := strings.ToLower(c.Arg("type").String())
+			if bumpType == "" || bumpType == "auto" {
+				bumpType = gitx.InferBump(cl.Commits)
+			}
+
+			nextVer, err := lastVer.Bump(bumpType, tagBumpOpts.PreId)
+			if err != nil {
+				return err
+			}
+
+			newTag := nextVer.String()
+			if arrutil.StringsHas(tags, newTag) {
+				return c.NewErrf("the tag %s already exists", newTag)
+			}
+
+			cl.Version = newTag
+			notes := cl.Markdown(false)
+			message := "release " + newTag + "\n\n" + notes
+
+			// show every step before run
+			steps = append(steps, fmt.Sprintf("git tag -a %s --cleanup=verbatim -m <changelog>", newTag))
+			if !noPush {
+				steps = append(steps, fmt.Sprintf("git push %s %s", gx.DefaultRemote, newTag))
+			}
+			if tagBumpOpts.Release {
+				steps = append(steps, fmt.Sprintf("create GitHub release %s for %s", newTag, repoPath))
+			}
+
+			show.AList("bump version", map[string]any{
+				"Latest tag": strutil.OrElse(lastTag, "-"),
+				"Bump type":  bumpType,
+				"New tag":    newTag,
+				"Commits":    len(cl.Commits),
+				"Dry Run":    GitOpts.DryRun,
+			})
+			colorp.Infoln("Changelog:")
+			fmt.Println(notes)
+			colorp.Infoln("Steps:")
+			for i, step := range steps {
+				colorp.Cyanf("  %d. %s\n", i+1, step)
+			}
+
+			if GitOpts.DryRun {
+				colorp.Warnln("\nDry run mode, the steps after fetch are not executed")
+				return nil
+			}
+
+			if !tagBumpOpts.Yes && interact.Unconfirmed("Ensure run the steps?", true) {
+				colorp.Infoln("Quit, Bye!")
+				return nil
+			}
+
+			// verbatim: keep the "### Features" lines, the default cleanup will strip "#" lines
+			if err := gp.Cmd("tag", "-a", newTag, "--cleanup=verbatim", "-m", message).Run(); err != nil {
+				return err
+			}
+			if !noPush {
+				if err := gp.Cmd("push", gx.DefaultRemote, newTag).Run(); err != nil {
+					return err
+				}
+			}
+			colorp.Successf("Successful create tag: %s\n", newTag)
+
+			if tagBumpOpts.Release {
+				rel, err := app.Ghub().CreateRelease(ghapi.ReleaseCreateInput{
+					RepoPath:   repoPath,
+					TagName:    newTag,
+					Body:       notes,
+					Draft:      tagBumpOpts.Draft,
+					Prerelease: nextVer.IsPre(),
+				})
+				if err != nil {
+					return err
+				}
+				colorp.Successf("Successful create release: %s\n", rel.HTMLURL)
+			}
+			return nil
+		},
+	}
+}
@@ -16,15 +16,20 @@ func NewEchoServerCmd() *gcli.Command {
 	var esOpts = struct {
 		port   uint
 		export bool
+		record recordOpts
 	}{}
 
 	return &gcli.Command{
 		Name:    "echo-server",
 		Desc:    "start an simple echo http server",
 		Aliases: []string{"echo-serve", "echo"},
+		Help: `
+Received requests are recorded, query them by the /_requests routes or the req-list command.
+`,
 		Config: func(c *gcli.Command) {
 			c.UintOpt(&esOpts.port, "port", "P", 0, "custom the echo server port, default will use random `port`")
 			c.BoolOpt(&esOpts.export, "export", "e", false, "export the http server, will listen on 0.0.0.0")
+			esOpts.record.bindFlags(c)
 		},
 		Func: func(c *gcli.Command, args []string) error {
 			if esOpts.port < 1 {
@@ -33,6 +38,12 @@ func NewEchoServerCmd() *gcli.Command {
 
 			srv := rux.New(func(r *rux.Router) {})
 			srv.Use(handlers.ConsoleLogger())
+			rec, err := esOpts.record.bindRecorder(srv)
+			if err != nil {
+				return err
+			}
+			defer rec.Close()
+
 			srv.Any("/{all}", func(c *rux.Context) {
 				data := testutil.BuildEchoReply(c.Req)
 
@@ -1,17 +1,30 @@
 package httpcmd
 
 import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
 	"github.com/gookit/gcli/v3"
 	"github.com/gookit/goutil/mathutil"
 	"github.com/gookit/goutil/timex"
+	"github.com/gookit/rux/v2"
 	"github.com/inhere/kite-go/internal/web/webhook"
+	"github.com/inhere/kite-go/pkg/hookrun"
 	"github.com/inhere/kite-go/pkg/httpserve"
+	"github.com/inhere/kite-go/pkg/reqrec"
 )
 
 var hookSrvOpts = struct {
 	Port   uint   `flag:"desc=custom the webhook server port, default will use random port;shorts=P"`
-	Config string `flag:"desc=custom the webhook server config file path;shorts=C"`
+	Config string `flag:"desc=the hooks config file, route webhooks to run kscript tasks or commands;shorts=C"`
 	Debug  bool   `flag:"desc=enable debug mode;shorts=D"`
+	// record received requests
+	Record     bool   `flag:"desc=enable record the received requests, require the runs_token on use config file"`
+	RecordSize int    `flag:"desc=the max number of recorded requests in memory;default=100"`
+	RecordFile string `flag:"desc=append recorded requests to the JSONL file, will load the file records on start"`
 }{}
 
 // NewHookServerCmd new command
@@ -24,11 +37,22 @@ func NewHookServerCmd() *gcli.Command {
 
   # start a webhook server
   {$fullCmd} -p 8080
-  # start a webhook server with config file
+  # start a webhook server with config file, route webhooks to run kscript tasks or commands
+  # see data/example/hook-server.yml for the config example
   {$fullCmd} -c ./hook-server.yml
+  # list the recent runs of the hooks, require the runs_token in config
+  curl -H 'X-Hook-Token: TOKEN' http://localhost:8080/_hook-runs
 
   # access the server
   curl -X POST http://localhost:8080/webhook -d '{"name": "test"}'
+
+  # record received requests, list and replay them. the token headers are redacted.
+  # on use config file, query the records require the runs_token.
+  {$fullCmd} -c ./hook-server.yml --record
+  curl -H 'X-Record-Token: TOKEN' http://localhost:8080/_requests
+  curl -H 'X-Record-Token: TOKEN' http://localhost:8080/_requests/last
+  kite http req-list -s localhost:8080 --token TOKEN
+  kite http req-replay -s localhost:8080 --token TOKEN --to http://localhost:9090
 `,
 		Aliases: []string{"webhook", "hook-server"},
 		Config: func(c *gcli.Command) {
@@ -42,6 +66,33 @@ func NewHookServerCmd() *gcli.Command {
 			}
 			s.SetHostPort("127.0.0.1", hookSrvOpts.Port)
 
+			var rec *reqrec.Recorder
+			if hookSrvOpts.Record {
+				ro := &recordOpts{size: hookSrvOpts.RecordSize, file: hookSrvOpts.RecordFile}
+				var err error
+				if rec, err = ro.bindRecorder(s.Rux()); err != nil {
+					return err
+				}
+				defer rec.Close()
+			}
+
+			if hookSrvOpts.Config != "" {
+				runner, err := bindHookRunner(s.Rux(), hookSrvOpts.Config)
+				if err != nil {
+					return err
+				}
+				defer runner.Stop()
+
+				// the records contains hook payloads, protect them by the runs token
+				if rec != nil {
+					if runner.RunsToken == "" {
+						return c.NewErr("the runs_token in config is required for record requests")
+					}
+					rec.Token = runner.RunsToken
+					rec.SkipPaths = append(rec.SkipPaths, hookrun.RunsPath)
+				}
+			}
+
 			webhook.Register(s.Rux())
 
 			// quick start
@@ -51,3 +102,52 @@ func NewHookServerCmd() *gcli.Command {
 		},
 	}
 }
+
+// load the hook config file, and add routes for the hooks
+func bindHookRunner(r *rux.Router, cfgFile string) (*hookrun.Runner, error) {
+	cfg, err := hookrun.LoadConfigFile(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+
+	runner, err := hookrun.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	runner.RunTask = runKiteTask
+
+	h := rux.WrapHTTPHandlerFunc(runner.ServeHTTP)
+	for _, path := range runner.Paths() {
+		r.Any(path, h)
+	}
+	r.GET(hookrun.RunsPath, h)
+
+	runner.Start()
+	return runner, nil
+}
+
+// run the kscript task by sub process: kite run --type script, the output will write to the hook log.
+func runKiteTask(ctx context.Context, h *hookrun.Hook, args []string, vars map[string]string, w io.Writer) error {
+	binFile, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmdArgs := []string{"run", "--type", "script"}
+	for name, val := range vars {
+		cmdArgs = append(cmdArgs, "--vars", name+"="+val)
+	}
+	cmdArgs = append(cmdArgs, h.Task)
+	cmdArgs = append(cmdArgs, args...)
+
+	cmd := exec.CommandContext(ctx, binFile, cmdArgs...)
+	cmd.Dir = h.Workdir
+	cmd.Stdout, cmd.Stderr = w, w
+	cmd.Env = os.Environ()
+	for name, val := range vars {
+		cmd.Env = append(cmd.Env, "HOOK_"+strings.ToUpper(name)+"="+val)
+	}
+
+	_, _ = io.WriteString(w, "> kite "+strings.Join(cmdArgs, " ")+"\n")
+	return cmd.Run()
+}
@@ -21,7 +21,12 @@ var HttpCmd = &gcli.Command{
 		NewOAPIServeCmd(),
 		NewJSONServerCmd(),
 		NewAccessCheckCmd(),
-		// TODO convert to CURL command. refer: https://github.com/moul/http2curl/blob/master/http2curl.go
+		NewTplExportCmd(),
+		NewTplImportCmd(),
+		NewReqListCmd(),
+		NewReqReplayCmd(),
+		NewShareServerCmd(),
+		NewSimpleStoreCmd(),
 	},
 	Config: func(c *gcli.Command) {
 
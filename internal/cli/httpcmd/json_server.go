@@ -1,19 +1,102 @@
 package httpcmd
 
-import "github.com/gookit/gcli/v3"
+import (
+	"github.com/gookit/gcli/v3"
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/goutil/timex"
+	"github.com/inhere/kite-go/pkg/jsonsrv"
+)
 
 // NewJSONServerCmd instance
 func NewJSONServerCmd() *gcli.Command {
+	var jsOpts = struct {
+		config string
+		port   uint
+		host   string
+		prefix string
+		watch  bool
+		write  bool
+		quiet  bool
+		export bool
+	}{}
 
 	return &gcli.Command{
 		Name:    "json-server",
-		Desc:    "start an simple json http server",
+		Desc:    "start a mock REST API server from a JSON file, like typicode/json-server",
 		Aliases: []string{"json-serve", "json-srv", "jss"},
 		Config: func(c *gcli.Command) {
+			c.StrOpt2(&jsOpts.config, "config, c", "the json-server config file. eg: data/json-server/demo1/json-server.json")
+			c.UintOpt(&jsOpts.port, "port", "P", 0, "custom the server `port`, default is \"1\" + MMDD of today. eg: 10425")
+			c.StrOpt2(&jsOpts.host, "host", "custom the server host, default is 127.0.0.1")
+			c.StrOpt2(&jsOpts.prefix, "prefix", "path prefix for all resource routes. eg: /api")
+			c.BoolOpt2(&jsOpts.watch, "watch, w", "watch the data file, reload data on it changed")
+			c.BoolOpt2(&jsOpts.write, "write, write-back", "write changes back to the data file")
+			c.BoolOpt2(&jsOpts.quiet, "quiet, q", "quiet mode, dont print request logs")
+			c.BoolOpt(&jsOpts.export, "export", "e", false, "export the http server, will listen on 0.0.0.0")
 
+			c.AddArg("datafile", "the JSON data file, each top-level key is a resource. will override config")
 		},
-		Func: func(c *gcli.Command, args []string) error {
-			return c.NewErr("TODO: not implement")
+		Help: `
+<info>Routes</>:
+  GET    /posts               list, support filter, sort, paginate and search
+  GET    /posts/1             get item
+  POST   /posts               create item
+  PUT    /posts/1             replace item
+  PATCH  /posts/1             update item fields
+  DELETE /posts/1             delete item
+  GET    /posts/1/comments    nested list, filter by comments.postId
+  GET    /profile             singular resource, allow GET, PUT, PATCH
+  GET    /db                  get full db data
+
+<info>Query</>:
+  ?title=hello&author.name=tom  filter by field, dot path for deep field
+  ?views_gte=10&views_lte=20    operators: _gte, _lte, _ne, _like
+  ?q=keyword                    full-text search
+  ?_sort=views,id&_order=desc,asc
+  ?_page=2&_limit=10  ?_start=10&_end=20
+
+<info>Examples</>:
+  {$fullCmd} db.json -w
+  {$fullCmd} -c data/json-server/demo1/json-server.json --write
+`,
+		Func: func(c *gcli.Command, _ []string) error {
+			cfg := &jsonsrv.Config{}
+			if jsOpts.config != "" {
+				var err error
+				if cfg, err = jsonsrv.LoadConfigFile(jsOpts.config); err != nil {
+					return err
+				}
+			} else {
+				cfg.SetBaseDir(c.WorkDir())
+			}
+
+			if df := c.Arg("datafile").String(); df != "" {
+				cfg.DataFile = df
+				cfg.SetBaseDir(c.WorkDir())
+			}
+			if cfg.DataFile == "" {
+				return c.NewErr("please input the datafile or set it in the config file")
+			}
+
+			if jsOpts.port > 0 {
+				cfg.Port = int(jsOpts.port)
+			} else if cfg.Port < 1 {
+				cfg.Port = mathutil.SafeInt("1" + timex.Now().DateFormat("md")) // eg: 10425
+			}
+
+			if jsOpts.export {
+				cfg.Host = "0.0.0.0"
+			} else if jsOpts.host != "" {
+				cfg.Host = jsOpts.host
+			}
+			if jsOpts.prefix != "" {
+				cfg.Prefix = jsOpts.prefix
+			}
+
+			cfg.Watch = cfg.Watch || jsOpts.watch
+			cfg.WriteBack = cfg.WriteBack || jsOpts.write
+			cfg.Quiet = cfg.Quiet || jsOpts.quiet
+			return jsonsrv.New(cfg).Start()
 		},
 	}
 }
@@ -0,0 +1,240 @@
+package httpcmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gookit/gcli/v3"
+	"github.com/gookit/gcli/v3/gflag"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/goutil/netutil/httpreq"
+	"github.com/gookit/goutil/timex"
+	"github.com/gookit/goutil/x/ccolor"
+	"github.com/gookit/rux/v2"
+	"github.com/inhere/kite-go/pkg/reqrec"
+)
+
+// record options for the echo and webhook server
+type recordOpts struct {
+	size int
+	file string
+}
+
+func (ro *recordOpts) bindFlags(c *gcli.Command) {
+	c.IntOpt2(&ro.size, "record-size", "the max number of recorded requests in memory. default: 100")
+	c.StrOpt2(&ro.file, "record-file", "append recorded requests to the JSONL file, will load the file records on start")
+}
+
+// bind a request recorder to the router, and add the query routes. see reqrec.PathPrefix
+func (ro *recordOpts) bindRecorder(r *rux.Router) (*reqrec.Recorder, error) {
+	rec := reqrec.New(ro.size)
+	if ro.file != "" {
+		if err := rec.OpenFile(ro.file); err != nil {
+			return nil, err
+		}
+	}
+
+	r.Use(func(c *rux.Context) {
+		if !rec.Skip(c.Req.URL.Path) {
+			if _, err := rec.Record(c.Req); err != nil {
+				c.HTTPError("record request error: "+err.Error(), 500)
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	})
+
+	qh := rux.WrapHTTPHandlerFunc(rec.ServeHTTP)
+	r.Add(reqrec.PathPrefix, qh, "GET", "DELETE")
+	r.GET(reqrec.PathPrefix+"/{id}", qh)
+	return rec, nil
+}
+
+// options for query recorded requests
+type recQueryOpts struct {
+	server string
+	token  string
+	file   string
+}
+
+func (qo *recQueryOpts) bindFlags(c *gcli.Command) {
+	c.StrOpt2(&qo.server, "server, s", "the running echo or webhook server address, default is 127.0.0.1:{default port}")
+	c.StrOpt2(&qo.token, "token", "the token for query records, required by the webhook server. eg: the runs_token in config")
+	c.StrOpt2(&qo.file, "file, f", "read records from the JSONL record file, instead of the server")
+}
+
+func (qo *recQueryOpts) serverAddr() string {
+	if qo.server != "" {
+		return qo.server
+	}
+	return "127.0.0.1:" + mathutil.String(mathutil.SafeUint("1"+timex.Now().DateFormat("md")))
+}
+
+// get record by ID, id can be "last"
+func (qo *recQueryOpts) record(id string) (*reqrec.Record, error) {
+	if qo.file == "" {
+		return reqrec.Fetch(qo.serverAddr(), qo.token, id)
+	}
+
+	list, err := reqrec.ReadFile(qo.file)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(list) - 1; i >= 0; i-- {
+		if id == "last" || strconv.FormatInt(list[i].ID, 10) == id {
+			return list[i], nil
+		}
+	}
+	return nil, errorx.Rawf("record %q not found in %s", id, qo.file)
+}
+
+// NewReqListCmd instance
+func NewReqListCmd() *gcli.Command {
+	var rlOpts = struct {
+		recQueryOpts
+		limit int
+	}{limit: 20}
+
+	return &gcli.Command{
+		Name:    "req-list",
+		Aliases: []string{"reqs", "requests"},
+		Desc:    "list or inspect the recorded requests of the echo or webhook server",
+		Help: `
+## Examples
+
+{$fullCmd}
+{$fullCmd} -s 127.0.0.1:8080 last
+{$fullCmd} -f ./tmp/hook-requests.jsonl 12
+`,
+		Config: func(c *gcli.Command) {
+			rlOpts.bindFlags(c)
+			c.IntOpt2(&rlOpts.limit, "limit, n", "the max number of records for list")
+			c.AddArg("id", "show the record details by ID, allow: last")
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			if id := c.Arg("id").String(); id != "" {
+				rec, err := rlOpts.record(id)
+				if err != nil {
+					return err
+				}
+				printRecord(rec)
+				return nil
+			}
+
+			var sums []*reqrec.Summary
+			if rlOpts.file != "" {
+				list, err := reqrec.ReadFile(rlOpts.file)
+				if err != nil {
+					return err
+				}
+				for i := len(list) - 1; i >= 0 && len(sums) < rlOpts.limit; i-- {
+					sums = append(sums, list[i].Summary())
+				}
+			} else {
+				var err error
+				if sums, err = reqrec.FetchList(rlOpts.serverAddr(), rlOpts.token, rlOpts.limit); err != nil {
+					return err
+				}
+			}
+
+			if len(sums) == 0 {
+				c.Infoln("No recorded requests")
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintln(tw, "ID\tTIME\tMETHOD\tURI\tSIZE\tEVENT")
+			for _, s := range sums {
+				_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\n", s.ID, s.Time.Format(time.DateTime), s.Method, s.URI, s.Size, s.Event)
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func printRecord(rec *reqrec.Record) {
+	ccolor.Printf("<cyan>#%d</> %s <green>%s %s</>\n", rec.ID, rec.Time.Format(time.DateTime), rec.Method, rec.URI())
+	ccolor.Printf("<gray>Host: %s, From: %s</>\n\n", rec.Host, rec.RemoteAddr)
+	fmt.Println(httpreq.HeaderToString(rec.Header))
+
+	if rec.Body == "" {
+		return
+	}
+
+	// pretty JSON body
+	var buf bytes.Buffer
+	if json.Indent(&buf, []byte(rec.Body), "", "  ") == nil {
+		fmt.Println(buf.String())
+	} else {
+		fmt.Println(rec.Body)
+	}
+}
+
+// NewReqReplayCmd instance
+func NewReqReplayCmd() *gcli.Command {
+	var rpOpts = struct {
+		recQueryOpts
+		to      string
+		timeout int
+	}{timeout: 10000}
+
+	return &gcli.Command{
+		Name:    "req-replay",
+		Aliases: []string{"replay"},
+		Desc:    "resend a recorded request of the echo or webhook server to another URL",
+		Help: `
+## Examples
+
+  # resend the last request to local app, will append the recorded path
+  {$fullCmd} --to http://127.0.0.1:8080
+  # resend record 12 from file to the full URL
+  {$fullCmd} -f ./tmp/hook-requests.jsonl --to http://127.0.0.1:8080/api/hooks/gitlab 12
+`,
+		Config: func(c *gcli.Command) {
+			rpOpts.bindFlags(c)
+			c.StrOpt2(&rpOpts.to, "to, t", "the target URL. only host will append the recorded path and query", gflag.WithRequired())
+			c.IntOpt2(&rpOpts.timeout, "timeout", "the request timeout, unit: ms")
+			c.AddArg("id", "the record ID for replay, default is the last")
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			id := c.Arg("id").String()
+			if id == "" {
+				id = "last"
+			}
+
+			rec, err := rpOpts.record(id)
+			if err != nil {
+				return err
+			}
+
+			req, err := rec.BuildRequest(rpOpts.to)
+			if err != nil {
+				return err
+			}
+
+			c.Infof("Replay record #%d: %s %s\n", rec.ID, req.Method, req.URL.String())
+			resp, err := httpreq.SendRequest(req, &httpreq.Option{Timeout: rpOpts.timeout})
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+
+			ccolor.Printf("<yellow>RESPONSE:</> %s %s\n", resp.Proto, resp.Status)
+			fmt.Println(string(body))
+			return nil
+		},
+	}
+}
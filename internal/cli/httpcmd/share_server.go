@@ -1,22 +1,70 @@
 package httpcmd
 
-/*
-AI Prompt:
+import (
+	"github.com/gookit/gcli/v3"
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/goutil/strutil"
+	"github.com/gookit/goutil/timex"
+	"github.com/inhere/kite-go/pkg/sharesrv"
+)
 
-使用 Golang + HTML/JS 实现一个简单的服务端页面，可以让多台机器通过浏览器打开此页面
+// NewShareServerCmd instance
+//
+// 在局域网内启动一个聊天页面，多台机器通过浏览器打开即可实时发送消息、上传和预览文件。
+func NewShareServerCmd() *gcli.Command {
+	var shOpts = struct {
+		host    string
+		port    uint
+		maxSize int
+		exts    string
+		dir     string
+		history int
+		quiet   bool
+	}{host: "0.0.0.0", maxSize: 50}
 
-- 用户可以直接进入页面，自动连接服务器 可以设置用户名
-- 都可以发送消息，两边都可以实时看到
-- 消息发送框可以上下拉伸，方便输入长文本
-- 可以上传发送文件，对方可以直接接收保存
-  - 文件大小限制 - 默认 50MB，可配置
-  - 文件类型支持 - 文档、图片、视频、音频、压缩包
-  - 文件预览 - 图片和文本文件在线预览
-- 界面简洁美观，不需要动画
-- 响应式界面 - 适配桌面和移动设备
+	return &gcli.Command{
+		Name:    "share",
+		Desc:    "start a LAN chat room page for share text snippets and files between machines",
+		Aliases: []string{"share-server", "chat"},
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&shOpts.host, "host", "custom the server host, default is 0.0.0.0 for access in LAN")
+			c.UintOpt(&shOpts.port, "port", "P", 0, "custom the server port, default will use random `port`")
+			c.IntOpt2(&shOpts.maxSize, "max-size, m", "the max size of upload file, unit: MB")
+			c.StrOpt2(&shOpts.exts, "exts", "custom allowed upload file extensions, * for allow all. eg: .txt,.png")
+			c.StrOpt2(&shOpts.dir, "dir, d", "the dir for save uploaded files, default will use a temp dir and remove it on exit")
+			c.IntOpt2(&shOpts.history, "history", "the max number of recent messages for send to new user, default is 100")
+			c.BoolOpt2(&shOpts.quiet, "quiet, q", "quiet mode, dont print logs")
+		},
+		Help: `
+Open the page on multiple machines, then can:
 
-技术方案：Golang1.21 + WebSocket + HTML/JS
-易用性: 跨平台支持 - Windows/Linux/macOS 全平台兼容
-可扩展: 清晰的代码结构，易于添加新功能 eg: 用户认证需求
+- set user name, send messages in real time. Ctrl+Enter to send
+- upload files by button, drag or paste. other users can download them
+- preview image, text, video and audio files online
 
- */
+<info>Examples</>:
+  {$fullCmd}
+  {$fullCmd} -P 8090 --max-size 200
+  {$fullCmd} --exts '*' -d ./tmp/share
+`,
+		Func: func(c *gcli.Command, _ []string) error {
+			if shOpts.port < 1 {
+				shOpts.port = mathutil.SafeUint("1" + timex.Now().DateFormat("md")) // eg: 10425
+			}
+
+			cfg := &sharesrv.Config{
+				Host:    shOpts.host,
+				Port:    shOpts.port,
+				MaxSize: int64(shOpts.maxSize) << 20,
+				FileDir: shOpts.dir,
+				History: shOpts.history,
+				Quiet:   shOpts.quiet,
+			}
+			if shOpts.exts != "" {
+				cfg.AllowExts = strutil.Split(shOpts.exts, ",")
+			}
+
+			return sharesrv.New(cfg).Start()
+		},
+	}
+}
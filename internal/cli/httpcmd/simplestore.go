@@ -1,9 +1,93 @@
 package httpcmd
 
-import "github.com/gookit/gcli/v3"
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
+	"github.com/gookit/gcli/v3"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/goutil/timex"
+	"github.com/gookit/goutil/x/ccolor"
+	"github.com/inhere/kite-go/pkg/kvstore"
+)
+
+// NewSimpleStoreCmd instance
 func NewSimpleStoreCmd() *gcli.Command {
+	var ssOpts = struct {
+		host    string
+		port    uint
+		dir     string
+		token   string
+		maxBlob int
+		quiet   bool
+	}{host: "0.0.0.0", maxBlob: 100}
+
 	return &gcli.Command{
-		// TODO
+		Name:    "kv-store",
+		Desc:    "start a simple key-value and file store server, for share small state between machines",
+		Aliases: []string{"store", "simple-store", "kvs"},
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&ssOpts.host, "host", "custom the server host, default is 0.0.0.0 for access in LAN")
+			c.UintOpt(&ssOpts.port, "port", "P", 0, "custom the server port, default will use random `port`")
+			c.StrOpt2(&ssOpts.dir, "dir, d", "the data dir for save values and files, default is {tmp}/kite-kvstore")
+			c.StrOpt2(&ssOpts.token, "token, t", "the auth token, default read from ENV KITE_KV_TOKEN. empty for disable auth")
+			c.IntOpt2(&ssOpts.maxBlob, "max-blob", "the max size of a blob file, unit: MB")
+			c.BoolOpt2(&ssOpts.quiet, "quiet, q", "quiet mode, dont print request logs")
+		},
+		Help: `
+<info>Routes</>:
+  GET    /kv                       list namespaces with key count
+  GET    /kv/{ns}?prefix=build.    list keys in the namespace
+  DELETE /kv/{ns}                  clear the namespace
+  PUT    /kv/{ns}/{key}?ttl=10m    set value. JSON body as JSON value, text or form body as string, others as file
+  GET    /kv/{ns}/{key}            get value. query: meta=1 for get the entry info
+  POST   /kv/{ns}/{key}?incr=1     increase the number value, returns the new value
+  DELETE /kv/{ns}/{key}            delete the key
+
+<info>Examples</>:
+  {$fullCmd} -P 8090 -t mytoken -d ./tmp/kv
+
+  # on other machines
+  curl -H 'X-Token: mytoken' -X PUT -d abc123 http://192.168.1.2:8090/kv/test/api.token?ttl=1h
+  curl -H 'X-Token: mytoken' http://192.168.1.2:8090/kv/test/api.token
+  curl -H 'X-Token: mytoken' -X POST http://192.168.1.2:8090/kv/test/build-number?incr=1
+  curl -H 'X-Token: mytoken' -T dist/app.tgz http://192.168.1.2:8090/kv/test/app.tgz
+  curl -H 'X-Token: mytoken' -o app.tgz http://192.168.1.2:8090/kv/test/app.tgz
+`,
+		Func: func(c *gcli.Command, _ []string) error {
+			if ssOpts.port < 1 {
+				ssOpts.port = mathutil.SafeUint("1" + timex.Now().DateFormat("md")) // eg: 10425
+			}
+			if ssOpts.dir == "" {
+				ssOpts.dir = filepath.Join(os.TempDir(), "kite-kvstore")
+			}
+			if ssOpts.token == "" {
+				ssOpts.token = os.Getenv("KITE_KV_TOKEN")
+			}
+
+			st, err := kvstore.Open(fsutil.ExpandHome(ssOpts.dir))
+			if err != nil {
+				return err
+			}
+
+			srv := kvstore.NewServer(st, ssOpts.token)
+			srv.Quiet = ssOpts.quiet
+			srv.MaxBlobSize = int64(ssOpts.maxBlob) << 20
+			stop := srv.StartCleaner(time.Minute)
+			defer stop()
+
+			addr := net.JoinHostPort(ssOpts.host, strconv.FormatUint(uint64(ssOpts.port), 10))
+			ccolor.Infof("KV store server listening on http://%s\n", addr)
+			ccolor.Printf(" - data dir: %s\n", ssOpts.dir)
+			if ssOpts.token == "" {
+				ccolor.Warnln(" - auth is disabled, set --token for enable it")
+			}
+			return http.ListenAndServe(addr, srv)
+		},
 	}
 }
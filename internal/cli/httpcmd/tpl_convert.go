@@ -0,0 +1,260 @@
+package httpcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gookit/gcli/v3"
+	"github.com/gookit/gcli/v3/gflag"
+	"github.com/gookit/goutil/cflag"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/x/ccolor"
+	"github.com/inhere/kite-go/internal/app"
+	"github.com/inhere/kite-go/pkg/httptpl"
+)
+
+// NewTplExportCmd instance
+func NewTplExportCmd() *gcli.Command {
+	var teOpts = struct {
+		domain   string
+		hcFile   string
+		envName  string
+		envFile  string
+		format   string
+		output   string
+		all      bool
+		userVars gflag.KVString
+	}{
+		format:   "curl",
+		userVars: cflag.NewKVString(),
+	}
+
+	return &gcli.Command{
+		Name:    "tpl-export",
+		Aliases: []string{"export-tpl", "tpl2curl"},
+		Desc:    "export http templates as curl command, Postman collection or ide http-client file",
+		Help: `
+## Examples
+
+{$fullCmd} -d gitlab -e prod create-mr
+{$fullCmd} --hc-file api-test.http -e dev --all -f postman -o api-test.postman.json
+{$fullCmd} -d gitlab -e prod --all -f http -o gitlab.http
+`,
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&teOpts.domain, "domain, d", "the domain or topic name")
+			c.StrOpt2(&teOpts.hcFile, "http-file, hc-file, hcf", "the ide http client file name or path")
+			c.StrOpt2(&teOpts.envName, "env, e", "sets env name for render variables")
+			c.StrOpt2(&teOpts.envFile, "env-file", "custom sets env file for render variables")
+			c.VarOpt2(&teOpts.userVars, "vars, var, v", "custom sets some variables. format: `KEY=VALUE`")
+			c.StrOpt2(&teOpts.format, "format, f", "the export format. allow: curl, postman, http")
+			c.StrOpt2(&teOpts.output, "output, o", "write the result to the file, default print to stdout")
+			c.BoolOpt2(&teOpts.all, "all, a", "export all templates in the domain or hc-file")
+			c.AddArg("names", "the template names for export", false, true)
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			dc, err := loadDomain(teOpts.domain, teOpts.hcFile)
+			if err != nil {
+				return err
+			}
+
+			var tpls []*httptpl.Template
+			if teOpts.all {
+				ts, ok := dc.Templates(teOpts.hcFile)
+				if !ok {
+					return errorx.Rawf("not found templates group %q on domain %q", teOpts.hcFile, dc.Name)
+				}
+				tpls = ts.List()
+			} else {
+				names := c.Arg("names").Strings()
+				if len(names) == 0 {
+					return errorx.Raw("please input the template names or use --all for export")
+				}
+
+				for _, name := range names {
+					t, err := dc.Find(teOpts.hcFile, name)
+					if err != nil {
+						return err
+					}
+					tpls = append(tpls, t)
+				}
+			}
+
+			vs, err := dc.BuildVars(teOpts.envName, teOpts.envFile)
+			if err != nil {
+				return err
+			}
+			vs.LoadSMap(teOpts.userVars.Data())
+
+			var out string
+			switch teOpts.format {
+			case "curl":
+				cmds := make([]string, 0, len(tpls))
+				for _, t := range tpls {
+					cmd, err := t.ToCurl(vs, dc.Header)
+					if err != nil {
+						return err
+					}
+					cmds = append(cmds, cmd)
+				}
+				out = strings.Join(cmds, "\n\n")
+			case "http", "hc":
+				if out, err = httptpl.ExportHCString(tpls, vs, dc.Header); err != nil {
+					return err
+				}
+			case "postman":
+				name := dc.Name
+				if teOpts.hcFile != "" {
+					name = fsutil.NameNoExt(teOpts.hcFile)
+				}
+
+				pc, err := httptpl.ExportPostman(name, tpls, vs, dc.Header)
+				if err != nil {
+					return err
+				}
+
+				bs, err := json.MarshalIndent(pc, "", "  ")
+				if err != nil {
+					return err
+				}
+				out = string(bs)
+			default:
+				return errorx.Rawf("invalid export format %q, allow: curl, postman, http", teOpts.format)
+			}
+
+			if teOpts.output == "" {
+				fmt.Println(out)
+				return nil
+			}
+
+			if err = fsutil.WriteFile(teOpts.output, out+"\n", fsutil.DefaultFilePerm); err != nil {
+				return err
+			}
+			c.Infof("Exported %d templates to %s\n", len(tpls), teOpts.output)
+			return nil
+		},
+	}
+}
+
+var oapiKeyReg = regexp.MustCompile(`(?m)^\s*"?(openapi|swagger)"?\s*:`)
+
+// NewTplImportCmd instance
+func NewTplImportCmd() *gcli.Command {
+	var tiOpts = struct {
+		domain    string
+		from      string
+		name      string
+		baseURL   string
+		overwrite bool
+		dryRun    bool
+	}{}
+
+	return &gcli.Command{
+		Name:    "tpl-import",
+		Aliases: []string{"import-tpl"},
+		Desc:    "import curl command, Postman collection or OpenAPI 3 document as http templates",
+		Help: `
+## Examples
+
+{$fullCmd} -d gitlab -n get-user "curl -H 'PRIVATE-TOKEN: {{token}}' https://{{host}}/api/v4/user"
+{$fullCmd} -d gitlab ./gitlab.postman.json
+{$fullCmd} -d petstore --base-url "{{host}}/v1" ./openapi.yaml --dry-run
+pbpaste | {$fullCmd} -d gitlab -
+`,
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&tiOpts.domain, "domain, d", "the domain name, will save templates to the template dir of it", gflag.WithRequired())
+			c.StrOpt2(&tiOpts.from, "from, f", "the source format, default will auto detect. allow: curl, postman, openapi")
+			c.StrOpt2(&tiOpts.name, "name, n", "the template name for import curl command")
+			c.StrOpt2(&tiOpts.baseURL, "base-url", "the URL prefix for import OpenAPI, default use the servers[0].url")
+			c.BoolOpt2(&tiOpts.overwrite, "overwrite, w", "overwrite the exists template files")
+			c.BoolOpt(&tiOpts.dryRun, "dry-run", "dry", false, "only print the templates, dont write to files")
+			c.AddArg("source", "the curl command, or a file path of the curl/postman/openapi. input - to read from stdin", true)
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			src := c.Arg("source").String()
+			var bs []byte
+			var err error
+			if src == "-" {
+				bs, err = io.ReadAll(os.Stdin)
+			} else if fsutil.IsFile(src) {
+				bs, err = os.ReadFile(src)
+			} else {
+				bs = []byte(src)
+			}
+			if err != nil {
+				return err
+			}
+
+			from := tiOpts.from
+			if from == "" {
+				text := strings.TrimSpace(string(bs))
+				switch {
+				case strings.HasPrefix(text, "curl "):
+					from = "curl"
+				case strings.Contains(text, "schema.getpostman.com"):
+					from = "postman"
+				case oapiKeyReg.MatchString(text):
+					from = "openapi"
+				default:
+					return errorx.Raw("cannot detect the source format, please set it by --from")
+				}
+			}
+
+			var tpls []*httptpl.Template
+			switch from {
+			case "curl":
+				t, err := httptpl.ParseCurl(string(bs))
+				if err != nil {
+					return err
+				}
+				if tiOpts.name != "" {
+					t.Name = tiOpts.name
+				}
+				tpls = append(tpls, t)
+			case "postman":
+				tpls, err = httptpl.ParsePostman(bs)
+			case "openapi", "oas":
+				tpls, err = httptpl.ParseOpenAPI(bs, tiOpts.baseURL)
+			default:
+				return errorx.Rawf("invalid source format %q, allow: curl, postman, openapi", from)
+			}
+			if err != nil {
+				return err
+			}
+
+			if tiOpts.dryRun {
+				for _, t := range tpls {
+					bs, err := t.ToDefJSON()
+					if err != nil {
+						return err
+					}
+					ccolor.Printf("<cyan>%s</>.json:\n%s\n", httptpl.TemplateFileName(t.Name), bs)
+				}
+				return nil
+			}
+
+			dc, err := app.HTpl.Domain(tiOpts.domain)
+			if err != nil {
+				return err
+			}
+
+			files, err := dc.SaveTemplates(tpls, tiOpts.overwrite)
+			for _, file := range files {
+				ccolor.Printf("  <green>saved</> %s\n", file)
+			}
+			if err != nil {
+				return err
+			}
+
+			c.Infof("Imported %d of %d templates to %s\n", len(files), len(tpls), dc.TplDir)
+			if n := len(tpls) - len(files); n > 0 {
+				ccolor.Warnf("Skipped %d exists templates, use --overwrite for overwrite them\n", n)
+			}
+			return nil
+		},
+	}
+}
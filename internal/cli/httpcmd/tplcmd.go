@@ -16,9 +16,11 @@ import (
 	"github.com/gookit/goutil/fsutil"
 	"github.com/gookit/goutil/maputil"
 	"github.com/gookit/goutil/netutil/httpreq"
+	"github.com/gookit/goutil/x/ccolor"
 	"github.com/gookit/goutil/x/stdio"
 	"github.com/inhere/kite-go/internal/app"
 	"github.com/inhere/kite-go/internal/biz/cmdbiz"
+	"github.com/inhere/kite-go/pkg/httptpl"
 )
 
 var stOpts = struct {
@@ -34,6 +36,12 @@ var stOpts = struct {
 	userVars gflag.KVString
 	verbose  bool
 	plugins  gflag.String
+	// batch send and search
+	all        bool
+	filter     string
+	search     string
+	parallel   int
+	stopOnFail bool
 }{
 	timeout:  500,
 	userVars: cflag.NewKVString(),
@@ -49,6 +57,31 @@ var SendTemplateCmd = &gcli.Command{
 
 {$fullCmd} -d gitlab --api api-build.json5 -e prod -v name=order
 
+## send request in ide http-client file
+
+{$fullCmd} --hc-file test/httptest/api-test.http --api "test request" -e development
+{$fullCmd} --hc-file test/httptest/api-test.http --api 2 -e development
+
+## search templates, send templates in batch
+
+{$fullCmd} -d gitlab --search "merge request"
+{$fullCmd} -d gitlab --filter "merge request" -e prod
+{$fullCmd} --hc-file api-test.http -e dev --all --parallel 4
+
+## find template by path or keywords on not found by name
+
+{$fullCmd} -d gitlab /api/v4/projects -e prod
+
+## send templates in order, as a smoke test
+
+{$fullCmd} --hc-file api-test.http -e dev login create-user get-user
+
+  # api-test.http, check response and capture variables by comment tags
+  ### login
+  # @assert status == 200
+  # @capture token = $.data.token
+  POST http://{{host}}/login
+
 ## use variable in template
 
 {$fullCmd} -d gitlab --plug git,fs --api api-build.json5 -e prod -v group={{git.group}} -v repoName={{git.repo}}
@@ -64,6 +97,12 @@ var SendTemplateCmd = &gcli.Command{
 		c.VarOpt2(&stOpts.plugins, "plugin,plug", "enable some plugins on exec request. allow:git,fs\ne.g. --plugin=plugin1,plugin2")
 		c.VarOpt2(&stOpts.userVars, "vars, var, v", "custom sets some variables on request. format: `KEY=VALUE`")
 		c.BoolOpt2(&stOpts.verbose, "verbose, vv", `show more info about request and response`)
+		c.BoolOpt2(&stOpts.all, "all, a", "send all templates in the domain or hc-file, will print a summary table")
+		c.StrOpt2(&stOpts.filter, "filter, f", "send all templates matched the keywords, will print a summary table")
+		c.IntOpt2(&stOpts.parallel, "parallel, p", "send templates in parallel on batch mode, captured variables will not be shared")
+		c.BoolOpt2(&stOpts.stopOnFail, "stop-on-fail, sof", "stop send on a request error or check failed in batch mode")
+		c.StrOpt2(&stOpts.search, "search, s", "search templates by keywords on name, desc and URL, only list them")
+		c.AddArg("names", "more template names for send in order, captured variables can be used by later templates", false, true)
 
 		// todo: loop query, send topic, send by template
 		// eg:
@@ -71,98 +110,208 @@ var SendTemplateCmd = &gcli.Command{
 		// 	kite http send-tpl --domain feishu -e dev bot-notify
 	},
 	Func: func(c *gcli.Command, _ []string) error {
-		dc, err := app.HTpl.Domain(stOpts.domain)
+		dc, err := loadDomain(stOpts.domain, stOpts.hcFile)
 		if err != nil {
 			return err
 		}
 
-		t, err := dc.Lookup(stOpts.hcFile, stOpts.tplName)
-		if err != nil {
-			return err
+		batchMode := stOpts.all || stOpts.filter != ""
+		if stOpts.search != "" || batchMode {
+			ts, ok := dc.Templates(stOpts.hcFile)
+			if !ok {
+				return errorx.Rawf("not found templates group %q on domain %q", stOpts.hcFile, dc.Name)
+			}
+
+			if stOpts.search != "" {
+				list := ts.Search(stOpts.search, 0)
+				c.Infof("Found %d templates by %q:\n", len(list), stOpts.search)
+				for _, t := range list {
+					ccolor.Printf("  <cyan>%s</>  %s %s  <gray>%s</>\n", t.Name, t.Method, t.URL, t.Desc)
+				}
+				return nil
+			}
+			return sendBatch(c, dc, ts)
+		}
+
+		names := c.Arg("names").Strings()
+		if stOpts.tplName != "" {
+			names = append([]string{stOpts.tplName}, names...)
+		}
+		if len(names) == 0 {
+			return errorx.Raw("please input the template name for send")
+		}
+
+		// lookup all templates before send
+		tpls := make([]*httptpl.Template, 0, len(names))
+		for _, name := range names {
+			t, err := dc.Find(stOpts.hcFile, name)
+			if err != nil {
+				return err
+			}
+			if t.Name != name {
+				c.Infof("Not found template %q, use the matched template %q\n", name, t.Name)
+			}
+			tpls = append(tpls, t)
 		}
 
-		var vs maputil.Data
-		vs, err = dc.BuildVars(stOpts.envName, stOpts.envFile)
+		vs, err := buildSendVars(c, dc)
 		if err != nil {
 			return err
 		}
 
-		vs.LoadSMap(stOpts.userVars.Data())
-
-		if len(stOpts.plugins) > 0 {
-			wDir := c.WorkDir()
-			names := stOpts.plugins.Strings()
-			for _, name := range names {
-				switch name {
-				case "fs":
-					vs.LoadSMap(map[string]string{
-						"fs.dir":  fsutil.Name(wDir),
-						"fs.path": wDir,
-					})
-				case "git":
-					if gitw.IsGitDir(wDir) {
-						lp := gitw.NewRepo(wDir)
-						if ri := lp.FirstRemoteInfo(); ri != nil {
-							vs.LoadSMap(map[string]string{
-								"git.group":    ri.Group,
-								"git.repo":     ri.Repo,
-								"git.repoPath": ri.RepoPath(),
-							})
-						}
-					}
-				}
+		var failed int
+		for _, t := range tpls {
+			t.SetTimeout(stOpts.timeout)
+			if stOpts.verbose {
+				bindVerboseHooks(t)
 			}
-		}
 
-		t.SetTimeout(stOpts.timeout)
+			if len(tpls) > 1 {
+				c.Infof("Send template %q: %s %s\n", t.Name, t.Method, t.URL)
+			}
 
-		if stOpts.verbose {
-			show.AList("Request Options:", map[string]any{
-				"timeout(ms)": t.Timeout,
-			})
+			opt := httpreq.NewOpt()
+			if err = t.Send(vs, dc.Header, opt); err != nil {
+				return err
+			}
 
-			if len(vs) > 0 {
-				// c.Infof("send request without some variables")
-				show.AList("Variables:", vs)
-			} else {
-				c.Infoln("Send template request without any variables")
+			if !stOpts.verbose && len(tpls) == 1 {
+				fmt.Println(t.Resp.BodyString())
 			}
 
-			t.BeforeSend = func(r *http.Request, b *bytes.Buffer) {
-				cliutil.Yellowln("REQUEST:")
-				cliutil.Greenf("%s %s\n\n", r.Method, r.URL.String())
-				if len(r.Header) > 0 {
-					fmt.Println(httpreq.HeaderToString(r.Header))
+			// check result and captured vars
+			if t.Checked != nil {
+				ccolor.Print(t.Checked.Report())
+				if !t.Passed() {
+					failed++
+					break
 				}
+			}
+			vs.LoadSMap(t.Captured)
+		}
+
+		if failed > 0 {
+			return errorx.Rawf("template %q check failed", tpls[len(tpls)-1].Name)
+		}
+		return nil
+	},
+}
+
+// load domain config by name, will use the hc-file dir as domain on domain is empty.
+func loadDomain(domain, hcFile string) (*httptpl.DomainConfig, error) {
+	if domain == "" && hcFile != "" {
+		return app.HTpl.HcDomain(hcFile)
+	}
+	return app.HTpl.Domain(domain)
+}
+
+// build variables for send templates
+func buildSendVars(c *gcli.Command, dc *httptpl.DomainConfig) (maputil.Data, error) {
+	vs, err := dc.BuildVars(stOpts.envName, stOpts.envFile)
+	if err != nil {
+		return nil, err
+	}
 
-				if b != nil && b.Len() > 0 {
-					fmt.Println(b.String())
+	vs.LoadSMap(stOpts.userVars.Data())
+
+	if len(stOpts.plugins) > 0 {
+		wDir := c.WorkDir()
+		names := stOpts.plugins.Strings()
+		for _, name := range names {
+			switch name {
+			case "fs":
+				vs.LoadSMap(map[string]string{
+					"fs.dir":  fsutil.Name(wDir),
+					"fs.path": wDir,
+				})
+			case "git":
+				if gitw.IsGitDir(wDir) {
+					lp := gitw.NewRepo(wDir)
+					if ri := lp.FirstRemoteInfo(); ri != nil {
+						vs.LoadSMap(map[string]string{
+							"git.group":    ri.Group,
+							"git.repo":     ri.Repo,
+							"git.repoPath": ri.RepoPath(),
+						})
+					}
 				}
 			}
-			t.AfterSend = func(resp *httpreq.Resp, err error) {
-				if err != nil {
-					return
-				}
+		}
+	}
 
-				cliutil.Yellowln("RESPONSE:")
-				if resp.IsEmptyBody() {
-					fmt.Print(resp.String())
-				} else {
-					fmt.Println(resp.String())
-				}
+	if stOpts.verbose {
+		show.AList("Request Options:", map[string]any{
+			"timeout(ms)": stOpts.timeout,
+		})
+
+		if len(vs) > 0 {
+			// c.Infof("send request without some variables")
+			show.AList("Variables:", vs)
+		} else {
+			c.Infoln("Send template request without any variables")
+		}
+	}
+	return vs, nil
+}
+
+// send templates in batch and print summary table
+func sendBatch(c *gcli.Command, dc *httptpl.DomainConfig, ts *httptpl.Templates) error {
+	vs, err := buildSendVars(c, dc)
+	if err != nil {
+		return err
+	}
+
+	br := ts.Send(vs, &httptpl.BatchOptions{
+		Filter:     stOpts.filter,
+		Parallel:   stOpts.parallel,
+		Timeout:    stOpts.timeout,
+		StopOnFail: stOpts.stopOnFail,
+		Header:     dc.Header,
+		BeforeSend: func(t *httptpl.Template) {
+			if stOpts.verbose {
+				bindVerboseHooks(t)
 			}
+		},
+	})
+
+	fmt.Print(br.String())
+	for _, item := range br.Items {
+		if t := item.Template; t.Checked != nil && !t.Checked.Passed() {
+			ccolor.Printf("\n<yellow>%s</> check report:\n", t.Name)
+			ccolor.Print(t.Checked.Report())
 		}
+	}
 
-		opt := httpreq.NewOpt()
-		if err = t.Send(vs, dc.Header, opt); err != nil {
-			return err
+	if n := br.Failed(); n > 0 {
+		return errorx.Rawf("%d of %d templates failed", n, len(br.Items))
+	}
+	return nil
+}
+
+// print request and response on verbose mode
+func bindVerboseHooks(t *httptpl.Template) {
+	t.BeforeSend = func(r *http.Request, b *bytes.Buffer) {
+		cliutil.Yellowln("REQUEST:")
+		cliutil.Greenf("%s %s\n\n", r.Method, r.URL.String())
+		if len(r.Header) > 0 {
+			fmt.Println(httpreq.HeaderToString(r.Header))
 		}
 
-		if !stOpts.verbose {
-			fmt.Println(t.Resp.BodyString())
+		if b != nil && b.Len() > 0 {
+			fmt.Println(b.String())
 		}
-		return nil
-	},
+	}
+	t.AfterSend = func(resp *httpreq.Resp, err error) {
+		if err != nil {
+			return
+		}
+
+		// NOTE: body maybe has been read on verify response, so use BodyString()
+		cliutil.Yellowln("RESPONSE:")
+		fmt.Println(resp.Proto, resp.Status)
+		fmt.Println(httpreq.HeaderToString(resp.Header))
+		fmt.Println(resp.BodyString())
+	}
 }
 
 var tiOpts = struct {
@@ -21,7 +21,7 @@ type diskCleanOpts struct {
 	// 扫描
 	ScanDirs    gflag.Strings `flag:"desc=扫描目录;name=scan;short=s"`
 	ExcludeDirs gflag.Strings `flag:"desc=排除目录;name=exclude;short=E"`
-	MaxDepth    int           `flag:"desc=最大扫描深度，-1 表示无限制;default=-1;short=D"`
+	MaxDepth    int           `flag:"desc=最大扫描深度，-1 表示无限制，0 默认: 项目模式无限制，否则只扫描一层;default=0;short=D"`
 	Concurrency int           `flag:"desc=并发扫描数;default=5;short=C"`
 
 	// 规则
@@ -36,6 +36,11 @@ type diskCleanOpts struct {
 	Yes      bool `flag:"desc=跳过确认;short=y"`
 	Force    bool `flag:"desc=强制执行高风险操作;short=f"`
 
+	// 项目模式
+	Project    bool `flag:"desc=项目模式，按项目(go.mod,package.json等)分组，跳过活跃的项目;short=P"`
+	StaleDays  int  `flag:"desc=项目模式下跳过 N 天内有修改的项目，0 表示不检查;name=stale-days;default=30"`
+	AllowDirty bool `flag:"desc=项目模式下也清理有未提交 git 变更的项目;name=allow-dirty"`
+
 	// 缓存
 	UseCache   bool `flag:"desc=使用缓存;default=true"`
 	ClearCache bool `flag:"desc=清除缓存"`
@@ -58,6 +63,9 @@ func NewSysCleanCmd() *gcli.Command {
 		Config: func(c *gcli.Command) {
 			goutil.MustOK(c.FromStruct(opts))
 		},
+		Subs: []*gcli.Command{
+			NewCleanTrashCmd(),
+		},
 		Func: func(c *gcli.Command, _ []string) error {
 			return runCleanCmd(opts, c)
 		},
@@ -81,6 +89,9 @@ func runCleanCmd(opts *diskCleanOpts, c *gcli.Command) error {
 	cfg.UseTrash = opts.UseTrash
 	cfg.Yes = opts.Yes
 	cfg.Force = opts.Force
+	cfg.ProjectMode = opts.Project
+	cfg.StaleDays = opts.StaleDays
+	cfg.AllowDirty = opts.AllowDirty
 	cfg.UseCache = opts.UseCache
 	cfg.OutputFormat = opts.OutputFormat
 	cfg.OutputFile = opts.OutputFile
@@ -185,6 +196,15 @@ func printScanSummary(report *sysclean.CleanReport, c *gcli.Command) {
 	c.Printf("  - 文件数: %d\n", report.ScanStats.TotalFiles)
 	c.Printf("  - 目录数: %d\n", report.ScanStats.TotalDirs)
 	c.Printf("  - 总大小: %s\n", formatSize(report.ScanStats.TotalSize))
+	if len(report.ByProject) > 0 {
+		var skipped int
+		for _, pg := range report.ByProject {
+			if pg.Skipped() {
+				skipped++
+			}
+		}
+		c.Printf("  - 项目数: %d (跳过 %d 个活跃项目)\n", len(report.ByProject), skipped)
+	}
 	c.Println()
 
 	// 显示高风险警告
@@ -0,0 +1,304 @@
+package syscmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gookit/gcli/v3"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/strutil"
+	"github.com/gookit/goutil/timex"
+	"github.com/inhere/kite-go/internal/service/sysservice/sysclean"
+)
+
+// NewCleanTrashCmd 回收站管理命令: 查看、恢复、彻底删除 clean 移动到回收站的文件
+func NewCleanTrashCmd() *gcli.Command {
+	return &gcli.Command{
+		Name:    "trash",
+		Desc:    "查看、恢复或彻底删除 clean 命令移动到回收站的文件",
+		Aliases: []string{"recycle"},
+		Subs: []*gcli.Command{
+			newTrashListCmd(),
+			newTrashRestoreCmd(),
+			newTrashPurgeCmd(),
+		},
+	}
+}
+
+// newTrashListCmd 列出回收站条目或清理清单
+func newTrashListCmd() *gcli.Command {
+	var runs bool
+
+	return &gcli.Command{
+		Name:    "list",
+		Desc:    "列出由 clean 移动到回收站的文件，或列出每次清理的清单",
+		Aliases: []string{"ls"},
+		Config: func(c *gcli.Command) {
+			c.BoolOpt2(&runs, "runs, r", "列出每次清理运行的清单，可用于 restore --run 整体回滚")
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			mm := sysclean.NewManifestManager("")
+			tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+			if runs {
+				list, err := mm.List()
+				if err != nil {
+					return err
+				}
+				if len(list) == 0 {
+					c.Println("没有清理清单记录")
+					return nil
+				}
+
+				_, _ = fmt.Fprintln(tw, "ID\t时间\t目标数\t大小\t回收站\t已回滚")
+				for _, m := range list {
+					restored := "-"
+					if m.RestoredAt != nil {
+						restored = m.RestoredAt.Format(time.DateTime)
+					}
+					_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%v\t%s\n", m.ID, m.CreatedAt.Format(time.DateTime),
+						len(m.Entries), formatSize(m.TotalSize()), m.UseTrash, restored)
+				}
+				return tw.Flush()
+			}
+
+			items, err := sysclean.ListTrash(mm)
+			if err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				c.Println("回收站中没有由 kite 清理的文件")
+				return nil
+			}
+
+			var total int64
+			_, _ = fmt.Fprintln(tw, "名称\t删除时间\t大小\t原始路径")
+			for _, item := range items {
+				total += item.Size
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", item.Name, item.DeletedAt.Format(time.DateTime), formatSize(item.Size), item.Path)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+
+			c.Printf("\n共 %d 项, %s\n", len(items), formatSize(total))
+			return nil
+		},
+	}
+}
+
+// newTrashRestoreCmd 恢复回收站条目到原始路径
+func newTrashRestoreCmd() *gcli.Command {
+	var opts = struct {
+		run       string
+		overwrite bool
+		dryRun    bool
+	}{}
+
+	return &gcli.Command{
+		Name:    "restore",
+		Desc:    "恢复回收站中的文件到原始路径，或按清单回滚一次清理",
+		Aliases: []string{"undo"},
+		Help: `
+## Examples
+
+  # 按名称或原始路径恢复
+  {$fullCmd} go-build.log /home/inhere/.cache/go-build
+  # 回滚最近一次清理
+  {$fullCmd} --run last
+  # 回滚指定的清理，ID 可通过 trash list --runs 查看
+  {$fullCmd} --run 20250101-120000
+`,
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&opts.run, "run, r", "按清理清单整体回滚，值为清单 ID 或 last")
+			c.BoolOpt2(&opts.overwrite, "overwrite, w", "原始路径已存在时覆盖")
+			c.BoolOpt2(&opts.dryRun, "dry-run, dry", "只显示将要恢复的文件")
+			c.AddArg("names", "回收站中的名称或原始路径", false, true)
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			mm := sysclean.NewManifestManager("")
+
+			if opts.run != "" {
+				m, err := mm.Load(opts.run)
+				if err != nil {
+					return err
+				}
+
+				c.Printf("回滚清理 %s (%s), 共 %d 项\n", m.ID, m.CreatedAt.Format(time.DateTime), len(m.Entries))
+				if opts.dryRun {
+					for _, e := range m.Entries {
+						c.Printf("  %s <- %s\n", e.Path, strutil.OrElse(e.TrashPath, "(已直接删除，无法恢复)"))
+					}
+					return nil
+				}
+
+				res, err := mm.Rollback(m, opts.overwrite)
+				printRestoreResult(c, res)
+				return err
+			}
+
+			names := c.Arg("names").Strings()
+			if len(names) == 0 {
+				return errorx.New("请输入要恢复的名称或路径，或使用 --run 回滚一次清理")
+			}
+
+			items, err := sysclean.ListTrash(mm)
+			if err != nil {
+				return err
+			}
+
+			res := &sysclean.RestoreResult{}
+			for _, name := range names {
+				item := findTrashItem(items, name)
+				if item == nil {
+					res.Errors = append(res.Errors, "回收站中未找到："+name)
+					continue
+				}
+
+				if opts.dryRun {
+					c.Printf("  %s <- %s\n", item.Path, item.TrashPath)
+					continue
+				}
+				if err := sysclean.RestoreTrash(item, opts.overwrite); err != nil {
+					res.Errors = append(res.Errors, err.Error())
+				} else {
+					res.Restored = append(res.Restored, item.Path)
+				}
+			}
+
+			printRestoreResult(c, res)
+			return nil
+		},
+	}
+}
+
+// findTrashItem 按回收站名称或原始路径查找
+func findTrashItem(items []*sysclean.TrashItem, name string) *sysclean.TrashItem {
+	for _, item := range items {
+		if item.Name == name || item.Path == name {
+			return item
+		}
+	}
+	return nil
+}
+
+func printRestoreResult(c *gcli.Command, res *sysclean.RestoreResult) {
+	if res == nil {
+		return
+	}
+
+	for _, path := range res.Restored {
+		c.Printf("  ✓ 已恢复 %s\n", path)
+	}
+	for _, msg := range res.Skipped {
+		c.Printf("  - 跳过 %s\n", msg)
+	}
+	for _, msg := range res.Errors {
+		c.Printf("  ✗ %s\n", msg)
+	}
+	c.Printf("\n恢复 %d 项, 跳过 %d 项, 失败 %d 项\n", len(res.Restored), len(res.Skipped), len(res.Errors))
+}
+
+// newTrashPurgeCmd 按时间或大小彻底删除回收站条目
+func newTrashPurgeCmd() *gcli.Command {
+	var opts = struct {
+		olderThan string
+		minSize   string
+		all       bool
+		yes       bool
+		dryRun    bool
+	}{}
+
+	return &gcli.Command{
+		Name:    "purge",
+		Desc:    "按删除时间或大小，彻底删除回收站中由 clean 移动的文件",
+		Aliases: []string{"empty"},
+		Help: `
+## Examples
+
+  # 删除 30 天前移动到回收站的文件
+  {$fullCmd} --older-than 30d
+  # 删除大于 100M 的文件
+  {$fullCmd} --min-size 100M -y
+  # 删除所有由 kite 清理的文件
+  {$fullCmd} --all
+`,
+		Config: func(c *gcli.Command) {
+			c.StrOpt2(&opts.olderThan, "older-than, o", "删除时间早于指定时长的条目. eg: 7d, 48h")
+			c.StrOpt2(&opts.minSize, "min-size, s", "大小不小于指定值的条目. eg: 100M, 1G")
+			c.BoolOpt2(&opts.all, "all, a", "删除所有由 kite 清理的条目")
+			c.BoolOpt2(&opts.yes, "yes, y", "跳过确认")
+			c.BoolOpt2(&opts.dryRun, "dry-run, dry", "只显示将要删除的条目")
+		},
+		Func: func(c *gcli.Command, _ []string) error {
+			if !opts.all && opts.olderThan == "" && opts.minSize == "" {
+				return errorx.New("请指定 --older-than, --min-size 或 --all")
+			}
+
+			var before time.Time
+			if opts.olderThan != "" {
+				dur, err := timex.ToDuration(opts.olderThan)
+				if err != nil {
+					return errorx.Wrap(err, "无效的 --older-than")
+				}
+				before = time.Now().Add(-dur)
+			}
+
+			var minSize int64
+			if opts.minSize != "" {
+				size, err := strutil.ToByteSize(opts.minSize)
+				if err != nil {
+					return errorx.Wrap(err, "无效的 --min-size")
+				}
+				minSize = int64(size)
+			}
+
+			items, err := sysclean.ListTrash(sysclean.NewManifestManager(""))
+			if err != nil {
+				return err
+			}
+
+			var total int64
+			var matched []*sysclean.TrashItem
+			for _, item := range items {
+				if !before.IsZero() && !item.DeletedAt.Before(before) {
+					continue
+				}
+				if minSize > 0 && item.Size < minSize {
+					continue
+				}
+				matched = append(matched, item)
+				total += item.Size
+			}
+
+			if len(matched) == 0 {
+				c.Println("没有匹配的回收站条目")
+				return nil
+			}
+
+			for _, item := range matched {
+				c.Printf("  %s  %s  %s\n", item.DeletedAt.Format(time.DateTime), formatSize(item.Size), item.Path)
+			}
+			c.Printf("\n匹配 %d 项, %s\n", len(matched), formatSize(total))
+			if opts.dryRun {
+				return nil
+			}
+			if !opts.yes && !confirmAction("确认彻底删除以上条目? 删除后无法恢复", c) {
+				c.Println("操作已取消")
+				return nil
+			}
+
+			var failed int
+			for _, item := range matched {
+				if err := sysclean.PurgeTrash(item); err != nil {
+					failed++
+					c.Printf("  ✗ %s: %v\n", item.Name, err)
+				}
+			}
+			c.Printf("已删除 %d 项, 失败 %d 项\n", len(matched)-failed, failed)
+			return nil
+		},
+	}
+}
@@ -25,6 +25,10 @@ type runAnyHandle struct {
 	envMap gflag.KVString
 	// auto find and chdir
 	chdir string
+	// max parallel jobs for run task deps
+	jobs int
+	// force run task, ignore up-to-date check
+	force bool
 
 	// global: 是否显示全局 task 信息（来自 DefineFiles 的任务）
 	global                             bool
@@ -54,6 +58,8 @@ var RunAnyCmd = &gcli.Command{
 		c.BoolOpt2(&runOpts.verbose, "verbose, verb", "Display context information on execute")
 
 		c.StrOpt2(&runOpts.chdir, "chdir, cd", "auto find match dir and chdir as workdir")
+		c.BoolOpt2(&runOpts.force, "force, f", "force run the task, ignore the up-to-date check by sources/status")
+		c.IntOpt2(&runOpts.jobs, "jobs, j", "max parallel jobs for run independent task deps, default is 1")
 		c.VarOpt2(&runOpts.envMap, "env,e", "custom set ENV value on run command, format: `KEY=VALUE`")
 		c.VarOpt2(&runOpts.varMap, "vars,var", "custom set var value on run command, format: `name=value`")
 		c.VarOpt(&runOpts.runType, "type", "t", "direct set type for run input, allow: "+runOpts.runType.EnumString())
@@ -129,9 +135,11 @@ func runAnything(c *gcli.Command, args []string) (err error) {
 		Verbose: runOpts.verbose,
 		DryRun:  runOpts.DryRun,
 		// custom ENV, vars
-		Env:  runOpts.envMap.Data(),
-		Vars: runOpts.varMap.Data(),
-		Type: runOpts.wrapType.String(),
+		Env:   runOpts.envMap.Data(),
+		Vars:  runOpts.varMap.Data(),
+		Type:  runOpts.wrapType.String(),
+		Jobs:  runOpts.jobs,
+		Force: runOpts.force,
 	}
 
 	// direct run as a script
@@ -180,6 +188,11 @@ func showInfo(name string) (err error) {
 		}
 		if si != nil {
 			show.AList("script task info", si)
+			if len(si.Vars) > 0 {
+				vars, err2 := app.Scripts.ResolveTaskVars(si, &kscript.RunCtx{Vars: runOpts.varMap.Data()})
+				show.AList("script task vars", vars)
+				return err2
+			}
 			return
 		}
 
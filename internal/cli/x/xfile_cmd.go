@@ -1,20 +1,77 @@
 package x
 
 import (
-	"errors"
-
 	"github.com/gookit/gcli/v3"
+	"github.com/gookit/gcli/v3/gflag"
+	"github.com/gookit/goutil/x/ccolor"
+	"github.com/inhere/kite-go/internal/biz/cmdbiz"
+	"github.com/inhere/kite-go/pkg/kiteext/xfile"
 )
 
-// XFileCmd TODO 暂时由 kscript.auto_task_files 替代
+var xfOpts = struct {
+	cmdbiz.CommonOpts
+	list  bool
+	file  string
+	depth int
+}{}
+
+// XFileCmd run actions in the kitefile of workdir or parent dir
 var XFileCmd = &gcli.Command{
 	Name:    "xfile",
 	Aliases: []string{"xrun", "xcli"},
-	Desc: "execute kite xfile command in workdir or parent dir. like makefile, just",
+	Desc:    "execute kite xfile command in workdir or parent dir. like makefile, just",
 	Config: func(c *gcli.Command) {
+		xfOpts.BindWorkdirDryRun(c)
+		c.BoolOpt2(&xfOpts.list, "list, l", "list all actions in the found kitefile files")
+		c.StrOpt2(&xfOpts.file, "file, f", "use the kitefile path, will not search in workdir and parent dirs")
+		c.IntOpt2(&xfOpts.depth, "depth", "max depth for search kitefile in parent dirs", gflag.WithDefault(5))
 
+		c.AddArg("action", "the action name for run, default run the default_action")
+		c.AddArg("args", "arguments for the action, use $1, $2 or $args in action commands", false, true)
 	},
+	Help: `
+kitefile names: kitefile, .kitefile and with ext: .yml, .yaml, .toml, .json
+
+<info>Examples</>:
+
+  # kitefile.yml
+  name: my-project
+  default_action: build
+  env: {APP_ENV: dev}
+  actions:
+    build:
+      desc: build the project
+      cmds:
+        - go build -o bin/app $args
+    logs:
+      type: bash
+      cmds: cat app.log | grep $1
+
+  $ {$fullCmd} --list
+  $ {$fullCmd} logs error
+`,
 	Func: func(c *gcli.Command, _ []string) error {
-		return errors.New("TODO")
+		xm := xfile.NewXFile()
+		xm.MaxFindDepth = xfOpts.depth
+
+		var err error
+		if xfOpts.file != "" {
+			err = xm.LoadFiles(xfOpts.file)
+		} else {
+			err = xm.Load(c.WorkDir())
+		}
+		if err != nil {
+			return err
+		}
+
+		name := c.Arg("action").String()
+		if xfOpts.list || (name == "" && xm.DefaultAction() == "") {
+			ccolor.Println(xm.HelpText())
+			return nil
+		}
+
+		return xm.RunAction(name, c.Arg("args").Strings(), &xfile.RunOpts{
+			DryRun: xfOpts.DryRun,
+		})
 	},
 }
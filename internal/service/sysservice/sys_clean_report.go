@@ -93,6 +93,9 @@ func (s *sysCleanService) GenerateReport(result *sysclean.ScanResult, isExecuted
 		}
 	}
 
+	// 项目统计
+	report.ByProject = result.Projects
+
 	// 添加错误到报告
 	for _, err := range result.Errors {
 		report.Errors = append(report.Errors, fmt.Sprintf("%s: %s", err.Path, err.Error))
@@ -147,6 +150,9 @@ func (s *sysCleanService) ExportMarkdown(report *sysclean.CleanReport) string {
 		if report.CleanStats.FailedCount > 0 {
 			buf.WriteString(fmt.Sprintf("| 失败数 | %d |\n", report.CleanStats.FailedCount))
 		}
+		if report.CleanStats.ManifestID != "" {
+			buf.WriteString(fmt.Sprintf("| 清理清单 | %s |\n", report.CleanStats.ManifestID))
+		}
 	}
 	buf.WriteString("\n")
 
@@ -176,6 +182,31 @@ func (s *sysCleanService) ExportMarkdown(report *sysclean.CleanReport) string {
 		buf.WriteString("\n")
 	}
 
+	// 项目统计
+	if len(report.ByProject) > 0 {
+		buf.WriteString("## 项目统计\n\n")
+		buf.WriteString("| 项目 | 类型 | 最后修改 | 目标数 | 可回收 | 状态 |\n")
+		buf.WriteString("|------|------|----------|--------|--------|------|\n")
+
+		var reclaimable int64
+		for _, pg := range report.ByProject {
+			status := "清理"
+			if pg.Skipped() {
+				status = "跳过: " + pg.SkipReason
+			} else {
+				reclaimable += pg.TotalSize
+			}
+			buf.WriteString(fmt.Sprintf("| %s | %s | %s | %d | %s | %s |\n",
+				pg.Root,
+				pg.Type,
+				pg.ModTime.Format("2006-01-02"),
+				pg.TargetCount,
+				formatSize(pg.TotalSize),
+				status))
+		}
+		buf.WriteString(fmt.Sprintf("\n共 %d 个项目，可回收 %s\n\n", len(report.ByProject), formatSize(reclaimable)))
+	}
+
 	// 规则详情（按风险等级分组）
 	if len(report.ByRule) > 0 {
 		buf.WriteString("## 规则详情\n\n")
@@ -288,6 +319,24 @@ func (s *sysCleanService) ExportText(report *sysclean.CleanReport) string {
 		buf.WriteString("\n")
 	}
 
+	// 项目统计
+	if len(report.ByProject) > 0 {
+		buf.WriteString("--- 项目统计 ---\n")
+		for _, pg := range report.ByProject {
+			status := "清理"
+			if pg.Skipped() {
+				status = "跳过: " + pg.SkipReason
+			}
+			buf.WriteString(fmt.Sprintf("%s [%s]: %d 目标, %s, %s\n",
+				pg.Root,
+				pg.Type,
+				pg.TargetCount,
+				formatSize(pg.TotalSize),
+				status))
+		}
+		buf.WriteString("\n")
+	}
+
 	// 规则详情
 	if len(report.ByRule) > 0 {
 		buf.WriteString("--- 规则详情 ---\n")
@@ -366,6 +415,7 @@ func (s *sysCleanService) Clean(ctx context.Context) (*sysclean.CleanReport, err
 		UseTrash: s.config.UseTrash,
 	}
 	startTime := time.Now()
+	manifest := sysclean.NewManifest(s.config.UseTrash)
 
 	for _, group := range result.Groups {
 		// 检查风险等级
@@ -376,13 +426,14 @@ func (s *sysCleanService) Clean(ctx context.Context) (*sysclean.CleanReport, err
 		}
 
 		for _, target := range group.Targets {
-			err := sysclean.DeleteTarget(target.Path, s.config.UseTrash, s.trashManager)
+			trashPath, err := sysclean.DeleteTarget(target.Path, s.config.UseTrash, s.trashManager)
 			if err != nil {
 				cleanStats.FailedCount++
 				cleanStats.FailedSpace += target.Size
 				report.Errors = append(report.Errors,
 					fmt.Sprintf("清理失败 %s: %s", target.Path, err.Error()))
 			} else {
+				manifest.Add(target, trashPath)
 				if target.IsDir {
 					cleanStats.DeletedDirs++
 				} else {
@@ -396,6 +447,15 @@ func (s *sysCleanService) Clean(ctx context.Context) (*sysclean.CleanReport, err
 	cleanStats.Duration = time.Since(startTime)
 	report.CleanStats = cleanStats
 
+	// 保存清理清单，用于 clean trash restore --run 整体回滚
+	if len(manifest.Entries) > 0 {
+		if err := s.manifestManager.Save(manifest); err != nil {
+			report.Warnings = append(report.Warnings, "保存清理清单失败: "+err.Error())
+		} else {
+			cleanStats.ManifestID = manifest.ID
+		}
+	}
+
 	// 清除缓存
 	_ = s.ClearCache()
 
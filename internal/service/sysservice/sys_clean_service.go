@@ -49,6 +49,8 @@ type sysCleanService struct {
 	presetRules  []*sysclean.CleanRule
 	cacheManager *sysclean.CacheManager
 	trashManager sysclean.TrashManager
+	// 清理清单，记录每次清理的目标
+	manifestManager *sysclean.ManifestManager
 }
 
 // NewSysCleanService 创建系统清理服务
@@ -69,6 +71,8 @@ func NewSysCleanService(config *sysclean.CleanConfig) SysCleanService {
 		presetRules:  sysclean.GetPresetRules(),
 		cacheManager: sysclean.NewCacheManager(cacheFile, config.CacheTTL),
 		trashManager: sysclean.NewTrashManager(),
+
+		manifestManager: sysclean.NewManifestManager(""),
 	}
 }
 
@@ -84,11 +88,18 @@ func SysCleanWithConfig(cfg *sysclean.CleanConfig) SysCleanService {
 
 // Scan 实现服务接口的扫描
 func (s *sysCleanService) Scan(ctx context.Context) (*sysclean.ScanResult, error) {
+	// 获取扫描目录
+	scanDirs := s.config.ScanDirs
+	if len(scanDirs) == 0 {
+		homeDir, _ := os.UserHomeDir()
+		scanDirs = []string{homeDir}
+	}
+
 	// 检查是否使用缓存
 	if s.config.UseCache {
 		cached, err := s.cacheManager.Load()
 		if err == nil && cached != nil {
-			return cached, nil
+			return s.applyProjectMode(cached, scanDirs), nil
 		}
 	}
 
@@ -106,13 +117,6 @@ func (s *sysCleanService) Scan(ctx context.Context) (*sysclean.ScanResult, error
 		rules = sysclean.FilterRulesByCategories(rules, s.config.Categories)
 	}
 
-	// 获取扫描目录
-	scanDirs := s.config.ScanDirs
-	if len(scanDirs) == 0 {
-		homeDir, _ := os.UserHomeDir()
-		scanDirs = []string{homeDir}
-	}
-
 	// 创建扫描器并执行扫描
 	scanner := sysclean.NewScanner(s.config, rules)
 	result, err := scanner.Scan(ctx, scanDirs)
@@ -128,7 +132,15 @@ func (s *sysCleanService) Scan(ctx context.Context) (*sysclean.ScanResult, error
 		_ = s.cacheManager.Save(result)
 	}
 
-	return result, nil
+	return s.applyProjectMode(result, scanDirs), nil
+}
+
+// applyProjectMode 项目模式下按项目分组并过滤活跃项目。缓存中保存的是未过滤的结果
+func (s *sysCleanService) applyProjectMode(result *sysclean.ScanResult, scanDirs []string) *sysclean.ScanResult {
+	if s.config.ProjectMode {
+		sysclean.ApplyProjectMode(result, scanDirs, s.config)
+	}
+	return result
 }
 
 // ScanWithRules 使用指定规则扫描
@@ -0,0 +1,357 @@
+package sysclean
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+)
+
+// ManifestEntry 清理清单中的一个目标
+type ManifestEntry struct {
+	// Path 原始路径
+	Path string `json:"path"`
+	// TrashPath 移动到回收站后的路径，直接删除时为空
+	TrashPath string `json:"trash_path,omitempty"`
+	// InfoPath Linux 下的 .trashinfo 文件路径
+	InfoPath string       `json:"info_path,omitempty"`
+	Size     int64        `json:"size"`
+	IsDir    bool         `json:"is_dir"`
+	RuleName string       `json:"rule_name"`
+	Category RuleCategory `json:"category"`
+}
+
+// Trashed 是否移动到了回收站(可恢复)
+func (e *ManifestEntry) Trashed() bool {
+	return e.TrashPath != ""
+}
+
+// Manifest 一次清理运行的清单，用于整体回滚
+type Manifest struct {
+	ID        string           `json:"id"`
+	CreatedAt time.Time        `json:"created_at"`
+	UseTrash  bool             `json:"use_trash"`
+	Entries   []*ManifestEntry `json:"entries"`
+	// Restored 已回滚的时间
+	RestoredAt *time.Time `json:"restored_at,omitempty"`
+
+	filePath string
+}
+
+// NewManifest 创建清理清单. ID 精确到毫秒，避免同一秒内多次清理时清单被覆盖
+func NewManifest(useTrash bool) *Manifest {
+	now := time.Now()
+	return &Manifest{
+		ID:        now.Format("20060102-150405.000"),
+		CreatedAt: now,
+		UseTrash:  useTrash,
+	}
+}
+
+// Add 添加清理成功的目标
+func (m *Manifest) Add(target *CleanTarget, trashPath string) {
+	entry := &ManifestEntry{
+		Path:      target.Path,
+		TrashPath: trashPath,
+		Size:      target.Size,
+		IsDir:     target.IsDir,
+		RuleName:  target.RuleName,
+		Category:  target.Category,
+	}
+	if trashPath != "" && CurrentPlatform() == PlatformLinux {
+		entry.InfoPath = trashInfoPath(trashPath)
+	}
+	m.Entries = append(m.Entries, entry)
+}
+
+// TotalSize 清单中目标的总大小
+func (m *Manifest) TotalSize() int64 {
+	var size int64
+	for _, e := range m.Entries {
+		size += e.Size
+	}
+	return size
+}
+
+// FilePath 清单文件路径
+func (m *Manifest) FilePath() string {
+	return m.filePath
+}
+
+// ManifestManager 清理清单管理器
+type ManifestManager struct {
+	dir string
+}
+
+// NewManifestManager 创建清单管理器. dir 为空时使用 ~/.kite-go/tmp/sys-clean/manifests
+func NewManifestManager(dir string) *ManifestManager {
+	if dir == "" {
+		homeDir, _ := os.UserHomeDir()
+		dir = filepath.Join(homeDir, ".kite-go", "tmp", "sys-clean", "manifests")
+	}
+	return &ManifestManager{dir: dir}
+}
+
+// Dir 清单保存目录
+func (mm *ManifestManager) Dir() string {
+	return mm.dir
+}
+
+// Save 保存清单
+func (mm *ManifestManager) Save(m *Manifest) error {
+	if err := os.MkdirAll(mm.dir, 0755); err != nil {
+		return errorx.Wrap(err, "创建清单目录失败")
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errorx.Wrap(err, "序列化清单失败")
+	}
+
+	m.filePath = filepath.Join(mm.dir, m.ID+".json")
+	if err := os.WriteFile(m.filePath, data, 0644); err != nil {
+		return errorx.Wrap(err, "写入清单文件失败")
+	}
+	return nil
+}
+
+// Load 按 ID 加载清单. id 为 last 时加载最近一次
+func (mm *ManifestManager) Load(id string) (*Manifest, error) {
+	if id == "last" {
+		list, err := mm.List()
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			return nil, errorx.New("没有清理清单记录")
+		}
+		return list[0], nil
+	}
+
+	return mm.loadFile(filepath.Join(mm.dir, id+".json"))
+}
+
+func (mm *ManifestManager) loadFile(filePath string) (*Manifest, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, errorx.Wrap(err, "读取清单文件失败")
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, errorx.Wrap(err, "解析清单文件失败")
+	}
+	m.filePath = filePath
+	return m, nil
+}
+
+// List 列出所有清单，最新的在前
+func (mm *ManifestManager) List() ([]*Manifest, error) {
+	files, err := filepath.Glob(filepath.Join(mm.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Manifest, 0, len(files))
+	for _, file := range files {
+		if m, err := mm.loadFile(file); err == nil {
+			list = append(list, m)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+// RestoreResult 恢复结果
+type RestoreResult struct {
+	Restored []string
+	Skipped  []string
+	Errors   []string
+}
+
+// Rollback 回滚清单中移动到回收站的所有目标
+func (mm *ManifestManager) Rollback(m *Manifest, overwrite bool) (*RestoreResult, error) {
+	res := &RestoreResult{}
+	for _, e := range m.Entries {
+		if !e.Trashed() {
+			res.Skipped = append(res.Skipped, e.Path+" (已直接删除，无法恢复)")
+			continue
+		}
+
+		item := &TrashItem{Path: e.Path, TrashPath: e.TrashPath, InfoPath: e.InfoPath}
+		if err := RestoreTrash(item, overwrite); err != nil {
+			res.Errors = append(res.Errors, err.Error())
+		} else {
+			res.Restored = append(res.Restored, e.Path)
+		}
+	}
+
+	now := time.Now()
+	m.RestoredAt = &now
+	return res, mm.Save(m)
+}
+
+// TrashItem 回收站中由 kite 清理的条目
+type TrashItem struct {
+	// Name 回收站中的文件名
+	Name string `json:"name"`
+	// Path 原始路径
+	Path string `json:"path"`
+	// TrashPath 在回收站中的路径
+	TrashPath string `json:"trash_path"`
+	// InfoPath Linux 下的 .trashinfo 文件路径
+	InfoPath  string    `json:"info_path,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+	Size      int64     `json:"size"`
+	IsDir     bool      `json:"is_dir"`
+}
+
+// ListTrash 列出回收站中由 kite 清理的条目，最新的在前
+//
+//   - Linux: 读取 ~/.local/share/Trash/info 中带有 kite 标记的 .trashinfo
+//   - 其他平台: 从清理清单中读取仍在回收站中的条目
+func ListTrash(mm *ManifestManager) ([]*TrashItem, error) {
+	var items []*TrashItem
+	if CurrentPlatform() == PlatformLinux {
+		infoFiles, err := filepath.Glob(filepath.Join(linuxTrashDir(), "info", "*.trashinfo"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, infoFile := range infoFiles {
+			item, err := ParseTrashInfo(infoFile)
+			if err != nil || item == nil {
+				continue
+			}
+			items = append(items, item)
+		}
+	} else {
+		list, err := mm.List()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range list {
+			for _, e := range m.Entries {
+				if e.Trashed() && fsutil.PathExists(e.TrashPath) {
+					items = append(items, &TrashItem{
+						Name:      filepath.Base(e.TrashPath),
+						Path:      e.Path,
+						TrashPath: e.TrashPath,
+						DeletedAt: m.CreatedAt,
+						Size:      e.Size,
+						IsDir:     e.IsDir,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// ParseTrashInfo 解析 .trashinfo 文件. 不是 kite 创建的条目返回 nil
+func ParseTrashInfo(infoFile string) (*TrashItem, error) {
+	data, err := os.ReadFile(infoFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var isKite bool
+	item := &TrashItem{InfoPath: infoFile}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, val, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "Path":
+			item.Path = unescapeTrashPath(val)
+		case "DeletionDate":
+			item.DeletedAt = parseDeletionDate(val)
+		case trashInfoKiteKey:
+			isKite = val == "true"
+		}
+	}
+	if !isKite {
+		return nil, nil
+	}
+
+	item.Name = strings.TrimSuffix(filepath.Base(infoFile), ".trashinfo")
+	item.TrashPath = filepath.Join(filepath.Dir(filepath.Dir(infoFile)), "files", item.Name)
+	fi, err := os.Lstat(item.TrashPath)
+	if err != nil {
+		return nil, errorx.Wrap(err, "回收站文件不存在")
+	}
+
+	item.IsDir = fi.IsDir()
+	item.Size = pathSize(item.TrashPath, fi)
+	return item, nil
+}
+
+// RestoreTrash 恢复回收站条目到原始路径
+func RestoreTrash(item *TrashItem, overwrite bool) error {
+	if !fsutil.PathExists(item.TrashPath) {
+		return errorx.Errorf("回收站文件不存在：%s", item.TrashPath)
+	}
+
+	if fsutil.PathExists(item.Path) {
+		if !overwrite {
+			return errorx.Errorf("目标已存在：%s", item.Path)
+		}
+		if err := os.RemoveAll(item.Path); err != nil {
+			return errorx.Wrap(err, "删除已存在的目标失败")
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(item.Path), 0755); err != nil {
+		return errorx.Wrap(err, "创建目标目录失败")
+	}
+	if err := os.Rename(item.TrashPath, item.Path); err != nil {
+		return errorx.Wrap(err, "恢复文件失败")
+	}
+
+	if item.InfoPath != "" {
+		_ = os.Remove(item.InfoPath)
+	}
+	return nil
+}
+
+// PurgeTrash 从回收站彻底删除条目
+func PurgeTrash(item *TrashItem) error {
+	if err := os.RemoveAll(item.TrashPath); err != nil {
+		return errorx.Wrap(err, "删除回收站文件失败")
+	}
+	if item.InfoPath != "" {
+		_ = os.Remove(item.InfoPath)
+	}
+	return nil
+}
+
+// pathSize 获取文件/目录大小
+func pathSize(path string, fi os.FileInfo) int64 {
+	if !fi.IsDir() {
+		return fi.Size()
+	}
+
+	var size int64
+	_ = filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,137 @@
+package sysclean
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+// 使用临时 HOME 和回收站目录
+func setupTrash(t *testing.T) (homeDir string, tm TrashManager) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the trash tests only run on linux")
+	}
+
+	homeDir = t.TempDir()
+	t.Setenv("HOME", homeDir)
+	assert.NoErr(t, os.MkdirAll(linuxTrashDir(), 0700))
+
+	tm = NewTrashManager()
+	assert.True(t, tm.IsAvailable())
+	return homeDir, tm
+}
+
+func TestNewManifest_ID(t *testing.T) {
+	m := NewManifest(true)
+	// ID with milliseconds
+	assert.Eq(t, m.CreatedAt.Format("20060102-150405.000"), m.ID)
+	assert.StrContains(t, m.ID, ".")
+}
+
+func TestParseTrashInfo(t *testing.T) {
+	homeDir, tm := setupTrash(t)
+
+	src := filepath.Join(homeDir, "my project", "node_modules")
+	assert.NoErr(t, os.MkdirAll(src, 0755))
+	assert.NoErr(t, os.WriteFile(filepath.Join(src, "a.js"), []byte("abc"), 0644))
+
+	trashPath, err := tm.Move(src)
+	assert.NoErr(t, err)
+	assert.False(t, fsutil.PathExists(src))
+
+	item, err := ParseTrashInfo(trashInfoPath(trashPath))
+	assert.NoErr(t, err)
+	assert.NotNil(t, item)
+	assert.Eq(t, src, item.Path)
+	assert.Eq(t, trashPath, item.TrashPath)
+	assert.Eq(t, "node_modules", item.Name)
+	assert.True(t, item.IsDir)
+	assert.Eq(t, int64(3), item.Size)
+	assert.False(t, item.DeletedAt.IsZero())
+
+	// not created by kite
+	infoFile := filepath.Join(linuxTrashDir(), "info", "other.trashinfo")
+	assert.NoErr(t, os.WriteFile(infoFile, []byte("[Trash Info]\nPath=/tmp/other\nDeletionDate=2026-01-02T15:04:05\n"), 0600))
+	item, err = ParseTrashInfo(infoFile)
+	assert.NoErr(t, err)
+	assert.Nil(t, item)
+
+	items, err := ListTrash(NewManifestManager(t.TempDir()))
+	assert.NoErr(t, err)
+	assert.Len(t, items, 1)
+	assert.Eq(t, src, items[0].Path)
+}
+
+func TestRestoreTrash_PurgeTrash(t *testing.T) {
+	homeDir, tm := setupTrash(t)
+
+	src := filepath.Join(homeDir, "app.log")
+	assert.NoErr(t, os.WriteFile(src, []byte("log"), 0644))
+	trashPath, err := tm.Move(src)
+	assert.NoErr(t, err)
+
+	item, err := ParseTrashInfo(trashInfoPath(trashPath))
+	assert.NoErr(t, err)
+
+	// target exists
+	assert.NoErr(t, os.WriteFile(src, []byte("new"), 0644))
+	assert.ErrSubMsg(t, RestoreTrash(item, false), "目标已存在")
+
+	assert.NoErr(t, RestoreTrash(item, true))
+	assert.Eq(t, "log", string(fsutil.MustReadFile(src)))
+	assert.False(t, fsutil.PathExists(trashPath))
+	assert.False(t, fsutil.PathExists(item.InfoPath))
+
+	// purge
+	trashPath, err = tm.Move(src)
+	assert.NoErr(t, err)
+	item, err = ParseTrashInfo(trashInfoPath(trashPath))
+	assert.NoErr(t, err)
+
+	assert.NoErr(t, PurgeTrash(item))
+	assert.False(t, fsutil.PathExists(trashPath))
+	assert.False(t, fsutil.PathExists(item.InfoPath))
+	assert.Err(t, RestoreTrash(item, false))
+}
+
+func TestManifestManager_Rollback(t *testing.T) {
+	homeDir, tm := setupTrash(t)
+	mm := NewManifestManager(filepath.Join(homeDir, "manifests"))
+
+	trashed := filepath.Join(homeDir, "proj", "dist")
+	deleted := filepath.Join(homeDir, "proj", "tmp.txt")
+	assert.NoErr(t, os.MkdirAll(trashed, 0755))
+	assert.NoErr(t, os.WriteFile(deleted, []byte("tmp"), 0644))
+
+	m := NewManifest(true)
+	trashPath, err := DeleteTarget(trashed, true, tm)
+	assert.NoErr(t, err)
+	m.Add(&CleanTarget{Path: trashed, IsDir: true, RuleName: "dist"}, trashPath)
+	_, err = DeleteTarget(deleted, false, tm)
+	assert.NoErr(t, err)
+	m.Add(&CleanTarget{Path: deleted, Size: 3, RuleName: "tmp"}, "")
+	assert.NoErr(t, mm.Save(m))
+
+	last, err := mm.Load("last")
+	assert.NoErr(t, err)
+	assert.Eq(t, m.ID, last.ID)
+	assert.Len(t, last.Entries, 2)
+	assert.Eq(t, int64(3), last.TotalSize())
+
+	res, err := mm.Rollback(last, false)
+	assert.NoErr(t, err)
+	assert.Eq(t, []string{trashed}, res.Restored)
+	assert.Len(t, res.Skipped, 1)
+	assert.Empty(t, res.Errors)
+	assert.True(t, fsutil.IsDir(trashed))
+	assert.False(t, fsutil.PathExists(deleted))
+
+	// the restored time is saved
+	m, err = mm.Load(m.ID)
+	assert.NoErr(t, err)
+	assert.NotNil(t, m.RestoredAt)
+}
@@ -0,0 +1,227 @@
+package sysclean
+
+import (
+	"bytes"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/fsutil"
+)
+
+// ProjectType 项目类型
+type ProjectType string
+
+const (
+	ProjectTypeGo     ProjectType = "go"
+	ProjectTypeNode   ProjectType = "node"
+	ProjectTypePython ProjectType = "python"
+	ProjectTypeJava   ProjectType = "java"
+	ProjectTypeRust   ProjectType = "rust"
+)
+
+// projectMarkers 项目根目录的标记文件，按顺序检测
+var projectMarkers = []struct {
+	File string
+	Type ProjectType
+}{
+	{"go.mod", ProjectTypeGo},
+	{"package.json", ProjectTypeNode},
+	{"pyproject.toml", ProjectTypePython},
+	{"setup.py", ProjectTypePython},
+	{"requirements.txt", ProjectTypePython},
+	{"pom.xml", ProjectTypeJava},
+	{"build.gradle", ProjectTypeJava},
+	{"build.gradle.kts", ProjectTypeJava},
+	{"Cargo.toml", ProjectTypeRust},
+}
+
+// projectWalkSkips 计算项目最后修改时间时跳过的目录名
+var projectWalkSkips = map[string]bool{".git": true, "node_modules": true, ".idea": true, ".vscode": true}
+
+// ProjectGroup 按项目分组的目标
+type ProjectGroup struct {
+	Root string      `json:"root"`
+	Type ProjectType `json:"type"`
+	// ModTime 项目源文件(不含清理目标)的最后修改时间
+	ModTime time.Time `json:"mod_time"`
+	// Dirty 是否有未提交的 git 变更
+	Dirty bool `json:"dirty,omitempty"`
+	// SkipReason 跳过原因，为空表示会被清理
+	SkipReason  string         `json:"skip_reason,omitempty"`
+	TargetCount int            `json:"target_count"`
+	TotalSize   int64          `json:"total_size"`
+	Targets     []*CleanTarget `json:"-"`
+}
+
+// Skipped 项目是否被跳过
+func (pg *ProjectGroup) Skipped() bool {
+	return pg.SkipReason != ""
+}
+
+// DetectProjectRoot 从 path 所在目录向上查找项目根目录，最多查找到 stopDir(包含)。
+// 未找到时返回空字符串
+func DetectProjectRoot(path, stopDir string) (string, ProjectType) {
+	dir := filepath.Dir(path)
+	for {
+		for _, m := range projectMarkers {
+			if fsutil.IsFile(filepath.Join(dir, m.File)) {
+				return dir, m.Type
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if dir == stopDir || parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// ApplyProjectMode 项目模式: 按项目分组扫描结果，跳过活跃的项目。
+//
+//   - 不在任何项目中的目标会被忽略
+//   - 项目在 config.StaleDays 天内有修改时跳过
+//   - 项目有未提交的 git 变更时跳过，除非 config.AllowDirty=true
+//
+// 会重建 result.Groups，只保留需要清理的项目中的目标
+func ApplyProjectMode(result *ScanResult, scanDirs []string, config *CleanConfig) {
+	projects := make(map[string]*ProjectGroup)
+	for _, group := range result.Groups {
+		for _, target := range group.Targets {
+			root, pType := DetectProjectRoot(target.Path, findScanDir(target.Path, scanDirs))
+			if root == "" {
+				continue
+			}
+
+			pg, ok := projects[root]
+			if !ok {
+				pg = &ProjectGroup{Root: root, Type: pType}
+				projects[root] = pg
+			}
+			pg.Targets = append(pg.Targets, target)
+			pg.TargetCount++
+			pg.TotalSize += target.Size
+		}
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -config.StaleDays)
+	keep := make(map[string]bool)
+	result.Projects = make([]*ProjectGroup, 0, len(projects))
+	for _, pg := range projects {
+		pg.ModTime = projectModTime(pg.Root, pg.Targets)
+		pg.Dirty = gitDirty(pg.Root)
+
+		switch {
+		case config.StaleDays > 0 && pg.ModTime.After(cutoff):
+			pg.SkipReason = "最近有修改"
+		case pg.Dirty && !config.AllowDirty:
+			pg.SkipReason = "有未提交的变更"
+		default:
+			for _, target := range pg.Targets {
+				keep[target.Path] = true
+			}
+		}
+		result.Projects = append(result.Projects, pg)
+	}
+
+	sort.Slice(result.Projects, func(i, j int) bool {
+		return result.Projects[i].TotalSize > result.Projects[j].TotalSize
+	})
+	rebuildGroups(result, keep)
+}
+
+// findScanDir 查找 path 所在的扫描目录
+func findScanDir(path string, scanDirs []string) string {
+	for _, dir := range scanDirs {
+		dir = filepath.Clean(fsutil.ExpandPath(dir))
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// projectModTime 获取项目中除清理目标外的文件的最后修改时间
+func projectModTime(root string, targets []*CleanTarget) time.Time {
+	skips := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		skips[target.Path] = true
+	}
+
+	var latest time.Time
+	_ = filepath.WalkDir(root, func(path string, ent fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if skips[path] || (ent.IsDir() && projectWalkSkips[ent.Name()]) {
+			if ent.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// 只统计文件，目录的修改时间会因清理目标的变化而更新
+		if ent.IsDir() {
+			return nil
+		}
+		if info, err := ent.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest
+}
+
+// gitDirty 检查项目目录是否有未提交的 git 变更。不是 git 仓库时返回 false
+func gitDirty(root string) bool {
+	cmd := exec.Command("git", "status", "--porcelain", "--", ".")
+	cmd.Dir = root
+
+	out, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	return len(bytes.TrimSpace(out)) > 0
+}
+
+// rebuildGroups 按保留的目标重建规则分组和统计
+func rebuildGroups(result *ScanResult, keep map[string]bool) {
+	groups := make([]*TargetGroup, 0, len(result.Groups))
+	result.TotalTargets, result.TotalSize = 0, 0
+	result.TotalFiles, result.TotalDirs = 0, 0
+
+	for _, group := range result.Groups {
+		ng := &TargetGroup{
+			RuleName:  group.RuleName,
+			Category:  group.Category,
+			RiskLevel: group.RiskLevel,
+			Targets:   make([]*CleanTarget, 0, len(group.Targets)),
+		}
+
+		for _, target := range group.Targets {
+			if !keep[target.Path] {
+				continue
+			}
+			ng.Targets = append(ng.Targets, target)
+			ng.TotalSize += target.Size
+			if target.IsDir {
+				ng.DirCount++
+			} else {
+				ng.FileCount++
+			}
+		}
+
+		if len(ng.Targets) > 0 {
+			groups = append(groups, ng)
+			result.TotalTargets += len(ng.Targets)
+			result.TotalSize += ng.TotalSize
+			result.TotalFiles += ng.FileCount
+			result.TotalDirs += ng.DirCount
+		}
+	}
+	result.Groups = groups
+}
@@ -0,0 +1,107 @@
+package sysclean
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func TestDetectProjectRoot(t *testing.T) {
+	dir := t.TempDir()
+	proj := filepath.Join(dir, "ws", "api")
+	assert.NoErr(t, os.MkdirAll(filepath.Join(proj, "web", "dist"), 0755))
+	assert.NoErr(t, os.WriteFile(filepath.Join(proj, "go.mod"), []byte("module api"), 0644))
+	assert.NoErr(t, os.WriteFile(filepath.Join(proj, "web", "package.json"), []byte("{}"), 0644))
+
+	// nearest project root
+	root, typ := DetectProjectRoot(filepath.Join(proj, "web", "dist"), dir)
+	assert.Eq(t, filepath.Join(proj, "web"), root)
+	assert.Eq(t, ProjectTypeNode, typ)
+
+	root, typ = DetectProjectRoot(filepath.Join(proj, "bin"), dir)
+	assert.Eq(t, proj, root)
+	assert.Eq(t, ProjectTypeGo, typ)
+
+	// not found, stop at the stop dir
+	root, _ = DetectProjectRoot(filepath.Join(dir, "ws", "tmp"), filepath.Join(dir, "ws"))
+	assert.Eq(t, "", root)
+}
+
+func TestProjectModTime(t *testing.T) {
+	proj := t.TempDir()
+	assert.NoErr(t, os.MkdirAll(filepath.Join(proj, "dist"), 0755))
+	assert.NoErr(t, os.MkdirAll(filepath.Join(proj, ".git"), 0755))
+
+	old := time.Now().AddDate(0, 0, -60)
+	src := filepath.Join(proj, "main.go")
+	assert.NoErr(t, os.WriteFile(src, []byte("package main"), 0644))
+	assert.NoErr(t, os.Chtimes(src, old, old))
+
+	// the targets and skip dirs are not counted
+	for _, file := range []string{"dist/app.js", ".git/index"} {
+		assert.NoErr(t, os.WriteFile(filepath.Join(proj, file), []byte("x"), 0644))
+	}
+
+	targets := []*CleanTarget{{Path: filepath.Join(proj, "dist"), IsDir: true}}
+	assert.Eq(t, old.Unix(), projectModTime(proj, targets).Unix())
+}
+
+func TestGitDirty(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// not a git repo
+	dir := t.TempDir()
+	assert.False(t, gitDirty(dir))
+
+	assert.NoErr(t, exec.Command("git", "init", "-q", dir).Run())
+	assert.False(t, gitDirty(dir))
+	assert.NoErr(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644))
+	assert.True(t, gitDirty(dir))
+}
+
+func TestApplyProjectMode(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().AddDate(0, 0, -60)
+	newProject := func(name string, modTime time.Time) *CleanTarget {
+		proj := filepath.Join(dir, name)
+		assert.NoErr(t, os.MkdirAll(filepath.Join(proj, "node_modules"), 0755))
+		pkgFile := filepath.Join(proj, "package.json")
+		assert.NoErr(t, os.WriteFile(pkgFile, []byte("{}"), 0644))
+		assert.NoErr(t, os.Chtimes(pkgFile, modTime, modTime))
+		return &CleanTarget{Path: filepath.Join(proj, "node_modules"), Size: 10, IsDir: true, RuleName: "node_modules"}
+	}
+
+	stale := newProject("stale", old)
+	active := newProject("active", time.Now())
+	// not in any project
+	assert.NoErr(t, os.MkdirAll(filepath.Join(dir, "node_modules"), 0755))
+	orphan := &CleanTarget{Path: filepath.Join(dir, "node_modules"), Size: 10, IsDir: true, RuleName: "node_modules"}
+
+	result := &ScanResult{Groups: []*TargetGroup{
+		{RuleName: "node_modules", Targets: []*CleanTarget{stale, active, orphan}, TotalSize: 30, DirCount: 3},
+	}}
+
+	ApplyProjectMode(result, []string{dir}, &CleanConfig{StaleDays: 30})
+	assert.Len(t, result.Projects, 2)
+	for _, pg := range result.Projects {
+		assert.Eq(t, ProjectTypeNode, pg.Type)
+		if pg.Root == filepath.Join(dir, "active") {
+			assert.Eq(t, "最近有修改", pg.SkipReason)
+		} else {
+			assert.False(t, pg.Skipped())
+		}
+	}
+
+	// only keep the stale project target
+	assert.Len(t, result.Groups, 1)
+	assert.Eq(t, []*CleanTarget{stale}, result.Groups[0].Targets)
+	assert.Eq(t, 1, result.TotalTargets)
+	assert.Eq(t, int64(10), result.TotalSize)
+	assert.Eq(t, 1, result.TotalDirs)
+}
@@ -77,7 +77,9 @@ func (s *Scanner) Scan(ctx context.Context, dirs []string) (*ScanResult, error)
 	doneChan := make(chan struct{})
 
 	// 启动结果收集器
+	collectDone := make(chan struct{})
 	go func() {
+		defer close(collectDone)
 		for {
 			select {
 			case target := <-targetChan:
@@ -85,7 +87,17 @@ func (s *Scanner) Scan(ctx context.Context, dirs []string) (*ScanResult, error)
 			case err := <-errorChan:
 				s.addError(err)
 			case <-doneChan:
-				return
+				// 扫描已结束，处理通道中剩余的结果
+				for {
+					select {
+					case target := <-targetChan:
+						s.addTarget(target, ruleGroups)
+					case err := <-errorChan:
+						s.addError(err)
+					default:
+						return
+					}
+				}
 			}
 		}
 	}()
@@ -99,6 +111,7 @@ func (s *Scanner) Scan(ctx context.Context, dirs []string) (*ScanResult, error)
 	// 等待所有扫描完成
 	s.wg.Wait()
 	close(doneChan)
+	<-collectDone
 
 	// 收集结果
 	s.result.ScanDuration = time.Since(startTime)
@@ -157,10 +170,11 @@ func (s *Scanner) scanDir(ctx context.Context, dir string, targetChan chan<- *Cl
 	}
 
 	// 展开路径中的 ~ 和环境变量
-	dir = fsutil.ExpandPath(dir)
+	dir = filepath.Clean(fsutil.ExpandPath(dir))
+	maxDepth := s.getMaxDepth()
 
-	// 遍历目录
-	err := fsutil.FindInDir(dir, func(path string, ent fs.DirEntry) error {
+	// 递归遍历目录
+	err := filepath.WalkDir(dir, func(path string, ent fs.DirEntry, err error) error {
 		// 检查上下文
 		select {
 		case <-ctx.Done():
@@ -168,6 +182,11 @@ func (s *Scanner) scanDir(ctx context.Context, dir string, targetChan chan<- *Cl
 		default:
 		}
 
+		// 忽略无法访问的目录和扫描根目录本身
+		if err != nil || path == dir {
+			return nil
+		}
+
 		// 获取文件信息
 		info, err := ent.Info()
 		if err != nil {
@@ -195,10 +214,18 @@ func (s *Scanner) scanDir(ctx context.Context, dir string, targetChan chan<- *Cl
 					Category:  rule.Category,
 					RiskLevel: rule.RiskLevel,
 				}
+				// 匹配的目录整体清理，不再深入扫描
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
 				break // 每个路径只匹配一个规则
 			}
 		}
 
+		// 检查扫描深度
+		if maxDepth > 0 && info.IsDir() && strings.Count(path[len(dir):], string(filepath.Separator)) >= maxDepth {
+			return filepath.SkipDir
+		}
 		return nil
 	})
 
@@ -220,10 +247,19 @@ func (s *Scanner) isExcluded(path string) bool {
 	return false
 }
 
-// getMaxDepth 获取最大深度
+// getMaxDepth 获取最大深度, 返回 0 表示无限制
+//
+//   - MaxDepth < 0: 无限制
+//   - MaxDepth = 0: 默认值. 项目模式无限制，否则只扫描一层
 func (s *Scanner) getMaxDepth() int {
-	if s.config.MaxDepth < 0 {
-		return 0 // 0 表示无限制
+	switch {
+	case s.config.MaxDepth < 0:
+		return 0
+	case s.config.MaxDepth == 0:
+		if s.config.ProjectMode {
+			return 0
+		}
+		return 1
 	}
 	return s.config.MaxDepth
 }
@@ -0,0 +1,43 @@
+package sysclean
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func TestScanner_Scan_maxDepth(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"dist", "app/dist", "app/sub/dist"} {
+		assert.NoErr(t, os.MkdirAll(filepath.Join(dir, sub), 0755))
+	}
+
+	rules := []*CleanRule{{Name: "dist", TargetType: TargetTypeDir, NameMatches: []string{"dist"}, Enabled: true}}
+	scanCount := func(cfg *CleanConfig) int {
+		cfg.ExcludeDirs = nil
+		res, err := NewScanner(cfg, rules).Scan(context.Background(), []string{dir})
+		assert.NoErr(t, err)
+		return res.TotalTargets
+	}
+
+	// default only scan the top level
+	cfg := DefaultCleanConfig()
+	assert.Eq(t, 0, cfg.MaxDepth)
+	assert.Eq(t, 1, scanCount(cfg))
+
+	// project mode or explicit max depth
+	cfg = DefaultCleanConfig()
+	cfg.ProjectMode = true
+	assert.Eq(t, 3, scanCount(cfg))
+
+	cfg = DefaultCleanConfig()
+	cfg.MaxDepth = 2
+	assert.Eq(t, 2, scanCount(cfg))
+
+	cfg = DefaultCleanConfig()
+	cfg.MaxDepth = -1
+	assert.Eq(t, 3, scanCount(cfg))
+}
@@ -1,9 +1,12 @@
 package sysclean
 
 import (
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gookit/goutil/errorx"
@@ -12,10 +15,17 @@ import (
 
 // TrashManager 回收站管理器接口
 type TrashManager interface {
-	Move(path string) error
+	// Move 移动到回收站，返回在回收站中的路径
+	Move(path string) (string, error)
 	IsAvailable() bool
 }
 
+// trashInfoKiteKey 标记 .trashinfo 是由 kite 清理创建的
+const trashInfoKiteKey = "X-Kite-Clean"
+
+// trashInfoDateLayout FreeDesktop.org 规范的 DeletionDate 格式
+const trashInfoDateLayout = "2006-01-02T15:04:05"
+
 // trashManager 回收站管理器实现
 type trashManager struct {
 	available bool
@@ -35,26 +45,24 @@ func checkTrashAvailable() bool {
 		return true
 	case "linux":
 		// Linux 需要检查 FreeDesktop.org trash 规范
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
+		if _, err := os.UserHomeDir(); err != nil {
 			return false
 		}
-		trashDir := filepath.Join(homeDir, ".local", "share", "Trash")
-		return fsutil.IsDir(trashDir)
+		return fsutil.IsDir(linuxTrashDir())
 	default:
 		return false
 	}
 }
 
 // Move 移动文件到回收站
-func (tm *trashManager) Move(path string) error {
+func (tm *trashManager) Move(path string) (string, error) {
 	if !tm.available {
-		return errorx.New("回收站功能不可用")
+		return "", errorx.New("回收站功能不可用")
 	}
 
 	// 检查源文件是否存在
 	if !fsutil.PathExists(path) {
-		return errorx.Errorf("文件不存在：%s", path)
+		return "", errorx.Errorf("文件不存在：%s", path)
 	}
 
 	switch runtime.GOOS {
@@ -65,7 +73,7 @@ func (tm *trashManager) Move(path string) error {
 	case "linux":
 		return tm.moveToTrashLinux(path)
 	default:
-		return errorx.New("不支持的操作系统")
+		return "", errorx.New("不支持的操作系统")
 	}
 }
 
@@ -75,17 +83,17 @@ func (tm *trashManager) IsAvailable() bool {
 }
 
 // moveToTrashWindows Windows 平台移动到回收站
-func (tm *trashManager) moveToTrashWindows(path string) error {
+func (tm *trashManager) moveToTrashWindows(path string) (string, error) {
 	// Windows 下使用简单的方式：移动到临时目录模拟回收站
 	// 完整实现需要调用 SHFileOperation API，这里使用简化版本
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	trashDir := filepath.Join(homeDir, ".kite-go", "trash")
 	if err := os.MkdirAll(trashDir, 0755); err != nil {
-		return errorx.Wrap(err, "创建回收站目录失败")
+		return "", errorx.Wrap(err, "创建回收站目录失败")
 	}
 
 	// 生成唯一的目标名称
@@ -101,23 +109,23 @@ func (tm *trashManager) moveToTrashWindows(path string) error {
 
 	// 移动文件
 	if err := os.Rename(path, targetPath); err != nil {
-		return errorx.Wrap(err, "移动文件到回收站失败")
+		return "", errorx.Wrap(err, "移动文件到回收站失败")
 	}
 
-	return nil
+	return targetPath, nil
 }
 
 // moveToTrashDarwin macOS 平台移动到回收站
-func (tm *trashManager) moveToTrashDarwin(path string) error {
+func (tm *trashManager) moveToTrashDarwin(path string) (string, error) {
 	// macOS 下移动到 ~/.Trash
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	trashDir := filepath.Join(homeDir, ".Trash")
 	if err := os.MkdirAll(trashDir, 0700); err != nil {
-		return errorx.Wrap(err, "创建回收站目录失败")
+		return "", errorx.Wrap(err, "创建回收站目录失败")
 	}
 
 	baseName := filepath.Base(path)
@@ -132,60 +140,92 @@ func (tm *trashManager) moveToTrashDarwin(path string) error {
 
 	// 移动文件
 	if err := os.Rename(path, targetPath); err != nil {
-		return errorx.Wrap(err, "移动文件到回收站失败")
+		return "", errorx.Wrap(err, "移动文件到回收站失败")
 	}
 
-	return nil
+	return targetPath, nil
 }
 
 // moveToTrashLinux Linux 平台移动到回收站
-func (tm *trashManager) moveToTrashLinux(path string) error {
+func (tm *trashManager) moveToTrashLinux(path string) (string, error) {
 	// Linux 下使用 FreeDesktop.org Trash 规范
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return err
-	}
-
-	trashDir := filepath.Join(homeDir, ".local", "share", "Trash")
+	trashDir := linuxTrashDir()
 	filesDir := filepath.Join(trashDir, "files")
 	infoDir := filepath.Join(trashDir, "info")
 
 	// 创建必要的目录
 	if err := os.MkdirAll(filesDir, 0700); err != nil {
-		return errorx.Wrap(err, "创建回收站文件目录失败")
+		return "", errorx.Wrap(err, "创建回收站文件目录失败")
 	}
 	if err := os.MkdirAll(infoDir, 0700); err != nil {
-		return errorx.Wrap(err, "创建回收站信息目录失败")
+		return "", errorx.Wrap(err, "创建回收站信息目录失败")
 	}
 
 	baseName := filepath.Base(path)
 	targetPath := filepath.Join(filesDir, baseName)
-	infoPath := filepath.Join(infoDir, baseName+".trashinfo")
 
 	// 如果目标已存在，添加后缀
-	counter := 1
-	for fsutil.PathExists(targetPath) {
-		ext := filepath.Ext(baseName)
-		name := baseName[:len(baseName)-len(ext)]
-		targetPath = filepath.Join(filesDir, name+"_"+string(rune('0'+counter))+ext)
-		infoPath = filepath.Join(infoDir, name+"_"+string(rune('0'+counter))+ext+".trashinfo")
-		counter++
+	ext := filepath.Ext(baseName)
+	name := baseName[:len(baseName)-len(ext)]
+	for counter := 1; fsutil.PathExists(targetPath) || fsutil.PathExists(trashInfoPath(targetPath)); counter++ {
+		targetPath = filepath.Join(filesDir, name+"_"+strconv.Itoa(counter)+ext)
 	}
+	infoPath := trashInfoPath(targetPath)
 
-	// 创建 .trashinfo 文件
-	infoContent := "[Trash Info]\nPath=" + path + "\nDeletionDate=" + time.Now().Format(time.RFC3339) + "\n"
+	// 创建 .trashinfo 文件. 附加 kite 标记，用于 clean trash 命令识别
+	absPath, _ := filepath.Abs(path)
+	infoContent := "[Trash Info]\nPath=" + escapeTrashPath(absPath) +
+		"\nDeletionDate=" + time.Now().Format(trashInfoDateLayout) +
+		"\n" + trashInfoKiteKey + "=true\n"
 	if err := os.WriteFile(infoPath, []byte(infoContent), 0600); err != nil {
-		return errorx.Wrap(err, "创建回收站信息文件失败")
+		return "", errorx.Wrap(err, "创建回收站信息文件失败")
 	}
 
 	// 移动文件
 	if err := os.Rename(path, targetPath); err != nil {
 		// 删除 info 文件
 		_ = os.Remove(infoPath)
-		return errorx.Wrap(err, "移动文件到回收站失败")
+		return "", errorx.Wrap(err, "移动文件到回收站失败")
 	}
 
-	return nil
+	return targetPath, nil
+}
+
+// linuxTrashDir Linux 回收站目录: ~/.local/share/Trash
+func linuxTrashDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".local", "share", "Trash")
+}
+
+// trashInfoPath 根据回收站文件路径获取 .trashinfo 路径
+func trashInfoPath(trashPath string) string {
+	trashDir := filepath.Dir(filepath.Dir(trashPath))
+	return filepath.Join(trashDir, "info", filepath.Base(trashPath)+".trashinfo")
+}
+
+// escapeTrashPath 按规范对 Path 做 URL 转义，保留路径分隔符
+func escapeTrashPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func unescapeTrashPath(val string) string {
+	if path, err := url.PathUnescape(val); err == nil {
+		return path
+	}
+	return val
+}
+
+// parseDeletionDate 解析 DeletionDate，兼容旧版本写入的 RFC3339 格式
+func parseDeletionDate(val string) time.Time {
+	if t, err := time.ParseInLocation(trashInfoDateLayout, val, time.Local); err == nil {
+		return t
+	}
+	t, _ := time.Parse(time.RFC3339, val)
+	return t
 }
 
 // DeleteDirect 直接删除文件（不使用回收站）
@@ -214,10 +254,10 @@ func DeleteDirect(path string) error {
 	return nil
 }
 
-// DeleteTarget 清理目标（根据配置决定是否使用回收站）
-func DeleteTarget(path string, useTrash bool, trashMgr TrashManager) error {
+// DeleteTarget 清理目标（根据配置决定是否使用回收站）. 移动到回收站时返回在回收站中的路径
+func DeleteTarget(path string, useTrash bool, trashMgr TrashManager) (string, error) {
 	if useTrash && trashMgr != nil && trashMgr.IsAvailable() {
 		return trashMgr.Move(path)
 	}
-	return DeleteDirect(path)
+	return "", DeleteDirect(path)
 }
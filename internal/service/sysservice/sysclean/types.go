@@ -159,6 +159,8 @@ type CleanStats struct {
 	FailedSpace   int64         `json:"failed_space"`
 	Duration      time.Duration `json:"duration"`
 	UseTrash      bool          `json:"use_trash"`
+	// ManifestID 本次清理的清单 ID
+	ManifestID    string        `json:"manifest_id,omitempty"`
 }
 
 // CategoryStats 分类统计
@@ -199,6 +201,8 @@ type ScanResult struct {
 
 	// 分组结果
 	Groups []*TargetGroup `json:"groups"`
+	// 项目模式下按项目分组的结果
+	Projects []*ProjectGroup `json:"projects,omitempty"`
 
 	// 错误列表
 	Errors []ScanError `json:"errors,omitempty"`
@@ -224,6 +228,9 @@ type CleanReport struct {
 	// 规则统计
 	ByRule map[string]*RuleStats `json:"by_rule"`
 
+	// 项目统计，仅项目模式
+	ByProject []*ProjectGroup `json:"by_project,omitempty"`
+
 	// 警告和错误
 	Warnings []string `json:"warnings,omitempty"`
 	Errors   []string `json:"errors,omitempty"`
@@ -234,7 +241,7 @@ type CleanConfig struct {
 	// 扫描配置
 	ScanDirs    []string `json:"scan_dirs,omitempty" yaml:"scan_dirs,omitempty"`
 	Concurrency int      `json:"concurrency,omitempty" yaml:"concurrency,omitempty"` // 默认 5
-	MaxDepth    int      `json:"max_depth,omitempty" yaml:"max_depth,omitempty"`     // -1 无限制
+	MaxDepth    int      `json:"max_depth,omitempty" yaml:"max_depth,omitempty"`     // -1 无限制, 0 默认: 项目模式无限制，否则只扫描一层
 	ExcludeDirs []string `json:"exclude_dirs,omitempty" yaml:"exclude_dirs,omitempty"`
 
 	// 规则配置
@@ -250,6 +257,11 @@ type CleanConfig struct {
 	FileExts []string `json:"file_exts,omitempty" yaml:"file_exts,omitempty"`
 	Pattern  string   `json:"pattern,omitempty" yaml:"pattern,omitempty"`
 
+	// 项目模式: 按项目分组，只清理不活跃项目中的目标
+	ProjectMode bool `json:"project_mode,omitempty" yaml:"project_mode,omitempty"`
+	StaleDays   int  `json:"stale_days,omitempty" yaml:"stale_days,omitempty"` // 跳过 N 天内有修改的项目
+	AllowDirty  bool `json:"allow_dirty,omitempty" yaml:"allow_dirty,omitempty"` // 也清理有未提交变更的项目
+
 	// 缓存配置
 	UseCache bool          `json:"use_cache,omitempty" yaml:"use_cache,omitempty"`
 	CacheTTL time.Duration `json:"cache_ttl,omitempty" yaml:"cache_ttl,omitempty"` // 默认 3 分钟
@@ -267,11 +279,12 @@ func DefaultCleanConfig() *CleanConfig {
 	return &CleanConfig{
 		ScanDirs:    []string{homeDir},
 		Concurrency: 5,
-		MaxDepth:    -1,
+		MaxDepth:    0,
 		ExcludeDirs: []string{".git", ".svn", "node_modules"},
 		UseCache:    true,
 		CacheTTL:    3 * time.Minute,
 		DryRun:      true,
+		StaleDays:   30,
 		OutputFormat: "markdown",
 	}
 }
@@ -1,18 +1,326 @@
 package gitx
 
-func BatchPull() {
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/sysutil"
+	"github.com/gookit/goutil/sysutil/cmdr"
+	"github.com/inhere/kite-go/pkg/util/bizutil"
+)
+
+// DefaultBatchDepth default max depth for find git repos in base dir
+const DefaultBatchDepth = 2
+
+// IsRepoDir check the dir is a git repository root. the .git maybe is a dir or file(worktree, submodule)
+func IsRepoDir(dir string) bool {
+	return fsutil.PathExists(filepath.Join(dir, ".git"))
+}
+
+// FindRepos find git repository dirs in the base dir, will not find in sub dirs of a repository.
+//
+// maxDepth is the max dir depth relative to baseDir, <= 0 will use DefaultBatchDepth.
+func FindRepos(baseDir string, maxDepth int) ([]string, error) {
+	if maxDepth <= 0 {
+		maxDepth = DefaultBatchDepth
+	}
+
+	baseDir = filepath.Clean(baseDir)
+	if IsRepoDir(baseDir) {
+		return []string{baseDir}, nil
+	}
+
+	var repos []string
+	err := filepath.WalkDir(baseDir, func(path string, ent fs.DirEntry, err error) error {
+		if err != nil || !ent.IsDir() || path == baseDir {
+			return nil
+		}
+
+		name := ent.Name()
+		if name[0] == '.' || name == "node_modules" || name == "vendor" {
+			return filepath.SkipDir
+		}
+
+		if IsRepoDir(path) {
+			repos = append(repos, path)
+			return filepath.SkipDir
+		}
+
+		rel, _ := filepath.Rel(baseDir, path)
+		if strings.Count(rel, string(filepath.Separator))+1 >= maxDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	sort.Strings(repos)
+	return repos, err
 }
 
-// GitBatchRun struct
+// RepoStatus git status info of a repository.
+type RepoStatus struct {
+	Branch string `json:"branch"`
+	// Upstream eg: origin/main. is empty if not set upstream
+	Upstream string `json:"upstream"`
+	Ahead    int    `json:"ahead"`
+	Behind   int    `json:"behind"`
+	Dirty    bool   `json:"dirty"`
+}
+
+// AheadBehind string. eg: ↑1 ↓2
+func (s *RepoStatus) AheadBehind() string {
+	if s.Upstream == "" {
+		return "-"
+	}
+	if s.Ahead == 0 && s.Behind == 0 {
+		return "✓"
+	}
+
+	var ss []string
+	if s.Ahead > 0 {
+		ss = append(ss, "↑"+strconv.Itoa(s.Ahead))
+	}
+	if s.Behind > 0 {
+		ss = append(ss, "↓"+strconv.Itoa(s.Behind))
+	}
+	return strings.Join(ss, " ")
+}
+
+// ReadRepoStatus read branch, ahead/behind and dirty status of the repo dir
+func ReadRepoStatus(dir string) *RepoStatus {
+	st := &RepoStatus{}
+	st.Branch, _ = gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	st.Upstream, _ = gitOutput(dir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
+
+	if st.Upstream != "" {
+		// output: "AHEAD\tBEHIND"
+		out, err := gitOutput(dir, "rev-list", "--left-right", "--count", "HEAD...@{u}")
+		if err == nil {
+			if nums := strings.Fields(out); len(nums) == 2 {
+				st.Ahead, _ = strconv.Atoi(nums[0])
+				st.Behind, _ = strconv.Atoi(nums[1])
+			}
+		}
+	}
+
+	out, _ := gitOutput(dir, "status", "--porcelain")
+	st.Dirty = out != ""
+	return st
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	out, err := cmdr.NewCmd("git", args...).WithWorkDir(dir).Output()
+	return strings.TrimSpace(out), err
+}
+
+// RepoResult the batch run result of a repository.
+type RepoResult struct {
+	Dir  string `json:"dir"`
+	Name string `json:"name"`
+	// Output combined output of the command
+	Output string        `json:"-"`
+	Error  error         `json:"-"`
+	Cost   time.Duration `json:"cost"`
+	// Status after run command
+	Status *RepoStatus `json:"status,omitempty"`
+}
+
+// Failed check
+func (r *RepoResult) Failed() bool {
+	return r.Error != nil
+}
+
+// ResultText string. eg: ok, failed: exit status 1
+func (r *RepoResult) ResultText() string {
+	if r.Error != nil {
+		return "failed"
+	}
+	return "ok"
+}
+
+// GitBatchRun run git or shell command in multi git repositories concurrently.
 type GitBatchRun struct {
+	// Dirs the repository dirs for run command.
+	Dirs []string
+	// Concurrency the max number of repos to run at the same time. default is 4
+	Concurrency int
+	// ShellMode run the args as shell command line. default will run as git sub-command
+	ShellMode bool
+	// WithStatus read repo status after run command
+	WithStatus bool
+	// DryRun only print the command, not execute it.
+	DryRun bool
+	// BeforeFn hook on before run in a repo
+	BeforeFn func(dir string)
+	// DoneFn hook on a repo is done. will be called in serial, so can print output grouped by repo.
+	DoneFn func(r *RepoResult)
 }
 
+// NewBatchRun instance
 func NewBatchRun(fn ...func(gbr *GitBatchRun)) *GitBatchRun {
-	gbr := &GitBatchRun{}
+	gbr := &GitBatchRun{Concurrency: 4}
 
 	if len(fn) > 0 {
 		fn[0](gbr)
 	}
 	return gbr
 }
+
+// Run the git sub-command(or shell command on ShellMode) in each repo dir.
+// returns results in the order of Dirs.
+func (b *GitBatchRun) Run(args ...string) ([]*RepoResult, error) {
+	if len(args) == 0 {
+		return nil, errorx.Raw("gitx: batch run command is empty")
+	}
+	if len(b.Dirs) == 0 {
+		return nil, errorx.Raw("gitx: not found any git repository for batch run")
+	}
+
+	limit := b.Concurrency
+	if limit <= 0 {
+		limit = 4
+	}
+
+	var doneMu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	results := make([]*RepoResult, len(b.Dirs))
+
+	for i, dir := range b.Dirs {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, dir string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if b.BeforeFn != nil {
+				b.BeforeFn(dir)
+			}
+
+			r := b.runInDir(dir, args)
+			results[i] = r
+			if b.DoneFn != nil {
+				doneMu.Lock()
+				b.DoneFn(r)
+				doneMu.Unlock()
+			}
+		}(i, dir)
+	}
+
+	wg.Wait()
+	return results, nil
+}
+
+func (b *GitBatchRun) runInDir(dir string, args []string) *RepoResult {
+	r := &RepoResult{Dir: dir, Name: filepath.Base(dir)}
+	start := time.Now()
+
+	var cmd *cmdr.Cmd
+	if b.ShellMode {
+		if sysutil.IsWindows() {
+			cmd = cmdr.NewCmd("cmd", "/c", ShellLine(args, bizutil.CmdQuote))
+		} else {
+			cmd = cmdr.NewCmd("sh", "-c", ShellLine(args, bizutil.ShellQuote))
+		}
+	} else {
+		cmd = cmdr.NewGitCmd(args[0], args[1:]...)
+	}
+
+	cmd.WithWorkDir(dir)
+	if b.DryRun {
+		r.Output = "DRY-RUN: " + cmd.Cmdline()
+	} else {
+		r.Output, r.Error = cmd.CombinedOutput()
+	}
+
+	r.Cost = time.Since(start)
+	if b.WithStatus {
+		r.Status = ReadRepoStatus(dir)
+	}
+	return r
+}
+
+// ShellLine build the shell command line by args.
+//
+//   - one arg: use it as the command line directly. eg: "make test && echo ok"
+//   - multi args: each arg will be quoted by quoteFn on it contains special chars.
+func ShellLine(args []string, quoteFn func(s string) string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+
+	ss := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`&|;<>()*?[]{}~!#%^") {
+			arg = quoteFn(arg)
+		}
+		ss[i] = arg
+	}
+	return strings.Join(ss, " ")
+}
+
+// Pull run git pull in each repo dir.
+func (b *GitBatchRun) Pull(args ...string) ([]*RepoResult, error) {
+	b.ShellMode = false
+	return b.Run(append([]string{"pull"}, args...)...)
+}
+
+// BatchPull quick run git pull for multi repo dirs.
+func BatchPull(dirs []string, concurrency int) ([]*RepoResult, error) {
+	return NewBatchRun(func(gbr *GitBatchRun) {
+		gbr.Dirs = dirs
+		gbr.Concurrency = concurrency
+		gbr.WithStatus = true
+	}).Pull()
+}
+
+// FailedDirs collect the failed repo dirs from results.
+func FailedDirs(results []*RepoResult) []string {
+	var dirs []string
+	for _, r := range results {
+		if r != nil && r.Failed() {
+			dirs = append(dirs, r.Dir)
+		}
+	}
+	return dirs
+}
+
+// SaveFailedDirs save the failed repo dirs to file, use for retry with --only-failed.
+func SaveFailedDirs(file string, dirs []string) error {
+	if dirs == nil {
+		dirs = []string{}
+	}
+
+	bs, err := json.MarshalIndent(dirs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(file, bs, fsutil.DefaultFilePerm)
+}
+
+// LoadFailedDirs load the failed repo dirs from file, returns empty on file not exists.
+func LoadFailedDirs(file string) ([]string, error) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var dirs []string
+	err = json.Unmarshal(bs, &dirs)
+	return dirs, err
+}
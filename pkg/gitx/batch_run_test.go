@@ -0,0 +1,88 @@
+package gitx_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/inhere/kite-go/pkg/gitx"
+	"github.com/inhere/kite-go/pkg/util/bizutil"
+)
+
+func initTestRepo(t *testing.T, dir string) {
+	assert.NoErr(t, os.MkdirAll(dir, 0755))
+	assert.NoErr(t, exec.Command("git", "init", "-q", dir).Run())
+}
+
+func TestFindRepos(t *testing.T) {
+	base := t.TempDir()
+	initTestRepo(t, filepath.Join(base, "svc-a"))
+	initTestRepo(t, filepath.Join(base, "group", "svc-b"))
+	initTestRepo(t, filepath.Join(base, "a", "b", "svc-c"))
+	// sub dir of a repo should be skipped
+	initTestRepo(t, filepath.Join(base, "svc-a", "sub"))
+
+	repos, err := gitx.FindRepos(base, 2)
+	assert.NoErr(t, err)
+	assert.Eq(t, []string{
+		filepath.Join(base, "group", "svc-b"),
+		filepath.Join(base, "svc-a"),
+	}, repos)
+
+	repos, err = gitx.FindRepos(base, 3)
+	assert.NoErr(t, err)
+	assert.Len(t, repos, 3)
+}
+
+func TestGitBatchRun_Run(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	base := t.TempDir()
+	dirA, dirB := filepath.Join(base, "svc-a"), filepath.Join(base, "svc-b")
+	initTestRepo(t, dirA)
+	initTestRepo(t, dirB)
+	assert.NoErr(t, os.WriteFile(filepath.Join(dirB, "new.txt"), []byte("hi"), 0644))
+
+	var doneNum int
+	gbr := gitx.NewBatchRun(func(gbr *gitx.GitBatchRun) {
+		gbr.Dirs = []string{dirA, dirB}
+		gbr.ShellMode = true
+		gbr.WithStatus = true
+		gbr.DoneFn = func(r *gitx.RepoResult) { doneNum++ }
+	})
+
+	results, err := gbr.Run("test -f new.txt && echo found")
+	assert.NoErr(t, err)
+	assert.Eq(t, 2, doneNum)
+	assert.True(t, results[0].Failed())
+	assert.False(t, results[1].Failed())
+	assert.StrContains(t, results[1].Output, "found")
+	assert.False(t, results[0].Status.Dirty)
+	assert.True(t, results[1].Status.Dirty)
+
+	failedFile := filepath.Join(base, "failed.json")
+	assert.NoErr(t, gitx.SaveFailedDirs(failedFile, gitx.FailedDirs(results)))
+	dirs, err := gitx.LoadFailedDirs(failedFile)
+	assert.NoErr(t, err)
+	assert.Eq(t, []string{dirA}, dirs)
+}
+
+func TestShellLine(t *testing.T) {
+	// one arg as the command line
+	assert.Eq(t, "make test && echo ok", gitx.ShellLine([]string{"make test && echo ok"}, bizutil.ShellQuote))
+
+	args := []string{"git", "commit", "-m", "fix: it's ok", ""}
+	assert.Eq(t, `git commit -m 'fix: it'\''s ok' ''`, gitx.ShellLine(args, bizutil.ShellQuote))
+	assert.Eq(t, `git commit -m ^"fix: it's ok^" ^"^"`, gitx.ShellLine(args, bizutil.CmdQuote))
+	assert.Eq(t, `echo ^"a \^"b\^" ^& 100^%^"`, gitx.ShellLine([]string{"echo", `a "b" & 100%`}, bizutil.CmdQuote))
+
+	if _, err := exec.LookPath("sh"); err == nil {
+		out, err := exec.Command("sh", "-c", gitx.ShellLine([]string{"echo", "a b", "$HOME", "c;d"}, bizutil.ShellQuote)).Output()
+		assert.NoErr(t, err)
+		assert.Eq(t, "a b $HOME c;d\n", string(out))
+	}
+}
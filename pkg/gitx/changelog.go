@@ -0,0 +1,353 @@
+package gitx
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/arrutil"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/strutil"
+	"github.com/gookit/goutil/sysutil/cmdr"
+)
+
+// ChlogSection config of a changelog section
+type ChlogSection struct {
+	Title string `json:"title"`
+	// Types the commit types for the section. "*" will match all not grouped commits.
+	Types []string `json:"types"`
+	// Breaking the section collect all breaking change commits.
+	Breaking bool `json:"breaking"`
+}
+
+// ChangelogConfig for generate changelog by conventional commits
+type ChangelogConfig struct {
+	// Sections the changelog sections, will render by the order.
+	// commits not matched any section will be dropped.
+	Sections []ChlogSection `json:"sections"`
+	// Exclude commits by subject contains the keywords
+	Exclude []string `json:"exclude"`
+	// WithAuthor credit the author for each commit and list contributors
+	WithAuthor bool `json:"with_author"`
+}
+
+// DefaultChangelogConfig instance
+func DefaultChangelogConfig() *ChangelogConfig {
+	return &ChangelogConfig{
+		Sections: []ChlogSection{
+			{Title: "Breaking Changes", Breaking: true},
+			{Title: "Features", Types: []string{"feat", "feature"}},
+			{Title: "Bug Fixes", Types: []string{"fix", "bugfix", "hotfix"}},
+			{Title: "Performance", Types: []string{"perf"}},
+			{Title: "Refactor", Types: []string{"refactor"}},
+			{Title: "Documentation", Types: []string{"docs", "doc"}},
+			{Title: "Others", Types: []string{"*"}},
+		},
+		Exclude:    []string{"Merge branch", "Merge pull request", "Merge remote-tracking branch"},
+		WithAuthor: true,
+	}
+}
+
+// ChlogGroup the commits of a section
+type ChlogGroup struct {
+	Title    string
+	Breaking bool
+	Commits  []*ConvCommit
+}
+
+// Changelog generator by conventional commits
+type Changelog struct {
+	*ChangelogConfig
+	// RepoURL the repo http URL for build links. eg: https://github.com/inhere/kite-go
+	RepoURL string
+	// HostType the git host type, use for build issue and PR links. see HostGitlab
+	HostType string
+	// Version title of the release. eg: v1.2.0
+	Version string
+	// Date of the release, default is today
+	Date    string
+	Commits []*ConvCommit
+}
+
+// NewChangelog instance
+func NewChangelog(cfg *ChangelogConfig) *Changelog {
+	if cfg == nil || len(cfg.Sections) == 0 {
+		cfg = DefaultChangelogConfig()
+	}
+	return &Changelog{
+		ChangelogConfig: cfg,
+		Date:            time.Now().Format(time.DateOnly),
+	}
+}
+
+// field and record separators for git log format
+const logFieldSep, logRecordSep = "\x1f", "\x1e"
+
+// FetchCommits fetch and parse the git log between two version(sha or tag) in the repo dir.
+func (cl *Changelog) FetchCommits(repoDir, sha1, sha2 string, noMerges bool) error {
+	format := strings.Join([]string{"%H", "%h", "%an", "%ae", "%as", "%s", "%b"}, logFieldSep) + logRecordSep
+
+	cmd := cmdr.NewGitCmd("log", "--format="+format).WithWorkDir(repoDir)
+	cmd.WithArgIf("--no-merges", noMerges)
+	if sha1 != "" {
+		cmd.AddArg(sha1 + ".." + sha2)
+	} else {
+		cmd.AddArg(sha2)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return errorx.Wrapf(err, "fetch git log error, cmd: %s", cmd.Cmdline())
+	}
+
+	cl.ParseLog(out)
+	return nil
+}
+
+// ParseLog parse git log output, format see FetchCommits
+func (cl *Changelog) ParseLog(out string) {
+	for _, record := range strings.Split(out, logRecordSep) {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), logFieldSep)
+		if len(fields) < 7 {
+			continue
+		}
+
+		cc := ParseConvCommit(fields[5], fields[6])
+		cc.Hash, cc.ShortHash = fields[0], fields[1]
+		cc.Author, cc.Email, cc.Date = fields[2], fields[3], fields[4]
+		cl.Commits = append(cl.Commits, cc)
+	}
+}
+
+func (cl *Changelog) excluded(cc *ConvCommit) bool {
+	for _, kw := range cl.Exclude {
+		if kw != "" && strings.Contains(cc.Subject, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// Groups the commits by sections, empty section will be ignored.
+func (cl *Changelog) Groups() []*ChlogGroup {
+	groups := make([]*ChlogGroup, len(cl.Sections))
+	for i, sec := range cl.Sections {
+		groups[i] = &ChlogGroup{Title: sec.Title, Breaking: sec.Breaking}
+	}
+
+	for _, cc := range cl.Commits {
+		if cl.excluded(cc) {
+			continue
+		}
+
+		idx, anyIdx := -1, -1
+		for i, sec := range cl.Sections {
+			if sec.Breaking {
+				if cc.Breaking {
+					idx = i
+					break
+				}
+				continue
+			}
+			if arrutil.StringsHas(sec.Types, cc.Type) {
+				idx = i
+				break
+			}
+			if anyIdx < 0 && arrutil.StringsHas(sec.Types, "*") {
+				anyIdx = i
+			}
+		}
+
+		if idx < 0 {
+			idx = anyIdx
+		}
+		if idx >= 0 {
+			groups[idx].Commits = append(groups[idx].Commits, cc)
+		}
+	}
+
+	return arrutil.Filter(groups, func(g *ChlogGroup) bool {
+		return len(g.Commits) > 0
+	})
+}
+
+// Contributors of the commits, sorted by first commit order.
+func (cl *Changelog) Contributors() []string {
+	var names []string
+	for _, cc := range cl.Commits {
+		if cl.excluded(cc) {
+			continue
+		}
+		if name := cl.authorName(cc); !arrutil.StringsHas(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// github noreply email. eg: 12345+inhere@users.noreply.github.com
+var ghNoReplyReg = regexp.MustCompile(`^(?:\d+\+)?([\w-]+)@users\.noreply\.github\.com$`)
+
+func (cl *Changelog) authorName(cc *ConvCommit) string {
+	if ss := ghNoReplyReg.FindStringSubmatch(cc.Email); len(ss) > 0 {
+		return "@" + ss[1]
+	}
+	return cc.Author
+}
+
+func (cl *Changelog) isGitlab() bool {
+	return cl.HostType == HostGitlab || strings.Contains(cl.RepoURL, "gitlab")
+}
+
+// CommitURL build
+func (cl *Changelog) CommitURL(hash string) string {
+	if cl.isGitlab() {
+		return cl.RepoURL + "/-/commit/" + hash
+	}
+	return cl.RepoURL + "/commit/" + hash
+}
+
+// IssueURL build
+func (cl *Changelog) IssueURL(num int) string {
+	if cl.isGitlab() {
+		return cl.RepoURL + "/-/issues/" + strconv.Itoa(num)
+	}
+	return cl.RepoURL + "/issues/" + strconv.Itoa(num)
+}
+
+// PullURL build
+func (cl *Changelog) PullURL(num int) string {
+	if cl.isGitlab() {
+		return cl.RepoURL + "/-/merge_requests/" + strconv.Itoa(num)
+	}
+	return cl.RepoURL + "/pull/" + strconv.Itoa(num)
+}
+
+// link text to url, return text only on RepoURL is empty
+func (cl *Changelog) link(text, url string) string {
+	if cl.RepoURL == "" {
+		return text
+	}
+	return "[" + text + "](" + url + ")"
+}
+
+func (cl *Changelog) entryLine(cc *ConvCommit, breaking bool) string {
+	var sb strings.Builder
+	sb.WriteString("- ")
+	if cc.Scope != "" {
+		sb.WriteString("**" + cc.Scope + ":** ")
+	}
+
+	if breaking && cc.BreakingNote != "" {
+		sb.WriteString(cc.Subject + ". " + strings.ReplaceAll(cc.BreakingNote, "\n", " "))
+	} else {
+		sb.WriteString(cc.Subject)
+	}
+
+	sb.WriteString(" (" + cl.link(cc.ShortHash, cl.CommitURL(cc.Hash)) + ")")
+	if cc.PRNum > 0 {
+		sb.WriteString(" " + cl.link("#"+strconv.Itoa(cc.PRNum), cl.PullURL(cc.PRNum)))
+	}
+	for _, num := range cc.Issues {
+		if num != cc.PRNum {
+			sb.WriteString(" " + cl.link("#"+strconv.Itoa(num), cl.IssueURL(num)))
+		}
+	}
+
+	if cl.WithAuthor {
+		sb.WriteString(" by " + cl.authorName(cc))
+	}
+	return sb.String()
+}
+
+// Markdown render the changelog. if withTitle=true, will add version title. eg: "## v1.2.0 (2024-01-02)"
+func (cl *Changelog) Markdown(withTitle bool) string {
+	var sb strings.Builder
+	if withTitle {
+		sb.WriteString("## " + strutil.OrElse(cl.Version, "Unreleased"))
+		if cl.Date != "" {
+			sb.WriteString(" (" + cl.Date + ")")
+		}
+		sb.WriteString("\n\n")
+	}
+
+	for _, g := range cl.Groups() {
+		sb.WriteString("### " + g.Title + "\n\n")
+		for _, cc := range g.Commits {
+			sb.WriteString(cl.entryLine(cc, g.Breaking) + "\n")
+		}
+		sb.WriteByte('\n')
+	}
+
+	if cl.WithAuthor {
+		if names := cl.Contributors(); len(names) > 0 {
+			sb.WriteString("### Contributors\n\n")
+			sb.WriteString(strings.Join(names, ", ") + "\n\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+// Text render the changelog as simple text, without links.
+func (cl *Changelog) Text() string {
+	repoURL := cl.RepoURL
+	cl.RepoURL = ""
+	defer func() { cl.RepoURL = repoURL }()
+
+	var sb strings.Builder
+	for _, g := range cl.Groups() {
+		sb.WriteString(g.Title + ":\n")
+		for _, cc := range g.Commits {
+			sb.WriteString("  " + cl.entryLine(cc, false) + "\n")
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// PrependToFile insert the version section before the first version section of the changelog file.
+// will create the file if not exists.
+func (cl *Changelog) PrependToFile(file string) error {
+	section := cl.Markdown(true)
+	if !fsutil.IsFile(file) {
+		return fsutil.WriteFile(file, "# Changelog\n\n"+section, fsutil.DefaultFilePerm)
+	}
+
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	src := string(bs)
+	if cl.Version != "" && versionTitleReg(cl.Version).MatchString(src) {
+		return errorx.Rawf("the version %s already exists in the file %s", cl.Version, file)
+	}
+
+	// insert before the first "## " version title
+	pos := strings.Index(src, "\n## ")
+	if strings.HasPrefix(src, "## ") {
+		pos = 0
+	} else if pos >= 0 {
+		pos++
+	} else {
+		pos = len(src)
+		if !strings.HasSuffix(src, "\n\n") {
+			section = strings.Repeat("\n", 2-countSuffixNL(src)) + section
+		}
+	}
+
+	dst := src[:pos] + section + "\n" + src[pos:]
+	return fsutil.WriteFile(file, strings.TrimRight(dst, "\n")+"\n", fsutil.DefaultFilePerm)
+}
+
+// match the version title line. eg: "## v1.2.0", "## v1.2.0 (2026-10-18)", but not "## v1.2.0-rc.1"
+func versionTitleReg(version string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^## ` + regexp.QuoteMeta(version) + `(?:[^\w.-]|$)`)
+}
+
+func countSuffixNL(s string) int {
+	return len(s) - len(strings.TrimRight(s, "\n"))
+}
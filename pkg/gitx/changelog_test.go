@@ -0,0 +1,87 @@
+package gitx_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/inhere/kite-go/pkg/gitx"
+)
+
+func TestParseConvCommit(t *testing.T) {
+	cc := gitx.ParseConvCommit("feat(api)!: add pull request api (#12)", `some details
+
+Closes #10, #11
+BREAKING CHANGE: the Api() return type
+  is changed`)
+
+	assert.Eq(t, "feat", cc.Type)
+	assert.Eq(t, "api", cc.Scope)
+	assert.Eq(t, "add pull request api", cc.Subject)
+	assert.Eq(t, "some details", cc.Body)
+	assert.Eq(t, 12, cc.PRNum)
+	assert.Eq(t, []int{10, 11}, cc.Issues)
+	assert.True(t, cc.Breaking)
+	assert.Eq(t, "the Api() return type\nis changed", cc.BreakingNote)
+	assert.Len(t, cc.Footers, 2)
+
+	cc = gitx.ParseConvCommit("update readme", "")
+	assert.False(t, cc.IsConventional())
+	assert.Eq(t, "update readme", cc.Subject)
+}
+
+func newTestChangelog() *gitx.Changelog {
+	cl := gitx.NewChangelog(nil)
+	cl.RepoURL = "https://github.com/inhere/kite-go"
+	cl.Version = "v1.2.0"
+	cl.Date = "2026-10-18"
+	cl.ParseLog("a1\x1fa1\x1finhere\x1f123+inhere@users.noreply.github.com\x1f2026-10-17\x1ffeat(git): add batch run (#3)\x1f\x1e\n" +
+		"b2\x1fb2\x1ftom\x1ftom@example.com\x1f2026-10-16\x1ffix: panic on empty remote\x1fFixes #5\n\x1e\n" +
+		"c3\x1fc3\x1ftom\x1ftom@example.com\x1f2026-10-15\x1frefactor!: rename config\x1f\x1e\n" +
+		"d4\x1fd4\x1ftom\x1ftom@example.com\x1f2026-10-14\x1fMerge branch 'dev'\x1f\x1e\n" +
+		"e5\x1fe5\x1ftom\x1ftom@example.com\x1f2026-10-13\x1fchore: update deps\x1f\x1e\n")
+	return cl
+}
+
+func TestChangelog_Markdown(t *testing.T) {
+	cl := newTestChangelog()
+	groups := cl.Groups()
+	assert.Len(t, groups, 4)
+	assert.Eq(t, "Breaking Changes", groups[0].Title)
+	assert.Eq(t, "Others", groups[3].Title)
+	assert.Eq(t, []string{"@inhere", "tom"}, cl.Contributors())
+
+	md := cl.Markdown(true)
+	assert.StrContains(t, md, "## v1.2.0 (2026-10-18)\n\n### Breaking Changes\n\n- rename config")
+	assert.StrContains(t, md, "- **git:** add batch run ([a1](https://github.com/inhere/kite-go/commit/a1)) [#3](https://github.com/inhere/kite-go/pull/3) by @inhere")
+	assert.StrContains(t, md, "[#5](https://github.com/inhere/kite-go/issues/5) by tom")
+	assert.NotContains(t, md, "Merge branch")
+
+	cl.HostType = gitx.HostGitlab
+	assert.Eq(t, "https://github.com/inhere/kite-go/-/merge_requests/3", cl.PullURL(3))
+}
+
+func TestChangelog_PrependToFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	assert.NoErr(t, os.WriteFile(file, []byte("# Changelog\n\n## v1.1.0 (2026-09-01)\n\n- old\n"), 0644))
+
+	cl := newTestChangelog()
+	assert.NoErr(t, cl.PrependToFile(file))
+
+	bs, err := os.ReadFile(file)
+	assert.NoErr(t, err)
+	assert.StrContains(t, string(bs), "# Changelog\n\n## v1.2.0 (2026-10-18)\n\n### Breaking Changes")
+	assert.StrContains(t, string(bs), "tom\n\n## v1.1.0 (2026-09-01)\n\n- old\n")
+
+	// version exists
+	assert.Err(t, cl.PrependToFile(file))
+
+	// version title without date, or is the first line
+	assert.NoErr(t, os.WriteFile(file, []byte("## v1.2.0\n\n- old\n"), 0644))
+	assert.ErrSubMsg(t, cl.PrependToFile(file), "the version v1.2.0 already exists")
+
+	// pre-release version is not same
+	assert.NoErr(t, os.WriteFile(file, []byte("# Changelog\n\n## v1.2.0-rc.1\n\n- old\n"), 0644))
+	assert.NoErr(t, cl.PrependToFile(file))
+}
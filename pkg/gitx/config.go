@@ -40,6 +40,10 @@ type Config struct {
 	BranchAliases maputil.Aliases `json:"branch_aliases"`
 	// PrUrlFormat pull request URL format template. can use var like {host}
 	PrUrlFormat string `json:"pr_url_format"`
+	// Workspaces named repo dir list, use for batch run. eg: {"services": ["~/work/svc-a", "~/work/svc-b"]}
+	Workspaces map[string][]string `json:"workspaces"`
+	// Changelog config for generate changelog by conventional commits
+	Changelog *ChangelogConfig `json:"changelog"`
 }
 
 // NewConfig instance
@@ -116,8 +120,22 @@ func (c *Config) ResolveAlias(name string) string {
 	return c.ResolveBranch(name)
 }
 
+// ChlogConfig get changelog config, will return default config on not setting.
+func (c *Config) ChlogConfig() *ChangelogConfig {
+	if c.Changelog == nil || len(c.Changelog.Sections) == 0 {
+		return DefaultChangelogConfig()
+	}
+	return c.Changelog
+}
+
 // Clone new config instance
 func (c *Config) Clone() *Config {
 	c1 := *c
 	return &c1
 }
+
+// WorkspaceDirs get repo dirs by workspace name
+func (c *Config) WorkspaceDirs(name string) ([]string, bool) {
+	dirs, ok := c.Workspaces[name]
+	return dirs, ok
+}
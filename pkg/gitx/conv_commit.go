@@ -0,0 +1,127 @@
+package gitx
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BreakingFooter key name
+const BreakingFooter = "BREAKING CHANGE"
+
+// header format: type(scope)!: subject
+var convHeaderReg = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// footer format: "Token: value" or "Token #value". eg: "Closes #12", "Reviewed-by: name"
+var convFooterReg = regexp.MustCompile(`^(BREAKING[ -]CHANGE|[a-zA-Z][\w-]*)(:\s*| #)(.+)$`)
+
+// PR number at subject end. eg: "fix: some error (#12)"
+var subjectPRReg = regexp.MustCompile(`\s*\(#(\d+)\)$`)
+
+// issue refs in footers value. eg: "#12, #13"
+var issueRefReg = regexp.MustCompile(`#(\d+)`)
+
+// CommitFooter item of conventional commit.
+type CommitFooter struct {
+	Token string `json:"token"`
+	Value string `json:"value"`
+}
+
+// ConvCommit a git commit parsed by Conventional Commits spec.
+//
+// see https://www.conventionalcommits.org/
+type ConvCommit struct {
+	Hash      string `json:"hash"`
+	ShortHash string `json:"short_hash"`
+	Author    string `json:"author"`
+	Email     string `json:"email"`
+	Date      string `json:"date"`
+	// Type commit type. eg: feat, fix. is empty on the header is not conventional
+	Type  string `json:"type"`
+	Scope string `json:"scope"`
+	// Subject without type, scope and PR number.
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	// Breaking is breaking change, by "type!:" or footer "BREAKING CHANGE: xx"
+	Breaking     bool   `json:"breaking"`
+	BreakingNote string `json:"breaking_note"`
+	// PRNum the pull request number from subject. eg: "(#12)"
+	PRNum int `json:"pr_num"`
+	// Issues the referenced issue numbers from footers. eg: "Closes #12"
+	Issues  []int          `json:"issues"`
+	Footers []CommitFooter `json:"footers"`
+}
+
+// IsConventional check
+func (cc *ConvCommit) IsConventional() bool {
+	return cc.Type != ""
+}
+
+// ParseConvCommit parse commit message(header and body) by Conventional Commits spec.
+func ParseConvCommit(header, body string) *ConvCommit {
+	cc := &ConvCommit{Subject: strings.TrimSpace(header)}
+	if ss := convHeaderReg.FindStringSubmatch(cc.Subject); len(ss) > 0 {
+		cc.Type = strings.ToLower(ss[1])
+		cc.Scope = strings.TrimSpace(ss[2])
+		cc.Breaking = ss[3] == "!"
+		cc.Subject = strings.TrimSpace(ss[4])
+	}
+
+	if ss := subjectPRReg.FindStringSubmatch(cc.Subject); len(ss) > 0 {
+		cc.PRNum, _ = strconv.Atoi(ss[1])
+		cc.Subject = strings.TrimSpace(cc.Subject[:len(cc.Subject)-len(ss[0])])
+	}
+
+	cc.parseBody(strings.TrimSpace(body))
+	return cc
+}
+
+// parse footers from the last paragraph of body
+func (cc *ConvCommit) parseBody(body string) {
+	if body == "" {
+		return
+	}
+
+	paras := strings.Split(body, "\n\n")
+	lines := strings.Split(paras[len(paras)-1], "\n")
+
+	var footers []CommitFooter
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \r")
+		if ss := convFooterReg.FindStringSubmatch(line); len(ss) > 0 {
+			val := strings.TrimSpace(ss[3])
+			// keep the "#" for "Token #value" format
+			if ss[2] == " #" {
+				val = "#" + val
+			}
+			footers = append(footers, CommitFooter{Token: ss[1], Value: val})
+		} else if len(footers) > 0 {
+			// multi line footer value
+			last := &footers[len(footers)-1]
+			last.Value += "\n" + strings.TrimSpace(line)
+		} else {
+			// not a footers paragraph
+			break
+		}
+	}
+
+	if len(footers) == 0 {
+		cc.Body = body
+		return
+	}
+
+	cc.Footers = footers
+	cc.Body = strings.TrimSpace(strings.Join(paras[:len(paras)-1], "\n\n"))
+	for _, ft := range footers {
+		switch strings.ToUpper(ft.Token) {
+		case BreakingFooter, "BREAKING-CHANGE":
+			cc.Breaking = true
+			cc.BreakingNote = ft.Value
+		case "CLOSE", "CLOSES", "CLOSED", "FIX", "FIXES", "FIXED", "RESOLVE", "RESOLVES", "RESOLVED", "REFS", "REF", "ISSUE", "ISSUES":
+			for _, ss := range issueRefReg.FindAllStringSubmatch(ft.Value, -1) {
+				num, _ := strconv.Atoi(ss[1])
+				cc.Issues = append(cc.Issues, num)
+			}
+		}
+	}
+}
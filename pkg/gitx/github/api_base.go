@@ -22,47 +22,71 @@ func (g *GitHub) apiBase() string {
 }
 
 func (g *GitHub) postJSON(path string, body any, out any) error {
-	reqBody, err := json.Marshal(body)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest(http.MethodPost, g.apiBase()+path, bytes.NewReader(reqBody))
-	if err != nil {
-		return err
-	}
-
-	g.setAPIHeaders(req)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	return g.doJSON(http.MethodPost, path, nil, body, out)
+}
 
-	return decodeJSONResponse(resp, out)
+func (g *GitHub) putJSON(path string, body any, out any) error {
+	return g.doJSON(http.MethodPut, path, nil, body, out)
 }
 
 func (g *GitHub) getJSON(path string, query url.Values, out any) error {
+	return g.doJSON(http.MethodGet, path, query, nil, out)
+}
+
+func (g *GitHub) doJSON(method, path string, query url.Values, body any, out any) error {
 	apiURL := g.apiBase() + path
 	if len(query) > 0 {
 		apiURL += "?" + query.Encode()
 	}
 
-	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	_, err := g.doJSONURL(method, apiURL, body, out)
+	return err
+}
+
+// doJSONURL send request to the full api URL, returns the response headers.
+func (g *GitHub) doJSONURL(method, apiURL string, body any, out any) (http.Header, error) {
+	var reqBody io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(bs)
+	}
+
+	req, err := http.NewRequest(method, apiURL, reqBody)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	g.setAPIHeaders(req)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	return decodeJSONResponse(resp, out)
+	return resp.Header, decodeJSONResponse(resp, out)
+}
+
+// nextPageURL parse the next page URL from the response header "Link".
+//
+// eg: <https://api.github.com/repositories/1/check-runs?page=2>; rel="next", <...>; rel="last"
+func nextPageURL(header http.Header) string {
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+	return ""
 }
 
 func (g *GitHub) setAPIHeaders(req *http.Request) {
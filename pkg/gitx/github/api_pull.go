@@ -0,0 +1,403 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PRUser info in pull request payload.
+type PRUser struct {
+	Login   string `json:"login"`
+	HTMLURL string `json:"html_url,omitempty"`
+}
+
+// PRBranch head or base branch info of pull request.
+type PRBranch struct {
+	// Label eg: owner:branch
+	Label string `json:"label"`
+	Ref   string `json:"ref"`
+	SHA   string `json:"sha"`
+}
+
+// PRLabel label info of pull request.
+type PRLabel struct {
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// PullRequest represents a github pull request.
+type PullRequest struct {
+	Number         int       `json:"number"`
+	State          string    `json:"state"`
+	Title          string    `json:"title"`
+	Body           string    `json:"body"`
+	Draft          bool      `json:"draft"`
+	HTMLURL        string    `json:"html_url"`
+	User           PRUser    `json:"user"`
+	Head           PRBranch  `json:"head"`
+	Base           PRBranch  `json:"base"`
+	Labels         []PRLabel `json:"labels,omitempty"`
+	Merged         bool      `json:"merged"`
+	Mergeable      *bool     `json:"mergeable,omitempty"`
+	MergeableState string    `json:"mergeable_state,omitempty"`
+	CreatedAt      string    `json:"created_at"`
+	UpdatedAt      string    `json:"updated_at"`
+}
+
+// PRCreateInput for creating a pull request.
+type PRCreateInput struct {
+	// RepoPath the base repository path, format: owner/repo
+	RepoPath string
+	Title    string
+	Body     string
+	// Head the source branch. for cross-repository PR, format: owner:branch
+	Head string
+	// Base the target branch
+	Base      string
+	Draft     bool
+	Reviewers []string
+	Labels    []string
+}
+
+type prCreateRequest struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body,omitempty"`
+	Draft bool   `json:"draft,omitempty"`
+}
+
+// PRListInput for querying pull requests.
+type PRListInput struct {
+	RepoPath string
+	// State allow: open, closed, all. default is open
+	State string
+	// Head filter by head user or org and branch name, format: owner:branch
+	Head  string
+	Base  string
+	Limit int
+}
+
+// PRMergeInput for merging a pull request.
+type PRMergeInput struct {
+	RepoPath string
+	Number   int
+	// Method allow: merge, squash, rebase. default is merge
+	Method        string
+	CommitTitle   string
+	CommitMessage string
+	// WaitChecks wait for the check runs of PR head are completed and success before merge.
+	WaitChecks bool
+	// WaitTimeout for wait checks, default is 10 minutes
+	WaitTimeout time.Duration
+	// WaitInterval for poll checks status, default is 10 seconds
+	WaitInterval time.Duration
+}
+
+type prMergeRequest struct {
+	CommitTitle   string `json:"commit_title,omitempty"`
+	CommitMessage string `json:"commit_message,omitempty"`
+	MergeMethod   string `json:"merge_method,omitempty"`
+	SHA           string `json:"sha,omitempty"`
+}
+
+// PRMergeResult for merged pull request.
+type PRMergeResult struct {
+	SHA     string `json:"sha"`
+	Merged  bool   `json:"merged"`
+	Message string `json:"message"`
+}
+
+// IssueComment represents a comment on issue or pull request.
+type IssueComment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	User    PRUser `json:"user"`
+}
+
+// CheckRun represents a check run of a commit.
+type CheckRun struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Status allow: queued, in_progress, completed
+	Status string `json:"status"`
+	// Conclusion on completed. eg: success, failure, neutral, cancelled, skipped, timed_out
+	Conclusion string `json:"conclusion"`
+	HTMLURL    string `json:"html_url"`
+}
+
+// Completed check the run is completed
+func (cr CheckRun) Completed() bool {
+	return cr.Status == "completed"
+}
+
+// Passed check the run is completed and not failed
+func (cr CheckRun) Passed() bool {
+	switch cr.Conclusion {
+	case "success", "neutral", "skipped":
+		return cr.Completed()
+	}
+	return false
+}
+
+// CheckRunList of a commit.
+type CheckRunList struct {
+	TotalCount int        `json:"total_count"`
+	CheckRuns  []CheckRun `json:"check_runs"`
+}
+
+// Pending the number of not completed check runs.
+func (l *CheckRunList) Pending() int {
+	var n int
+	for _, cr := range l.CheckRuns {
+		if !cr.Completed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed the completed but not passed check runs.
+func (l *CheckRunList) Failed() []CheckRun {
+	var list []CheckRun
+	for _, cr := range l.CheckRuns {
+		if cr.Completed() && !cr.Passed() {
+			list = append(list, cr)
+		}
+	}
+	return list
+}
+
+func (g *GitHub) checkRepoAndToken(repoPath string) error {
+	if strings.TrimSpace(repoPath) == "" {
+		return fmt.Errorf("github: repo path is required")
+	}
+	if strings.TrimSpace(g.Token) == "" {
+		return fmt.Errorf("github: token is required")
+	}
+	return nil
+}
+
+func pullPath(repoPath string, number int) string {
+	return "/repos/" + strings.Trim(repoPath, "/") + "/pulls/" + strconv.Itoa(number)
+}
+
+// CreatePullRequest creates pull request, then request reviewers and add labels.
+func (g *GitHub) CreatePullRequest(in PRCreateInput) (*PullRequest, error) {
+	if err := g.checkRepoAndToken(in.RepoPath); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(in.Head) == "" || strings.TrimSpace(in.Base) == "" {
+		return nil, fmt.Errorf("github: head and base branch is required")
+	}
+	if strings.TrimSpace(in.Title) == "" {
+		return nil, fmt.Errorf("github: pull request title is required")
+	}
+
+	repoPath := strings.Trim(in.RepoPath, "/")
+	reqBody := prCreateRequest{
+		Title: in.Title,
+		Head:  in.Head,
+		Base:  in.Base,
+		Body:  in.Body,
+		Draft: in.Draft,
+	}
+
+	var pr PullRequest
+	if err := g.postJSON("/repos/"+repoPath+"/pulls", reqBody, &pr); err != nil {
+		return nil, err
+	}
+
+	if len(in.Reviewers) > 0 {
+		err := g.postJSON(pullPath(repoPath, pr.Number)+"/requested_reviewers", map[string][]string{
+			"reviewers": in.Reviewers,
+		}, nil)
+		if err != nil {
+			return &pr, fmt.Errorf("github: pull request #%d created, but request reviewers failed: %w", pr.Number, err)
+		}
+	}
+
+	if len(in.Labels) > 0 {
+		issuePath := "/repos/" + repoPath + "/issues/" + strconv.Itoa(pr.Number)
+		err := g.postJSON(issuePath+"/labels", map[string][]string{"labels": in.Labels}, &pr.Labels)
+		if err != nil {
+			return &pr, fmt.Errorf("github: pull request #%d created, but add labels failed: %w", pr.Number, err)
+		}
+	}
+	return &pr, nil
+}
+
+// ListPullRequests lists repository pull requests.
+func (g *GitHub) ListPullRequests(in PRListInput) ([]PullRequest, error) {
+	if err := g.checkRepoAndToken(in.RepoPath); err != nil {
+		return nil, err
+	}
+
+	limit := in.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := url.Values{"per_page": []string{strconv.Itoa(limit)}}
+	if in.State != "" {
+		query.Set("state", in.State)
+	}
+	if in.Head != "" {
+		query.Set("head", in.Head)
+	}
+	if in.Base != "" {
+		query.Set("base", in.Base)
+	}
+
+	var items []PullRequest
+	if err := g.getJSON("/repos/"+strings.Trim(in.RepoPath, "/")+"/pulls", query, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetPullRequest gets pull request by number.
+func (g *GitHub) GetPullRequest(repoPath string, number int) (*PullRequest, error) {
+	if err := g.checkRepoAndToken(repoPath); err != nil {
+		return nil, err
+	}
+
+	var pr PullRequest
+	if err := g.getJSON(pullPath(repoPath, number), nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// CommentPullRequest adds a comment to the pull request.
+func (g *GitHub) CommentPullRequest(repoPath string, number int, body string) (*IssueComment, error) {
+	if err := g.checkRepoAndToken(repoPath); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("github: comment body is required")
+	}
+
+	var comment IssueComment
+	apiPath := "/repos/" + strings.Trim(repoPath, "/") + "/issues/" + strconv.Itoa(number) + "/comments"
+	if err := g.postJSON(apiPath, map[string]string{"body": body}, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListCheckRuns lists check runs for the commit ref(sha, branch or tag name).
+func (g *GitHub) ListCheckRuns(repoPath, ref string) (*CheckRunList, error) {
+	if err := g.checkRepoAndToken(repoPath); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(ref) == "" {
+		return nil, fmt.Errorf("github: commit ref is required")
+	}
+
+	var list CheckRunList
+	apiURL := g.apiBase() + "/repos/" + strings.Trim(repoPath, "/") + "/commits/" + url.PathEscape(ref) + "/check-runs?per_page=100"
+
+	// follow the "Link" header to fetch all pages
+	for apiURL != "" {
+		var page CheckRunList
+		header, err := g.doJSONURL(http.MethodGet, apiURL, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		list.TotalCount = page.TotalCount
+		list.CheckRuns = append(list.CheckRuns, page.CheckRuns...)
+		apiURL = nextPageURL(header)
+	}
+	return &list, nil
+}
+
+// WaitChecksGrace the grace period for wait check runs created.
+// if no check runs appear within it, the ref is considered to have no checks.
+var WaitChecksGrace = 30 * time.Second
+
+// WaitCheckRuns polls the check runs of the ref until all completed.
+// returns error on timeout or has failed check runs.
+//
+// NOTE: the check runs maybe not created yet on the ref just pushed, so will wait WaitChecksGrace
+// for them to appear. if still no check runs after that, returns success.
+func (g *GitHub) WaitCheckRuns(repoPath, ref string, timeout, interval time.Duration) (*CheckRunList, error) {
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	startAt := time.Now()
+	deadline := startAt.Add(timeout)
+	for {
+		list, err := g.ListCheckRuns(repoPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		if failed := list.Failed(); len(failed) > 0 {
+			names := make([]string, 0, len(failed))
+			for _, cr := range failed {
+				names = append(names, cr.Name+"("+cr.Conclusion+")")
+			}
+			return list, fmt.Errorf("github: check runs failed: %s", strings.Join(names, ", "))
+		}
+		if len(list.CheckRuns) > 0 && list.Pending() == 0 {
+			return list, nil
+		}
+		// no check runs on the ref
+		if len(list.CheckRuns) == 0 && time.Since(startAt) >= WaitChecksGrace {
+			return list, nil
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			if len(list.CheckRuns) == 0 {
+				return list, fmt.Errorf("github: wait check runs timeout, no check runs found for %s", ref)
+			}
+			return list, fmt.Errorf("github: wait check runs timeout, %d pending", list.Pending())
+		}
+		time.Sleep(interval)
+	}
+}
+
+// MergePullRequest merges the pull request. If in.WaitChecks=true, will wait the check runs of PR head success.
+func (g *GitHub) MergePullRequest(in PRMergeInput) (*PRMergeResult, error) {
+	if err := g.checkRepoAndToken(in.RepoPath); err != nil {
+		return nil, err
+	}
+	if in.Number <= 0 {
+		return nil, fmt.Errorf("github: pull request number is required")
+	}
+
+	reqBody := prMergeRequest{
+		CommitTitle:   in.CommitTitle,
+		CommitMessage: in.CommitMessage,
+		MergeMethod:   in.Method,
+	}
+
+	if in.WaitChecks {
+		pr, err := g.GetPullRequest(in.RepoPath, in.Number)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = g.WaitCheckRuns(in.RepoPath, pr.Head.SHA, in.WaitTimeout, in.WaitInterval); err != nil {
+			return nil, err
+		}
+		// make sure the head is not changed after checks
+		reqBody.SHA = pr.Head.SHA
+	}
+
+	var res PRMergeResult
+	if err := g.putJSON(pullPath(in.RepoPath, in.Number)+"/merge", reqBody, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
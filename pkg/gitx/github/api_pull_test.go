@@ -0,0 +1,209 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/inhere/kite-go/pkg/gitx"
+)
+
+func TestGitHub_CreatePullRequest(t *testing.T) {
+	var paths []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Eq(t, http.MethodPost, r.Method)
+		assert.Contains(t, r.Header.Get("Authorization"), "token test-token")
+		paths = append(paths, r.URL.Path)
+
+		var body map[string]any
+		err := json.NewDecoder(r.Body).Decode(&body)
+		assert.NoErr(t, err)
+
+		switch r.URL.Path {
+		case "/repos/owner/repo/pulls":
+			assert.Eq(t, "feat: add pr api", body["title"])
+			assert.Eq(t, "inhere:feat-pr", body["head"])
+			assert.Eq(t, "main", body["base"])
+			assert.Eq(t, true, body["draft"])
+
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"number":   12,
+				"draft":    true,
+				"html_url": "https://github.com/owner/repo/pull/12",
+			})
+		case "/repos/owner/repo/pulls/12/requested_reviewers":
+			assert.Eq(t, []any{"alice"}, body["reviewers"])
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		case "/repos/owner/repo/issues/12/labels":
+			assert.Eq(t, []any{"feature"}, body["labels"])
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"name": "feature"}})
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	gh := New(&gitx.Config{HostUrl: "https://github.com"})
+	gh.Token = "test-token"
+	gh.BaseApi = srv.URL
+
+	pr, err := gh.CreatePullRequest(PRCreateInput{
+		RepoPath:  "owner/repo",
+		Title:     "feat: add pr api",
+		Head:      "inhere:feat-pr",
+		Base:      "main",
+		Draft:     true,
+		Reviewers: []string{"alice"},
+		Labels:    []string{"feature"},
+	})
+
+	assert.NoErr(t, err)
+	assert.Eq(t, 12, pr.Number)
+	assert.Eq(t, "https://github.com/owner/repo/pull/12", pr.HTMLURL)
+	assert.Eq(t, "feature", pr.Labels[0].Name)
+	assert.Len(t, paths, 3)
+}
+
+func TestGitHub_MergePullRequest_waitChecks(t *testing.T) {
+	var checkCalls int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/owner/repo/pulls/12":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"number": 12,
+				"head":   map[string]any{"ref": "feat-pr", "sha": "head-sha"},
+			})
+		case "GET /repos/owner/repo/commits/head-sha/check-runs":
+			checkCalls++
+			status := "in_progress"
+			if checkCalls > 1 {
+				status = "completed"
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"total_count": 2,
+				"check_runs": []map[string]any{
+					{"name": "test", "status": status, "conclusion": "success"},
+					{"name": "lint", "status": "completed", "conclusion": "skipped"},
+				},
+			})
+		case "PUT /repos/owner/repo/pulls/12/merge":
+			var body map[string]any
+			assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Eq(t, "squash", body["merge_method"])
+			assert.Eq(t, "head-sha", body["sha"])
+
+			_ = json.NewEncoder(w).Encode(map[string]any{"sha": "merged-sha", "merged": true})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	gh := New(&gitx.Config{HostUrl: "https://github.com"})
+	gh.Token = "test-token"
+	gh.BaseApi = srv.URL
+
+	res, err := gh.MergePullRequest(PRMergeInput{
+		RepoPath:     "owner/repo",
+		Number:       12,
+		Method:       "squash",
+		WaitChecks:   true,
+		WaitInterval: time.Millisecond,
+	})
+
+	assert.NoErr(t, err)
+	assert.True(t, res.Merged)
+	assert.Eq(t, "merged-sha", res.SHA)
+	assert.Eq(t, 2, checkCalls)
+}
+
+func TestGitHub_WaitCheckRuns_failed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Eq(t, "/repos/owner/repo/commits/head-sha/check-runs", r.URL.Path)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"total_count": 1,
+			"check_runs":  []map[string]any{{"name": "test", "status": "completed", "conclusion": "failure"}},
+		})
+	}))
+	defer srv.Close()
+
+	gh := New(&gitx.Config{HostUrl: "https://github.com"})
+	gh.Token = "test-token"
+	gh.BaseApi = srv.URL
+
+	_, err := gh.WaitCheckRuns("owner/repo", "head-sha", time.Second, time.Millisecond)
+	assert.Err(t, err)
+	assert.Contains(t, err.Error(), "test(failure)")
+}
+
+func TestGitHub_WaitCheckRuns_empty(t *testing.T) {
+	var reqNum int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqNum++
+		_ = json.NewEncoder(w).Encode(map[string]any{"total_count": 0, "check_runs": []any{}})
+	}))
+	defer srv.Close()
+
+	gh := New(&gitx.Config{HostUrl: "https://github.com"})
+	gh.Token = "test-token"
+	gh.BaseApi = srv.URL
+
+	// no check runs within the grace period, will poll until timeout
+	_, err := gh.WaitCheckRuns("owner/repo", "head-sha", 50*time.Millisecond, 10*time.Millisecond)
+	assert.Err(t, err)
+	assert.Contains(t, err.Error(), "no check runs found for head-sha")
+	assert.True(t, reqNum > 1)
+
+	// still no check runs after the grace period, is success
+	defer func(grace time.Duration) { WaitChecksGrace = grace }(WaitChecksGrace)
+	WaitChecksGrace = 20 * time.Millisecond
+	reqNum = 0
+
+	list, err := gh.WaitCheckRuns("owner/repo", "head-sha", time.Second, 10*time.Millisecond)
+	assert.NoErr(t, err)
+	assert.Empty(t, list.CheckRuns)
+	assert.True(t, reqNum > 1)
+}
+
+func TestGitHub_ListCheckRuns_pages(t *testing.T) {
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Eq(t, "/repos/owner/repo/commits/head-sha/check-runs", r.URL.Path)
+		assert.Eq(t, "100", r.URL.Query().Get("per_page"))
+
+		if r.URL.Query().Get("page") == "2" {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"total_count": 2,
+				"check_runs":  []map[string]any{{"name": "lint", "status": "in_progress"}},
+			})
+			return
+		}
+
+		next := srvURL + "/repos/owner/repo/commits/head-sha/check-runs?per_page=100&page=2"
+		w.Header().Set("Link", `<`+next+`>; rel="next", <`+next+`>; rel="last"`)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"total_count": 2,
+			"check_runs":  []map[string]any{{"name": "test", "status": "completed", "conclusion": "success"}},
+		})
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	gh := New(&gitx.Config{HostUrl: "https://github.com"})
+	gh.Token = "test-token"
+	gh.BaseApi = srv.URL
+
+	list, err := gh.ListCheckRuns("owner/repo", "head-sha")
+	assert.NoErr(t, err)
+	assert.Eq(t, 2, list.TotalCount)
+	assert.Len(t, list.CheckRuns, 2)
+	assert.Eq(t, "lint", list.CheckRuns[1].Name)
+	assert.Eq(t, 1, list.Pending())
+}
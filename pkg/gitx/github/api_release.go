@@ -0,0 +1,67 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReleaseCreateInput for creating a release by GitHub API.
+type ReleaseCreateInput struct {
+	RepoPath string
+	TagName  string
+	// Target commitish for the tag, is unused if the tag already exists.
+	Target     string
+	Name       string
+	Body       string
+	Draft      bool
+	Prerelease bool
+}
+
+type releaseCreateRequest struct {
+	TagName    string `json:"tag_name"`
+	Target     string `json:"target_commitish,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Body       string `json:"body,omitempty"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// Release represents a github release.
+type Release struct {
+	ID         int64  `json:"id"`
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	HTMLURL    string `json:"html_url"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// CreateRelease creates a release for the tag.
+func (g *GitHub) CreateRelease(in ReleaseCreateInput) (*Release, error) {
+	if err := g.checkRepoAndToken(in.RepoPath); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(in.TagName) == "" {
+		return nil, fmt.Errorf("github: release tag name is required")
+	}
+
+	reqBody := releaseCreateRequest{
+		TagName:    in.TagName,
+		Target:     in.Target,
+		Name:       in.Name,
+		Body:       in.Body,
+		Draft:      in.Draft,
+		Prerelease: in.Prerelease,
+	}
+	if reqBody.Name == "" {
+		reqBody.Name = in.TagName
+	}
+
+	var rel Release
+	if err := g.postJSON("/repos/"+strings.Trim(in.RepoPath, "/")+"/releases", reqBody, &rel); err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
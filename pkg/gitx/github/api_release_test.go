@@ -0,0 +1,35 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/inhere/kite-go/pkg/gitx"
+)
+
+func TestGitHub_CreateRelease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Eq(t, "POST /repos/owner/repo/releases", r.Method+" "+r.URL.Path)
+
+		var body map[string]any
+		assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Eq(t, "v1.2.0", body["tag_name"])
+		assert.Eq(t, "v1.2.0", body["name"])
+		assert.Eq(t, true, body["prerelease"])
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 1, "html_url": "https://github.com/owner/repo/releases/tag/v1.2.0"})
+	}))
+	defer srv.Close()
+
+	gh := New(&gitx.Config{HostUrl: "https://github.com"})
+	gh.Token = "test-token"
+	gh.BaseApi = srv.URL
+
+	rel, err := gh.CreateRelease(ReleaseCreateInput{RepoPath: "owner/repo", TagName: "v1.2.0", Body: "notes", Prerelease: true})
+	assert.NoErr(t, err)
+	assert.Eq(t, "https://github.com/owner/repo/releases/tag/v1.2.0", rel.HTMLURL)
+}
@@ -1 +1,122 @@
 package gitlab
+
+// User info in gitlab api response
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	WebURL   string `json:"web_url,omitempty"`
+}
+
+// Project info
+type Project struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	Path              string `json:"path"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	DefaultBranch     string `json:"default_branch"`
+	WebURL            string `json:"web_url"`
+	SSHURLToRepo      string `json:"ssh_url_to_repo"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	// ForkedFromProject the main project, on the project is a fork
+	ForkedFromProject *Project `json:"forked_from_project,omitempty"`
+}
+
+// Commit info
+type Commit struct {
+	ID          string `json:"id"`
+	ShortID     string `json:"short_id"`
+	Title       string `json:"title"`
+	Message     string `json:"message"`
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+	CreatedAt   string `json:"created_at"`
+	WebURL      string `json:"web_url,omitempty"`
+}
+
+// Branch info
+type Branch struct {
+	Name      string  `json:"name"`
+	Merged    bool    `json:"merged"`
+	Protected bool    `json:"protected"`
+	Default   bool    `json:"default"`
+	WebURL    string  `json:"web_url"`
+	Commit    *Commit `json:"commit,omitempty"`
+}
+
+// MergeRequest info
+type MergeRequest struct {
+	ID              int    `json:"id"`
+	IID             int    `json:"iid"`
+	ProjectID       int    `json:"project_id"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	State           string `json:"state"`
+	MergeStatus     string `json:"merge_status"`
+	SourceBranch    string `json:"source_branch"`
+	TargetBranch    string `json:"target_branch"`
+	SourceProjectID int    `json:"source_project_id"`
+	TargetProjectID int    `json:"target_project_id"`
+	SHA             string `json:"sha"`
+	WebURL          string `json:"web_url"`
+	Author          *User  `json:"author,omitempty"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+	MergedAt        string `json:"merged_at,omitempty"`
+}
+
+// MRCreateInput for create merge request
+type MRCreateInput struct {
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	Title        string `json:"title"`
+	Description  string `json:"description,omitempty"`
+	// TargetProjectID the numeric id of target project, required on create MR from a fork.
+	TargetProjectID    int    `json:"target_project_id,omitempty"`
+	AssigneeID         int    `json:"assignee_id,omitempty"`
+	Labels             string `json:"labels,omitempty"`
+	RemoveSourceBranch bool   `json:"remove_source_branch,omitempty"`
+	Squash             bool   `json:"squash,omitempty"`
+}
+
+// MRListOpts for list merge requests
+type MRListOpts struct {
+	// State allow: opened, closed, locked, merged, all
+	State        string
+	SourceBranch string
+	TargetBranch string
+	Search       string
+	Limit        int
+}
+
+// MRMergeOpts for accept merge request
+type MRMergeOpts struct {
+	MergeCommitMessage       string `json:"merge_commit_message,omitempty"`
+	Squash                   bool   `json:"squash,omitempty"`
+	ShouldRemoveSourceBranch bool   `json:"should_remove_source_branch,omitempty"`
+	// SHA if present, then this SHA must match the HEAD of the source branch
+	SHA string `json:"sha,omitempty"`
+}
+
+// Pipeline info
+type Pipeline struct {
+	ID        int    `json:"id"`
+	IID       int    `json:"iid"`
+	ProjectID int    `json:"project_id"`
+	Status    string `json:"status"`
+	Source    string `json:"source"`
+	Ref       string `json:"ref"`
+	SHA       string `json:"sha"`
+	WebURL    string `json:"web_url"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// Tag info
+type Tag struct {
+	Name      string  `json:"name"`
+	Message   string  `json:"message"`
+	Target    string  `json:"target"`
+	Protected bool    `json:"protected"`
+	Commit    *Commit `json:"commit,omitempty"`
+}
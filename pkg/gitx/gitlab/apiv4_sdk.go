@@ -1,9 +1,316 @@
 package gitlab
 
-// GlabApiV4 struct
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/strutil"
+)
+
+// ApiError gitlab api response error
+type ApiError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error string
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("gitlab api request failed(status %d): %s", e.StatusCode, e.Message)
+}
+
+// GlabApiV4 gitlab api v4 client, auth by person access token.
+//
+// The pid param of methods is project id, allow int or urlencode(group/name). eg: 23, "group%2Fname"
 type GlabApiV4 struct {
+	// BaseApi url. eg: https://gitlab.example.com/api/v4
+	BaseApi string
+	// Token person access token
+	Token  string
+	Client *http.Client
+}
+
+// NewApiV4 instance
+func NewApiV4(baseApi, token string) *GlabApiV4 {
+	return &GlabApiV4{
+		BaseApi: strings.TrimRight(baseApi, "/"),
+		Token:   token,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+//
+// ---------- projects ----------
+//
+
+// GetProject by project id
+func (a *GlabApiV4) GetProject(pid string) (*Project, error) {
+	p := &Project{}
+	return p, a.getJSON("/projects/"+pid, nil, p)
+}
+
+// ListProjects search projects of the current user is member of.
+func (a *GlabApiV4) ListProjects(search string, limit int) ([]*Project, error) {
+	query := pageQuery(limit)
+	query.Set("membership", "true")
+	if search != "" {
+		query.Set("search", search)
+	}
+
+	var list []*Project
+	return list, a.getJSON("/projects", query, &list)
+}
+
+//
+// ---------- branches ----------
+//
+
+// ListBranches of the project. search is optional.
+func (a *GlabApiV4) ListBranches(pid, search string, limit int) ([]*Branch, error) {
+	query := pageQuery(limit)
+	if search != "" {
+		query.Set("search", search)
+	}
+
+	var list []*Branch
+	return list, a.getJSON("/projects/"+pid+"/repository/branches", query, &list)
+}
+
+// GetBranch by name
+func (a *GlabApiV4) GetBranch(pid, name string) (*Branch, error) {
+	br := &Branch{}
+	return br, a.getJSON("/projects/"+pid+"/repository/branches/"+url.PathEscape(name), nil, br)
+}
+
+// CreateBranch from the ref(branch name or commit sha)
+func (a *GlabApiV4) CreateBranch(pid, name, ref string) (*Branch, error) {
+	query := url.Values{"branch": {name}, "ref": {ref}}
+
+	br := &Branch{}
+	return br, a.doJSON(http.MethodPost, "/projects/"+pid+"/repository/branches", query, nil, br)
+}
+
+// DeleteBranch by name
+func (a *GlabApiV4) DeleteBranch(pid, name string) error {
+	return a.doJSON(http.MethodDelete, "/projects/"+pid+"/repository/branches/"+url.PathEscape(name), nil, nil, nil)
+}
+
+//
+// ---------- merge requests ----------
+//
+
+// CreateMR create merge request on the source project.
+//
+// For create MR from a fork to main project, pid is the fork project id and must set in.TargetProjectID
+func (a *GlabApiV4) CreateMR(pid string, in *MRCreateInput) (*MergeRequest, error) {
+	if in.SourceBranch == "" || in.TargetBranch == "" {
+		return nil, fmt.Errorf("gitlab: source and target branch is required")
+	}
+	if in.Title == "" {
+		return nil, fmt.Errorf("gitlab: merge request title is required")
+	}
+
+	mr := &MergeRequest{}
+	return mr, a.doJSON(http.MethodPost, "/projects/"+pid+"/merge_requests", nil, in, mr)
+}
+
+// ListMRs of the project
+func (a *GlabApiV4) ListMRs(pid string, opts *MRListOpts) ([]*MergeRequest, error) {
+	if opts == nil {
+		opts = &MRListOpts{}
+	}
+
+	query := pageQuery(opts.Limit)
+	query.Set("state", strutil.OrElse(opts.State, "opened"))
+	setIfNotEmpty(query, "source_branch", opts.SourceBranch)
+	setIfNotEmpty(query, "target_branch", opts.TargetBranch)
+	setIfNotEmpty(query, "search", opts.Search)
+
+	var list []*MergeRequest
+	return list, a.getJSON("/projects/"+pid+"/merge_requests", query, &list)
+}
+
+// GetMR by merge request iid
+func (a *GlabApiV4) GetMR(pid string, iid int) (*MergeRequest, error) {
+	mr := &MergeRequest{}
+	return mr, a.getJSON(mrPath(pid, iid), nil, mr)
+}
+
+// MergeMR accept the merge request
+func (a *GlabApiV4) MergeMR(pid string, iid int, opts *MRMergeOpts) (*MergeRequest, error) {
+	if opts == nil {
+		opts = &MRMergeOpts{}
+	}
+
+	mr := &MergeRequest{}
+	return mr, a.doJSON(http.MethodPut, mrPath(pid, iid)+"/merge", nil, opts, mr)
+}
+
+// ApproveMR approve the merge request by current user
+func (a *GlabApiV4) ApproveMR(pid string, iid int) error {
+	return a.doJSON(http.MethodPost, mrPath(pid, iid)+"/approve", nil, nil, nil)
+}
+
+func mrPath(pid string, iid int) string {
+	return "/projects/" + pid + "/merge_requests/" + strconv.Itoa(iid)
+}
+
+//
+// ---------- pipelines ----------
+//
+
+// ListPipelines of the project. ref is optional.
+func (a *GlabApiV4) ListPipelines(pid, ref string, limit int) ([]*Pipeline, error) {
+	query := pageQuery(limit)
+	setIfNotEmpty(query, "ref", ref)
+
+	var list []*Pipeline
+	return list, a.getJSON("/projects/"+pid+"/pipelines", query, &list)
+}
+
+// GetPipeline by id
+func (a *GlabApiV4) GetPipeline(pid string, id int) (*Pipeline, error) {
+	pl := &Pipeline{}
+	return pl, a.getJSON("/projects/"+pid+"/pipelines/"+strconv.Itoa(id), nil, pl)
+}
+
+// CreatePipeline run new pipeline for the ref
+func (a *GlabApiV4) CreatePipeline(pid, ref string) (*Pipeline, error) {
+	pl := &Pipeline{}
+	return pl, a.doJSON(http.MethodPost, "/projects/"+pid+"/pipeline", url.Values{"ref": {ref}}, nil, pl)
+}
+
+//
+// ---------- tags ----------
+//
+
+// ListTags of the project, order by updated desc.
+func (a *GlabApiV4) ListTags(pid string, limit int) ([]*Tag, error) {
+	var list []*Tag
+	return list, a.getJSON("/projects/"+pid+"/repository/tags", pageQuery(limit), &list)
+}
+
+// CreateTag on the ref. message is optional, will create annotated tag on not empty.
+func (a *GlabApiV4) CreateTag(pid, name, ref, message string) (*Tag, error) {
+	body := map[string]string{"tag_name": name, "ref": ref}
+	if message != "" {
+		body["message"] = message
+	}
+
+	tag := &Tag{}
+	return tag, a.doJSON(http.MethodPost, "/projects/"+pid+"/repository/tags", nil, body, tag)
+}
+
+// DeleteTag by name
+func (a *GlabApiV4) DeleteTag(pid, name string) error {
+	return a.doJSON(http.MethodDelete, "/projects/"+pid+"/repository/tags/"+url.PathEscape(name), nil, nil, nil)
+}
+
+//
+// ---------- helpers ----------
+//
+
+func (a *GlabApiV4) getJSON(path string, query url.Values, out any) error {
+	return a.doJSON(http.MethodGet, path, query, nil, out)
+}
+
+func (a *GlabApiV4) doJSON(method, path string, query url.Values, body, out any) error {
+	if strings.TrimSpace(a.Token) == "" {
+		return fmt.Errorf("gitlab: token is required")
+	}
+	if a.BaseApi == "" {
+		return fmt.Errorf("gitlab: base api url is required")
+	}
+
+	apiURL := a.BaseApi + path
+	if len(query) > 0 {
+		apiURL += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(bs)
+	}
+
+	req, err := http.NewRequest(method, apiURL, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", a.Token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &ApiError{StatusCode: resp.StatusCode, Message: errMessage(respBody, resp.Status)}
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// errMessage parse error message from response. gitlab returns {"message": ...} or {"error": ...}
+func errMessage(body []byte, status string) string {
+	var data struct {
+		Message any    `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &data); err == nil {
+		if data.Message != nil {
+			if s, ok := data.Message.(string); ok {
+				return s
+			}
+			bs, _ := json.Marshal(data.Message)
+			return string(bs)
+		}
+		if data.Error != "" {
+			return data.Error
+		}
+	}
+
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return msg
+	}
+	return status
+}
+
+func pageQuery(limit int) url.Values {
+	if limit <= 0 {
+		limit = 20
+	}
+	return url.Values{"per_page": {strconv.Itoa(limit)}}
 }
 
-func NewApiV4() *GlabApiV4 {
-	return &GlabApiV4{}
+func setIfNotEmpty(query url.Values, key, val string) {
+	if val != "" {
+		query.Set(key, val)
+	}
 }
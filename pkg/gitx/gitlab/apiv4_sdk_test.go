@@ -0,0 +1,177 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/inhere/kite-go/pkg/gitx"
+)
+
+func newTestGitLab(t *testing.T, handler http.HandlerFunc) *GitLab {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Eq(t, "test-token", r.Header.Get("PRIVATE-TOKEN"))
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	gl := New(&gitx.Config{HostUrl: "https://gitlab.example.com"})
+	gl.Token = "test-token"
+	gl.BaseApi = srv.URL + "/api/v4"
+	return gl
+}
+
+func TestGlProject_CreateMergeRequest_fromFork(t *testing.T) {
+	var paths []string
+	srcBranch := "feat-x"
+	gl := newTestGitLab(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Frepo":
+			assert.Eq(t, http.MethodGet, r.Method)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": 12, "path_with_namespace": "group/repo"})
+		case "/api/v4/projects/inhere%2Frepo/merge_requests":
+			assert.Eq(t, http.MethodPost, r.Method)
+
+			var body map[string]any
+			assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Eq(t, srcBranch, body["source_branch"])
+			assert.Eq(t, "master", body["target_branch"])
+			assert.Eq(t, "feat: add x", body["title"])
+			assert.Eq(t, float64(12), body["target_project_id"])
+
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"iid":     5,
+				"state":   "opened",
+				"web_url": "https://gitlab.example.com/group/repo/-/merge_requests/5",
+			})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.EscapedPath())
+		}
+	})
+
+	glp := &GlProject{GitLab: gl}
+	glp.SetMainProjId(BuildProjectID("group", "repo"))
+	glp.SetForkProjId(BuildProjectID("inhere", "repo"))
+
+	mr, err := glp.CreateMergeRequest(&MRCreateInput{
+		SourceBranch: "feat-x",
+		TargetBranch: "master",
+		Title:        "feat: add x",
+	}, true)
+	assert.NoErr(t, err)
+	assert.Eq(t, 5, mr.IID)
+	assert.Eq(t, "https://gitlab.example.com/group/repo/-/merge_requests/5", mr.WebURL)
+	assert.Len(t, paths, 2)
+
+	// same source and target branch, direct=false will also create from fork
+	paths = paths[:0]
+	srcBranch = "master"
+	mr, err = glp.CreateMergeRequest(&MRCreateInput{
+		SourceBranch: "master",
+		TargetBranch: "master",
+		Title:        "feat: add x",
+	}, false)
+	assert.NoErr(t, err)
+	assert.Eq(t, 5, mr.IID)
+	assert.Eq(t, "POST /api/v4/projects/inhere%2Frepo/merge_requests", paths[1])
+}
+
+func TestGlabApiV4_mergeRequests(t *testing.T) {
+	gl := newTestGitLab(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v4/projects/12/merge_requests":
+			assert.Eq(t, "opened", r.URL.Query().Get("state"))
+			assert.Eq(t, "qa", r.URL.Query().Get("target_branch"))
+			assert.Eq(t, "5", r.URL.Query().Get("per_page"))
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"iid": 5, "title": "feat: add x"}})
+		case "GET /api/v4/projects/12/merge_requests/5":
+			_ = json.NewEncoder(w).Encode(map[string]any{"iid": 5, "merge_status": "can_be_merged"})
+		case "POST /api/v4/projects/12/merge_requests/5/approve":
+			w.WriteHeader(http.StatusCreated)
+		case "PUT /api/v4/projects/12/merge_requests/5/merge":
+			var body map[string]any
+			assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Eq(t, true, body["squash"])
+			_ = json.NewEncoder(w).Encode(map[string]any{"iid": 5, "state": "merged"})
+		case "PUT /api/v4/projects/12/merge_requests/6/merge":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			_, _ = w.Write([]byte(`{"message":"405 Method Not Allowed"}`))
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+	api := gl.Api()
+
+	list, err := api.ListMRs("12", &MRListOpts{TargetBranch: "qa", Limit: 5})
+	assert.NoErr(t, err)
+	assert.Len(t, list, 1)
+	assert.Eq(t, "feat: add x", list[0].Title)
+
+	mr, err := api.GetMR("12", 5)
+	assert.NoErr(t, err)
+	assert.Eq(t, "can_be_merged", mr.MergeStatus)
+
+	assert.NoErr(t, api.ApproveMR("12", 5))
+
+	mr, err = api.MergeMR("12", 5, &MRMergeOpts{Squash: true})
+	assert.NoErr(t, err)
+	assert.Eq(t, "merged", mr.State)
+
+	_, err = api.MergeMR("12", 6, nil)
+	apiErr, ok := err.(*ApiError)
+	assert.True(t, ok)
+	assert.Eq(t, http.StatusMethodNotAllowed, apiErr.StatusCode)
+	assert.Eq(t, "405 Method Not Allowed", apiErr.Message)
+}
+
+func TestGlabApiV4_branchesPipelinesTags(t *testing.T) {
+	gl := newTestGitLab(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /api/v4/projects/12/repository/branches/feat%2Fx":
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": "feat/x", "commit": map[string]any{"id": "abc123"}})
+		case "POST /api/v4/projects/12/repository/branches":
+			assert.Eq(t, "hotfix", r.URL.Query().Get("branch"))
+			assert.Eq(t, "master", r.URL.Query().Get("ref"))
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": "hotfix"})
+		case "GET /api/v4/projects/12/pipelines":
+			assert.Eq(t, "master", r.URL.Query().Get("ref"))
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"id": 100, "status": "success", "ref": "master"}})
+		case "POST /api/v4/projects/12/repository/tags":
+			var body map[string]string
+			assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Eq(t, "v1.2.0", body["tag_name"])
+			assert.Eq(t, "release v1.2.0", body["message"])
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": "v1.2.0", "target": "abc123"})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.EscapedPath())
+		}
+	})
+	api := gl.Api()
+
+	br, err := api.GetBranch("12", "feat/x")
+	assert.NoErr(t, err)
+	assert.Eq(t, "abc123", br.Commit.ID)
+
+	br, err = api.CreateBranch("12", "hotfix", "master")
+	assert.NoErr(t, err)
+	assert.Eq(t, "hotfix", br.Name)
+
+	pls, err := api.ListPipelines("12", "master", 0)
+	assert.NoErr(t, err)
+	assert.Eq(t, "success", pls[0].Status)
+
+	tag, err := api.CreateTag("12", "v1.2.0", "master", "release v1.2.0")
+	assert.NoErr(t, err)
+	assert.Eq(t, "abc123", tag.Target)
+}
+
+func TestGlabApiV4_requireToken(t *testing.T) {
+	_, err := NewApiV4("https://gitlab.example.com/api/v4", "").GetProject("12")
+	assert.Err(t, err)
+	assert.StrContains(t, err.Error(), "token")
+}
@@ -1,6 +1,8 @@
 package gitlab
 
 import (
+	"strings"
+
 	"github.com/inhere/kite-go/pkg/gitx"
 )
 
@@ -25,6 +27,8 @@ type GitLab struct {
 	// BranchAliases maputil.Aliases `json:"branch_aliases"`
 	// DenyBranches deny as source branch for create PR.
 	DenyBranches map[string]string `json:"deny_branches"`
+
+	api *GlabApiV4
 }
 
 // New instance.
@@ -40,3 +44,15 @@ func New(cfg *gitx.Config) *GitLab {
 func (g *GitLab) LocGlProject(dir string) *GlProject {
 	return NewGlProject(dir, g)
 }
+
+// Api v4 client instance. BaseApi default is {HostUrl}/api/v4
+func (g *GitLab) Api() *GlabApiV4 {
+	if g.api == nil {
+		baseApi := g.BaseApi
+		if baseApi == "" {
+			baseApi = strings.TrimRight(g.HostUrl, "/") + "/api/v4"
+		}
+		g.api = NewApiV4(baseApi, g.Token)
+	}
+	return g.api
+}
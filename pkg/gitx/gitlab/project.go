@@ -73,7 +73,7 @@ func (p *GlProject) ForkRmtInfo() *gitw.RemoteInfo {
 
 func (p *GlProject) MainRmtInfo() *gitw.RemoteInfo {
 	if p.mainRemoteInfo == nil {
-		goutil.PanicErr(p.CheckDefaultRemote())
+		goutil.PanicErr(p.CheckSourceRemote())
 		p.mainRemoteInfo = p.lp.RemoteInfo(p.SourceRemote)
 	}
 	return p.mainRemoteInfo
@@ -105,3 +105,30 @@ func (p *GlProject) ForkProjectId() string {
 func (p *GlProject) SetMainProjId(mainProjId string) {
 	p.mainProjId = mainProjId
 }
+
+func (p *GlProject) SetForkProjId(forkProjId string) {
+	p.forkProjId = forkProjId
+}
+
+// CreateMergeRequest create MR by gitlab api.
+//
+//   - direct=true: create MR from the fork project to the main project.
+//   - direct=false: create MR between branches of the main project.
+//
+// NOTE: same source and target branch always create MR from the fork project to the main project.
+func (p *GlProject) CreateMergeRequest(in *MRCreateInput, direct bool) (*MergeRequest, error) {
+	api := p.Api()
+	if !direct && in.SourceBranch != in.TargetBranch {
+		return api.CreateMR(p.MainProjectId(), in)
+	}
+
+	// gitlab requires the numeric id of target project
+	if in.TargetProjectID == 0 {
+		mainProj, err := api.GetProject(p.MainProjectId())
+		if err != nil {
+			return nil, err
+		}
+		in.TargetProjectID = mainProj.ID
+	}
+	return api.CreateMR(p.ForkProjectId(), in)
+}
@@ -0,0 +1,195 @@
+package gitx
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// version bump types
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+	BumpPre   = "pre"
+)
+
+var semverReg = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?$`)
+
+// SemVer a semantic version. see https://semver.org
+type SemVer struct {
+	Major, Minor, Patch int
+	// Pre the pre-release version. eg: rc.1, beta.2
+	Pre string
+}
+
+// ParseSemVer parse version string, allow prefix "v". eg: v1.2.3, 1.2.3-rc.1
+func ParseSemVer(ver string) (*SemVer, error) {
+	ss := semverReg.FindStringSubmatch(strings.TrimSpace(ver))
+	if len(ss) == 0 {
+		return nil, fmt.Errorf("invalid semantic version: %q", ver)
+	}
+
+	sv := &SemVer{Pre: ss[4]}
+	sv.Major, _ = strconv.Atoi(ss[1])
+	sv.Minor, _ = strconv.Atoi(ss[2])
+	sv.Patch, _ = strconv.Atoi(ss[3])
+	return sv, nil
+}
+
+// String version with prefix "v". eg: v1.2.3
+func (v *SemVer) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// IsPre check is pre-release version
+func (v *SemVer) IsPre() bool {
+	return v.Pre != ""
+}
+
+// Compare the version with other. returns -1, 0, 1
+func (v *SemVer) Compare(o *SemVer) int {
+	if d := cmpInt(v.Major, o.Major); d != 0 {
+		return d
+	}
+	if d := cmpInt(v.Minor, o.Minor); d != 0 {
+		return d
+	}
+	if d := cmpInt(v.Patch, o.Patch); d != 0 {
+		return d
+	}
+
+	// pre-release version has lower precedence
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	return comparePre(v.Pre, o.Pre)
+}
+
+// compare pre-release identifiers by dot separated parts
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+
+		var d int
+		switch {
+		case aErr == nil && bErr == nil:
+			d = cmpInt(an, bn)
+		case aErr == nil: // numeric is lower than non-numeric
+			d = -1
+		case bErr == nil:
+			d = 1
+		default:
+			d = strings.Compare(as[i], bs[i])
+		}
+		if d != 0 {
+			return d
+		}
+	}
+	return cmpInt(len(as), len(bs))
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Bump get next version by bump type. preId is used for BumpPre, default is "rc".
+//
+// If current is pre-release, bump major/minor/patch will release it when possible.
+// eg: v1.2.0-rc.2 bump patch -> v1.2.0
+//
+// returns error on the preId will make a lower version. eg: v1.2.0-rc.1 bump pre by beta -> v1.2.0-beta.1
+func (v *SemVer) Bump(typ, preId string) (*SemVer, error) {
+	nv := *v
+	nv.Pre = ""
+
+	switch typ {
+	case BumpMajor:
+		if !v.IsPre() || v.Minor != 0 || v.Patch != 0 {
+			nv.Major, nv.Minor, nv.Patch = v.Major+1, 0, 0
+		}
+	case BumpMinor:
+		if !v.IsPre() || v.Patch != 0 {
+			nv.Minor, nv.Patch = v.Minor+1, 0
+		}
+	case BumpPatch:
+		if !v.IsPre() {
+			nv.Patch++
+		}
+	case BumpPre:
+		if preId == "" {
+			preId = "rc"
+		}
+		nv.Pre = nextPre(v, preId)
+		if !v.IsPre() {
+			nv.Patch++
+		} else if nv.Compare(v) <= 0 {
+			return nil, fmt.Errorf("the pre-release id %q will make a lower version %s than %s", preId, nv.String(), v.String())
+		}
+	default:
+		return nil, fmt.Errorf("invalid bump type %q, allow: major, minor, patch, pre", typ)
+	}
+	return &nv, nil
+}
+
+// eg: rc.1 -> rc.2, beta.2 with preId=rc -> rc.1
+func nextPre(v *SemVer, preId string) string {
+	if v.IsPre() && strings.HasPrefix(v.Pre, preId+".") {
+		if n, err := strconv.Atoi(v.Pre[len(preId)+1:]); err == nil {
+			return preId + "." + strconv.Itoa(n+1)
+		}
+	}
+	return preId + ".1"
+}
+
+// LatestSemverTag find the largest semver tag from tags, returns empty on not found.
+func LatestSemverTag(tags []string) (string, *SemVer) {
+	var latest string
+	var lv *SemVer
+	for _, tag := range tags {
+		sv, err := ParseSemVer(tag)
+		if err != nil {
+			continue
+		}
+		if lv == nil || sv.Compare(lv) > 0 {
+			latest, lv = tag, sv
+		}
+	}
+	return latest, lv
+}
+
+// InferBump infer the bump type by conventional commits.
+//
+//   - has breaking change: major
+//   - has feat commit: minor
+//   - others: patch
+func InferBump(commits []*ConvCommit) string {
+	typ := BumpPatch
+	for _, cc := range commits {
+		if cc.Breaking {
+			return BumpMajor
+		}
+		if cc.Type == "feat" || cc.Type == "feature" {
+			typ = BumpMinor
+		}
+	}
+	return typ
+}
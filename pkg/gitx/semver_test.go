@@ -0,0 +1,60 @@
+package gitx_test
+
+import (
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/inhere/kite-go/pkg/gitx"
+)
+
+func TestSemVer_Bump(t *testing.T) {
+	tests := []struct {
+		ver, typ, want string
+	}{
+		{"v1.2.3", gitx.BumpMajor, "v2.0.0"},
+		{"v1.2.3", gitx.BumpMinor, "v1.3.0"},
+		{"1.2.3", gitx.BumpPatch, "v1.2.4"},
+		{"v1.2.3", gitx.BumpPre, "v1.2.4-rc.1"},
+		{"v1.2.4-rc.1", gitx.BumpPre, "v1.2.4-rc.2"},
+		{"v1.2.4-beta.3", gitx.BumpPre, "v1.2.4-rc.1"},
+		{"v1.2.4-rc.2", gitx.BumpPatch, "v1.2.4"},
+		{"v1.3.0-rc.2", gitx.BumpMinor, "v1.3.0"},
+		{"v1.2.4-rc.2", gitx.BumpMinor, "v1.3.0"},
+		{"v2.0.0-rc.1", gitx.BumpMajor, "v2.0.0"},
+	}
+
+	for _, tt := range tests {
+		sv, err := gitx.ParseSemVer(tt.ver)
+		assert.NoErr(t, err)
+		nv, err := sv.Bump(tt.typ, "")
+		assert.NoErr(t, err)
+		assert.Eq(t, tt.want, nv.String(), tt.ver+" bump "+tt.typ)
+	}
+
+	// pre id will make a lower version
+	sv, err := gitx.ParseSemVer("v1.2.4-rc.1")
+	assert.NoErr(t, err)
+	_, err = sv.Bump(gitx.BumpPre, "beta")
+	assert.ErrSubMsg(t, err, "will make a lower version v1.2.4-beta.1")
+	nv, err := sv.Bump(gitx.BumpPre, "rc")
+	assert.NoErr(t, err)
+	assert.Eq(t, "v1.2.4-rc.2", nv.String())
+
+	_, err = gitx.ParseSemVer("release-1")
+	assert.Err(t, err)
+}
+
+func TestLatestSemverTag(t *testing.T) {
+	tag, sv := gitx.LatestSemverTag([]string{"v1.2.0", "v1.10.0-rc.2", "v1.10.0-rc.10", "v1.9.3", "nightly"})
+	assert.Eq(t, "v1.10.0-rc.10", tag)
+	assert.Eq(t, 10, sv.Minor)
+
+	tag, _ = gitx.LatestSemverTag([]string{"v1.10.0-rc.10", "v1.10.0"})
+	assert.Eq(t, "v1.10.0", tag)
+
+	assert.Eq(t, gitx.BumpMinor, gitx.InferBump([]*gitx.ConvCommit{
+		gitx.ParseConvCommit("fix: a", ""),
+		gitx.ParseConvCommit("feat: b", ""),
+	}))
+	assert.Eq(t, gitx.BumpMajor, gitx.InferBump([]*gitx.ConvCommit{gitx.ParseConvCommit("fix!: a", "")}))
+}
@@ -0,0 +1,45 @@
+package gitx
+
+import (
+	"strings"
+
+	"github.com/gookit/goutil/sysutil/cmdr"
+)
+
+// VersionTags list tags of the repo dir, sorted by version desc.
+func VersionTags(repoDir string) ([]string, error) {
+	out, err := cmdr.NewGitCmd("tag", "--list", "--sort=-v:refname").WithWorkDir(repoDir).Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// ResolveVersion resolve the keywords to real version(tag or sha) in the repo dir.
+//
+//   - last, latest: the latest tag
+//   - prev, previous: the previous tag of latest
+//   - head: HEAD commit
+//
+// returns empty on the tag not exists.
+func ResolveVersion(repoDir, ver string) (string, error) {
+	switch strings.ToLower(ver) {
+	case "head":
+		return "HEAD", nil
+	case "last", "latest", "prev", "previous":
+		tags, err := VersionTags(repoDir)
+		if err != nil {
+			return "", err
+		}
+
+		idx := 0
+		if strings.HasPrefix(strings.ToLower(ver), "prev") {
+			idx = 1
+		}
+		if len(tags) > idx {
+			return tags[idx], nil
+		}
+		return "", nil
+	}
+	return ver, nil
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package hookrun
+
+import "os/exec"
+
+// setRawCmdLine is a no-op on non-Windows systems.
+func setRawCmdLine(_ *exec.Cmd, _ string) {}
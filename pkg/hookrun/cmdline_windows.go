@@ -0,0 +1,14 @@
+//go:build windows
+
+package hookrun
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setRawCmdLine set the raw command line for run by cmd.exe,
+// avoid the quoted vars in line are escaped again by Go runtime.
+func setRawCmdLine(cmd *exec.Cmd, line string) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: line}
+}
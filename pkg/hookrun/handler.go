@@ -0,0 +1,308 @@
+package hookrun
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/netutil/httpctype"
+	"github.com/inhere/kite-go/pkg/util/httputil"
+)
+
+// MaxBodySize max webhook request body size for read
+var MaxBodySize int64 = 10 << 20
+
+// RunsPath for query recent runs of the runner
+const RunsPath = "/_hook-runs"
+
+// Payload of a webhook request, for get values by source.
+type Payload struct {
+	Method string
+	Path   string
+	Header http.Header
+	Query  url.Values
+	Body   []byte
+	// Data decoded body data. JSON body or form data.
+	Data any
+}
+
+// NewPayload read request body and decode it. the form body will use the "payload" field as JSON.
+func NewPayload(r *http.Request) (*Payload, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Payload{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header,
+		Query:  r.URL.Query(),
+		Body:   body,
+	}
+	if len(body) == 0 {
+		return p, nil
+	}
+
+	if httpctype.ToKind(r.Header.Get(httpctype.Key), "") == httpctype.KindForm {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+
+		if pl := form.Get("payload"); pl != "" {
+			body = []byte(pl)
+		} else {
+			data := make(map[string]any, len(form))
+			for k := range form {
+				data[k] = form.Get(k)
+			}
+			p.Data = data
+			return p, nil
+		}
+	}
+
+	// ignore decode error, can still get the raw body
+	_ = json.Unmarshal(body, &p.Data)
+	return p, nil
+}
+
+// Value get value by source. returns empty string on not found.
+//
+// Sources:
+//
+//	$.a.b[0]    value from JSON body by path. eg: $.ref, $.repository.name, $.commits[0].id
+//	header.X    value from request header. eg: header.X-Gitlab-Event
+//	query.x     value from URL query
+//	method      request method
+//	path        request path
+//	body        raw request body
+func (p *Payload) Value(source string) string {
+	switch {
+	case source == "method":
+		return p.Method
+	case source == "path":
+		return p.Path
+	case source == "body":
+		return string(p.Body)
+	case strings.HasPrefix(source, "header."):
+		return p.Header.Get(source[7:])
+	case strings.HasPrefix(source, "query."):
+		return p.Query.Get(source[6:])
+	case source == "$":
+		return toString(p.Data)
+	case strings.HasPrefix(source, "$."):
+		// eg: commits[0].id -> commits.0.id
+		path := strings.ReplaceAll(strings.ReplaceAll(source[2:], "[", "."), "]", "")
+		val, _ := maputil.GetFromAny(path, p.Data)
+		return toString(val)
+	}
+	return ""
+}
+
+func toString(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	default:
+		bs, _ := json.Marshal(v)
+		return string(bs)
+	}
+}
+
+// Verify the request by hook secret. returns true on secret is empty.
+func (h *Hook) Verify(p *Payload) bool {
+	if h.Secret == "" {
+		return true
+	}
+
+	provider := h.Provider
+	if provider == "" {
+		provider = detectProvider(p.Header)
+	}
+
+	switch provider {
+	case "github":
+		sig := p.Header.Get("X-Hub-Signature-256")
+		if !strings.HasPrefix(sig, "sha256=") {
+			return false
+		}
+
+		mac := hmac.New(sha256.New, []byte(h.Secret))
+		mac.Write(p.Body)
+		expect := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(sig[7:]), []byte(expect))
+	case "gitlab":
+		return secureEq(p.Header.Get("X-Gitlab-Token"), h.Secret)
+	case "gitee":
+		return secureEq(p.Header.Get("X-Gitee-Token"), h.Secret)
+	}
+	return false
+}
+
+func detectProvider(hs http.Header) string {
+	switch {
+	case hs.Get("X-Hub-Signature-256") != "" || hs.Get("X-GitHub-Event") != "":
+		return "github"
+	case hs.Get("X-Gitlab-Token") != "" || hs.Get("X-Gitlab-Event") != "":
+		return "gitlab"
+	case hs.Get("X-Gitee-Token") != "" || hs.Get("X-Gitee-Event") != "":
+		return "gitee"
+	}
+	return ""
+}
+
+func secureEq(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// Match check the payload matched all filters. returns the not matched reason.
+func (h *Hook) Match(p *Payload) (bool, string) {
+	for source, expect := range h.Filters {
+		val := p.Value(source)
+
+		if len(expect) > 1 && expect[0] == '/' && expect[len(expect)-1] == '/' {
+			reg, err := regexp.Compile(expect[1 : len(expect)-1])
+			if err != nil {
+				return false, fmt.Sprintf("invalid filter regexp %q: %v", expect, err)
+			}
+			if !reg.MatchString(val) {
+				return false, fmt.Sprintf("%s=%q not match %s", source, val, expect)
+			}
+		} else if val != expect {
+			return false, fmt.Sprintf("%s=%q not equals %q", source, val, expect)
+		}
+	}
+	return true, ""
+}
+
+// ExtractVars from payload by Hook.Vars
+func (h *Hook) ExtractVars(p *Payload) map[string]string {
+	vars := make(map[string]string, len(h.Vars))
+	for name, source := range h.Vars {
+		vars[name] = p.Value(source)
+	}
+	return vars
+}
+
+// HookResult of a hook for the webhook request
+type HookResult struct {
+	Hook   string `json:"hook"`
+	JobID  int64  `json:"job_id,omitempty"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Paths of all hooks, no duplicate.
+func (r *Runner) Paths() []string {
+	var paths []string
+	exists := make(map[string]bool)
+	for _, h := range r.Hooks {
+		if !exists[h.Path] {
+			exists[h.Path] = true
+			paths = append(paths, h.Path)
+		}
+	}
+	return paths
+}
+
+// ServeHTTP handle the webhook request, will queue matched hooks. also handle RunsPath.
+//
+// Responses:
+//
+//	202 has queued, skipped or rejected hooks, the body is []HookResult
+//	401 all hooks verify failed
+//	404 no hook for the path and method
+//	503 the run queue is full
+func (r *Runner) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == RunsPath {
+		r.serveRuns(w, req)
+		return
+	}
+
+	var hooks []*Hook
+	for _, h := range r.Hooks {
+		if h.Path == req.URL.Path && h.allow(req.Method) {
+			hooks = append(hooks, h)
+		}
+	}
+	if len(hooks) == 0 {
+		httputil.WriteJSONIndent(w, http.StatusNotFound, map[string]any{"error": "no hook for " + req.Method + " " + req.URL.Path})
+		return
+	}
+
+	p, err := NewPayload(req)
+	if err != nil {
+		httputil.WriteJSONIndent(w, http.StatusBadRequest, map[string]any{"error": "read payload error: " + err.Error()})
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(p.Body))
+
+	var verified bool
+	var results []*HookResult
+	for _, h := range hooks {
+		if !h.Verify(p) {
+			r.logf("<yellow>[hook]</> hook %q verify failed, from %s\n", h.Name, req.RemoteAddr)
+			continue
+		}
+		verified = true
+
+		if ok, reason := h.Match(p); !ok {
+			r.logf("<gray>[hook]</> skip hook %q: %s\n", h.Name, reason)
+			results = append(results, &HookResult{Hook: h.Name, Status: "skipped", Reason: reason})
+			continue
+		}
+
+		vars := h.ExtractVars(p)
+		if err := h.CheckVars(vars); err != nil {
+			r.logf("<yellow>[hook]</> reject hook %q: %v\n", h.Name, err)
+			results = append(results, &HookResult{Hook: h.Name, Status: "rejected", Reason: err.Error()})
+			continue
+		}
+
+		job, err := r.Enqueue(h, vars)
+		if err != nil {
+			httputil.WriteJSONIndent(w, http.StatusServiceUnavailable, map[string]any{"error": err.Error(), "results": results})
+			return
+		}
+		results = append(results, &HookResult{Hook: h.Name, JobID: job.ID, Status: StatusQueued})
+	}
+
+	if !verified {
+		httputil.WriteJSONIndent(w, http.StatusUnauthorized, map[string]any{"error": "verify webhook secret failed"})
+		return
+	}
+	httputil.WriteJSONIndent(w, http.StatusAccepted, results)
+}
+
+// serve the recent runs. require the RunsToken, will be 404 on it is empty.
+func (r *Runner) serveRuns(w http.ResponseWriter, req *http.Request) {
+	if r.RunsToken == "" {
+		httputil.WriteJSONIndent(w, http.StatusNotFound, map[string]any{"error": "the hook runs query is disabled"})
+		return
+	}
+
+	token := req.Header.Get("X-Hook-Token")
+	if token == "" {
+		token = req.URL.Query().Get("token")
+	}
+	if !secureEq(token, r.RunsToken) {
+		httputil.WriteJSONIndent(w, http.StatusUnauthorized, map[string]any{"error": "invalid hook runs token"})
+		return
+	}
+	httputil.WriteJSONIndent(w, http.StatusOK, r.Jobs())
+}
@@ -0,0 +1,445 @@
+// Package hookrun route webhook requests to run kscript tasks or shell commands.
+package hookrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/config/v2"
+	"github.com/gookit/config/v2/yaml"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/x/ccolor"
+	"github.com/inhere/kite-go/pkg/util/bizutil"
+)
+
+// Config of the webhook runner. see data/example/hook-server.yml for example.
+type Config struct {
+	// LogDir for write the run output logs, one file per day. default print to stdout
+	LogDir string `json:"log_dir"`
+	// QueueSize max pending runs, default is 20
+	QueueSize int `json:"queue_size"`
+	// Shell for run command, default is sh, cmd on Windows
+	Shell string `json:"shell"`
+	// Hooks route config list
+	Hooks []*Hook `json:"hooks"`
+	// Quiet mode, dont print logs to console
+	Quiet bool `json:"quiet"`
+	// RunsToken for query the recent runs by RunsPath, allow use ENV var. eg: ${HOOK_RUNS_TOKEN}
+	//
+	// the token is read from header X-Hook-Token or query "token". RunsPath is disabled on empty.
+	RunsToken string `json:"runs_token"`
+}
+
+// LoadConfigFile load config from YAML or JSON file. the relative log_dir and workdir will relative the file dir.
+func LoadConfigFile(cfgFile string) (*Config, error) {
+	// not parse ENV, the ${name} in args and run is hook vars
+	cfg := config.NewEmpty("hook-config", config.WithTagName("json"))
+	cfg.WithDriver(config.JSONDriver, yaml.Driver)
+	if err := cfg.LoadFiles(cfgFile); err != nil {
+		return nil, fmt.Errorf("failed to load config file %s: %w", cfgFile, err)
+	}
+
+	c := &Config{}
+	if err := cfg.Decode(c); err != nil {
+		return nil, fmt.Errorf("failed to decode hook config: %w", err)
+	}
+
+	baseDir := filepath.Dir(cfgFile)
+	c.LogDir = resolvePath(baseDir, c.LogDir)
+	for _, h := range c.Hooks {
+		h.Workdir = resolvePath(baseDir, h.Workdir)
+	}
+	return c, nil
+}
+
+func resolvePath(baseDir, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	path = fsutil.ExpandHome(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	return path
+}
+
+// Hook route config, map a webhook path to an action: run kscript task or shell command.
+type Hook struct {
+	// Name of the hook, default is the Path
+	Name string `json:"name"`
+	// Path of the webhook request. eg: /hooks/deploy-api
+	Path string `json:"path"`
+	// Methods allowed, default is POST
+	Methods []string `json:"methods"`
+	// Provider for verify the Secret. allow: github, gitlab, gitee. default will detect by headers.
+	Provider string `json:"provider"`
+	// Secret for verify request, allow use ENV var. eg: ${GITLAB_HOOK_TOKEN}
+	//
+	//  - github: verify the X-Hub-Signature-256 header
+	//  - gitlab: check the X-Gitlab-Token header
+	//  - gitee: check the X-Gitee-Token header
+	Secret string `json:"secret"`
+	// Filters all must be matched. format: {source: expected value}
+	//
+	// see Payload.Value for the source. eg: {"header.X-Gitlab-Event": "Push Hook", "$.ref": "/^refs/tags/v/"}
+	//
+	// TIP: value like /REGEXP/ will match by regexp.
+	Filters map[string]string `json:"filters"`
+	// Vars extract values from payload as variables. format: {name: source}
+	Vars map[string]string `json:"vars"`
+
+	// Task name of kscript task for run
+	//
+	// NOTE: the vars will be rendered to task commands without quote, so the payload values for
+	// task hook only allow safe chars: letters, digits and "_./@:+=,-". see CheckVars
+	Task string `json:"task"`
+	// Args for run the task, allow use vars. eg: ${branch}
+	Args []string `json:"args"`
+	// Run shell command, allow use vars, the value will be quoted. eg: ./deploy.sh ${branch}
+	//
+	// the vars also set as ENV: HOOK_{NAME}. eg: HOOK_BRANCH
+	Run string `json:"run"`
+	// Workdir for run the action
+	Workdir string `json:"workdir"`
+	// Timeout for run the action, default is 30m
+	Timeout string `json:"timeout"`
+
+	timeout time.Duration
+}
+
+// init and check the hook config
+func (h *Hook) init() error {
+	if h.Path == "" {
+		return errorx.Raw("hook path is required")
+	}
+	if !strings.HasPrefix(h.Path, "/") {
+		h.Path = "/" + h.Path
+	}
+
+	h.Name = strings.TrimSpace(h.Name)
+	if h.Name == "" {
+		h.Name = h.Path
+	}
+	if h.Task == "" && h.Run == "" {
+		return errorx.Rawf("hook %q: task or run is required", h.Name)
+	}
+
+	if len(h.Methods) == 0 {
+		h.Methods = []string{"POST"}
+	}
+	for i, m := range h.Methods {
+		h.Methods[i] = strings.ToUpper(m)
+	}
+
+	h.timeout = 30 * time.Minute
+	if h.Timeout != "" {
+		dur, err := time.ParseDuration(h.Timeout)
+		if err != nil {
+			return errorx.Rawf("hook %q: invalid timeout %q", h.Name, h.Timeout)
+		}
+		h.timeout = dur
+	}
+
+	h.Secret = os.ExpandEnv(h.Secret)
+	h.Provider = strings.ToLower(h.Provider)
+	return nil
+}
+
+var safeValReg = regexp.MustCompile(`^[\w./@:+=,-]*$`)
+
+// CheckVars check the vars is safe for run task. the shell command will quote the vars, so no check.
+func (h *Hook) CheckVars(vars map[string]string) error {
+	if h.Task == "" {
+		return nil
+	}
+
+	for name, val := range vars {
+		if !safeValReg.MatchString(val) {
+			return errorx.Rawf("var %q contains unsafe chars for run task: %q", name, val)
+		}
+	}
+	return nil
+}
+
+// allow the request method
+func (h *Hook) allow(method string) bool {
+	for _, m := range h.Methods {
+		if m == method || m == "ANY" {
+			return true
+		}
+	}
+	return false
+}
+
+// RunTaskFunc run a kscript task for the hook. args has been rendered by vars.
+type RunTaskFunc func(ctx context.Context, h *Hook, args []string, vars map[string]string, w io.Writer) error
+
+// Job status constants
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+// Job of run a hook action
+type Job struct {
+	ID     int64             `json:"id"`
+	Hook   string            `json:"hook"`
+	Vars   map[string]string `json:"vars"`
+	Status string            `json:"status"`
+	Error  string            `json:"error,omitempty"`
+	// QueuedAt time of the job queued
+	QueuedAt time.Time `json:"queued_at"`
+	StartAt  time.Time `json:"start_at,omitempty"`
+	Cost     string    `json:"cost,omitempty"`
+
+	hook *Hook
+}
+
+// MaxJobHistory max number of the finished jobs kept in memory
+var MaxJobHistory = 50
+
+// Runner receive webhook requests and run the actions in a queue, the runs will not overlap.
+type Runner struct {
+	*Config
+	// RunTask handler for run the kscript task. must be set on has task hooks.
+	RunTask RunTaskFunc
+
+	mu   sync.Mutex
+	seq  int64
+	jobs []*Job
+	// run queue
+	queue chan *Job
+	done  chan struct{}
+}
+
+// New runner with config
+func New(c *Config) (*Runner, error) {
+	if c.QueueSize <= 0 {
+		c.QueueSize = 20
+	}
+	c.RunsToken = os.ExpandEnv(c.RunsToken)
+
+	for _, h := range c.Hooks {
+		if err := h.init(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Runner{
+		Config: c,
+		queue:  make(chan *Job, c.QueueSize),
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// Start the worker for run queued jobs
+func (r *Runner) Start() {
+	go func() {
+		defer close(r.done)
+		for job := range r.queue {
+			r.runJob(job)
+		}
+	}()
+}
+
+// Stop the worker, will wait the queued jobs finished.
+func (r *Runner) Stop() {
+	close(r.queue)
+	<-r.done
+}
+
+// Enqueue a job for run the hook action. returns error on the queue is full.
+func (r *Runner) Enqueue(h *Hook, vars map[string]string) (*Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	job := &Job{
+		ID:       r.seq,
+		Hook:     h.Name,
+		Vars:     vars,
+		Status:   StatusQueued,
+		QueuedAt: time.Now(),
+		hook:     h,
+	}
+
+	select {
+	case r.queue <- job:
+	default:
+		return nil, errorx.Rawf("the run queue is full(size: %d)", r.QueueSize)
+	}
+
+	r.jobs = append(r.jobs, job)
+	if len(r.jobs) > MaxJobHistory {
+		r.jobs = r.jobs[len(r.jobs)-MaxJobHistory:]
+	}
+
+	r.logf("<cyan>[hook]</> queued job #%d for hook %q, vars: %v\n", job.ID, h.Name, vars)
+	return job, nil
+}
+
+// Jobs recent jobs, the newest last. returns copies for avoid data race.
+func (r *Runner) Jobs() []Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]Job, len(r.jobs))
+	for i, job := range r.jobs {
+		list[i] = *job
+	}
+	return list
+}
+
+func (r *Runner) setJob(job *Job, fn func(job *Job)) {
+	r.mu.Lock()
+	fn(job)
+	r.mu.Unlock()
+}
+
+func (r *Runner) runJob(job *Job) {
+	start := time.Now()
+	r.setJob(job, func(job *Job) {
+		job.Status = StatusRunning
+		job.StartAt = start
+	})
+
+	w, closeFn := r.logWriter()
+	defer closeFn()
+
+	h := job.hook
+	_, _ = fmt.Fprintf(w, "==== [%s] job #%d hook %q start\n", start.Format(time.DateTime), job.ID, h.Name)
+	r.logf("<cyan>[hook]</> start job #%d for hook %q\n", job.ID, h.Name)
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	var err error
+	if h.Task != "" {
+		if r.RunTask == nil {
+			err = errorx.Raw("the task runner is not set")
+		} else {
+			err = r.RunTask(ctx, h, renderArgs(h.Args, job.Vars), job.Vars, w)
+		}
+	} else {
+		err = r.runShell(ctx, h, job.Vars, w)
+	}
+
+	cost := time.Since(start).Round(time.Millisecond)
+	r.setJob(job, func(job *Job) {
+		job.Cost = cost.String()
+		job.Status = StatusSuccess
+		if err != nil {
+			job.Status = StatusFailed
+			job.Error = err.Error()
+		}
+	})
+
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "==== job #%d failed, cost: %s, error: %v\n", job.ID, cost, err)
+		r.logf("<red>[hook]</> job #%d for hook %q failed, error: %v\n", job.ID, h.Name, err)
+	} else {
+		_, _ = fmt.Fprintf(w, "==== job #%d success, cost: %s\n", job.ID, cost)
+		r.logf("<green>[hook]</> job #%d for hook %q success, cost: %s\n", job.ID, h.Name, cost)
+	}
+}
+
+// log writer for the run output. will write to stdout on LogDir is empty.
+func (r *Runner) logWriter() (io.Writer, func()) {
+	if r.LogDir == "" {
+		return os.Stdout, func() {}
+	}
+
+	logFile := filepath.Join(r.LogDir, "hook-"+time.Now().Format("20060102")+".log")
+	if err := fsutil.MkParentDir(logFile); err != nil {
+		r.logf("<red>[hook]</> create log dir error: %v\n", err)
+		return os.Stdout, func() {}
+	}
+
+	f, err := fsutil.OpenAppendFile(logFile)
+	if err != nil {
+		r.logf("<red>[hook]</> open log file error: %v\n", err)
+		return os.Stdout, func() {}
+	}
+	return f, func() { _ = f.Close() }
+}
+
+func (r *Runner) runShell(ctx context.Context, h *Hook, vars map[string]string, w io.Writer) error {
+	shell := r.Shell
+	if shell == "" {
+		shell = "sh"
+		if runtime.GOOS == "windows" {
+			shell = "cmd"
+		}
+	}
+
+	flag, quoteFn := shellFlagQuote(shell)
+	line := renderVars(h.Run, vars, quoteFn)
+	_, _ = fmt.Fprintf(w, "> %s\n", line)
+
+	cmd := exec.CommandContext(ctx, shell, flag, line)
+	if flag == "/C" {
+		setRawCmdLine(cmd, shell+" "+flag+" "+line)
+	}
+	cmd.Dir = h.Workdir
+	cmd.Stdout, cmd.Stderr = w, w
+	cmd.Env = os.Environ()
+	for name, val := range vars {
+		cmd.Env = append(cmd.Env, "HOOK_"+strings.ToUpper(name)+"="+val)
+	}
+	return cmd.Run()
+}
+
+func (r *Runner) logf(format string, args ...any) {
+	if !r.Quiet {
+		ccolor.Printf(format, args...)
+	}
+}
+
+var varReg = regexp.MustCompile(`\$\{(\w+)}`)
+
+// render ${name} by vars, missing var will be kept.
+// shellFlagQuote get the command line flag and quote func of the shell.
+// Windows cmd.exe use "/C" and the cmd-specific quoting, others use "-c" and POSIX quoting.
+func shellFlagQuote(shell string) (string, func(s string) string) {
+	name := strings.ToLower(shell)
+	if pos := strings.LastIndexAny(name, `/\`); pos >= 0 {
+		name = name[pos+1:]
+	}
+	if name == "cmd" || name == "cmd.exe" {
+		return "/C", bizutil.CmdQuote
+	}
+	return "-c", bizutil.ShellQuote
+}
+
+func renderVars(s string, vars map[string]string, fn func(s string) string) string {
+	return varReg.ReplaceAllStringFunc(s, func(sub string) string {
+		if val, ok := vars[sub[2:len(sub)-1]]; ok {
+			if fn != nil {
+				return fn(val)
+			}
+			return val
+		}
+		return sub
+	})
+}
+
+func renderArgs(args []string, vars map[string]string) []string {
+	list := make([]string, len(args))
+	for i, arg := range args {
+		list[i] = renderVars(arg, vars, nil)
+	}
+	return list
+}
@@ -0,0 +1,201 @@
+package hookrun
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/inhere/kite-go/pkg/util/bizutil"
+)
+
+func TestRunner_ServeHTTP(t *testing.T) {
+	logDir := t.TempDir()
+	outFile := filepath.Join(logDir, "out.txt")
+
+	r, err := New(&Config{
+		LogDir:    logDir,
+		Quiet:     true,
+		RunsToken: "tk",
+		Hooks: []*Hook{
+			{
+				Name:     "deploy",
+				Path:     "hooks/deploy",
+				Provider: "github",
+				Secret:   "s3cret",
+				Filters:  map[string]string{"header.X-GitHub-Event": "push", "$.ref": "/^refs/heads/(main|dev)$/"},
+				Vars:     map[string]string{"branch": "$.ref", "commit": "$.commits[0].id"},
+				Task:     "deploy",
+				Args:     []string{"--branch", "${branch}"},
+			},
+			{
+				Name:    "notify",
+				Path:    "/hooks/deploy",
+				Vars:    map[string]string{"ref": "$.ref", "event": "header.X-GitHub-Event"},
+				Run:     "echo ${event} ${ref} > " + outFile,
+				Timeout: "10s",
+			},
+		},
+	})
+	assert.NoErr(t, err)
+	assert.Eq(t, []string{"/hooks/deploy"}, r.Paths())
+
+	var taskArgs []string
+	r.RunTask = func(_ context.Context, h *Hook, args []string, vars map[string]string, w io.Writer) error {
+		taskArgs = append([]string{h.Task}, args...)
+		_, err := fmt.Fprintln(w, "run task", h.Task, vars["commit"])
+		return err
+	}
+	r.Start()
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	send := func(event, sig, body string) (int, []*HookResult) {
+		req, err := http.NewRequest("POST", srv.URL+"/hooks/deploy", strings.NewReader(body))
+		assert.NoErr(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", event)
+		if sig != "" {
+			req.Header.Set("X-Hub-Signature-256", sig)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoErr(t, err)
+		defer resp.Body.Close()
+
+		var results []*HookResult
+		_ = json.NewDecoder(resp.Body).Decode(&results)
+		return resp.StatusCode, results
+	}
+
+	body := `{"ref": "refs/heads/main", "commits": [{"id": "abc123"}]}`
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	// signed: both hooks are queued
+	code, results := send("push", sign(body), body)
+	assert.Eq(t, http.StatusAccepted, code)
+	assert.Len(t, results, 2)
+	assert.Eq(t, StatusQueued, results[0].Status)
+
+	// bad signature: only the no secret hook
+	code, results = send("push", "sha256=bad", body)
+	assert.Eq(t, http.StatusAccepted, code)
+	assert.Len(t, results, 1)
+	assert.Eq(t, "notify", results[0].Hook)
+
+	// filter not matched
+	body = strings.Replace(body, "main", "feat", 1)
+	code, results = send("push", sign(body), body)
+	assert.Eq(t, http.StatusAccepted, code)
+	assert.Len(t, results, 2)
+	assert.Eq(t, "skipped", results[0].Status)
+
+	// unsafe var value for task hook
+	body = `{"ref": "refs/heads/main", "commits": [{"id": "abc; rm -rf /"}]}`
+	code, results = send("push", sign(body), body)
+	assert.Eq(t, http.StatusAccepted, code)
+	assert.Len(t, results, 2)
+	assert.Eq(t, "rejected", results[0].Status)
+	assert.StrContains(t, results[0].Reason, `var "commit" contains unsafe chars`)
+	assert.Eq(t, StatusQueued, results[1].Status)
+
+	// query the runs require token
+	resp, err := http.Get(srv.URL + RunsPath)
+	assert.NoErr(t, err)
+	assert.Eq(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+	resp, err = http.Get(srv.URL + RunsPath + "?token=tk")
+	assert.NoErr(t, err)
+	assert.Eq(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// wait the queued jobs finished
+	r.Stop()
+
+	jobs := r.Jobs()
+	assert.Len(t, jobs, 5)
+	for _, job := range jobs {
+		assert.Eq(t, StatusSuccess, job.Status, job.Error)
+	}
+	assert.Eq(t, []string{"deploy", "--branch", "refs/heads/main"}, taskArgs)
+	assert.Eq(t, "abc123", jobs[0].Vars["commit"])
+
+	bs, err := os.ReadFile(outFile)
+	assert.NoErr(t, err)
+	assert.Eq(t, "push refs/heads/main\n", string(bs))
+
+	logs, err := filepath.Glob(filepath.Join(logDir, "hook-*.log"))
+	assert.NoErr(t, err)
+	assert.Len(t, logs, 1)
+	bs, err = os.ReadFile(logs[0])
+	assert.NoErr(t, err)
+	assert.StrContains(t, string(bs), "run task deploy abc123")
+}
+
+func TestPayload_Value(t *testing.T) {
+	body := "payload=" + `{"ref":"v1.0.0","repo":{"name":"kite"},"n":3}`
+	req := httptest.NewRequest("POST", "/hook?env=prod", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Gitlab-Token", "tk")
+
+	p, err := NewPayload(req)
+	assert.NoErr(t, err)
+	assert.Eq(t, "v1.0.0", p.Value("$.ref"))
+	assert.Eq(t, "kite", p.Value("$.repo.name"))
+	assert.Eq(t, "3", p.Value("$.n"))
+	assert.Eq(t, `{"name":"kite"}`, p.Value("$.repo"))
+	assert.Eq(t, "", p.Value("$.not-exist"))
+	assert.Eq(t, "prod", p.Value("query.env"))
+	assert.Eq(t, "tk", p.Value("header.X-Gitlab-Token"))
+
+	h := &Hook{Path: "/hook", Run: "echo", Secret: "tk"}
+	assert.NoErr(t, h.init())
+	assert.True(t, h.Verify(p))
+	h.Secret = "other"
+	assert.False(t, h.Verify(p))
+
+	assert.Eq(t, `'it'\''s'`, bizutil.ShellQuote("it's"))
+	assert.Eq(t, "a 'b' ${c}", renderVars("a ${b} ${c}", map[string]string{"b": "b"}, bizutil.ShellQuote))
+
+	// cmd.exe use the cmd-specific quoting
+	flag, quoteFn := shellFlagQuote(`C:\Windows\System32\cmd.exe`)
+	assert.Eq(t, "/C", flag)
+	assert.Eq(t, `deploy.bat ^"a^&b^" main`, renderVars("deploy.bat ${v} ${b}", map[string]string{"v": "a&b", "b": "main"}, quoteFn))
+	flag, _ = shellFlagQuote("bash")
+	assert.Eq(t, "-c", flag)
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	t.Setenv("GITLAB_HOOK_TOKEN", "tk")
+	t.Setenv("HOOK_RUNS_TOKEN", "runs-tk")
+	cfg, err := LoadConfigFile("../../data/example/hook-server.yml")
+	assert.NoErr(t, err)
+	assert.Len(t, cfg.Hooks, 2)
+	assert.Eq(t, 20, cfg.QueueSize)
+	assert.Eq(t, filepath.Join("../../data/example", "tmp/hook-logs"), cfg.LogDir)
+
+	r, err := New(cfg)
+	assert.NoErr(t, err)
+	assert.Eq(t, "runs-tk", r.RunsToken)
+	h := r.Hooks[1]
+	assert.Eq(t, "tk", h.Secret)
+	assert.Eq(t, []string{"POST"}, h.Methods)
+	assert.Eq(t, "Tag Push Hook", h.Filters["header.X-Gitlab-Event"])
+	assert.Eq(t, "$.project.path_with_namespace", h.Vars["project"])
+	assert.Eq(t, []string{"--ref", "${ref}"}, r.Hooks[0].Args)
+}
@@ -0,0 +1,169 @@
+package httptpl
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/netutil/httpreq"
+	"github.com/gookit/goutil/strutil"
+)
+
+// BatchOptions for send multi templates
+type BatchOptions struct {
+	// Filter keywords for match templates, see Templates.Search
+	Filter string
+	// Parallel number for send requests. <= 1 will send in order,
+	// and the captured variables can be used by later templates.
+	Parallel int
+	// Timeout for each request, unit: ms
+	Timeout int
+	// StopOnFail stop send on a request error or check failed. only for send in order.
+	StopOnFail bool
+	// Header global headers for each request
+	Header map[string]string
+	// BeforeSend hook for each template
+	BeforeSend func(t *Template)
+}
+
+// BatchItem result of send a template
+type BatchItem struct {
+	Template *Template
+	Status   int
+	Cost     time.Duration
+	Err      error
+}
+
+// Passed check the item is passed
+func (bi *BatchItem) Passed() bool {
+	return bi.Err == nil && bi.Template.Passed()
+}
+
+// BatchResult of send multi templates
+type BatchResult struct {
+	Items []*BatchItem
+	Cost  time.Duration
+}
+
+// Failed number of items
+func (br *BatchResult) Failed() (n int) {
+	for _, item := range br.Items {
+		if !item.Passed() {
+			n++
+		}
+	}
+	return
+}
+
+// String summary table of the batch result
+func (br *BatchResult) String() string {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "#\tNAME\tMETHOD\tSTATUS\tCOST\tRESULT")
+
+	for i, item := range br.Items {
+		t := item.Template
+		status, result := "-", "ok"
+		if item.Status > 0 {
+			status = strconv.Itoa(item.Status)
+		}
+
+		if item.Err != nil {
+			result = "error: " + strutil.Truncate(item.Err.Error(), 80, "...")
+		} else if t.Checked != nil && !t.Checked.Passed() {
+			result = fmt.Sprintf("check failed: %d", t.Checked.Failed())
+		}
+
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, t.Name, t.Method, status, item.Cost.Round(time.Millisecond), result)
+	}
+	_ = tw.Flush()
+
+	failed := br.Failed()
+	sb.WriteString(fmt.Sprintf("\nTotal: %d, passed: %d, failed: %d, cost: %s\n", len(br.Items), len(br.Items)-failed, failed, br.Cost.Round(time.Millisecond)))
+	return sb.String()
+}
+
+// Send templates in batch. will send all templates on opt.Filter is empty.
+func (ts *Templates) Send(vars maputil.Data, opt *BatchOptions) *BatchResult {
+	if opt == nil {
+		opt = &BatchOptions{}
+	}
+
+	list := ts.List()
+	if opt.Filter != "" {
+		list = ts.Search(opt.Filter, 0)
+		// keep the order by index
+		sortByIndex(list)
+	}
+
+	start := time.Now()
+	br := &BatchResult{Items: make([]*BatchItem, len(list))}
+
+	if opt.Parallel > 1 {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, opt.Parallel)
+		for i, t := range list {
+			wg.Add(1)
+			sem <- struct{}{}
+
+			go func(i int, t *Template) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				br.Items[i] = sendItem(t, vars, opt)
+			}(i, t)
+		}
+		wg.Wait()
+	} else {
+		// copy vars, will add captured variables to it.
+		seqVars := make(maputil.Data, len(vars))
+		seqVars.Load(vars)
+
+		for i, t := range list {
+			item := sendItem(t, seqVars, opt)
+			br.Items[i] = item
+			seqVars.LoadSMap(t.Captured)
+
+			if opt.StopOnFail && !item.Passed() {
+				br.Items = br.Items[:i+1]
+				break
+			}
+		}
+	}
+
+	br.Cost = time.Since(start)
+	return br
+}
+
+func sendItem(t *Template, vars maputil.Data, opt *BatchOptions) *BatchItem {
+	t.SetTimeout(opt.Timeout)
+	if opt.BeforeSend != nil {
+		opt.BeforeSend(t)
+	}
+
+	start := time.Now()
+	item := &BatchItem{Template: t}
+	item.Err = t.Send(vars, opt.Header, httpreq.NewOpt())
+	item.Cost = time.Since(start)
+
+	if t.Resp != nil {
+		item.Status = t.Resp.StatusCode
+		// read and close the body
+		if err := t.Resp.ReadBody(); err != nil && item.Err == nil {
+			item.Err = err
+		}
+	}
+	return item
+}
+
+func sortByIndex(list []*Template) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Index < list[j].Index
+	})
+}
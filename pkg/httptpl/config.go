@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
 	"github.com/gookit/goutil/maputil"
 	"github.com/gookit/goutil/strutil"
 	"github.com/inhere/kite-go/pkg/util/bizutil"
@@ -141,6 +142,17 @@ func (d *DomainConfig) LoadConfig() error {
 			return err
 		}
 		d.Envs.Merge(se)
+
+		// auto load the private env file for ide http-client
+		if filepath.Base(d.EnvFile) == HcEnvFile {
+			privFile := filepath.Join(filepath.Dir(d.EnvFile), HcPrivateEnvFile)
+			if fsutil.IsFile(privFile) {
+				if se, err = LoadEnvsByFile(privFile); err != nil {
+					return err
+				}
+				d.Envs.Merge(se)
+			}
+		}
 	}
 
 	return nil
@@ -191,6 +203,36 @@ func (d *DomainConfig) Lookup(group, name string) (*Template, error) {
 	return ts.Lookup(name)
 }
 
+// Find template by name, request path or keywords on not found by name.
+//
+// Usage:
+//
+//	d.Find("", "create-user")
+//	d.Find("", "/api/users") // find by request path
+//	d.Find("jenkins.http", "build job") // find by keywords
+func (d *DomainConfig) Find(group, name string) (*Template, error) {
+	t, err := d.Lookup(group, name)
+	if err == nil {
+		return t, nil
+	}
+
+	ts, ok := d.Templates(group)
+	if !ok {
+		return nil, err
+	}
+
+	if strings.HasPrefix(name, "/") || strings.Contains(name, "://") {
+		t = ts.GetByPath(name)
+	} else {
+		t = ts.FindOne(name)
+	}
+
+	if t == nil {
+		return nil, err
+	}
+	return t, nil
+}
+
 // Templates get by name.
 //
 // Usage:
@@ -215,10 +257,20 @@ func (d *DomainConfig) Templates(group string) (*Templates, bool) {
 		// try load from hc-file
 		ts.typ = TypeHttpClient
 		fName := strutil.OrCond(strings.HasSuffix(group, HcFileExt), group, group+HcFileExt)
-		err := ts.FromHCFile(d.TplDir + "/" + fName)
-		if err != nil {
+		hcFile := fName
+		if !filepath.IsAbs(hcFile) && !fsutil.IsFile(hcFile) {
+			hcFile = d.TplDir + "/" + fName
+		}
+
+		if err := ts.FromHCFile(hcFile); err != nil {
 			return nil, false
 		}
+
+		// load env files in the hc-file dir
+		if envs, err := LoadHCEnvs(filepath.Dir(hcFile)); err == nil {
+			d.Envs.Merge(envs)
+		}
+		d.tsMap[group] = ts
 	}
 
 	return ts, true
@@ -231,10 +283,10 @@ func (d *DomainConfig) DefaultTemplates() *Templates {
 
 // BuildVars by env name and file
 func (d *DomainConfig) BuildVars(envName, envFile string) (maputil.Data, error) {
-	var vs maputil.Data
+	vs := make(maputil.Data)
 	// append global vars
 	if len(d.Vars) > 0 {
-		vs = d.Vars
+		vs.Load(d.Vars)
 	}
 
 	// load env vars
@@ -0,0 +1,206 @@
+package httptpl
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func TestTemplate_export(t *testing.T) {
+	ts := NewTemplates("api.http")
+	assert.NoErr(t, ts.FromHCString(`
+### create user
+# create a new user
+# @assert status == 201
+# @capture userId = $.data.id
+POST {{host}}/api/users?from=cli
+Content-Type: application/json
+Authorization: Bearer {{token}}
+
+{"name": "{{name}}", "desc": "it's me"}
+`))
+
+	vars := maputil.Data{"host": "http://localhost:8080", "name": "inhere"}
+	tpl := ts.Get("create user")
+
+	// curl, missing var is kept
+	cmd, err := tpl.ToCurl(vars, map[string]string{"X-Req-From": "kite"})
+	assert.NoErr(t, err)
+	assert.StrContains(t, cmd, `curl -X POST 'http://localhost:8080/api/users?from=cli'`)
+	assert.StrContains(t, cmd, `-H 'Authorization: Bearer {{token}}'`)
+	assert.StrContains(t, cmd, `-H 'X-Req-From: kite'`)
+	assert.StrContains(t, cmd, `--data-raw '{"name": "inhere", "desc": "it'\''s me"}'`)
+
+	// import from the exported curl
+	ct, err := ParseCurl(cmd)
+	assert.NoErr(t, err)
+	assert.Eq(t, "POST", ct.Method)
+	assert.Eq(t, "post-api-users", ct.Name)
+	assert.Eq(t, "http://localhost:8080/api/users?from=cli", ct.URL)
+	assert.Eq(t, `{"name": "inhere", "desc": "it's me"}`, ct.Body)
+	assert.Eq(t, "Bearer {{token}}", ct.Header["Authorization"])
+
+	// .http file
+	s, err := tpl.ToHCString(vars, nil)
+	assert.NoErr(t, err)
+	hts := NewTemplates("export.http")
+	assert.NoErr(t, hts.FromHCString(s))
+	ht := hts.Get("create user")
+	assert.NotNil(t, ht)
+	assert.Eq(t, "create a new user", ht.Desc)
+	assert.Eq(t, "http://localhost:8080/api/users?from=cli", ht.URL)
+	// the asserts and captures are exported
+	assert.StrContains(t, s, "# @assert status == 201\n# @capture userId = $.data.id\n")
+	assert.Eq(t, map[string]any{"status == 201": "status == 201"}, ht.Expect.Asserts)
+	assert.Eq(t, map[string]string{"userId": "$.data.id"}, ht.Captures)
+
+	// postman
+	pc, err := ExportPostman("demo", ts.List(), vars, nil)
+	assert.NoErr(t, err)
+	bs, err := json.Marshal(pc)
+	assert.NoErr(t, err)
+
+	tpls, err := ParsePostman(bs)
+	assert.NoErr(t, err)
+	assert.Len(t, tpls, 1)
+	assert.Eq(t, "create user", tpls[0].Name)
+	assert.Eq(t, "POST", tpls[0].Method)
+	assert.Eq(t, "application/json", tpls[0].Header["Content-Type"])
+}
+
+func TestParseCurl(t *testing.T) {
+	tpl, err := ParseCurl(`curl -sL "https://example.com/api/search" -G \
+  --data-urlencode 'q=kite' -u admin:pass123 -m 1.5`)
+	assert.NoErr(t, err)
+	assert.Eq(t, "GET", tpl.Method)
+	assert.Eq(t, "https://example.com/api/search?q=kite", tpl.URL)
+	assert.Eq(t, "admin", tpl.BasicAuth.Username)
+	assert.Eq(t, 1500, tpl.Timeout)
+
+	tpl, err = ParseCurl(`curl --url https://example.com/login -F user=inhere -F avatar=@a.png`)
+	assert.NoErr(t, err)
+	assert.Eq(t, "POST", tpl.Method)
+	assert.Eq(t, map[string]any{"user": "inhere"}, tpl.Form)
+
+	_, err = ParseCurl(`wget https://example.com`)
+	assert.Err(t, err)
+}
+
+func TestParsePostman(t *testing.T) {
+	tpls, err := ParsePostman([]byte(`{
+  "info": {"name": "demo", "schema": "` + PostmanSchema + `"},
+  "item": [
+    {"name": "users", "item": [
+      {"name": "login", "request": {
+        "method": "POST",
+        "url": {"protocol": "https", "host": ["{{host}}"], "path": ["api", "login"]},
+        "body": {"mode": "urlencoded", "urlencoded": [{"key": "user", "value": "inhere"}, {"key": "debug", "value": "1", "disabled": true}]},
+        "auth": {"type": "basic", "basic": [{"key": "username", "value": "admin"}, {"key": "password", "value": "{{pwd}}"}]}
+      }}
+    ]},
+    {"name": "home", "request": "https://{{host}}/", "description": {"content": "home page"}}
+  ]
+}`))
+	assert.NoErr(t, err)
+	assert.Len(t, tpls, 2)
+	assert.Eq(t, "users.login", tpls[0].Name)
+	assert.Eq(t, "https://{{host}}/api/login", tpls[0].URL)
+	assert.Eq(t, map[string]any{"user": "inhere"}, tpls[0].Form)
+	assert.Eq(t, "{{pwd}}", tpls[0].BasicAuth.Password)
+	assert.Eq(t, "GET", tpls[1].Method)
+	assert.Eq(t, "home page", tpls[1].Desc)
+}
+
+func TestParseOpenAPI_SaveTemplates(t *testing.T) {
+	tpls, err := ParseOpenAPI([]byte(`
+openapi: 3.0.1
+servers:
+  - url: https://{env}.example.com/v1
+paths:
+  /users/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: integer}}
+    get:
+      operationId: getUser
+      summary: get user by id
+      parameters:
+        - {name: fields, in: query, schema: {type: string, default: all}}
+        - {name: debug, in: query, schema: {type: boolean}}
+      responses:
+        200:
+          description: ok
+  /users:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/User'}
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        name: {type: string, example: inhere}
+        age: {type: integer}
+        tags: {type: array, items: {type: string}}
+`), "")
+	assert.NoErr(t, err)
+	assert.Len(t, tpls, 2)
+
+	post := tpls[0]
+	assert.Eq(t, "post-users", post.Name)
+	assert.Eq(t, "https://{{env}}.example.com/v1/users", post.URL)
+	assert.Eq(t, map[string]any{"name": "inhere", "age": 0, "tags": []any{""}}, post.JSON)
+
+	get := tpls[1]
+	assert.Eq(t, "getUser", get.Name)
+	assert.Eq(t, "get user by id", get.Desc)
+	assert.Eq(t, "https://{{env}}.example.com/v1/users/{{id}}", get.URL)
+	assert.Eq(t, map[string]any{"fields": "all"}, get.Query)
+
+	// save to domain template dir
+	dc := NewDomainConfig("demo", "")
+	dc.PathResolver = func(path string) string { return path }
+	dc.TplDir = t.TempDir()
+	assert.NoErr(t, dc.Init())
+
+	files, err := dc.SaveTemplates(tpls, false)
+	assert.NoErr(t, err)
+	assert.Eq(t, []string{filepath.Join(dc.TplDir, "post-users.json"), filepath.Join(dc.TplDir, "getUser.json")}, files)
+
+	files, err = dc.SaveTemplates(tpls, false)
+	assert.NoErr(t, err)
+	assert.Empty(t, files)
+
+	saved, err := dc.Lookup("", "getUser")
+	assert.NoErr(t, err)
+	assert.Eq(t, get.URL, saved.URL)
+	assert.Eq(t, "all", saved.Query["fields"])
+
+	// duplicate names
+	_, err = dc.SaveTemplates([]*Template{{Name: "get user"}, {Name: "get-user"}}, true)
+	assert.ErrSubMsg(t, err, `duplicate template name "get-user"`)
+}
+
+func TestParseOpenAPI_contentType(t *testing.T) {
+	tpls, err := ParseOpenAPI([]byte(`
+openapi: 3.0.1
+paths:
+  /users:
+    post:
+      requestBody:
+        content:
+          application/vnd.api+json:
+            example: {from: vnd}
+          application/json:
+            example: {from: json}
+          text/plain:
+            example: hi
+`), "https://example.com")
+	assert.NoErr(t, err)
+	assert.Len(t, tpls, 1)
+	assert.Eq(t, map[string]any{"from": "json"}, tpls[0].JSON)
+}
@@ -0,0 +1,295 @@
+package httptpl
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/netutil/httpctype"
+	"github.com/gookit/goutil/netutil/httpreq"
+	"github.com/gookit/goutil/strutil"
+	"github.com/inhere/kite-go/pkg/util/bizutil"
+)
+
+// RenderedRequest request data of a template, the variables has been rendered.
+//
+// TIP: missing variables will be kept as is. eg: {{token}}
+type RenderedRequest struct {
+	Name string
+	Desc string
+
+	Method string
+	// URL full URL with query
+	URL    string
+	Header map[string]string
+	// Body contents
+	Body string
+	// BodyFile path, only has value on the body is read from a file.
+	BodyFile string
+
+	// Asserts expressions for check response, from Template.Expect
+	Asserts []string
+	// Captures response values as variables, from Template.Captures
+	Captures map[string]string
+}
+
+// HeaderKeys sorted header names
+func (rr *RenderedRequest) HeaderKeys() []string {
+	keys := make([]string, 0, len(rr.Header))
+	for key := range rr.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// HeaderValue get header value by name, ignore case.
+func (rr *RenderedRequest) HeaderValue(name string) (string, bool) {
+	for key, val := range rr.Header {
+		if strings.EqualFold(key, name) {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// set header on not exists
+func (rr *RenderedRequest) setDefaultHeader(name, val string) {
+	if _, ok := rr.HeaderValue(name); !ok {
+		rr.Header[name] = val
+	}
+}
+
+// Render the template request data with variables and headers.
+// unlike the BuildRequest, it will not return error on missing variables.
+func (t *Template) Render(vars maputil.Data, hs map[string]string) (*RenderedRequest, error) {
+	rplMu.Lock()
+	defer rplMu.Unlock()
+
+	rr := &RenderedRequest{
+		Name:   t.Name,
+		Desc:   t.Desc,
+		Method: strings.ToUpper(strutil.OrElse(t.Method, "GET")),
+		URL:    rpl.Replace(t.URL, vars),
+		Header: make(map[string]string, len(t.Header)+len(hs)),
+		Captures: t.Captures,
+	}
+	if t.Expect != nil {
+		rr.Asserts = t.Expect.AssertExprs()
+	}
+
+	if len(t.Query) > 0 {
+		q := make(url.Values, len(t.Query))
+		for k, v := range t.Query {
+			q.Set(k, rpl.Replace(strutil.SafeString(v), vars))
+		}
+		rr.URL += strutil.OrCond(strings.ContainsRune(rr.URL, '?'), "&", "?") + q.Encode()
+	}
+
+	for _, mp := range []map[string]string{t.Header, hs} {
+		for name, val := range mp {
+			rr.Header[name] = rpl.Replace(val, vars)
+		}
+	}
+
+	if t.BasicAuth != nil && t.BasicAuth.IsValid() {
+		value := rpl.Replace(t.BasicAuth.String(), vars)
+		rr.Header["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	if httpreq.IsNoBodyMethod(rr.Method) {
+		return rr, nil
+	}
+
+	switch {
+	case t.JSON != nil:
+		bs, err := json.MarshalIndent(t.JSON, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		rr.Body = rpl.Replace(string(bs), vars)
+		rr.setDefaultHeader(httpctype.Key, httpctype.JSON)
+	case len(t.Form) > 0:
+		rr.Body = applyVarsForUV(httpreq.ToQueryValues(t.Form), vars).Encode()
+		rr.setDefaultHeader(httpctype.Key, httpctype.Form)
+	case t.BodyFile != "":
+		bs, err := os.ReadFile(t.BodyFile)
+		if err != nil {
+			return nil, err
+		}
+		rr.BodyFile = t.BodyFile
+		rr.Body = rpl.Replace(string(bs), vars)
+	case t.Body != nil:
+		switch typVal := t.Body.(type) {
+		case string:
+			rr.Body = rpl.Replace(typVal, vars)
+		case []byte:
+			rr.Body = rpl.Replace(string(typVal), vars)
+		default: // encode by content type
+			cType, _ := rr.HeaderValue(httpctype.Key)
+			if httpctype.ToKind(cType, "") == httpctype.KindForm {
+				rr.Body = applyVarsForUV(httpreq.ToQueryValues(typVal), vars).Encode()
+				break
+			}
+
+			bs, err := json.MarshalIndent(typVal, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			rr.Body = rpl.Replace(string(bs), vars)
+			rr.setDefaultHeader(httpctype.Key, httpctype.JSON)
+		}
+	}
+	return rr, nil
+}
+
+// ToCurl render the template and convert to a curl command line.
+//
+// Output like:
+//
+//	curl -X POST 'https://example.com/api/users' \
+//	  -H 'Content-Type: application/json' \
+//	  --data-raw '{"name": "inhere"}'
+func (t *Template) ToCurl(vars maputil.Data, hs map[string]string) (string, error) {
+	rr, err := t.Render(vars, hs)
+	if err != nil {
+		return "", err
+	}
+	return rr.ToCurl(), nil
+}
+
+// ToCurl convert to a curl command line
+func (rr *RenderedRequest) ToCurl() string {
+	var sb strings.Builder
+	sb.WriteString("curl")
+	if rr.Method != "GET" || rr.Body != "" {
+		sb.WriteString(" -X " + rr.Method)
+	}
+	sb.WriteString(" " + bizutil.ShellQuote(rr.URL))
+
+	for _, name := range rr.HeaderKeys() {
+		sb.WriteString(" \\\n  -H " + bizutil.ShellQuote(name+": "+rr.Header[name]))
+	}
+
+	if rr.Body != "" {
+		sb.WriteString(" \\\n  --data-raw " + bizutil.ShellQuote(rr.Body))
+	}
+	return sb.String()
+}
+
+// ToHCString render the template and convert to ide http-client file request part.
+func (t *Template) ToHCString(vars maputil.Data, hs map[string]string) (string, error) {
+	rr, err := t.Render(vars, hs)
+	if err != nil {
+		return "", err
+	}
+	return rr.ToHCString(), nil
+}
+
+// ToHCString convert to ide http-client file request part. see parseHCString for the format.
+func (rr *RenderedRequest) ToHCString() string {
+	var sb strings.Builder
+	sb.WriteString("###")
+	if rr.Name != "" && !strings.HasPrefix(rr.Name, "#") {
+		sb.WriteString(" " + rr.Name)
+	}
+	sb.WriteByte('\n')
+
+	if rr.Desc != "" {
+		for _, line := range strings.Split(rr.Desc, "\n") {
+			sb.WriteString("# " + line + "\n")
+		}
+	}
+
+	for _, expr := range rr.Asserts {
+		sb.WriteString("# @assert " + expr + "\n")
+	}
+	names := make([]string, 0, len(rr.Captures))
+	for name := range rr.Captures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sb.WriteString("# @capture " + name + " = " + rr.Captures[name] + "\n")
+	}
+
+	sb.WriteString(rr.Method + " " + rr.URL + "\n")
+	for _, name := range rr.HeaderKeys() {
+		sb.WriteString(name + ": " + rr.Header[name] + "\n")
+	}
+
+	if rr.BodyFile != "" {
+		sb.WriteString("\n< " + rr.BodyFile + "\n")
+	} else if rr.Body != "" {
+		sb.WriteString("\n" + strings.TrimRight(rr.Body, "\n") + "\n")
+	}
+	return sb.String()
+}
+
+// ExportHCString render templates and export as ide http-client file contents.
+func ExportHCString(tpls []*Template, vars maputil.Data, hs map[string]string) (string, error) {
+	parts := make([]string, 0, len(tpls))
+	for _, t := range tpls {
+		s, err := t.ToHCString(vars, hs)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// definition template file data, will ignore empty fields.
+type defTemplate struct {
+	Version   string                 `json:"version,omitempty"`
+	Kind      string                 `json:"kind,omitempty"`
+	Name      string                 `json:"name,omitempty"`
+	Desc      string                 `json:"desc,omitempty"`
+	URL       string                 `json:"url"`
+	Method    string                 `json:"method,omitempty"`
+	Query     map[string]any         `json:"query,omitempty"`
+	Header    map[string]string      `json:"header,omitempty"`
+	BasicAuth *httpreq.BasicAuthConf `json:"basic_auth,omitempty"`
+	Timeout   int                    `json:"timeout,omitempty"`
+	Body      any                    `json:"body,omitempty"`
+	JSON      any                    `json:"json,omitempty"`
+	Form      map[string]any         `json:"form,omitempty"`
+	BodyFile  string                 `json:"body_file,omitempty"`
+	Expect    *Response              `json:"expect,omitempty"`
+	Captures  map[string]string      `json:"captures,omitempty"`
+}
+
+// ToDefJSON convert to definition template file contents. variables will be kept.
+func (t *Template) ToDefJSON() ([]byte, error) {
+	dt := &defTemplate{
+		Version:   t.Version,
+		Kind:      t.Kind,
+		Name:      t.Name,
+		Desc:      t.Desc,
+		URL:       t.URL,
+		Method:    t.Method,
+		Query:     t.Query,
+		Header:    t.Header,
+		BasicAuth: t.BasicAuth,
+		Timeout:   t.Timeout,
+		Body:      t.Body,
+		JSON:      t.JSON,
+		Form:      t.Form,
+		BodyFile:  t.BodyFile,
+		Expect:    t.Expect,
+		Captures:  t.Captures,
+	}
+	if dt.Expect != nil && dt.Expect.IsEmpty() {
+		dt.Expect = nil
+	}
+
+	// the body maybe is bytes. eg: from hc-file
+	if bs, ok := dt.Body.([]byte); ok {
+		dt.Body = string(bs)
+	}
+	return json.MarshalIndent(dt, "", "  ")
+}
@@ -1,8 +1,273 @@
 package httptpl
 
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+)
+
 // HcFileExt name
 const HcFileExt = ".http"
 const RequestSplit = "\n###"
 const StartPrefix = "###"
 
 const BodySplit = "\n\n"
+
+// env files for the ide http-client, private env will override the public env.
+const (
+	HcEnvFile        = "http-client.env.json"
+	HcPrivateEnvFile = "http-client.private.env.json"
+)
+
+// LoadHCEnvs load http-client.env.json and http-client.private.env.json from the dir
+func LoadHCEnvs(dir string) (EnvsMap, error) {
+	envs := make(EnvsMap)
+	for _, name := range []string{HcEnvFile, HcPrivateEnvFile} {
+		envFile := filepath.Join(dir, name)
+		if !fsutil.IsFile(envFile) {
+			continue
+		}
+
+		em, err := LoadEnvsByFile(envFile)
+		if err != nil {
+			return nil, err
+		}
+		envs.Merge(em)
+	}
+	return envs, nil
+}
+
+// SplitHCRequests split hc-file contents to request parts. each part starts with "###" line, except the first.
+func SplitHCRequests(s string) []string {
+	var parts []string
+	var sb strings.Builder
+
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, StartPrefix) && sb.Len() > 0 {
+			parts = append(parts, sb.String())
+			sb.Reset()
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+
+	if sb.Len() > 0 {
+		parts = append(parts, sb.String())
+	}
+	return parts
+}
+
+// IsEmptyHCPart check the hc-file part has no request line, only contains comments or blank lines.
+func IsEmptyHCPart(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !isHCComment(line) && !strings.HasPrefix(line, StartPrefix) && !isHCVarLine(line) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHCComment(line string) bool {
+	return line[0] == '#' || strings.HasPrefix(line, "//")
+}
+
+// in-file variable line. eg: @host = localhost:8080
+func isHCVarLine(line string) bool {
+	return line[0] == '@' && strings.ContainsRune(line, '=')
+}
+
+// parse states of the hc-file request part
+const (
+	hcStateStart = iota
+	hcStateHeader
+	hcStateBody
+)
+
+// parse hc-file request part contents. format:
+//
+//	@host = localhost:8080
+//
+//	### request name
+//	# @name request-name
+//	POST https://{{host}}/api/users?page=1
+//	    &size=10
+//	Content-Type: application/json
+//	Authorization: Bearer {{token}}
+//
+//	{"name": "{{name}}"}
+//
+//	> {% client.global.set("id", response.body.id); %}
+//
+// Body can be a file include line: < ./body.json
+//
+// Check response and capture variables by comment tags, the JS response handler is not supported:
+//
+//	# @assert status == 201
+//	# @assert $.data.name == inhere
+//	# @capture userId = $.data.id
+//
+// In-file variables will be replaced on parsed, other {{var}} will be replaced on send.
+func (t *Template) parseHCString(s string) error {
+	state := hcStateStart
+	var bodyLines []string
+	var inHandler bool
+
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch state {
+		case hcStateStart:
+			if trimmed == "" {
+				continue
+			}
+
+			if strings.HasPrefix(trimmed, StartPrefix) {
+				if name := strings.TrimSpace(trimmed[3:]); name != "" && t.Name == "" {
+					t.Name = name
+				}
+				continue
+			}
+
+			if isHCComment(trimmed) {
+				comment := strings.TrimSpace(strings.TrimLeft(trimmed, "#/"))
+				if tag, ok := strings.CutPrefix(comment, "@name"); ok {
+					if t.Desc == "" {
+						t.Desc = t.Name
+					}
+					t.Name = strings.TrimSpace(strings.TrimLeft(tag, " ="))
+				} else if expr, ok := strings.CutPrefix(comment, "@assert "); ok {
+					t.addAssert(strings.TrimSpace(expr))
+				} else if capture, ok := strings.CutPrefix(comment, "@capture "); ok {
+					name, src, _ := strings.Cut(capture, "=")
+					if t.Captures == nil {
+						t.Captures = make(map[string]string)
+					}
+					t.Captures[strings.TrimSpace(name)] = strings.TrimSpace(src)
+				} else if comment != "" && comment[0] != '@' && t.Desc == "" {
+					t.Desc = comment
+				}
+				continue
+			}
+
+			if isHCVarLine(trimmed) {
+				name, val, _ := strings.Cut(trimmed[1:], "=")
+				t.fileVars[strings.TrimSpace(name)] = strings.TrimSpace(val)
+				continue
+			}
+
+			t.parseRequestLine(trimmed)
+			state = hcStateHeader
+		case hcStateHeader:
+			if trimmed == "" {
+				state = hcStateBody
+				continue
+			}
+
+			// continuation lines of the URL query. eg: "    &size=10"
+			if line[0] == ' ' || line[0] == '\t' {
+				if trimmed[0] == '?' || trimmed[0] == '&' {
+					t.URL += trimmed
+					continue
+				}
+			}
+			if isHCComment(trimmed) {
+				continue
+			}
+
+			name, val, ok := strings.Cut(trimmed, ":")
+			if !ok {
+				return errorx.Rawf("invalid header line %q in request %q", trimmed, t.Name)
+			}
+			t.Header[strings.TrimSpace(name)] = strings.TrimSpace(val)
+		case hcStateBody:
+			// response handler script or reference, ignore them.
+			if inHandler {
+				inHandler = !strings.Contains(trimmed, "%}")
+				continue
+			}
+			if strings.HasPrefix(trimmed, "> ") || strings.HasPrefix(trimmed, "<> ") {
+				inHandler = strings.HasPrefix(trimmed, "> {%") && !strings.Contains(trimmed, "%}")
+				continue
+			}
+			bodyLines = append(bodyLines, line)
+		}
+	}
+
+	if state == hcStateStart {
+		return errorx.Rawf("not found request line in the http-client request %q", t.Name)
+	}
+
+	t.applyFileVars()
+	body := t.replaceFileVars(strings.TrimSpace(strings.Join(bodyLines, "\n")))
+	if body == "" {
+		return nil
+	}
+
+	// body from file. eg: < ./body.json
+	if fPath, ok := strings.CutPrefix(body, "< "); ok && !strings.Contains(fPath, "\n") {
+		t.BodyFile = t.resolvePath(strings.TrimSpace(fPath))
+	} else {
+		t.Body = body
+	}
+	return nil
+}
+
+// parse request line. format: [METHOD] URL [HTTP/VERSION]
+func (t *Template) parseRequestLine(line string) {
+	nodes := strings.Fields(line)
+	if len(nodes) > 1 && strings.HasPrefix(nodes[len(nodes)-1], "HTTP/") {
+		nodes = nodes[:len(nodes)-1]
+	}
+
+	if len(nodes) > 1 {
+		t.Method = strings.ToUpper(nodes[0])
+		t.URL = strings.Join(nodes[1:], " ")
+	} else {
+		t.URL = nodes[0]
+	}
+}
+
+func (t *Template) addAssert(expr string) {
+	if t.Expect == nil {
+		t.Expect = &Response{}
+	}
+	if t.Expect.Asserts == nil {
+		t.Expect.Asserts = make(map[string]any)
+	}
+	t.Expect.Asserts[expr] = expr
+}
+
+func (t *Template) applyFileVars() {
+	t.URL = t.replaceFileVars(t.URL)
+	for name, val := range t.Header {
+		t.Header[name] = t.replaceFileVars(val)
+	}
+}
+
+func (t *Template) replaceFileVars(s string) string {
+	if len(t.fileVars) == 0 || !strings.Contains(s, "{{") {
+		return s
+	}
+
+	for name, val := range t.fileVars {
+		s = strings.ReplaceAll(s, "{{"+name+"}}", val)
+	}
+	return s
+}
+
+// resolve relative path by the template file dir
+func (t *Template) resolvePath(fPath string) string {
+	if t.path == "" || filepath.IsAbs(fPath) {
+		return fPath
+	}
+	return filepath.Join(filepath.Dir(t.path), fPath)
+}
+
+// default name for the unnamed request in hc-file. format: #INDEX, INDEX start from 1.
+func hcDefaultName(index int) string {
+	return "#" + strconv.Itoa(index+1)
+}
@@ -0,0 +1,94 @@
+package httptpl
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+const testHCFile = `# comments before first request
+@api = api/v1
+
+### get item
+GET http://{{host}}/{{api}}/item?id=99
+    &type=book
+Accept: application/json
+
+### create user
+# @name create-user
+POST https://{{host}}/api/users HTTP/1.1
+Content-Type: application/json
+// comment in headers
+
+{
+  "name": "{{name}}"
+}
+
+> {%
+    client.global.set("id", response.body.id);
+%}
+
+###
+PUT http://{{host}}/api/users/1
+Content-Type: application/json
+
+< ./body.json
+`
+
+func TestTemplates_FromHCFile(t *testing.T) {
+	dir := t.TempDir()
+	hcFile := filepath.Join(dir, "api.http")
+	assert.NoErr(t, os.WriteFile(hcFile, []byte(testHCFile), 0644))
+	assert.NoErr(t, os.WriteFile(filepath.Join(dir, "body.json"), []byte(`{"age": {{age}}}`), 0644))
+	assert.NoErr(t, os.WriteFile(filepath.Join(dir, HcEnvFile), []byte(`{"dev": {"host": "localhost", "name": "tom"}}`), 0644))
+	assert.NoErr(t, os.WriteFile(filepath.Join(dir, HcPrivateEnvFile), []byte(`{"dev": {"name": "inhere"}}`), 0644))
+
+	ts := NewTemplates("api.http")
+	assert.NoErr(t, ts.FromHCFile(hcFile))
+	assert.Len(t, ts.All(), 3)
+
+	// request line, query continuation and headers
+	tpl, err := ts.Lookup("get item")
+	assert.NoErr(t, err)
+	assert.Eq(t, "GET", tpl.Method)
+	assert.Eq(t, "http://{{host}}/api/v1/item?id=99&type=book", tpl.URL)
+	assert.Eq(t, "application/json", tpl.Header["Accept"])
+	assert.Nil(t, tpl.Body)
+
+	// @name, body and response handler
+	tpl, err = ts.Lookup("create-user")
+	assert.NoErr(t, err)
+	assert.Eq(t, "POST", tpl.Method)
+	assert.Eq(t, "create user", tpl.Desc)
+	assert.Len(t, tpl.Header, 1)
+	assert.Eq(t, "{\n  \"name\": \"{{name}}\"\n}", tpl.Body)
+
+	// unnamed and body file
+	tpl, err = ts.Lookup("3")
+	assert.NoErr(t, err)
+	assert.Eq(t, "#3", tpl.Name)
+	assert.Eq(t, filepath.Join(dir, "body.json"), tpl.BodyFile)
+
+	// envs and build request
+	envs, err := LoadHCEnvs(dir)
+	assert.NoErr(t, err)
+	assert.Eq(t, "inhere", envs["dev"].Str("name"))
+
+	tpl = ts.Get("create-user")
+	req, err := tpl.BuildRequest(envs["dev"], nil)
+	assert.NoErr(t, err)
+	assert.Eq(t, "https://localhost/api/users", req.URL.String())
+	bs, err := io.ReadAll(req.Body)
+	assert.NoErr(t, err)
+	assert.StrContains(t, string(bs), `"name": "inhere"`)
+
+	req, err = ts.Get("#3").BuildRequest(maputil.Data{"host": "localhost", "age": 20}, nil)
+	assert.NoErr(t, err)
+	bs, err = io.ReadAll(req.Body)
+	assert.NoErr(t, err)
+	assert.Eq(t, `{"age": 20}`, string(bs))
+}
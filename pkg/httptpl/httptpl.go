@@ -2,6 +2,7 @@ package httptpl
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/gookit/goutil/errorx"
 	"github.com/gookit/goutil/fsutil"
@@ -76,3 +77,20 @@ func (m *Manager) Domain(name string) (*DomainConfig, error) {
 
 	return nil, errorx.Rawf("not found domain config of the %q", name)
 }
+
+// HcDomain create a domain config for the ide http-client file.
+// will load http-client env files from the hc-file dir.
+func (m *Manager) HcDomain(hcFile string) (*DomainConfig, error) {
+	hcFile = m.PathResolver(hcFile)
+	if !fsutil.IsFile(hcFile) {
+		return nil, errorx.Rawf("http-client file %q not exists", hcFile)
+	}
+
+	dc := NewDomainConfig(fsutil.NameNoExt(hcFile), "")
+	dc.TplDir = filepath.Dir(hcFile)
+	dc.PathResolver = m.PathResolver
+	if err := dc.Init(); err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
@@ -0,0 +1,528 @@
+package httptpl
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/netutil/httpctype"
+	"github.com/gookit/goutil/netutil/httpreq"
+	"github.com/gookit/goutil/strutil"
+)
+
+// curl options with value, but not used on convert.
+var curlValueOpts = map[string]bool{
+	"-o": true, "--output": true, "-x": true, "--proxy": true, "-w": true, "--write-out": true,
+	"--connect-timeout": true, "-c": true, "--cookie-jar": true, "-E": true, "--cert": true,
+	"--key": true, "--cacert": true, "-r": true, "--range": true, "--retry": true, "-T": true,
+	"--upload-file": true, "--resolve": true, "-K": true, "--config": true,
+}
+
+// ParseCurl parse a curl command line to template. eg:
+//
+//	curl -X POST 'https://example.com/api/users' -H 'Content-Type: application/json' -d '{"name": "inhere"}'
+//
+// Supported options:
+//
+//	-X, --request; -H, --header; -d, --data, --data-raw, --data-binary, --data-urlencode, --json;
+//	-F, --form; -u, --user; -A, --user-agent; -b, --cookie; -e, --referer; -G, --get; -m, --max-time; --url
+func ParseCurl(line string) (*Template, error) {
+	args := splitShellArgs(line)
+	if len(args) == 0 || filepath.Base(args[0]) != "curl" {
+		return nil, errorx.Raw("invalid curl command, must start with: curl")
+	}
+
+	t := NewTemplate()
+	t.Method = ""
+	t.Header = make(map[string]string)
+
+	var getMode, isJSON bool
+	var data []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		// get the option value
+		next := func() string {
+			if i+1 < len(args) {
+				i++
+				return args[i]
+			}
+			return ""
+		}
+
+		switch arg {
+		case "-X", "--request":
+			t.Method = strings.ToUpper(next())
+		case "-H", "--header":
+			name, val, _ := strings.Cut(next(), ":")
+			t.Header[strings.TrimSpace(name)] = strings.TrimSpace(val)
+		case "-d", "--data", "--data-raw", "--data-binary", "--data-ascii", "--data-urlencode":
+			data = append(data, next())
+		case "--json":
+			isJSON = true
+			data = append(data, next())
+		case "-F", "--form":
+			if t.Form == nil {
+				t.Form = make(map[string]any)
+			}
+			// TIP: upload file field is not supported. eg: -F 'file=@a.txt'
+			if name, val, ok := strings.Cut(next(), "="); ok && !strings.HasPrefix(val, "@") {
+				t.Form[name] = val
+			}
+		case "-u", "--user":
+			user, pwd, _ := strings.Cut(next(), ":")
+			t.BasicAuth = &httpreq.BasicAuthConf{Username: user, Password: pwd}
+		case "-A", "--user-agent":
+			t.Header["User-Agent"] = next()
+		case "-b", "--cookie":
+			t.Header["Cookie"] = next()
+		case "-e", "--referer":
+			t.Header["Referer"] = next()
+		case "-G", "--get":
+			getMode = true
+		case "-m", "--max-time":
+			if sec, err := strconv.ParseFloat(next(), 64); err == nil {
+				t.Timeout = int(sec * 1000)
+			}
+		case "--url":
+			t.URL = next()
+		default:
+			if strings.HasPrefix(arg, "-") {
+				if curlValueOpts[arg] {
+					i++
+				}
+				continue // ignore other options. eg: -s, -L, -k, --compressed
+			}
+			if t.URL == "" {
+				t.URL = arg
+			}
+		}
+	}
+
+	if t.URL == "" {
+		return nil, errorx.Raw("not found request URL in the curl command")
+	}
+
+	if len(data) > 0 {
+		body := strings.Join(data, "&")
+		if getMode {
+			t.URL += strutil.OrCond(strings.ContainsRune(t.URL, '?'), "&", "?") + body
+		} else if len(data) == 1 && strings.HasPrefix(body, "@") {
+			t.BodyFile = body[1:]
+		} else {
+			t.Body = body
+		}
+
+		if !getMode {
+			if isJSON {
+				setHeaderIfMissing(t.Header, httpctype.Key, httpctype.JSON)
+				setHeaderIfMissing(t.Header, "Accept", httpctype.MIMEJSON)
+			} else {
+				setHeaderIfMissing(t.Header, httpctype.Key, httpctype.Form)
+			}
+		}
+	}
+
+	if t.Method == "" {
+		t.Method = strutil.OrCond(!getMode && (len(data) > 0 || len(t.Form) > 0), "POST", "GET")
+	}
+	t.Name = nameFromRequest(t.Method, t.URL)
+	return t, nil
+}
+
+func setHeaderIfMissing(hs map[string]string, name, val string) {
+	for key := range hs {
+		if strings.EqualFold(key, name) {
+			return
+		}
+	}
+	hs[name] = val
+}
+
+// split command line to args like shell, support quotes, escape char and line continuation.
+func splitShellArgs(line string) []string {
+	var args []string
+	var sb strings.Builder
+	var quote byte
+	var inArg bool
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				sb.WriteByte(c)
+			}
+		case c == '\\' && i+1 < len(line):
+			i++
+			if line[i] == '\n' || line[i] == '\r' {
+				// line continuation
+				if line[i] == '\r' && i+1 < len(line) && line[i+1] == '\n' {
+					i++
+				}
+				continue
+			}
+			if quote == '"' && !strings.ContainsRune("\"\\$`", rune(line[i])) {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(line[i])
+			inArg = true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				sb.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, sb.String())
+				sb.Reset()
+				inArg = false
+			}
+		default:
+			sb.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, sb.String())
+	}
+	return args
+}
+
+var nameReg = regexp.MustCompile(`[^\w.-]+`)
+
+// build template name by request. eg: GET /api/users/{{id}} => get-api-users-id
+func nameFromRequest(method, rawURL string) string {
+	path := strings.Trim(urlPath(rawURL), "/")
+	name := strings.ToLower(method) + "-" + nameReg.ReplaceAllString(path, "-")
+	return strings.Trim(strings.ReplaceAll(name, "--", "-"), "-")
+}
+
+// OpenAPI 3 document, only contains the fields used by convert templates.
+type oapiDoc struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	// {path: {method: operation}}, path item also contains "parameters", "summary" ...
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas    map[string]*oapiSchema `json:"schemas"`
+		Parameters map[string]*oapiParam  `json:"parameters"`
+	} `json:"components"`
+}
+
+type oapiOperation struct {
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Description string       `json:"description"`
+	Parameters  []*oapiParam `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]*oapiMedia `json:"content"`
+	} `json:"requestBody"`
+}
+
+type oapiParam struct {
+	Ref      string      `json:"$ref"`
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Required bool        `json:"required"`
+	Example  any         `json:"example"`
+	Schema   *oapiSchema `json:"schema"`
+}
+
+type oapiMedia struct {
+	Example  any `json:"example"`
+	Examples map[string]struct {
+		Value any `json:"value"`
+	} `json:"examples"`
+	Schema *oapiSchema `json:"schema"`
+}
+
+type oapiSchema struct {
+	Ref        string                 `json:"$ref"`
+	Type       string                 `json:"type"`
+	Example    any                    `json:"example"`
+	Default    any                    `json:"default"`
+	Enum       []any                  `json:"enum"`
+	Properties map[string]*oapiSchema `json:"properties"`
+	Items      *oapiSchema            `json:"items"`
+}
+
+var oapiMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// ParseOpenAPI parse OpenAPI 3 document(JSON or YAML) operations to templates.
+//
+//   - baseURL is the URL prefix for templates. default use servers[0].url, fallback is {{host}}
+//   - path params will convert to variables. eg: /users/{id} => /users/{{id}}
+//   - request body will use the example, or build a sample by the schema.
+func ParseOpenAPI(bs []byte, baseURL string) ([]*Template, error) {
+	jsonBs, err := yaml.YAMLToJSON(bs)
+	if err != nil {
+		return nil, errorx.Rf("invalid OpenAPI document: %v", err)
+	}
+
+	doc := &oapiDoc{}
+	if err = json.Unmarshal(jsonBs, doc); err != nil {
+		return nil, errorx.Rf("invalid OpenAPI document: %v", err)
+	}
+
+	if baseURL == "" && len(doc.Servers) > 0 {
+		// server variables. eg: https://{env}.example.com
+		baseURL = oapiVarReg.ReplaceAllString(doc.Servers[0].URL, "{{$1}}")
+	}
+	baseURL = strings.TrimSuffix(strutil.OrElse(baseURL, "{{host}}"), "/")
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var tpls []*Template
+	for _, path := range paths {
+		item := doc.Paths[path]
+		var common []*oapiParam
+		if raw, ok := item["parameters"]; ok {
+			_ = json.Unmarshal(raw, &common)
+		}
+
+		for _, method := range oapiMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+
+			op := &oapiOperation{}
+			if err = json.Unmarshal(raw, op); err != nil {
+				return nil, errorx.Rf("invalid operation %s %s: %v", method, path, err)
+			}
+
+			t := doc.newTemplate(strings.ToUpper(method), baseURL, path, slices.Concat(common, op.Parameters), op)
+			t.Index = len(tpls)
+			tpls = append(tpls, t)
+		}
+	}
+	return tpls, nil
+}
+
+func (doc *oapiDoc) newTemplate(method, baseURL, path string, params []*oapiParam, op *oapiOperation) *Template {
+	t := NewTemplate()
+	t.Method = method
+	t.Desc = strutil.OrElse(op.Summary, op.Description)
+	t.Header = make(map[string]string)
+
+	for _, p := range params {
+		if p.Ref != "" {
+			if p = doc.Components.Parameters[refName(p.Ref)]; p == nil {
+				continue
+			}
+		}
+
+		// optional param is added only has example value
+		val := paramExample(p)
+		if val == "" && !p.Required {
+			continue
+		}
+		val = strutil.OrElse(val, "{{"+p.Name+"}}")
+
+		switch p.In {
+		case "query":
+			if t.Query == nil {
+				t.Query = make(map[string]any)
+			}
+			t.Query[p.Name] = val
+		case "header":
+			t.Header[p.Name] = val
+		}
+	}
+
+	path = oapiVarReg.ReplaceAllString(path, "{{$1}}")
+	t.URL = baseURL + path
+	t.Name = strutil.OrElse(op.OperationID, nameFromRequest(method, path))
+
+	if op.RequestBody == nil {
+		return t
+	}
+
+	// sort the content types for stable result, and "application/json" is first
+	cTypes := make([]string, 0, len(op.RequestBody.Content))
+	for cType := range op.RequestBody.Content {
+		cTypes = append(cTypes, cType)
+	}
+	sort.Slice(cTypes, func(i, j int) bool {
+		if cTypes[i] == httpctype.MIMEJSON || cTypes[j] == httpctype.MIMEJSON {
+			return cTypes[i] == httpctype.MIMEJSON
+		}
+		return cTypes[i] < cTypes[j]
+	})
+
+	// prefer the JSON body
+	for _, cType := range cTypes {
+		if httpctype.ToKind(cType, "") == httpctype.KindJSON {
+			t.JSON = doc.mediaExample(op.RequestBody.Content[cType])
+			t.Header[httpctype.Key] = httpctype.JSON
+			return t
+		}
+	}
+
+	for _, cType := range cTypes {
+		if httpctype.ToKind(cType, "") == httpctype.KindForm {
+			if mp, ok := doc.mediaExample(op.RequestBody.Content[cType]).(map[string]any); ok {
+				t.Form = mp
+				t.Header[httpctype.Key] = httpctype.Form
+			}
+			break
+		}
+	}
+	return t
+}
+
+// get example of the media, will build a sample by the schema on no example.
+func (doc *oapiDoc) mediaExample(media *oapiMedia) any {
+	if media.Example != nil {
+		return media.Example
+	}
+	for _, ex := range media.Examples {
+		return ex.Value
+	}
+
+	if media.Schema != nil {
+		return doc.sample(media.Schema, 0)
+	}
+	return nil
+}
+
+// path params and server variables. eg: /users/{id}
+var oapiVarReg = regexp.MustCompile(`{(\w+)}`)
+
+func paramExample(p *oapiParam) string {
+	if p.Example != nil {
+		return valueString(p.Example)
+	}
+
+	if s := p.Schema; s != nil {
+		if s.Example != nil {
+			return valueString(s.Example)
+		}
+		if s.Default != nil {
+			return valueString(s.Default)
+		}
+	}
+	return ""
+}
+
+// build sample value by schema. depth for avoid infinite loop on recursive ref.
+func (doc *oapiDoc) sample(s *oapiSchema, depth int) any {
+	if s.Ref != "" {
+		if depth > 5 {
+			return nil
+		}
+		ref, ok := doc.Components.Schemas[refName(s.Ref)]
+		if !ok {
+			return nil
+		}
+		return doc.sample(ref, depth+1)
+	}
+
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	}
+
+	switch s.Type {
+	case "object", "":
+		if len(s.Properties) == 0 {
+			return map[string]any{}
+		}
+		mp := make(map[string]any, len(s.Properties))
+		for name, prop := range s.Properties {
+			mp[name] = doc.sample(prop, depth+1)
+		}
+		return mp
+	case "array":
+		if s.Items == nil {
+			return []any{}
+		}
+		return []any{doc.sample(s.Items, depth+1)}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	}
+	return ""
+}
+
+// eg: #/components/schemas/User => User
+func refName(ref string) string {
+	return ref[strings.LastIndexByte(ref, '/')+1:]
+}
+
+// SaveTemplates save templates to the TplDir as JSON definition files.
+// the file name is the template name, will skip exists file on overwrite=false.
+// will return error on the tpls has duplicate names.
+//
+// Returns the saved file paths.
+func (d *DomainConfig) SaveTemplates(tpls []*Template, overwrite bool) ([]string, error) {
+	if d.TplDir == "" {
+		return nil, errorx.Rawf("the template dir is not set for domain %q", d.Name)
+	}
+
+	names := make(map[string]bool, len(tpls))
+	for _, t := range tpls {
+		name := TemplateFileName(t.Name)
+		if names[name] {
+			return nil, errorx.Rawf("duplicate template name %q, please rename it before save", name)
+		}
+		names[name] = true
+	}
+	if err := fsutil.MkdirQuick(d.TplDir); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, t := range tpls {
+		t.Name = TemplateFileName(t.Name)
+		tplFile := filepath.Join(d.TplDir, t.Name+".json")
+		if !overwrite && fsutil.IsFile(tplFile) {
+			continue
+		}
+
+		bs, err := t.ToDefJSON()
+		if err != nil {
+			return files, err
+		}
+		if err = fsutil.WriteFile(tplFile, bs, fsutil.DefaultFilePerm); err != nil {
+			return files, err
+		}
+		files = append(files, tplFile)
+	}
+
+	// reload the default templates on next use
+	delete(d.tsMap, d.Name)
+	return files, nil
+}
+
+// TemplateFileName safe file name by template name. eg: "users.create user" => "users.create-user"
+func TemplateFileName(name string) string {
+	name = strings.Trim(nameReg.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		return "request"
+	}
+	return name
+}
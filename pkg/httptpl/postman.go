@@ -0,0 +1,269 @@
+package httptpl
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/netutil/httpctype"
+	"github.com/gookit/goutil/netutil/httpreq"
+)
+
+// PostmanSchema of the collection v2.1
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// PostmanCollection of the Postman collection v2.1 format.
+//
+// TIP: only contains the fields used by convert templates.
+type PostmanCollection struct {
+	Info     PostmanInfo    `json:"info"`
+	Item     []*PostmanItem `json:"item"`
+	Variable []*PostmanKV   `json:"variable,omitempty"`
+}
+
+// PostmanInfo of the collection
+type PostmanInfo struct {
+	Name        string `json:"name"`
+	Description any    `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// PostmanItem a request or a folder(has sub items)
+type PostmanItem struct {
+	Name        string          `json:"name"`
+	Description any             `json:"description,omitempty"`
+	Item        []*PostmanItem  `json:"item,omitempty"`
+	Request     *PostmanRequest `json:"request,omitempty"`
+}
+
+// PostmanRequest of an item
+type PostmanRequest struct {
+	Method string       `json:"method"`
+	Header []*PostmanKV `json:"header"`
+	// URL string or object: {"raw": "...", "host": [...], "path": [...]}
+	URL         any          `json:"url"`
+	Body        *PostmanBody `json:"body,omitempty"`
+	Auth        *PostmanAuth `json:"auth,omitempty"`
+	Description any          `json:"description,omitempty"`
+}
+
+// UnmarshalJSON the request allow be a URL string.
+func (r *PostmanRequest) UnmarshalJSON(bs []byte) error {
+	var rawURL string
+	if err := json.Unmarshal(bs, &rawURL); err == nil {
+		r.Method, r.URL = "GET", rawURL
+		return nil
+	}
+
+	type request PostmanRequest
+	return json.Unmarshal(bs, (*request)(r))
+}
+
+// PostmanBody of the request. mode: raw, urlencoded, formdata
+type PostmanBody struct {
+	Mode       string       `json:"mode"`
+	Raw        string       `json:"raw,omitempty"`
+	URLEncoded []*PostmanKV `json:"urlencoded,omitempty"`
+	FormData   []*PostmanKV `json:"formdata,omitempty"`
+	Options    any          `json:"options,omitempty"`
+}
+
+// PostmanAuth of the request. type: basic, bearer, noauth ...
+type PostmanAuth struct {
+	Type   string       `json:"type"`
+	Basic  []*PostmanKV `json:"basic,omitempty"`
+	Bearer []*PostmanKV `json:"bearer,omitempty"`
+}
+
+// PostmanKV key-value item for header, form, variable and auth
+type PostmanKV struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Type     string `json:"type,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// ExportPostman render templates and export as a Postman v2.1 collection.
+func ExportPostman(name string, tpls []*Template, vars maputil.Data, hs map[string]string) (*PostmanCollection, error) {
+	pc := &PostmanCollection{
+		Info: PostmanInfo{Name: name, Schema: PostmanSchema},
+		Item: make([]*PostmanItem, 0, len(tpls)),
+	}
+
+	for _, t := range tpls {
+		rr, err := t.Render(vars, hs)
+		if err != nil {
+			return nil, err
+		}
+
+		req := &PostmanRequest{
+			Method: rr.Method,
+			Header: make([]*PostmanKV, 0, len(rr.Header)),
+			URL:    map[string]any{"raw": rr.URL},
+		}
+		for _, key := range rr.HeaderKeys() {
+			req.Header = append(req.Header, &PostmanKV{Key: key, Value: rr.Header[key]})
+		}
+
+		if rr.Body != "" {
+			req.Body = &PostmanBody{Mode: "raw", Raw: rr.Body}
+			if cType, _ := rr.HeaderValue(httpctype.Key); httpctype.ToKind(cType, "") == httpctype.KindJSON {
+				req.Body.Options = map[string]any{"raw": map[string]string{"language": "json"}}
+			}
+		}
+
+		item := &PostmanItem{Name: t.Name, Request: req}
+		if rr.Desc != "" {
+			item.Description = rr.Desc
+		}
+		pc.Item = append(pc.Item, item)
+	}
+	return pc, nil
+}
+
+// ParsePostman parse Postman v2.1 collection contents to templates.
+// the item name in folders will be prefixed by the folder names. eg: users.create
+func ParsePostman(bs []byte) ([]*Template, error) {
+	pc := &PostmanCollection{}
+	if err := json.Unmarshal(bs, pc); err != nil {
+		return nil, errorx.Rf("invalid postman collection: %v", err)
+	}
+
+	var tpls []*Template
+	var walk func(prefix string, items []*PostmanItem)
+	walk = func(prefix string, items []*PostmanItem) {
+		for _, item := range items {
+			name := prefix + item.Name
+			if item.Request == nil {
+				walk(name+".", item.Item)
+				continue
+			}
+
+			t := item.Request.toTemplate()
+			t.Name = name
+			t.Desc = postmanDesc(item.Description, item.Request.Description)
+			t.Index = len(tpls)
+			tpls = append(tpls, t)
+		}
+	}
+
+	walk("", pc.Item)
+	return tpls, nil
+}
+
+func (r *PostmanRequest) toTemplate() *Template {
+	t := NewTemplate()
+	t.Method = strings.ToUpper(r.Method)
+	t.URL = postmanURL(r.URL)
+	t.Header = make(map[string]string, len(r.Header))
+	for _, kv := range r.Header {
+		if !kv.Disabled {
+			t.Header[kv.Key] = kv.valueString()
+		}
+	}
+
+	if auth := r.Auth; auth != nil {
+		switch auth.Type {
+		case "basic":
+			t.BasicAuth = &httpreq.BasicAuthConf{
+				Username: postmanKVFind(auth.Basic, "username"),
+				Password: postmanKVFind(auth.Basic, "password"),
+			}
+		case "bearer":
+			t.Header["Authorization"] = "Bearer " + postmanKVFind(auth.Bearer, "token")
+		}
+	}
+
+	if body := r.Body; body != nil {
+		switch body.Mode {
+		case "raw":
+			t.Body = body.Raw
+			lang, _ := maputil.GetFromAny("raw.language", body.Options)
+			if lang == "json" {
+				if _, ok := t.Header[httpctype.Key]; !ok {
+					t.Header[httpctype.Key] = httpctype.JSON
+				}
+			}
+		case "urlencoded", "formdata":
+			// TIP: the file fields in formdata is not supported
+			t.Form = make(map[string]any)
+			for _, kv := range append(body.URLEncoded, body.FormData...) {
+				if !kv.Disabled && kv.Type != "file" {
+					t.Form[kv.Key] = kv.valueString()
+				}
+			}
+		}
+	}
+	return t
+}
+
+func (kv *PostmanKV) valueString() string {
+	if kv.Value == nil {
+		return ""
+	}
+	return valueString(kv.Value)
+}
+
+func postmanKVFind(kvs []*PostmanKV, key string) string {
+	for _, kv := range kvs {
+		if kv.Key == key {
+			return kv.valueString()
+		}
+	}
+	return ""
+}
+
+// URL value is string or object
+func postmanURL(val any) string {
+	switch typVal := val.(type) {
+	case string:
+		return typVal
+	case map[string]any:
+		if raw, ok := typVal["raw"].(string); ok && raw != "" {
+			return raw
+		}
+
+		var sb strings.Builder
+		if proto, ok := typVal["protocol"].(string); ok {
+			sb.WriteString(proto + "://")
+		}
+		sb.WriteString(joinAnyList(typVal["host"], "."))
+		if path := joinAnyList(typVal["path"], "/"); path != "" {
+			sb.WriteString("/" + path)
+		}
+		return sb.String()
+	}
+	return ""
+}
+
+func joinAnyList(val any, sep string) string {
+	switch typVal := val.(type) {
+	case string:
+		return typVal
+	case []any:
+		ss := make([]string, len(typVal))
+		for i, v := range typVal {
+			ss[i] = valueString(v)
+		}
+		return strings.Join(ss, sep)
+	}
+	return ""
+}
+
+// description value is string or object: {"content": "..."}
+func postmanDesc(vals ...any) string {
+	for _, val := range vals {
+		switch typVal := val.(type) {
+		case string:
+			if typVal != "" {
+				return typVal
+			}
+		case map[string]any:
+			if s, ok := typVal["content"].(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
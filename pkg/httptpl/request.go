@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/gookit/goutil/byteutil"
 	"github.com/gookit/goutil/errorx"
@@ -59,6 +60,8 @@ type Template struct {
 	BodyFile string `json:"body_file"`
 	// body buffer on build request
 	bodyBuf *bytes.Buffer
+	// in-file variables of the hc-file. eg: @host = localhost
+	fileVars map[string]string
 
 	// BeforeSend hook
 	BeforeSend func(r *http.Request, body *bytes.Buffer) `json:"-"`
@@ -67,6 +70,17 @@ type Template struct {
 
 	// Resp http response data check
 	Resp *httpreq.Resp `json:"response"`
+	// Expect check the response after send
+	Expect *Response `json:"expect"`
+	// Captures response values as variables, can be used by later templates.
+	//
+	// format: {var name: source}, see RespData.Value for the source.
+	Captures map[string]string `json:"captures"`
+
+	// Checked result after send. has value on Expect or Captures is not empty.
+	Checked *CheckResult `json:"-"`
+	// Captured variables after send
+	Captured map[string]string `json:"-"`
 }
 
 // NewTemplate instance
@@ -98,11 +112,17 @@ func (t *Template) FromJSONFile(p string) error {
 	return t.FromJSONBytes(bs)
 }
 
-// FromHCString parse from hc-file request part contents
+// FromHCString parse from hc-file request part contents. see parseHCString for the format.
 func (t *Template) FromHCString(s string) error {
 	t.src = s
-	// TODO parse
-	return nil
+	t.typ = TypeHttpClient
+	if t.Header == nil {
+		t.Header = make(map[string]string)
+	}
+	if t.fileVars == nil {
+		t.fileVars = make(map[string]string)
+	}
+	return t.parseHCString(s)
 }
 
 // SetTimeout for request
@@ -134,6 +154,9 @@ func (t *Template) Send(vars maputil.Data, hs map[string]string, opt *httpreq.Op
 	// send request
 	resp, err := httpreq.WrapResp(httpreq.SendRequest(req, opt))
 	t.Resp = resp
+	if err == nil {
+		t.Verify(resp)
+	}
 
 	if t.AfterSend != nil {
 		t.AfterSend(resp, err)
@@ -141,8 +164,49 @@ func (t *Template) Send(vars maputil.Data, hs map[string]string, opt *httpreq.Op
 	return err
 }
 
+// Verify check the response by Expect and capture variables by Captures.
+func (t *Template) Verify(resp *httpreq.Resp) *CheckResult {
+	t.Checked, t.Captured = nil, nil
+	hasExpect := t.Expect != nil && !t.Expect.IsEmpty()
+	if !hasExpect && len(t.Captures) == 0 {
+		return nil
+	}
+
+	rd, err := NewRespData(resp)
+	if err != nil {
+		t.Checked = &CheckResult{}
+		t.Checked.Add("read body", false, "%s", err.Error())
+		return t.Checked
+	}
+
+	if hasExpect {
+		t.Checked = t.Expect.Check(rd)
+	} else {
+		t.Checked = &CheckResult{}
+	}
+
+	if len(t.Captures) > 0 {
+		vars, err := rd.Capture(t.Captures)
+		t.Captured = vars
+		if err != nil {
+			t.Checked.Add("captures", false, "%s", err.Error())
+		}
+	}
+	return t.Checked
+}
+
+// Passed check the response is passed. will return true on not checked.
+func (t *Template) Passed() bool {
+	return t.Checked == nil || t.Checked.Passed()
+}
+
+// lock for the shared var replacer
+var rplMu sync.Mutex
+
 // BuildRequest instance
 func (t *Template) BuildRequest(vars maputil.Data, hs map[string]string) (*http.Request, error) {
+	rplMu.Lock()
+	defer rplMu.Unlock()
 	rpl.ResetMissVars()
 
 	// build URL
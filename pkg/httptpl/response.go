@@ -1,12 +1,339 @@
 package httptpl
 
-// Response struct
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/netutil/httpreq"
+	"github.com/gookit/goutil/strutil"
+)
+
+// Response struct for check the response
 type Response struct {
 	Status      int    `json:"status"`
 	Struct      any    `json:"struct"`
 	ContentType string `json:"content_type"`
-	// Required fields in response.
+	// Required JSON paths in response. eg: ["$.id", "$.data.name"]
 	Required []string `json:"required"`
 	// Asserts {name: "$.name == inhere"}
+	//
+	// Expression format: SOURCE OPERATOR [VALUE], see RespData.Value for the SOURCE.
+	//
+	// operators: ==, !=, >, >=, <, <=, contains, !contains, matches, exists, !exists
+	//
+	// Also allow use SOURCE as key for check equals: {"$.code": 0, "status": 200}
 	Asserts map[string]any `json:"asserts"`
 }
+
+// IsEmpty check
+func (r *Response) IsEmpty() bool {
+	return r.Status == 0 && r.ContentType == "" && len(r.Required) == 0 && len(r.Asserts) == 0
+}
+
+// AssertExprs convert the expect settings to assert expressions. eg: "status == 201"
+func (r *Response) AssertExprs() []string {
+	var exprs []string
+	if r.Status > 0 {
+		exprs = append(exprs, "status == "+strconv.Itoa(r.Status))
+	}
+	if r.ContentType != "" {
+		exprs = append(exprs, "header.Content-Type contains "+r.ContentType)
+	}
+
+	for _, path := range r.Required {
+		if !strings.HasPrefix(path, "$") {
+			path = "$." + path
+		}
+		exprs = append(exprs, path+" exists")
+	}
+
+	names := make([]string, 0, len(r.Asserts))
+	for name := range r.Asserts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		val := r.Asserts[name]
+		if expr, ok := val.(string); ok && assertReg.MatchString(expr) {
+			exprs = append(exprs, expr)
+		} else {
+			exprs = append(exprs, fmt.Sprintf("%s == %s", name, valueString(val)))
+		}
+	}
+	return exprs
+}
+
+// Check the response data
+func (r *Response) Check(rd *RespData) *CheckResult {
+	cr := &CheckResult{}
+	if r.Status > 0 {
+		cr.Add("status", rd.Status == r.Status, "expect %d, actual %d", r.Status, rd.Status)
+	}
+
+	if r.ContentType != "" {
+		ct := rd.Header.Get("Content-Type")
+		cr.Add("content type", strings.Contains(strings.ToLower(ct), strings.ToLower(r.ContentType)), "expect %q, actual %q", r.ContentType, ct)
+	}
+
+	for _, path := range r.Required {
+		if !strings.HasPrefix(path, "$") {
+			path = "$." + path
+		}
+		_, ok := rd.Value(path)
+		cr.Add("required "+path, ok, "not found in response")
+	}
+
+	// sort for stable report
+	names := make([]string, 0, len(r.Asserts))
+	for name := range r.Asserts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		val := r.Asserts[name]
+		if expr, ok := val.(string); ok && assertReg.MatchString(expr) {
+			ok, msg := rd.Assert(expr)
+			cr.Add(name, ok, "%s", msg)
+			continue
+		}
+
+		// key as the source, check equals
+		ok, msg := rd.Assert(fmt.Sprintf("%s == %s", name, valueString(val)))
+		cr.Add(name, ok, "%s", msg)
+	}
+	return cr
+}
+
+// AssertResult of check the response
+type AssertResult struct {
+	Name string
+	Pass bool
+	// Message on failed
+	Message string
+}
+
+// CheckResult of check the response
+type CheckResult struct {
+	Items []*AssertResult
+}
+
+// Add check item result
+func (cr *CheckResult) Add(name string, pass bool, format string, args ...any) {
+	ar := &AssertResult{Name: name, Pass: pass}
+	if !pass {
+		ar.Message = fmt.Sprintf(format, args...)
+	}
+	cr.Items = append(cr.Items, ar)
+}
+
+// Passed check all items is passed
+func (cr *CheckResult) Passed() bool { return cr.Failed() == 0 }
+
+// Failed number of items
+func (cr *CheckResult) Failed() (n int) {
+	for _, item := range cr.Items {
+		if !item.Pass {
+			n++
+		}
+	}
+	return
+}
+
+// Report string, contains color tags.
+func (cr *CheckResult) Report() string {
+	var sb strings.Builder
+	for _, item := range cr.Items {
+		if item.Pass {
+			sb.WriteString("  <green>PASS</> " + item.Name + "\n")
+		} else {
+			sb.WriteString("  <red>FAIL</> " + item.Name + ": " + item.Message + "\n")
+		}
+	}
+
+	failed := cr.Failed()
+	sb.WriteString(fmt.Sprintf("  total: %d, passed: %d, failed: %d\n", len(cr.Items), len(cr.Items)-failed, failed))
+	return sb.String()
+}
+
+// RespData for check and capture values from response
+type RespData struct {
+	Status int
+	Header http.Header
+	Body   string
+	// decoded JSON body
+	json    any
+	jsonErr error
+}
+
+// NewRespData from response. will read the response body, returns error on read body failed.
+func NewRespData(resp *httpreq.Resp) (*RespData, error) {
+	// NOTE: dont use resp.BodyString(), it will panic on read error.
+	// the body is buffered by ReadBody, can still be read by later.
+	if err := resp.ReadBody(); err != nil {
+		return nil, err
+	}
+
+	rd := &RespData{
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   resp.BodyString(),
+	}
+
+	rd.jsonErr = json.Unmarshal([]byte(rd.Body), &rd.json)
+	return rd, nil
+}
+
+var indexReg = regexp.MustCompile(`\[(\d+)]`)
+
+// Value get value by source. source formats:
+//
+//	$.data.name, $.items[0].id  - value by JSON path
+//	header.X-Token              - response header value, also allow header:X-Token
+//	status                      - response status code
+//	body                        - response body string
+//	regex:token=(\w+)           - first submatch of the regexp on body
+func (rd *RespData) Value(src string) (any, bool) {
+	switch {
+	case src == "status":
+		return rd.Status, true
+	case src == "body":
+		return rd.Body, true
+	case strings.HasPrefix(src, "$"):
+		if rd.jsonErr != nil {
+			return nil, false
+		}
+
+		path := strings.TrimPrefix(strings.TrimPrefix(src, "$"), ".")
+		path = indexReg.ReplaceAllString(path, ".$1")
+		return maputil.GetFromAny(strings.TrimPrefix(path, "."), rd.json)
+	case strings.HasPrefix(src, "header.") || strings.HasPrefix(src, "header:"):
+		vs, ok := rd.Header[http.CanonicalHeaderKey(src[7:])]
+		if !ok || len(vs) == 0 {
+			return nil, false
+		}
+		return vs[0], true
+	case strings.HasPrefix(src, "regex:"):
+		reg, err := regexp.Compile(src[6:])
+		if err != nil {
+			return nil, false
+		}
+
+		ms := reg.FindStringSubmatch(rd.Body)
+		if len(ms) == 0 {
+			return nil, false
+		}
+		return ms[len(ms)-1], true
+	}
+	return nil, false
+}
+
+// assert expression: SOURCE OPERATOR [VALUE]
+var assertReg = regexp.MustCompile(`^\s*(\S+)\s+(==|!=|>=|<=|>|<|!?contains|matches|!?exists)(?:\s+(.*))?$`)
+
+// Assert check the expression. eg: "$.name == inhere"
+func (rd *RespData) Assert(expr string) (ok bool, msg string) {
+	ms := assertReg.FindStringSubmatch(expr)
+	if ms == nil {
+		return false, "invalid assert expression: " + expr
+	}
+
+	src, op, want := ms[1], ms[2], unquote(strings.TrimSpace(ms[3]))
+	val, found := rd.Value(src)
+	switch op {
+	case "exists":
+		return found, src + " not exists"
+	case "!exists":
+		return !found, src + " should not exists"
+	}
+	if !found {
+		return false, src + " not found in response"
+	}
+
+	actual := valueString(val)
+	switch op {
+	case "==":
+		ok = compareValue(actual, want) == 0
+	case "!=":
+		ok = compareValue(actual, want) != 0
+	case ">":
+		ok = compareValue(actual, want) > 0
+	case ">=":
+		ok = compareValue(actual, want) >= 0
+	case "<":
+		ok = compareValue(actual, want) < 0
+	case "<=":
+		ok = compareValue(actual, want) <= 0
+	case "contains":
+		ok = strings.Contains(actual, want)
+	case "!contains":
+		ok = !strings.Contains(actual, want)
+	case "matches":
+		reg, err := regexp.Compile(want)
+		if err != nil {
+			return false, "invalid regexp: " + want
+		}
+		ok = reg.MatchString(actual)
+	}
+	return ok, fmt.Sprintf("expect %s %s %q, actual %q", src, op, want, strutil.Truncate(actual, 120, "..."))
+}
+
+// Capture values from response by sources. returns error on the source value not found.
+func (rd *RespData) Capture(captures map[string]string) (map[string]string, error) {
+	vars := make(map[string]string, len(captures))
+	for name, src := range captures {
+		val, ok := rd.Value(src)
+		if !ok {
+			return vars, errorx.Rawf("capture %q failed, not found %q in response", name, src)
+		}
+		vars[name] = valueString(val)
+	}
+	return vars, nil
+}
+
+// compare value as number on both are numeric.
+func compareValue(actual, want string) int {
+	if f1, err := strconv.ParseFloat(actual, 64); err == nil {
+		if f2, err := strconv.ParseFloat(want, 64); err == nil {
+			switch {
+			case f1 < f2:
+				return -1
+			case f1 > f2:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(actual, want)
+}
+
+// convert value to string, map and slice will encode as JSON.
+func valueString(val any) string {
+	switch typVal := val.(type) {
+	case nil:
+		return "null"
+	case string:
+		return typVal
+	case float64:
+		return strconv.FormatFloat(typVal, 'f', -1, 64)
+	case map[string]any, []any:
+		bs, _ := json.Marshal(typVal)
+		return string(bs)
+	}
+	return fmt.Sprint(val)
+}
+
+func unquote(s string) string {
+	if len(s) > 1 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package httptpl
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/netutil/httpreq"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func TestTemplate_Send_checkAndCapture(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("X-Request-Id", "req-01")
+			_, _ = io.WriteString(w, `{"code": 0, "data": {"token": "abc123", "roles": ["admin"]}}`)
+		case "/users":
+			if r.Header.Get("Authorization") != "Bearer abc123" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = io.WriteString(w, `{"code": 401}`)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"code": 0, "data": {"id": 12, "name": "inhere"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	ts := NewTemplates("smoke.http")
+	assert.NoErr(t, ts.FromHCString(`
+### login
+# @assert status == 200
+# @assert $.data.roles[0] == admin
+# @capture token = $.data.token
+# @capture reqId = header.X-Request-Id
+POST {{host}}/login
+
+### create user
+# @assert status == 201
+# @assert $.data.name contains "inh"
+# @assert $.data.id >= 10
+# @assert $.data.email exists
+# @capture userId = $.data.id
+POST {{host}}/users
+Authorization: Bearer {{token}}
+`))
+
+	vars := maputil.Data{"host": srv.URL}
+	login := ts.Get("login")
+	assert.NoErr(t, login.Send(vars, nil, nil))
+	assert.True(t, login.Passed())
+	assert.Eq(t, "abc123", login.Captured["token"])
+	assert.Eq(t, "req-01", login.Captured["reqId"])
+
+	vars.LoadSMap(login.Captured)
+	create := ts.Get("create user")
+	assert.NoErr(t, create.Send(vars, nil, nil))
+	assert.False(t, create.Passed())
+	assert.Eq(t, 1, create.Checked.Failed())
+	assert.Eq(t, "12", create.Captured["userId"])
+	assert.StrContains(t, create.Checked.Report(), "FAIL</> $.data.email exists")
+
+	// definition template with expect
+	tpl := NewTemplate()
+	assert.NoErr(t, tpl.FromJSONString(`{
+  "url": "{{host}}/users",
+  "header": {"Authorization": "Bearer invalid"},
+  "expect": {"status": 201, "content_type": "json", "required": ["data.id"], "asserts": {"$.code": 0}}
+}`))
+	assert.NoErr(t, tpl.Send(vars, nil, nil))
+	report := tpl.Checked.Report()
+	assert.Eq(t, 3, tpl.Checked.Failed(), report)
+	assert.True(t, strings.Contains(report, "PASS</> content type"))
+}
+
+func TestTemplate_Verify_readBodyError(t *testing.T) {
+	tpl := NewTemplate()
+	tpl.Expect = &Response{Asserts: map[string]any{"status": 200}}
+
+	resp := httpreq.NewResp(&http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+		Body:       io.NopCloser(iotest.ErrReader(io.ErrUnexpectedEOF)),
+	})
+
+	// should not panic
+	cr := tpl.Verify(resp)
+	assert.False(t, cr.Passed())
+	assert.False(t, tpl.Passed())
+	assert.Eq(t, "read body", cr.Items[0].Name)
+	assert.StrContains(t, cr.Items[0].Message, "unexpected EOF")
+}
@@ -3,6 +3,9 @@ package httptpl
 import (
 	"io/fs"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/gookit/goutil/errorx"
 	"github.com/gookit/goutil/fsutil"
@@ -61,26 +64,54 @@ func (ts *Templates) FromHCFile(hcFile string) error {
 	return ts.FromHCString(string(bs))
 }
 
+// FromHCString parse ide http-client file contents.
+//
+// unnamed request will use the index as name, eg: #1
+// duplicate name will be renamed by append the number, eg: "get user#2"
 func (ts *Templates) FromHCString(s string) error {
 	ts.src = s
-	ss := strutil.Split("\n"+s, RequestSplit)
+	ts.typ = TypeHttpClient
+	// in-file variables, will be shared by the requests
+	fileVars := make(map[string]string)
+	// count of parsed requests
+	var index int
 
-	for i, part := range ss {
+	for _, part := range SplitHCRequests(s) {
 		t := NewTemplate()
-		t.typ = ts.typ
-		t.Index = i
+		t.path = ts.path
+		t.fileVars = fileVars
+		t.Index = index
+
+		if IsEmptyHCPart(part) {
+			// only collect in-file variables
+			_ = t.parseHCString(part)
+			continue
+		}
 
 		if err := t.FromHCString(part); err != nil {
 			return err
 		}
+		if t.Name == "" {
+			t.Name = hcDefaultName(t.Index)
+		}
+		if _, ok := ts.set[t.Name]; ok {
+			t.Name = ts.uniqueName(t.Name)
+		}
 
+		index++
 		ts.set[t.Name] = t
 	}
 	return nil
 }
 
-func (ts *Templates) Send(keywords string) error {
-	return nil
+// make unique name for duplicate request name. eg: "get user#2"
+func (ts *Templates) uniqueName(name string) string {
+	for n := 2; ; n++ {
+		newName := name + "#" + strconv.Itoa(n)
+		if _, ok := ts.set[newName]; !ok {
+			return newName
+		}
+	}
 }
 
 func (ts *Templates) Get(name string) *Template {
@@ -98,8 +129,11 @@ func (ts *Templates) Lookup(name string) (*Template, error) {
 		return t, nil
 	}
 
-	// is from hc-file
+	// is from hc-file. allow use index number for unnamed request
 	t, ok := ts.set[name]
+	if !ok {
+		t, ok = ts.set["#"+name]
+	}
 	if ok {
 		return t, nil
 	}
@@ -108,6 +142,11 @@ func (ts *Templates) Lookup(name string) (*Template, error) {
 
 // LoadTemplate file and register
 func (ts *Templates) LoadTemplate(name string) (*Template, error) {
+	key := ts.tplKey(name)
+	if t, ok := ts.set[key]; ok {
+		return t, nil
+	}
+
 	c := bizutil.NewConfig()
 	t := NewTemplate()
 
@@ -123,6 +162,8 @@ func (ts *Templates) LoadTemplate(name string) (*Template, error) {
 		if err := c.Decode(t); err != nil {
 			return nil, err
 		}
+
+		ts.set[key] = t
 		return t, nil
 	}
 
@@ -140,37 +181,111 @@ func (ts *Templates) LoadTemplate(name string) (*Template, error) {
 			if err := c.Decode(t); err != nil {
 				return nil, err
 			}
+
+			ts.set[key] = t
 			return t, nil
 		}
 	}
 	return nil, errorx.Rawf("not found template %q on %q", name, ts.name)
 }
 
+// template key in the set, will remove the allowed ext.
+func (ts *Templates) tplKey(name string) string {
+	for _, ext := range ts.exts {
+		if strings.HasSuffix(name, "."+ext) {
+			return name[:len(name)-len(ext)-1]
+		}
+	}
+	return name
+}
+
 // GetByPath get Template by request uri path.
+// path can be a full URL, {{var}} in template path will match any path segment.
+//
+// Usage:
+//
+//	ts.GetByPath("/api/users/12") // can match template URL: {{host}}/api/users/{{id}}
 func (ts *Templates) GetByPath(path string) *Template {
+	path = urlPath(path)
+	for _, t := range ts.List() {
+		if matchTplPath(urlPath(t.URL), path) {
+			return t
+		}
+	}
 	return nil
 }
 
+// FindOne template by keywords. see Search
 func (ts *Templates) FindOne(keywords string) *Template {
+	if list := ts.Search(keywords, 1); len(list) > 0 {
+		return list[0]
+	}
 	return nil
 }
 
+// Search templates by keywords, will match the name, desc, method and URL.
+// multi keywords split by space, all keywords must be matched.
+//
+// Results sort by: name equals, name contains, index
 func (ts *Templates) Search(keywords string, limit int) []*Template {
-	return nil
-}
+	words := strings.Fields(strings.ToLower(keywords))
+	keyword := strings.ToLower(strings.TrimSpace(keywords))
 
-// Each template
-func (ts *Templates) Each(fn func(t *Template)) {
-	if ts.IsHcType() {
-		for _, t := range ts.set {
-			fn(t)
+	type scored struct {
+		t     *Template
+		score int
+	}
+
+	var matched []scored
+	for _, t := range ts.List() {
+		name := strings.ToLower(t.Name)
+		text := strings.ToLower(strings.Join([]string{t.Name, t.Desc, t.Method, t.URL}, " "))
+		if !strutil.ContainsAll(text, words) {
+			continue
+		}
+
+		score := 0
+		if name == keyword {
+			score = 2
+		} else if strings.Contains(name, keyword) {
+			score = 1
 		}
-		return
+		matched = append(matched, scored{t: t, score: score})
 	}
 
-	err := ts.LoadAll()
-	if err != nil {
-		return
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].score > matched[j].score
+	})
+
+	list := make([]*Template, 0, len(matched))
+	for _, m := range matched {
+		if limit > 0 && len(list) >= limit {
+			break
+		}
+		list = append(list, m.t)
+	}
+	return list
+}
+
+// List all templates, sorted by index. will load all template files for definition type.
+func (ts *Templates) List() []*Template {
+	if ts.IsDefType() {
+		_ = ts.LoadAll()
+	}
+
+	list := make([]*Template, 0, len(ts.set))
+	for _, t := range ts.set {
+		list = append(list, t)
+	}
+
+	sortByIndex(list)
+	return list
+}
+
+// Each template, sorted by index.
+func (ts *Templates) Each(fn func(t *Template)) {
+	for _, t := range ts.List() {
+		fn(t)
 	}
 }
 
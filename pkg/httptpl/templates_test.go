@@ -0,0 +1,124 @@
+package httptpl
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func TestTemplates_Search_GetByPath(t *testing.T) {
+	ts := NewTemplates("api.http")
+	assert.NoErr(t, ts.FromHCString(`
+### list users
+GET {{host}}/api/users?page=1
+
+### get user
+# get user detail by id
+GET {{host}}/api/users/{{id}}
+
+### create merge request
+POST https://{{host}}/api/projects/{{pid}}/merge_requests
+`))
+
+	list := ts.Search("user", 0)
+	assert.Len(t, list, 2)
+	assert.Eq(t, "list users", list[0].Name)
+	assert.Eq(t, "get user", ts.FindOne("get user").Name)
+	assert.Eq(t, "get user", ts.FindOne("detail").Name)
+	assert.Eq(t, "create merge request", ts.FindOne("POST merge").Name)
+	assert.Nil(t, ts.FindOne("not-exists"))
+
+	assert.Eq(t, "list users", ts.GetByPath("/api/users").Name)
+	assert.Eq(t, "get user", ts.GetByPath("http://localhost/api/users/12?x=1").Name)
+	assert.Eq(t, "create merge request", ts.GetByPath("/api/projects/3/merge_requests").Name)
+	assert.Nil(t, ts.GetByPath("/api/users/12/posts"))
+
+	// definition templates in dir
+	dir := t.TempDir()
+	assert.NoErr(t, os.WriteFile(filepath.Join(dir, "user-info.json"), []byte(`{"url": "{{host}}/api/user/info", "desc": "current user"}`), 0644))
+	assert.NoErr(t, os.WriteFile(filepath.Join(dir, "repo-list.json"), []byte(`{"url": "{{host}}/api/repos"}`), 0644))
+
+	dts := NewTemplates("demo")
+	dts.path, dts.exts = dir, []string{"json"}
+	assert.Len(t, dts.List(), 2)
+	assert.Eq(t, "/api/repos", urlPath(dts.FindOne("repos").URL))
+	assert.Eq(t, dts.Get("user-info"), dts.GetByPath("/api/user/info"))
+}
+
+func TestTemplates_Send_batch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			_, _ = io.WriteString(w, `{"token": "abc"}`)
+		case "/profile":
+			if r.Header.Get("Authorization") != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ts := NewTemplates("api.http")
+	assert.NoErr(t, ts.FromHCString(`
+### login
+# @capture token = $.token
+GET {{host}}/login
+
+### profile
+# @assert status == 200
+GET {{host}}/profile
+Authorization: {{token}}
+
+### missing page
+# @assert status == 200
+GET {{host}}/missing
+`))
+
+	vars := maputil.Data{"host": srv.URL}
+	br := ts.Send(vars, nil)
+	assert.Len(t, br.Items, 3)
+	assert.Eq(t, 1, br.Failed())
+	assert.Eq(t, 200, br.Items[1].Status)
+	assert.Eq(t, 404, br.Items[2].Status)
+	assert.StrContains(t, br.String(), "Total: 3, passed: 2, failed: 1")
+	// input vars not changed
+	assert.False(t, vars.Has("token"))
+
+	// filter and parallel. the captured token is not shared
+	br = ts.Send(vars, &BatchOptions{Filter: "GET", Parallel: 3, StopOnFail: true})
+	assert.Len(t, br.Items, 3)
+	assert.Eq(t, "login", br.Items[0].Template.Name)
+	assert.Eq(t, 2, br.Failed())
+}
+
+func TestTemplates_FromHCString_dupName(t *testing.T) {
+	ts := NewTemplates("api.http")
+	assert.NoErr(t, ts.FromHCString(`
+@host = localhost
+
+### get user
+GET {{host}}/api/users/1
+
+###
+GET {{host}}/api/users
+
+### get user
+GET {{host}}/api/users/2
+`))
+
+	list := ts.List()
+	assert.Len(t, list, 3)
+	assert.Eq(t, "get user", list[0].Name)
+	assert.Eq(t, "#2", list[1].Name)
+	assert.Eq(t, "get user#2", list[2].Name)
+	assert.Eq(t, 2, list[2].Index)
+	assert.Eq(t, "localhost/api/users/2", ts.Get("get user#2").URL)
+}
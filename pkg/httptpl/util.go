@@ -1,5 +1,7 @@
 package httptpl
 
+import "strings"
+
 func ParseAsTemplate(s string) *Template {
 	return nil
 }
@@ -15,3 +17,46 @@ func ParseClientFile(fpath string) *Templates {
 func ParseAsTemplates(s string) *Templates {
 	return nil
 }
+
+// get path from URL, URL can contain vars. eg: {{host}}/api/users?id=1 => /api/users
+func urlPath(s string) string {
+	if i := strings.IndexAny(s, "?#"); i >= 0 {
+		s = s[:i]
+	}
+
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	if !strings.HasPrefix(s, "/") {
+		// remove host part. eg: {{host}}/api, example.com/api
+		if i := strings.IndexByte(s, '/'); i >= 0 {
+			s = s[i:]
+		} else {
+			s = "/"
+		}
+	}
+	return strings.TrimSuffix(s, "/")
+}
+
+// match template path with the request path. {{var}} in template path will match any path segment.
+func matchTplPath(tplPath, path string) bool {
+	if tplPath == path {
+		return true
+	}
+	if !strings.Contains(tplPath, "{{") {
+		return false
+	}
+
+	tNodes := strings.Split(tplPath, "/")
+	pNodes := strings.Split(path, "/")
+	if len(tNodes) != len(pNodes) {
+		return false
+	}
+
+	for i, node := range tNodes {
+		if node != pNodes[i] && !(strings.HasPrefix(node, "{{") && strings.HasSuffix(node, "}}")) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,421 @@
+package jsonsrv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/strutil"
+	"github.com/gookit/goutil/x/ccolor"
+	"github.com/inhere/kite-go/pkg/util/httputil"
+)
+
+// ServeHTTP implement the http.Handler
+//
+// Routes of a collection resource. eg: posts
+//
+//	GET    /posts
+//	GET    /posts/1
+//	POST   /posts
+//	PUT    /posts/1
+//	PATCH  /posts/1
+//	DELETE /posts/1
+//	GET    /posts/1/comments  // nested, filter comments by postId=1
+//	POST   /posts/1/comments  // nested, will set postId=1
+//
+// Routes of a singular resource. eg: profile
+//
+//	GET /profile
+//	PUT /profile
+//	PATCH /profile
+//
+// Other:
+//
+//	GET /db - get full db data
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		if !s.Quiet {
+			ccolor.Printf("%s %s <cyan>%d</> %s\n", r.Method, r.URL.RequestURI(), rw.status, time.Since(start))
+		}
+	}()
+
+	// CORS for frontend dev
+	h := rw.Header()
+	h.Set("Access-Control-Allow-Origin", strutil.OrElse(r.Header.Get("Origin"), "*"))
+	h.Set("Access-Control-Allow-Credentials", "true")
+	h.Set("Access-Control-Allow-Methods", "GET,HEAD,PUT,PATCH,POST,DELETE")
+	h.Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
+	if r.Method == http.MethodOptions {
+		h.Set("Access-Control-Allow-Headers", strutil.OrElse(r.Header.Get("Access-Control-Request-Headers"), "*"))
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// rewrite path and query
+	if path, query, ok := s.rewritePath(r.URL.Path); ok {
+		r.URL.Path = path
+		if query != "" {
+			q := r.URL.Query()
+			for k, vs := range parseQuery(query) {
+				q[k] = vs
+			}
+			r.URL.RawQuery = q.Encode()
+		}
+	}
+
+	// custom routes
+	if route := s.matchRoute(r); route != nil {
+		s.serveRoute(rw, r, route)
+		return
+	}
+
+	path := r.URL.Path
+	if s.Prefix != "" {
+		if !strings.HasPrefix(path, s.Prefix+"/") {
+			httputil.WriteJSONIndent(rw, http.StatusNotFound, map[string]any{})
+			return
+		}
+		path = path[len(s.Prefix):]
+	}
+
+	nodes := strings.Split(strings.Trim(path, "/"), "/")
+	if nodes[0] == "db" && len(nodes) == 1 && r.Method == http.MethodGet {
+		httputil.WriteJSONIndent(rw, http.StatusOK, s.DB())
+		return
+	}
+	s.serveResource(rw, r, nodes)
+}
+
+func (s *Server) serveResource(w http.ResponseWriter, r *http.Request, nodes []string) {
+	name := nodes[0]
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+	} else {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	res, ok := s.db[name]
+	if !ok || len(nodes) > 3 {
+		httputil.WriteJSONIndent(w, http.StatusNotFound, map[string]any{})
+		return
+	}
+
+	switch typVal := res.(type) {
+	case []any:
+		switch len(nodes) {
+		case 1:
+			s.serveCollection(w, r, name, typVal)
+		case 2:
+			s.serveItem(w, r, name, typVal, nodes[1])
+		default:
+			s.serveNested(w, r, name, nodes[1], nodes[2])
+		}
+	case map[string]any:
+		if len(nodes) > 1 {
+			httputil.WriteJSONIndent(w, http.StatusNotFound, map[string]any{})
+			return
+		}
+		s.serveSingular(w, r, name, typVal)
+	default:
+		if len(nodes) > 1 || r.Method != http.MethodGet {
+			httputil.WriteJSONIndent(w, http.StatusNotFound, map[string]any{})
+			return
+		}
+		httputil.WriteJSONIndent(w, http.StatusOK, res)
+	}
+}
+
+// GET list, POST create
+func (s *Server) serveCollection(w http.ResponseWriter, r *http.Request, name string, list []any) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		s.writeList(w, r, list)
+	case http.MethodPost:
+		s.createItem(w, r, name, list, nil)
+	default:
+		httputil.WriteJSONIndent(w, http.StatusMethodNotAllowed, map[string]any{})
+	}
+}
+
+func (s *Server) writeList(w http.ResponseWriter, r *http.Request, list []any) {
+	result := queryList(list, r.URL.Query())
+	if result.total >= 0 {
+		w.Header().Set("X-Total-Count", fmt.Sprint(result.total))
+	}
+	if len(result.links) > 0 {
+		w.Header().Set("Link", buildLinks(r, result.links))
+	}
+	httputil.WriteJSONIndent(w, http.StatusOK, result.items)
+}
+
+func (s *Server) createItem(w http.ResponseWriter, r *http.Request, name string, list []any, setFields map[string]any) {
+	item, err := readBodyObject(r)
+	if err != nil {
+		httputil.WriteJSONIndent(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+
+	for k, v := range setFields {
+		item[k] = v
+	}
+
+	if id, ok := item[s.IDKey]; ok {
+		if findIndex(list, s.IDKey, valueString(id)) >= 0 {
+			httputil.WriteJSONIndent(w, http.StatusConflict, map[string]any{"error": fmt.Sprintf("duplicate %s: %v", s.IDKey, id)})
+			return
+		}
+	} else {
+		item[s.IDKey] = s.newID(list)
+	}
+
+	s.db[name] = append(list, item)
+	s.writeChanged(w, http.StatusCreated, item)
+}
+
+// GET, PUT, PATCH, DELETE item
+func (s *Server) serveItem(w http.ResponseWriter, r *http.Request, name string, list []any, id string) {
+	idx := findIndex(list, s.IDKey, id)
+	if idx < 0 {
+		httputil.WriteJSONIndent(w, http.StatusNotFound, map[string]any{})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		httputil.WriteJSONIndent(w, http.StatusOK, list[idx])
+	case http.MethodPut, http.MethodPatch:
+		body, err := readBodyObject(r)
+		if err != nil {
+			httputil.WriteJSONIndent(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+
+		item := body
+		if r.Method == http.MethodPatch {
+			item, _ = list[idx].(map[string]any)
+			if item == nil {
+				item = make(map[string]any)
+			}
+			for k, v := range body {
+				item[k] = v
+			}
+		}
+
+		// keep the item id
+		item[s.IDKey] = list[idx].(map[string]any)[s.IDKey]
+		list[idx] = item
+		s.writeChanged(w, http.StatusOK, item)
+	case http.MethodDelete:
+		s.db[name] = append(list[:idx:idx], list[idx+1:]...)
+		s.writeChanged(w, http.StatusOK, map[string]any{})
+	default:
+		httputil.WriteJSONIndent(w, http.StatusMethodNotAllowed, map[string]any{})
+	}
+}
+
+// nested resources. eg: /posts/1/comments => comments?postId=1
+func (s *Server) serveNested(w http.ResponseWriter, r *http.Request, parent, id, child string) {
+	list, ok := s.db[child].([]any)
+	if !ok {
+		httputil.WriteJSONIndent(w, http.StatusNotFound, map[string]any{})
+		return
+	}
+
+	fkName := singular(parent) + strings.ToUpper(s.IDKey[:1]) + s.IDKey[1:]
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		q := r.URL.Query()
+		q.Set(fkName, id)
+		r.URL.RawQuery = q.Encode()
+		s.writeList(w, r, list)
+	case http.MethodPost:
+		var fkVal any = id
+		if pList, ok := s.db[parent].([]any); ok {
+			if idx := findIndex(pList, s.IDKey, id); idx >= 0 {
+				fkVal = pList[idx].(map[string]any)[s.IDKey]
+			}
+		}
+		s.createItem(w, r, child, list, map[string]any{fkName: fkVal})
+	default:
+		httputil.WriteJSONIndent(w, http.StatusMethodNotAllowed, map[string]any{})
+	}
+}
+
+// GET, PUT, PATCH singular resource
+func (s *Server) serveSingular(w http.ResponseWriter, r *http.Request, name string, obj map[string]any) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		httputil.WriteJSONIndent(w, http.StatusOK, obj)
+	case http.MethodPut, http.MethodPatch, http.MethodPost:
+		body, err := readBodyObject(r)
+		if err != nil {
+			httputil.WriteJSONIndent(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+
+		if r.Method == http.MethodPatch {
+			for k, v := range body {
+				obj[k] = v
+			}
+			body = obj
+		}
+		s.db[name] = body
+		s.writeChanged(w, http.StatusOK, body)
+	default:
+		httputil.WriteJSONIndent(w, http.StatusMethodNotAllowed, map[string]any{})
+	}
+}
+
+// write response after data changed, will save data to file on enabled WriteBack.
+func (s *Server) writeChanged(w http.ResponseWriter, status int, data any) {
+	if err := s.save(); err != nil {
+		ccolor.Warnf("WARN: write data to file error: %v\n", err)
+	}
+	httputil.WriteJSONIndent(w, status, data)
+}
+
+/*
+----------- region T: custom routes
+*/
+
+func (s *Server) matchRoute(r *http.Request) *Route {
+	for _, route := range s.Routes {
+		if route.Method != "" && !strings.EqualFold(route.Method, r.Method) {
+			continue
+		}
+
+		if prefix, ok := strings.CutSuffix(route.Path, "*"); ok {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return route
+			}
+		} else if route.Path == r.URL.Path {
+			return route
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveRoute(w http.ResponseWriter, r *http.Request, route *Route) {
+	if route.Type == "static" {
+		if strings.Contains(r.URL.Path, "..") {
+			httputil.WriteJSONIndent(w, http.StatusBadRequest, map[string]any{"error": "invalid path"})
+			return
+		}
+
+		// default serve the request path from the base dir
+		fPath := filepath.FromSlash(r.URL.Path)
+		if route.Dir != "" {
+			prefix := strings.TrimSuffix(route.Path, "*")
+			fPath = filepath.Join(s.resolvePath(route.Dir), filepath.FromSlash(strings.TrimPrefix(r.URL.Path, prefix)))
+		} else {
+			fPath = s.resolvePath(strings.TrimPrefix(fPath, string(filepath.Separator)))
+		}
+		http.ServeFile(w, r, fPath)
+		return
+	}
+
+	resp := route.Response
+	if resp == nil {
+		resp = &RouteResponse{}
+	}
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if str, ok := resp.Body.(string); ok {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, str)
+		return
+	}
+	httputil.WriteJSONIndent(w, status, resp.Body)
+}
+
+/*
+----------- region T: helper
+*/
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func readBodyObject(r *http.Request) (map[string]any, error) {
+	bs, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// support form data
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		vs, err := url.ParseQuery(string(bs))
+		if err != nil {
+			return nil, err
+		}
+
+		mp := make(map[string]any, len(vs))
+		for k := range vs {
+			mp[k] = vs.Get(k)
+		}
+		return mp, nil
+	}
+
+	mp := make(map[string]any)
+	if len(strings.TrimSpace(string(bs))) == 0 {
+		return mp, nil
+	}
+	if err = json.Unmarshal(bs, &mp); err != nil {
+		return nil, fmt.Errorf("invalid JSON object body: %v", err)
+	}
+	return mp, nil
+}
+
+func findIndex(list []any, idKey, id string) int {
+	for i, item := range list {
+		if mp, ok := item.(map[string]any); ok {
+			if val, ok := mp[idKey]; ok && valueString(val) == id {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// singular name of the resource. eg: posts => post, categories => category
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ses"), strings.HasSuffix(name, "xes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "s"):
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+func parseQuery(query string) url.Values {
+	vs, _ := url.ParseQuery(query)
+	return vs
+}
@@ -0,0 +1,275 @@
+// Package jsonsrv provides a json-server like mock REST API server from a JSON file.
+//
+// refer: https://github.com/typicode/json-server
+package jsonsrv
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/x/ccolor"
+)
+
+// Config for the json server
+type Config struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// DataFile JSON db file path. each top-level key is a resource.
+	//  - relative path is based on the config file dir.
+	DataFile string `json:"datafile"`
+	// WriteBack write changes to the DataFile
+	WriteBack bool `json:"write_back"`
+	// Watch the DataFile, reload data on it changed.
+	Watch bool `json:"watch"`
+	// IDKey name of the resource item. default is "id"
+	IDKey string `json:"id_key"`
+	// Prefix path for all resource routes. eg: /api
+	Prefix string `json:"prefix"`
+	// Rewrites custom route rewrites. eg:
+	//
+	//	{"/api/v1/*": "/$1", "/blog/:resource/:id/show": "/:resource/:id"}
+	Rewrites map[string]string `json:"rewrites"`
+	// Routes custom static or mock response routes. they are matched before resources.
+	Routes []*Route `json:"routes"`
+	// Quiet mode, dont print request logs
+	Quiet bool `json:"quiet"`
+
+	// baseDir for resolve relative path. default is the config file dir.
+	baseDir string
+}
+
+// Route custom route config
+type Route struct {
+	// Type of the route. allow: static, response(default)
+	Type string `json:"type"`
+	// Method of the route, empty for match any method.
+	Method string `json:"method"`
+	// Path of the route. ends with "*" for match path prefix. eg: /public/assets/*
+	Path string `json:"path"`
+	// Dir for type=static. default will serve the request path from the base dir.
+	Dir string `json:"dir"`
+	// Response for type=response
+	Response *RouteResponse `json:"response"`
+}
+
+// RouteResponse mock response for the custom route
+type RouteResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    any               `json:"body"`
+}
+
+// Server json server. implemented http.Handler
+type Server struct {
+	*Config
+	mu sync.RWMutex
+	// db data loaded from DataFile
+	db map[string]any
+	// rewrites compiled from Config.Rewrites
+	rewrites []*rewriteRule
+	// hash of last written file contents, for skip reload on self write.
+	lastWrite string
+	// closeFn for stop watch file
+	closeFn func()
+}
+
+// New create json server with config
+func New(cfg *Config) *Server {
+	if cfg.IDKey == "" {
+		cfg.IDKey = "id"
+	}
+	cfg.Prefix = strings.TrimRight(cfg.Prefix, "/")
+
+	return &Server{Config: cfg, db: make(map[string]any)}
+}
+
+// LoadConfigFile load config from JSON file
+func LoadConfigFile(fPath string) (*Config, error) {
+	bs, err := os.ReadFile(fPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	if err = json.Unmarshal(bs, cfg); err != nil {
+		return nil, errorx.Rf("decode config file %s error: %v", fPath, err)
+	}
+
+	cfg.baseDir = filepath.Dir(fPath)
+	return cfg, nil
+}
+
+// SetBaseDir for resolve relative DataFile and static dir.
+func (c *Config) SetBaseDir(dir string) { c.baseDir = dir }
+
+func (c *Config) resolvePath(path string) string {
+	path = fsutil.ExpandHome(path)
+	if c.baseDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.baseDir, path)
+}
+
+// DataFilePath get resolved data file path
+func (c *Config) DataFilePath() string { return c.resolvePath(c.DataFile) }
+
+// Init load data file and compile rewrites.
+func (s *Server) Init() error {
+	rules, err := compileRewrites(s.Rewrites)
+	if err != nil {
+		return err
+	}
+	s.rewrites = rules
+
+	if s.DataFile == "" {
+		return nil
+	}
+	return s.Reload()
+}
+
+// Reload data from the DataFile
+func (s *Server) Reload() error {
+	fPath := s.DataFilePath()
+	bs, err := os.ReadFile(fPath)
+	if err != nil {
+		// not exists file will be created on write back
+		if os.IsNotExist(err) && s.WriteBack {
+			return nil
+		}
+		return err
+	}
+
+	if len(bytes.TrimSpace(bs)) == 0 {
+		bs = []byte("{}")
+	}
+
+	db := make(map[string]any)
+	if err = json.Unmarshal(bs, &db); err != nil {
+		return errorx.Rf("decode data file %s error: %v", fPath, err)
+	}
+
+	s.mu.Lock()
+	s.db = db
+	s.mu.Unlock()
+	return nil
+}
+
+// DB get a copy of the db data
+func (s *Server) DB() map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneValue(s.db).(map[string]any)
+}
+
+// Addr get listen address
+func (s *Server) Addr() string {
+	host := s.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return host + ":" + strconv.Itoa(s.Port)
+}
+
+// Start the json server. will block until the server stopped.
+func (s *Server) Start() error {
+	if err := s.Init(); err != nil {
+		return err
+	}
+
+	if s.Watch && s.DataFile != "" {
+		if err := s.WatchFile(); err != nil {
+			return err
+		}
+		defer s.Close()
+	}
+
+	ccolor.Infof("JSON server listening on http://%s\n", s.Addr())
+	for name := range s.DB() {
+		ccolor.Printf(" - resource <cyan>http://%s%s/%s</>\n", s.Addr(), s.Prefix, name)
+	}
+	return http.ListenAndServe(s.Addr(), s)
+}
+
+// Close stop watch the data file
+func (s *Server) Close() {
+	if s.closeFn != nil {
+		s.closeFn()
+		s.closeFn = nil
+	}
+}
+
+// save db data to file on WriteBack=true. must be called under lock.
+func (s *Server) save() error {
+	if !s.WriteBack || s.DataFile == "" {
+		return nil
+	}
+
+	bs, err := json.MarshalIndent(s.db, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	s.lastWrite = hashBytes(bs)
+	return fsutil.WriteFile(s.DataFilePath(), bs, fsutil.DefaultFilePerm)
+}
+
+// new id for the collection. numeric ids will use max+1, otherwise random string.
+func (s *Server) newID(list []any) any {
+	var maxID float64
+	for _, item := range list {
+		mp, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		switch id := mp[s.IDKey].(type) {
+		case float64:
+			maxID = max(maxID, id)
+		case nil:
+		default:
+			return randomID()
+		}
+	}
+	return maxID + 1
+}
+
+func randomID() string {
+	bs := make([]byte, 4)
+	_, _ = rand.Read(bs)
+	return hex.EncodeToString(bs)
+}
+
+func hashBytes(bs []byte) string {
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:])
+}
+
+// cloneValue deep clone JSON value
+func cloneValue(val any) any {
+	switch typVal := val.(type) {
+	case map[string]any:
+		mp := make(map[string]any, len(typVal))
+		for k, v := range typVal {
+			mp[k] = cloneValue(v)
+		}
+		return mp
+	case []any:
+		list := make([]any, len(typVal))
+		for i, v := range typVal {
+			list[i] = cloneValue(v)
+		}
+		return list
+	}
+	return val
+}
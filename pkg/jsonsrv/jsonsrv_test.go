@@ -0,0 +1,160 @@
+package jsonsrv
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+const testDB = `{
+  "posts": [
+    {"id": 1, "title": "hello kite", "views": 10, "author": {"name": "tom"}},
+    {"id": 2, "title": "json server", "views": 30, "author": {"name": "inhere"}},
+    {"id": 3, "title": "go mock api", "views": 20, "author": {"name": "tom"}}
+  ],
+  "comments": [
+    {"id": 1, "body": "nice", "postId": 1},
+    {"id": 2, "body": "good", "postId": 2}
+  ],
+  "profile": {"name": "kite"}
+}`
+
+func newTestServer(t *testing.T, fn func(cfg *Config)) (*Server, string) {
+	dir := t.TempDir()
+	fPath := filepath.Join(dir, "db.json")
+	assert.NoErr(t, os.WriteFile(fPath, []byte(testDB), 0644))
+
+	cfg := &Config{DataFile: fPath, Quiet: true}
+	if fn != nil {
+		fn(cfg)
+	}
+
+	s := New(cfg)
+	assert.NoErr(t, s.Init())
+	return s, fPath
+}
+
+func doRequest(s *Server, method, uri, body string) (*httptest.ResponseRecorder, any) {
+	r := httptest.NewRequest(method, uri, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	var data any
+	_ = json.Unmarshal(w.Body.Bytes(), &data)
+	return w, data
+}
+
+func listIDs(data any) []string {
+	var ids []string
+	for _, item := range data.([]any) {
+		ids = append(ids, valueString(item.(map[string]any)["id"]))
+	}
+	return ids
+}
+
+func TestServer_crud(t *testing.T) {
+	s, fPath := newTestServer(t, nil)
+
+	w, data := doRequest(s, "GET", "/posts/2", "")
+	assert.Eq(t, 200, w.Code)
+	assert.Eq(t, "json server", data.(map[string]any)["title"])
+
+	w, data = doRequest(s, "POST", "/posts", `{"title": "new post"}`)
+	assert.Eq(t, 201, w.Code)
+	assert.Eq(t, float64(4), data.(map[string]any)["id"])
+
+	w, _ = doRequest(s, "POST", "/posts", `{"id": 4, "title": "dup"}`)
+	assert.Eq(t, 409, w.Code)
+
+	w, data = doRequest(s, "PATCH", "/posts/4", `{"views": 5}`)
+	assert.Eq(t, 200, w.Code)
+	assert.Eq(t, "new post", data.(map[string]any)["title"])
+	assert.Eq(t, float64(5), data.(map[string]any)["views"])
+
+	w, data = doRequest(s, "PUT", "/posts/4", `{"title": "replaced"}`)
+	assert.Eq(t, 200, w.Code)
+	assert.Eq(t, float64(4), data.(map[string]any)["id"])
+	assert.Nil(t, data.(map[string]any)["views"])
+
+	w, _ = doRequest(s, "DELETE", "/posts/4", "")
+	assert.Eq(t, 200, w.Code)
+	w, _ = doRequest(s, "GET", "/posts/4", "")
+	assert.Eq(t, 404, w.Code)
+
+	// nested and singular
+	_, data = doRequest(s, "GET", "/posts/1/comments", "")
+	assert.Eq(t, []string{"1"}, listIDs(data))
+	_, data = doRequest(s, "GET", "/profile", "")
+	assert.Eq(t, "kite", data.(map[string]any)["name"])
+
+	// not write back
+	bs, err := os.ReadFile(fPath)
+	assert.NoErr(t, err)
+	assert.Eq(t, testDB, string(bs))
+}
+
+func TestServer_query(t *testing.T) {
+	s, _ := newTestServer(t, nil)
+
+	_, data := doRequest(s, "GET", "/posts?author.name=tom", "")
+	assert.Eq(t, []string{"1", "3"}, listIDs(data))
+
+	_, data = doRequest(s, "GET", "/posts?views_gte=20", "")
+	assert.Eq(t, []string{"2", "3"}, listIDs(data))
+
+	_, data = doRequest(s, "GET", "/posts?title_like=^go", "")
+	assert.Eq(t, []string{"3"}, listIDs(data))
+
+	_, data = doRequest(s, "GET", "/posts?q=KITE", "")
+	assert.Eq(t, []string{"1"}, listIDs(data))
+
+	_, data = doRequest(s, "GET", "/posts?_sort=views&_order=desc", "")
+	assert.Eq(t, []string{"2", "3", "1"}, listIDs(data))
+
+	w, data := doRequest(s, "GET", "/posts?_page=2&_limit=2", "")
+	assert.Eq(t, []string{"3"}, listIDs(data))
+	assert.Eq(t, "3", w.Header().Get("X-Total-Count"))
+	assert.StrContains(t, w.Header().Get("Link"), `rel="prev"`)
+	assert.NotContains(t, w.Header().Get("Link"), `rel="next"`)
+
+	_, data = doRequest(s, "GET", "/posts?_start=1&_limit=1", "")
+	assert.Eq(t, []string{"2"}, listIDs(data))
+}
+
+func TestServer_rewriteAndWriteBack(t *testing.T) {
+	s, fPath := newTestServer(t, func(cfg *Config) {
+		cfg.WriteBack = true
+		cfg.Prefix = "/api"
+		cfg.Rewrites = map[string]string{
+			"/v1/*":              "/api/$1",
+			"/blog/:id/comments": "/api/comments?postId=:id",
+		}
+	})
+
+	w, data := doRequest(s, "GET", "/v1/posts/1", "")
+	assert.Eq(t, 200, w.Code)
+	assert.Eq(t, "hello kite", data.(map[string]any)["title"])
+
+	_, data = doRequest(s, "GET", "/blog/2/comments", "")
+	assert.Eq(t, []string{"2"}, listIDs(data))
+
+	w, _ = doRequest(s, "GET", "/posts", "")
+	assert.Eq(t, 404, w.Code)
+
+	w, _ = doRequest(s, "POST", "/api/comments", `{"body": "saved", "postId": 3}`)
+	assert.Eq(t, 201, w.Code)
+
+	bs, err := os.ReadFile(fPath)
+	assert.NoErr(t, err)
+	assert.StrContains(t, string(bs), `"saved"`)
+	assert.Eq(t, s.lastWrite, hashBytes(bs))
+}
@@ -0,0 +1,298 @@
+package jsonsrv
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/goutil/maputil"
+)
+
+// filter operators suffix. eg: views_gte=10
+var filterOps = []string{"_gte", "_lte", "_ne", "_like"}
+
+type listResult struct {
+	items []any
+	// total count before paginate. -1 for not set header
+	total int
+	// pagination links. key: first, prev, next, last
+	links map[string]int
+}
+
+// queryList filter, search, sort and paginate the list by query params.
+//
+//	?title=hello&author.name=tom   filter by field, dot path for deep field
+//	?id=1&id=2                     multi values for match any
+//	?views_gte=10&views_lte=20     operators: _gte, _lte, _ne, _like(regexp)
+//	?q=keyword                     full-text search on all string values
+//	?_sort=views,id&_order=desc,asc
+//	?_page=2&_limit=10             paginate, default limit is 10 on set _page
+//	?_start=10&_end=20, ?_start=10&_limit=10
+func queryList(list []any, q url.Values) *listResult {
+	items := make([]any, 0, len(list))
+	filters := parseFilters(q)
+	keyword := strings.ToLower(q.Get("q"))
+
+	for _, item := range list {
+		if !matchFilters(item, filters) {
+			continue
+		}
+		if keyword != "" && !containsText(item, keyword) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if sortKeys := q.Get("_sort"); sortKeys != "" {
+		sortItems(items, strings.Split(sortKeys, ","), strings.Split(q.Get("_order"), ","))
+	}
+
+	res := &listResult{items: items, total: -1}
+	total := len(items)
+	limit, hasLimit := queryInt(q, "_limit")
+
+	// paginate
+	if page, ok := queryInt(q, "_page"); ok {
+		if !hasLimit || limit < 1 {
+			limit = 10
+		}
+		page = max(page, 1)
+		lastPage := max((total+limit-1)/limit, 1)
+
+		res.total = total
+		res.items = sliceItems(items, (page-1)*limit, page*limit)
+		res.links = map[string]int{"first": 1, "last": lastPage}
+		if page > 1 {
+			res.links["prev"] = page - 1
+		}
+		if page < lastPage {
+			res.links["next"] = page + 1
+		}
+		return res
+	}
+
+	// slice
+	start, hasStart := queryInt(q, "_start")
+	end, hasEnd := queryInt(q, "_end")
+	if hasStart || hasEnd || hasLimit {
+		res.total = total
+		if !hasEnd {
+			end = total
+			if hasLimit {
+				end = start + limit
+			}
+		}
+		res.items = sliceItems(items, start, end)
+	}
+	return res
+}
+
+type filter struct {
+	path string
+	op   string
+	vals []string
+	reg  []*regexp.Regexp
+}
+
+func parseFilters(q url.Values) []*filter {
+	var filters []*filter
+	for key, vals := range q {
+		if key == "q" || strings.HasPrefix(key, "_") {
+			continue
+		}
+
+		f := &filter{path: key, vals: vals}
+		for _, op := range filterOps {
+			if path, ok := strings.CutSuffix(key, op); ok {
+				f.path, f.op = path, op
+				break
+			}
+		}
+
+		if f.op == "_like" {
+			for _, val := range vals {
+				if reg, err := regexp.Compile("(?i)" + val); err == nil {
+					f.reg = append(f.reg, reg)
+				}
+			}
+		}
+		filters = append(filters, f)
+	}
+	return filters
+}
+
+func matchFilters(item any, filters []*filter) bool {
+	mp, ok := item.(map[string]any)
+	if !ok {
+		return len(filters) == 0
+	}
+
+	for _, f := range filters {
+		val, ok := maputil.GetByPath(f.path, mp)
+		if !ok && f.op != "_ne" {
+			return false
+		}
+		if !f.match(val) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *filter) match(val any) bool {
+	str := valueString(val)
+	switch f.op {
+	case "_gte", "_lte":
+		for _, want := range f.vals {
+			cmp := compareValue(val, want)
+			if (f.op == "_gte" && cmp < 0) || (f.op == "_lte" && cmp > 0) {
+				return false
+			}
+		}
+		return true
+	case "_ne":
+		for _, want := range f.vals {
+			if str == want {
+				return false
+			}
+		}
+		return true
+	case "_like":
+		for _, reg := range f.reg {
+			if reg.MatchString(str) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// equal any of the values
+	for _, want := range f.vals {
+		if str == want {
+			return true
+		}
+	}
+	return false
+}
+
+// check the value contains keyword text. will search deep in map and list.
+func containsText(val any, keyword string) bool {
+	switch typVal := val.(type) {
+	case map[string]any:
+		for _, v := range typVal {
+			if containsText(v, keyword) {
+				return true
+			}
+		}
+	case []any:
+		for _, v := range typVal {
+			if containsText(v, keyword) {
+				return true
+			}
+		}
+	case string:
+		return strings.Contains(strings.ToLower(typVal), keyword)
+	case nil:
+	default:
+		return strings.Contains(valueString(typVal), keyword)
+	}
+	return false
+}
+
+func sortItems(items []any, keys, orders []string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		for idx, key := range keys {
+			vi := itemField(items[i], key)
+			vj := itemField(items[j], key)
+
+			cmp := compareValue(vi, valueString(vj))
+			if cmp == 0 {
+				continue
+			}
+			if idx < len(orders) && strings.EqualFold(orders[idx], "desc") {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
+
+func itemField(item any, path string) any {
+	if mp, ok := item.(map[string]any); ok {
+		val, _ := maputil.GetByPath(path, mp)
+		return val
+	}
+	return nil
+}
+
+// compare value with the string. will compare as number on both are numeric.
+func compareValue(val any, want string) int {
+	str := valueString(val)
+	if f1, err := strconv.ParseFloat(str, 64); err == nil {
+		if f2, err := strconv.ParseFloat(want, 64); err == nil {
+			switch {
+			case f1 < f2:
+				return -1
+			case f1 > f2:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(str, want)
+}
+
+// valueString convert JSON value to string. float number will not use exponent format.
+func valueString(val any) string {
+	switch typVal := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return typVal
+	case float64:
+		return strconv.FormatFloat(typVal, 'f', -1, 64)
+	}
+	return fmt.Sprint(val)
+}
+
+func sliceItems(items []any, start, end int) []any {
+	start = min(max(start, 0), len(items))
+	end = min(max(end, start), len(items))
+	return items[start:end]
+}
+
+func queryInt(q url.Values, key string) (int, bool) {
+	if !q.Has(key) {
+		return 0, false
+	}
+
+	val, err := strconv.Atoi(q.Get(key))
+	return val, err == nil
+}
+
+// build pagination Link header. eg: <http://localhost/posts?_page=1>; rel="first", ...
+func buildLinks(r *http.Request, links map[string]int) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	var ss []string
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		page, ok := links[rel]
+		if !ok {
+			continue
+		}
+
+		q := r.URL.Query()
+		q.Set("_page", strconv.Itoa(page))
+		link := fmt.Sprintf("%s://%s%s?%s", scheme, r.Host, r.URL.Path, q.Encode())
+		ss = append(ss, fmt.Sprintf("<%s>; rel=%q", link, rel))
+	}
+	return strings.Join(ss, ", ")
+}
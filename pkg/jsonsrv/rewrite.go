@@ -0,0 +1,87 @@
+package jsonsrv
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/goutil/errorx"
+)
+
+type rewriteRule struct {
+	from    string
+	pattern *regexp.Regexp
+	// param names in the from pattern. "*" param name is the index. eg: "1"
+	params []string
+	target string
+}
+
+var paramReg = regexp.MustCompile(`:(\w+)|\*`)
+
+// compile rewrite rules. longer pattern will be matched first.
+//
+//	"/api/*": "/$1"
+//	"/blog/:resource/:id/show": "/:resource/:id"
+func compileRewrites(rewrites map[string]string) ([]*rewriteRule, error) {
+	rules := make([]*rewriteRule, 0, len(rewrites))
+	for from, target := range rewrites {
+		rule := &rewriteRule{from: from, target: target}
+
+		var sb strings.Builder
+		var last int
+		for _, loc := range paramReg.FindAllStringSubmatchIndex(from, -1) {
+			sb.WriteString(regexp.QuoteMeta(from[last:loc[0]]))
+			last = loc[1]
+
+			if loc[2] < 0 { // is "*"
+				rule.params = append(rule.params, strconv.Itoa(len(rule.params)+1))
+				sb.WriteString("(.*)")
+			} else {
+				rule.params = append(rule.params, from[loc[2]:loc[3]])
+				sb.WriteString("([^/]+)")
+			}
+		}
+		sb.WriteString(regexp.QuoteMeta(from[last:]))
+
+		reg, err := regexp.Compile("^" + sb.String() + "$")
+		if err != nil {
+			return nil, errorx.Rf("invalid rewrite pattern %q: %v", from, err)
+		}
+		rule.pattern = reg
+		rules = append(rules, rule)
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		if len(rules[i].from) == len(rules[j].from) {
+			return rules[i].from < rules[j].from
+		}
+		return len(rules[i].from) > len(rules[j].from)
+	})
+	return rules, nil
+}
+
+// rewrite the request path by rules. returns new path and query string from target.
+func (s *Server) rewritePath(path string) (newPath, query string, ok bool) {
+	for _, rule := range s.rewrites {
+		ms := rule.pattern.FindStringSubmatch(path)
+		if ms == nil {
+			continue
+		}
+
+		target := rule.target
+		// replace from last, avoid $1 replace the $10
+		for i := len(rule.params) - 1; i >= 0; i-- {
+			val := ms[i+1]
+			name := rule.params[i]
+			target = strings.ReplaceAll(target, "$"+strconv.Itoa(i+1), val)
+			if _, err := strconv.Atoi(name); err != nil {
+				target = strings.ReplaceAll(target, ":"+name, val)
+			}
+		}
+
+		newPath, query, _ = strings.Cut(target, "?")
+		return newPath, query, true
+	}
+	return path, "", false
+}
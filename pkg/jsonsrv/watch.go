@@ -0,0 +1,86 @@
+package jsonsrv
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gookit/goutil/x/ccolor"
+)
+
+// WatchFile watch the DataFile changes and reload data.
+// will skip the changes written by the server self.
+func (s *Server) WatchFile() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// watch the dir, editors may replace the file on save.
+	fPath, err := filepath.Abs(s.DataFilePath())
+	if err != nil {
+		return err
+	}
+	if err = watcher.Add(filepath.Dir(fPath)); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	done := make(chan struct{})
+	s.closeFn = func() {
+		close(done)
+		_ = watcher.Close()
+	}
+
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case <-done:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Name != fPath || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+
+				// debounce multi events on once save
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(100*time.Millisecond, s.reloadOnChange)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				ccolor.Warnf("WARN: watch data file error: %v\n", err)
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *Server) reloadOnChange() {
+	bs, err := os.ReadFile(s.DataFilePath())
+	if err != nil {
+		return
+	}
+
+	s.mu.RLock()
+	selfWrite := s.lastWrite == hashBytes(bs)
+	s.mu.RUnlock()
+	if selfWrite {
+		return
+	}
+
+	if err = s.Reload(); err != nil {
+		ccolor.Warnf("WARN: reload data file error, keep old data. %v\n", err)
+		return
+	}
+	if !s.Quiet {
+		ccolor.Infof("data file changed, reloaded: %s\n", s.DataFile)
+	}
+}
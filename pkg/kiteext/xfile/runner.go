@@ -0,0 +1,185 @@
+package xfile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/goutil/cliutil/cmdline"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/strutil"
+	"github.com/gookit/goutil/strutil/textutil"
+	"github.com/gookit/goutil/sysutil/cmdr"
+	"github.com/gookit/goutil/x/ccolor"
+)
+
+// RunOpts for run an action
+type RunOpts struct {
+	DryRun bool
+	// Workdir custom workdir for run. default use XAction.Workdir or XFile.Workdir()
+	Workdir string
+	// Stdout, Stderr for command output. default is os.Stdout, os.Stderr
+	Stdout, Stderr io.Writer
+}
+
+// RunAction run an action by name. if name is empty, will run the DefaultAction.
+//
+// args will be set as vars: $args - all args, $1, $2 ... - arg by position.
+func (m *XFileManager) RunAction(name string, args []string, opts *RunOpts) error {
+	if !m.loaded {
+		return errorx.Raw("kitefile is not loaded")
+	}
+
+	if name == "" {
+		if name = m.DefaultAction(); name == "" {
+			return errorx.Raw("not set the default action, please input an action name")
+		}
+	}
+
+	act, ok := m.Action(name)
+	if !ok {
+		return errorx.Rawf("action %q is not found in the kitefile", name)
+	}
+	return act.Run(args, opts)
+}
+
+// FileDir of the action defined kitefile
+func (a *XAction) FileDir() string {
+	if a.xf == nil {
+		return ""
+	}
+	return a.xf.Dir()
+}
+
+// WorkdirPath for run the action. relative path is based on the kitefile dir.
+func (a *XAction) WorkdirPath() string {
+	if a.xf == nil {
+		return a.Workdir
+	}
+	if a.Workdir == "" {
+		return a.xf.Workdir()
+	}
+	return resolveDir(a.xf.Workdir(), a.Workdir)
+}
+
+// Run the action commands, stop on a command failed.
+func (a *XAction) Run(args []string, opts *RunOpts) error {
+	if opts == nil {
+		opts = &RunOpts{}
+	}
+	stdout := opts.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	stderr := opts.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	workdir := strutil.OrElse(opts.Workdir, a.WorkdirPath())
+	env := make(map[string]string)
+	if a.xf != nil {
+		env = a.xf.MergedEnv()
+	}
+	env = maputil.MergeStrMap(a.Env, env)
+
+	vars := a.buildVars(args)
+	rpl := textutil.NewStrVarRenderer()
+	rpl.SetGetter(func(name string) (string, bool) {
+		val, ok := env[name]
+		return val, ok
+	})
+
+	for _, line := range a.Cmds {
+		line = strings.TrimSpace(rpl.Render(line, vars))
+		if line == "" {
+			continue
+		}
+
+		if opts.DryRun {
+			ccolor.Fprintf(stdout, "<cyan>DRY-RUN</>: %s (in %s)\n", line, workdir)
+			continue
+		}
+
+		ccolor.Fprintf(stdout, "<magenta>RUN</>: %s\n", line)
+		cmd := a.newCmd(line).WorkDirOnNE(workdir).AppendEnv(env)
+		cmd.WithOutput(stdout, stderr)
+		if err := cmd.Run(); err != nil {
+			return errorx.Rf("run action %q command %q error: %v", a.Name, line, err)
+		}
+	}
+	return nil
+}
+
+// build vars for render commands. action vars and input args
+func (a *XAction) buildVars(args []string) map[string]any {
+	vars := make(map[string]any, len(a.Vars)+len(args)+1)
+	for k, v := range a.Vars {
+		vars[k] = v
+	}
+
+	vars["args"] = strings.Join(args, " ")
+	for i, arg := range args {
+		vars[strconv.Itoa(i+1)] = arg
+	}
+	return vars
+}
+
+// create command. if Type is empty, will direct run the command line, so it works on Windows.
+func (a *XAction) newCmd(line string) *cmdr.Cmd {
+	switch a.Type {
+	case "":
+		bin, args := cmdline.NewParser(line).WithParseEnv().BinAndArgs()
+		return cmdr.NewCmd(bin, args...)
+	case "cmd", "cmd.exe":
+		return cmdr.NewCmd(a.Type, "/c", line)
+	case "pwsh", "powershell":
+		return cmdr.NewCmd(a.Type, "-Command", line)
+	default:
+		return cmdr.NewCmd(a.Type, "-c", line)
+	}
+}
+
+// HelpText of the kitefile. includes name, desc and actions list.
+func (m *XFileManager) HelpText() string {
+	var sb strings.Builder
+	if top := m.top; top != nil {
+		if top.Name != "" {
+			sb.WriteString(fmt.Sprintf("<green>%s</>", top.Name))
+			if top.Version != "" {
+				sb.WriteString(fmt.Sprintf(" (v%s)", top.Version))
+			}
+			sb.WriteByte('\n')
+		}
+		if top.Desc != "" {
+			sb.WriteString(top.Desc + "\n")
+		}
+		sb.WriteString(fmt.Sprintf("<gray>File: %s</>\n", top.filePath))
+		for xf := top.parent; xf != nil; xf = xf.parent {
+			sb.WriteString(fmt.Sprintf("<gray>Parent: %s</>\n", xf.filePath))
+		}
+	}
+
+	sb.WriteString("\n<comment>Actions:</>\n")
+	names := m.ActionNames()
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	defAction := m.DefaultAction()
+	for _, name := range names {
+		desc := m.actions[name].Desc
+		if desc == "" {
+			desc = "<gray>no description</>"
+		}
+		if name == defAction {
+			desc += " <cyan>(default)</>"
+		}
+		sb.WriteString(fmt.Sprintf("  <info>%s</>  %s\n", strutil.PadRight(name, " ", width), desc))
+	}
+	return sb.String()
+}
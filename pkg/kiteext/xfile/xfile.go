@@ -1,5 +1,21 @@
 package xfile
 
+import (
+	"maps"
+	"path/filepath"
+	"sort"
+
+	"github.com/gookit/config/v2"
+	"github.com/gookit/config/v2/toml"
+	"github.com/gookit/config/v2/yaml"
+	"github.com/gookit/goutil/arrutil"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/strutil"
+	"github.com/gookit/slog"
+)
+
 var (
 	// DefaultTaskFiles 默认自动查找的task文件名称 eg "kite.task[s].yml", "kite.script[s].yml"
 	DefaultTaskFiles = []string{".kite.task", ".kite.tasks", ".kite.script", ".kite.scripts"}
@@ -7,13 +23,27 @@ var (
 	DefaultDefineExts = []string{".yml", ".yaml", ".toml", ".json"}
 )
 
+// xfile modes
+const (
+	ModeTopCfg  = "top_cfg"
+	ModeSetting = "setting"
+)
+
+const (
+	modeKey    = "__xfile_mode"
+	settingKey = "__setting"
+)
+
 type XFileManager struct {
 	// 离工作目录最近的一个 kitefile 文件
 	top *XFile
+	// merged actions from all found kitefile files
+	actions map[string]*XAction
+	loaded  bool
 
 	// 允许的文件名列表 default: 'kitefile', '.kitefile', 'kitefile.yml', 'kitefile.yaml'
 	Filenames []string `json:"filenames"`
-	FileExts []string `json:"file_exts"`
+	FileExts  []string `json:"file_exts"`
 	// 除了在当前目录中搜索，还搜索以下目录。找到一个 kitefile 文件后，停止搜索
 	ExtraFind string `json:"extra_find"`
 	// 向上搜索目录最大深度，默认为 5. 找到的都作为 kf 父级
@@ -22,23 +52,146 @@ type XFileManager struct {
 
 func NewXFile() *XFileManager {
 	return &XFileManager{
-		Filenames: []string{"kitefile", ".kitefile"},
-		FileExts:  DefaultDefineExts,
+		Filenames:    []string{"kitefile", ".kitefile"},
+		FileExts:     DefaultDefineExts,
 		MaxFindDepth: 5,
 	}
 }
 
+// Top kitefile, nearest to the workdir
+func (m *XFileManager) Top() *XFile { return m.top }
+
+// FindFiles kitefile files from the dir and parent dirs. the nearest file is first.
+func (m *XFileManager) FindFiles(dir string) (files []string) {
+	findDir, _ := filepath.Abs(dir)
+	for level := 1; level <= m.MaxFindDepth; level++ {
+		if fPath := m.findInDir(findDir); fPath != "" {
+			files = append(files, fPath)
+		}
+
+		parent := filepath.Dir(findDir)
+		if parent == findDir {
+			break
+		}
+		findDir = parent
+	}
+
+	// search the extra dir on not found
+	if len(files) == 0 && m.ExtraFind != "" {
+		if fPath := m.findInDir(fsutil.ExpandHome(m.ExtraFind)); fPath != "" {
+			files = append(files, fPath)
+		}
+	}
+	return
+}
+
+// find one kitefile in the dir
+func (m *XFileManager) findInDir(dir string) string {
+	for _, name := range m.Filenames {
+		for _, ext := range append([]string{""}, m.FileExts...) {
+			fPath := filepath.Join(dir, name+ext)
+			if fsutil.IsFile(fPath) {
+				return fPath
+			}
+		}
+	}
+	return ""
+}
+
+// Load kitefile files from the dir and parent dirs. nearest file as top, others as parents.
+func (m *XFileManager) Load(dir string) error {
+	files := m.FindFiles(dir)
+	if len(files) == 0 {
+		return errorx.Rawf("not found kitefile(%s) in %s and parent dirs", strutil.JoinComma(m.Filenames), dir)
+	}
+	return m.LoadFiles(files...)
+}
+
+// LoadFiles load kitefile files, the first file is top, others as parents.
+func (m *XFileManager) LoadFiles(files ...string) error {
+	var child *XFile
+	for _, fPath := range files {
+		xf, err := LoadXFile(fPath)
+		if err != nil {
+			return err
+		}
+
+		if child == nil {
+			m.top = xf
+		} else {
+			child.parent = xf
+		}
+		child = xf
+	}
+
+	m.actions = m.top.MergedActions()
+	m.loaded = true
+	return nil
+}
+
+// Action get by name. will search in top and parent kitefile
+func (m *XFileManager) Action(name string) (*XAction, bool) {
+	act, ok := m.actions[name]
+	return act, ok
+}
+
+// ActionNames sorted list
+func (m *XFileManager) ActionNames() []string {
+	names := maputil.Keys(m.actions)
+	sort.Strings(names)
+	return names
+}
+
+// Actions merged from all kitefile
+func (m *XFileManager) Actions() map[string]*XAction { return m.actions }
+
+// DefaultAction name, will use the nearest setting.
+func (m *XFileManager) DefaultAction() string {
+	for xf := m.top; xf != nil; xf = xf.parent {
+		if xf.DefaultAction != "" {
+			return xf.DefaultAction
+		}
+	}
+	return ""
+}
+
 // XAction struct
 type XAction struct {
-	Name    string   `json:"name"`
-	Desc    string   `json:"desc"`
-	User    string   `json:"user"`
-	Workdir string   `json:"workdir"`
-	Cmds    []string `json:"cmds"`
+	// xf the kitefile of the action defined
+	xf *XFile
+
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+	// User TODO run as the user
+	User    string `json:"user"`
+	Workdir string `json:"workdir"`
+	// Type shell wrap for run commands. allow: sh, bash, zsh, cmd, pwsh
+	//  - default is empty, will direct run each command. works on Windows.
+	Type string `json:"type"`
+	// Vars for render commands. usage: $name, ${name}
+	Vars map[string]string `json:"vars"`
+	// Env append set for run the action. will merge XFile.Env
+	Env  map[string]string `json:"env"`
+	Cmds []string          `json:"cmds"`
+}
+
+func newXAction(name string, data maputil.Data) *XAction {
+	return &XAction{
+		Name:    name,
+		Desc:    data.StrOne("desc", "description"),
+		User:    data.Str("user"),
+		Type:    data.Str("type"),
+		Workdir: data.StrOne("workdir", "dir"),
+		Vars:    data.StringMap("vars"),
+		Env:     data.StringMap("env"),
+		Cmds:    data.StringsOne("cmds", "cmd", "run"),
+	}
 }
 
 type XFile struct {
 	parent *XFile
+	// includer the kitefile which includes current file
+	includer *XFile
 	// 当前 .kitefile 文件路径
 	filePath string
 	// mode: top_cfg, setting
@@ -52,7 +205,7 @@ type XFile struct {
 	Author  string `json:"author"`
 	// Homepage message
 	Homepage string `json:"homepage"`
-	// 包含/引用的公共定义文件
+	// 包含/引用的公共定义文件. 相对路径基于当前文件目录
 	Include []string
 	// Env setting for run
 	Env map[string]string
@@ -66,3 +219,172 @@ type XFile struct {
 	// Actions 定义
 	Actions map[string]*XAction `json:"actions"`
 }
+
+// LoadXFile from file path. will load include files.
+func LoadXFile(fPath string) (*XFile, error) {
+	return loadXFile(fPath, nil)
+}
+
+func loadXFile(fPath string, loading []string) (*XFile, error) {
+	fPath, _ = filepath.Abs(fPath)
+	if arrutil.StringsContains(loading, fPath) {
+		return nil, errorx.Rawf("kitefile %s has cycle include", fPath)
+	}
+
+	data, err := readFileData(fPath)
+	if err != nil {
+		return nil, errorx.Rf("load kitefile %s error: %v", fPath, err)
+	}
+
+	slog.Debugf("xfile: load kitefile %q", fPath)
+	xf := &XFile{filePath: fPath}
+	xf.loadData(data)
+
+	// load include files, current file settings first.
+	for _, incFile := range xf.Include {
+		incFile = fsutil.ExpandHome(incFile)
+		if !filepath.IsAbs(incFile) {
+			incFile = filepath.Join(xf.Dir(), incFile)
+		}
+
+		incXf, err := loadXFile(incFile, append(loading, fPath))
+		if err != nil {
+			return nil, err
+		}
+		incXf.includer = xf
+		xf.mergeFrom(incXf)
+	}
+	return xf, nil
+}
+
+// read file data, kitefile without ext will be as YAML.
+func readFileData(fPath string) (map[string]any, error) {
+	loader := config.New("xfile")
+	loader.AddDriver(yaml.Driver)
+	loader.AddDriver(toml.Driver)
+
+	var err error
+	if fsutil.FileExt(fPath) == "" {
+		err = loader.LoadFilesByFormat(config.Yaml, fPath)
+	} else {
+		err = loader.LoadFiles(fPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return loader.Data(), nil
+}
+
+func (xf *XFile) loadData(mp map[string]any) {
+	data := maputil.Data(mp)
+	xf.Mode = strutil.OrElse(data.Str(modeKey), ModeTopCfg)
+	xf.Actions = make(map[string]*XAction)
+
+	// mode=setting: the settings in "__setting", other top-level keys are actions.
+	if xf.Mode == ModeSetting {
+		for key, val := range mp {
+			if key == modeKey || key == settingKey {
+				continue
+			}
+			xf.addAction(key, val)
+		}
+		data = data.Sub(settingKey)
+	} else if actions, ok := data.Get("actions").(map[string]any); ok {
+		for name, val := range actions {
+			xf.addAction(name, val)
+		}
+	}
+
+	xf.Name = data.Str("name")
+	xf.Desc = data.StrOne("desc", "description")
+	xf.Version = data.Str("version")
+	xf.Author = data.Str("author")
+	xf.Homepage = data.Str("homepage")
+	xf.Include = data.StringsOne("include", "includes")
+	xf.Env = data.StringMap("env")
+	xf.DefaultAction = data.StrOne("default_action", "default_cmd")
+	xf.Context.Workdir = data.StrOne("workdir", "context.workdir")
+}
+
+// action config allow: string, string list, map
+func (xf *XFile) addAction(name string, val any) {
+	var act *XAction
+	switch typVal := val.(type) {
+	case map[string]any:
+		act = newXAction(name, typVal)
+	case []any:
+		act = &XAction{Name: name, Cmds: arrutil.SliceToStrings(typVal)}
+	default:
+		act = &XAction{Name: name, Cmds: []string{strutil.SafeString(val)}}
+	}
+
+	act.xf = xf
+	xf.Actions[name] = act
+}
+
+// merge settings and actions from include file. current file settings first.
+func (xf *XFile) mergeFrom(inc *XFile) {
+	xf.Env = maputil.MergeStrMap(xf.Env, inc.Env)
+	xf.DefaultAction = strutil.OrElse(xf.DefaultAction, inc.DefaultAction)
+
+	for name, act := range inc.Actions {
+		if _, ok := xf.Actions[name]; !ok {
+			xf.Actions[name] = act
+		}
+	}
+}
+
+// MergedActions from parent kitefile. child action will override parent.
+func (xf *XFile) MergedActions() map[string]*XAction {
+	actions := make(map[string]*XAction)
+	if xf.parent != nil {
+		actions = xf.parent.MergedActions()
+	}
+
+	for name, act := range xf.Actions {
+		actions[name] = act
+	}
+	return actions
+}
+
+// MergedEnv from parent kitefile. child env will override parent.
+//
+// an included kitefile will use the env of the file which includes it, so the actions from it inherit the parent env.
+func (xf *XFile) MergedEnv() map[string]string {
+	if xf.includer != nil {
+		return xf.includer.MergedEnv()
+	}
+
+	env := make(map[string]string)
+	if xf.parent != nil {
+		env = xf.parent.MergedEnv()
+	}
+
+	maps.Copy(env, xf.Env)
+	return env
+}
+
+// Parent kitefile
+func (xf *XFile) Parent() *XFile { return xf.parent }
+
+// FilePath of the kitefile
+func (xf *XFile) FilePath() string { return xf.filePath }
+
+// Dir of the kitefile
+func (xf *XFile) Dir() string { return filepath.Dir(xf.filePath) }
+
+// Workdir for run actions. default is the kitefile dir.
+func (xf *XFile) Workdir() string {
+	if xf.Context.Workdir == "" {
+		return xf.Dir()
+	}
+	return resolveDir(xf.Dir(), xf.Context.Workdir)
+}
+
+func resolveDir(baseDir, dir string) string {
+	dir = fsutil.ExpandHome(dir)
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(baseDir, dir)
+}
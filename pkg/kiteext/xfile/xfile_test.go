@@ -0,0 +1,102 @@
+package xfile_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/inhere/kite-go/pkg/kiteext/xfile"
+)
+
+func TestXFileManager_Load(t *testing.T) {
+	root := t.TempDir()
+	subDir := filepath.Join(root, "app", "sub")
+	assert.NoErr(t, os.MkdirAll(subDir, 0755))
+
+	writeFile(t, filepath.Join(root, "kitefile"), `
+name: root
+env: {APP_ENV: prod, OWNER: root}
+default_action: hello
+actions:
+  hello: echo hello from root
+  root-only:
+    desc: only in root
+    cmds: [echo root]
+`)
+	writeFile(t, filepath.Join(root, "app", "common.yml"), `
+env: {APP_ENV: common, LINT_OPT: -v}
+actions:
+  lint: echo lint ${APP_ENV} ${OWNER} ${LINT_OPT}
+  build: echo common build
+`)
+	writeFile(t, filepath.Join(root, "app", "kitefile.yml"), `
+name: app
+desc: the app kitefile
+include: [common.yml]
+env: {APP_ENV: dev}
+actions:
+  build:
+    desc: build the app
+    type: sh
+    vars: {name: kite}
+    cmds:
+      - echo "build $name $1 $APP_ENV $OWNER"
+      - test "$(basename $(pwd))" = app
+`)
+
+	m := xfile.NewXFile()
+	assert.NoErr(t, m.Load(subDir))
+	assert.Eq(t, "app", m.Top().Name)
+	assert.Eq(t, "root", m.Top().Parent().Name)
+	assert.Eq(t, []string{"build", "hello", "lint", "root-only"}, m.ActionNames())
+	assert.Eq(t, "hello", m.DefaultAction())
+	assert.Eq(t, "prod", m.Top().Parent().MergedEnv()["APP_ENV"])
+
+	act, ok := m.Action("build")
+	assert.True(t, ok)
+	assert.Eq(t, "build the app", act.Desc)
+	assert.StrContains(t, m.HelpText(), "only in root")
+
+	if _, err := os.Stat("/bin/sh"); err == nil {
+		buf := new(bytes.Buffer)
+		err = m.RunAction("build", []string{"v1"}, &xfile.RunOpts{Stdout: buf, Stderr: buf})
+		assert.NoErr(t, err)
+		assert.StrContains(t, buf.String(), "build kite v1 dev root")
+	}
+
+	buf := new(bytes.Buffer)
+	assert.NoErr(t, m.RunAction("", nil, &xfile.RunOpts{DryRun: true, Stdout: buf}))
+	assert.StrContains(t, buf.String(), "echo hello from root")
+	assert.Err(t, m.RunAction("not-exists", nil, nil))
+
+	// the included action inherit the parent env
+	buf.Reset()
+	assert.NoErr(t, m.RunAction("lint", nil, &xfile.RunOpts{DryRun: true, Stdout: buf}))
+	assert.StrContains(t, buf.String(), "echo lint dev root -v")
+}
+
+func TestLoadXFile_settingMode(t *testing.T) {
+	fPath := filepath.Join(t.TempDir(), "kitefile")
+	writeFile(t, fPath, `
+__xfile_mode: setting
+__setting:
+  desc: setting mode file
+  default_cmd: build
+build:
+  cmds: echo build
+test: [echo test1, echo test2]
+`)
+
+	xf, err := xfile.LoadXFile(fPath)
+	assert.NoErr(t, err)
+	assert.Eq(t, "setting mode file", xf.Desc)
+	assert.Eq(t, "build", xf.DefaultAction)
+	assert.Len(t, xf.Actions, 2)
+	assert.Eq(t, []string{"echo test1", "echo test2"}, xf.Actions["test"].Cmds)
+}
+
+func writeFile(t *testing.T, fPath, contents string) {
+	assert.NoErr(t, os.WriteFile(fPath, []byte(contents), 0644))
+}
@@ -55,6 +55,8 @@ func NewRunner(fns ...func(kr *Runner)) *Runner {
 		AutoTaskFiles: DefaultTaskFiles,
 		AutoTaskExts:  DefaultDefineExts,
 		AutoMaxDepth:  6,
+		AutoTaskfile:  true,
+		StateDir:      "$data/task-states",
 	}
 
 	for _, fn := range fns {
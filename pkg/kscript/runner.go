@@ -3,6 +3,8 @@ package kscript
 import (
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/gookit/config/v2"
 	"github.com/gookit/config/v2/ini"
@@ -16,6 +18,7 @@ import (
 	"github.com/gookit/goutil/mathutil"
 	"github.com/gookit/goutil/strutil"
 	"github.com/gookit/goutil/sysutil"
+	"github.com/gookit/goutil/x/ccolor"
 	"github.com/gookit/slog"
 )
 
@@ -69,6 +72,8 @@ type Runner struct {
 	AutoTaskExts []string `json:"auto_task_exts"`
 	// auto 向上搜索目录最大深度，默认为 6. 找到第一个匹配的就停止
 	AutoMaxDepth int `json:"auto_max_depth"`
+	// AutoTaskfile 目录中没有 AutoTaskFiles 时，自动查找并加载 Taskfile.dev 文件. see TaskfileNames
+	AutoTaskfile bool `json:"auto_taskfile"`
 
 	// Scripts 通过配置定义的各种简单的任务命令。tasks config and loaded from DefineFiles.
 	//
@@ -101,6 +106,13 @@ type Runner struct {
 	//  - group: map[string]map[string]string grouped var map.
 	taskSettings TaskSettings
 
+	// StateDir for save task run states. eg: sources fingerprint for check up-to-date
+	//  - each task workdir has a state file in the dir.
+	StateDir string `json:"state_dir"`
+	// loaded task states, key is the task workdir
+	states  map[string]*taskStates
+	stateMu sync.Mutex
+
 	// ------------------------ config for script file --------------------
 
 	// ScriptDirs 独立的 script file 文件查找目录。例如 bash, python, php 等脚本文件
@@ -173,12 +185,11 @@ func (r *Runner) LoadScriptTasks() (err error) {
 		}
 
 		slog.Debugf("load script task file %q", fPath)
-		err = loader.LoadFiles(fPath)
+		loaded, err := r.loadTaskFile(loader, fPath)
 		if err != nil {
 			return errorx.Errorf("load task file %q error: %s", fPath, err)
 		}
 
-		loaded := loader.Data()
 		r.globalScripts = maputil.SimpleMerge(loaded, r.globalScripts)
 		r.Scripts = maputil.SimpleMerge(loaded, r.Scripts)
 		loader.ClearData()
@@ -187,12 +198,11 @@ func (r *Runner) LoadScriptTasks() (err error) {
 	// 从工作目录/父级目录自动加载
 	if fPaths := r.findAutoTaskFiles(); len(fPaths) > 0 {
 		for _, fPath := range fPaths {
-			err = loader.LoadFiles(fPath)
+			loaded, err := r.loadTaskFile(loader, fPath)
 			if err != nil {
 				return errorx.Wrapf(err, "load auto task file %q error: %s", fPath, err)
 			}
 
-			loaded := loader.Data()
 			r.projectScripts = maputil.SimpleMerge(loaded, r.projectScripts)
 			r.Scripts = maputil.SimpleMerge(loaded, r.Scripts)
 			loader.ClearData()
@@ -212,6 +222,26 @@ func (r *Runner) LoadScriptTasks() (err error) {
 	return nil
 }
 
+// load task file data. Taskfile.dev file will be converted to script tasks.
+func (r *Runner) loadTaskFile(loader *config.Config, fPath string) (map[string]any, error) {
+	if IsTaskfile(fPath) {
+		tf, err := LoadTaskfile(fPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tf.Unsupported) > 0 {
+			ccolor.Warnf("WARN: Taskfile %s has unsupported settings(ignored): %s\n", fPath, strings.Join(tf.Unsupported, ", "))
+		}
+		return tf.Tasks, nil
+	}
+
+	if err := loader.LoadFiles(fPath); err != nil {
+		return nil, err
+	}
+	return loader.Data(), nil
+}
+
 // 从工作目录/父级目录自动查找 task 定义文件,向上层级越高的文件在前面(先加载)
 func (r *Runner) findAutoTaskFiles() (ss []string) {
 	findDir := sysutil.Workdir()
@@ -237,6 +267,18 @@ func (r *Runner) findAutoTaskFiles() (ss []string) {
 			}
 		}
 
+		// fallback find Taskfile.dev file
+		if !founded && r.AutoTaskfile {
+			for _, fName := range TaskfileNames {
+				fPath := findDir + "/" + fName
+				if fsutil.IsFile(fPath) {
+					slog.Debugf("found Taskfile %q", fPath)
+					ss = append(ss, fPath)
+					break
+				}
+			}
+		}
+
 		if findLevel >= r.AutoMaxDepth {
 			break
 		}
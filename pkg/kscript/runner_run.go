@@ -1,10 +1,19 @@
 package kscript
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/gookit/cliui/show"
+	"github.com/gookit/goutil/arrutil"
+	"github.com/gookit/goutil/cliutil/cmdline"
 	"github.com/gookit/goutil/errorx"
 	"github.com/gookit/goutil/fsutil"
 	"github.com/gookit/goutil/maputil"
@@ -107,12 +116,37 @@ func (r *Runner) RunScriptTask(name string, args []string, ctx *RunCtx) error {
 	return errorx.Rawf("script task %q is not exists", name)
 }
 
+// run the script task and its deps. each dep task will run at most once per invocation.
 func (r *Runner) runScriptTask(st *ScriptTask, inArgs []string, ctx *RunCtx) error {
+	if ctx.graph != nil {
+		return ctx.graph.runOnce(ctx.runKey(st.Name), func() error {
+			return r.execScriptTask(st, inArgs, ctx)
+		})
+	}
+
+	// top-level task: build the dependency graph and check cycle.
+	ctx.graph = newTaskGraph(ctx.Jobs)
+	defer func() { ctx.graph = nil }()
+
+	if err := ctx.graph.checkCycle(r, st); err != nil {
+		return err
+	}
+	return ctx.graph.runOnce(ctx.runKey(st.Name), func() error {
+		return r.execScriptTask(st, inArgs, ctx)
+	})
+}
+
+func (r *Runner) execScriptTask(st *ScriptTask, inArgs []string, ctx *RunCtx) error {
 	ctx.ScriptType = TypeTask
 	if ctx.BeforeFn != nil {
 		ctx.BeforeFn(st, ctx)
 	}
 
+	if !st.MatchPlatform() {
+		ccolor.Cyanf("SKIP: task %s, current platform %s/%s not in %v\n", st.Name, runtime.GOOS, runtime.GOARCH, st.Platform)
+		return nil
+	}
+
 	cmdLn := len(st.Cmds)
 	if cmdLn == 0 {
 		return errorx.Rawf("empty cmd config for script task %q", ctx.Name)
@@ -150,6 +184,8 @@ func (r *Runner) runScriptTask(st *ScriptTask, inArgs []string, ctx *RunCtx) err
 		vars["dirname"] = fsutil.Name(workdir)
 	}
 
+	ctx.lazyVars.workdir = workdir
+
 	// append args to vars
 	ctx.AppendArgsToVars(vars)
 	ccolor.Magentaln("CURRENT DIR:", sysutil.Workdir())
@@ -157,88 +193,432 @@ func (r *Runner) runScriptTask(st *ScriptTask, inArgs []string, ctx *RunCtx) err
 		show.AList("Task Vars", vars)
 	}
 
-	// 先执行 deps 任务
+	// 先执行 deps 任务. 无相互依赖的任务可以并行执行
 	if len(st.Deps) > 0 {
-		for _, depTask := range st.Deps {
-			ccolor.Magentaln("Run Depends Task:", depTask)
+		if err = r.runTaskDeps(st, inArgs, vars, ctx); err != nil {
+			return err
+		}
+	}
 
-			dst, err1 := r.LoadScriptTaskInfo(depTask)
-			if err1 != nil {
-				return errorx.Rf("task %s: load dep task %q info fail: %v", st.Name, depTask, err)
-			}
-			if dst == nil {
-				return errorx.Rawf("task %s: the dep task %q not found", st.Name, depTask)
-			}
+	// check the task if condition
+	if st.If != "" {
+		ok, err1 := r.checkIfCond(st.If, vars, workdir, envMap, ctx)
+		if err1 != nil {
+			return errorx.Rf("task %s: %v", st.Name, err1)
+		}
+		if !ok {
+			ccolor.Cyanf("SKIP: task %s, the if condition %q is false\n", st.Name, st.If)
+			return nil
+		}
+	}
 
-			// 递归执行依赖任务
-			if err2 := r.runScriptTask(dst, inArgs, ctx); err2 != nil {
-				return err2
-			}
+	// task timeout control
+	runCtx := context.Background()
+	if st.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, st.Timeout)
+		defer cancel()
+	}
+
+	tr := &taskRun{
+		st:      st,
+		ctx:     ctx,
+		vars:    vars,
+		shell:   shell,
+		workdir: workdir,
+		envMap:  envMap,
+		runCtx:  runCtx,
+		inArgs:  inArgs,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+	}
+
+	// add task name prefix for each output line on parallel run
+	if ctx.graph.parallel() {
+		tr.stdout = ctx.graph.newPrefixWriter(os.Stdout, st.Name)
+		tr.stderr = ctx.graph.newPrefixWriter(os.Stderr, st.Name)
+	}
+
+	// check the task is up-to-date by sources or status
+	var fingerprint string
+	if !ctx.Force {
+		var upToDate bool
+		upToDate, fingerprint, err = r.checkUpToDate(st, tr)
+		if err != nil {
+			return errorx.Rf("task %s: check up-to-date error: %v", st.Name, err)
+		}
+		if upToDate {
+			ccolor.Cyanf("SKIP: task %s is up-to-date\n", st.Name)
+			return nil
 		}
 	}
 
 	startTime := timex.Now().T()
-	showIndex := cmdLn > 1 && !ctx.Silent
+	if st.For != nil {
+		if err = r.runTaskForLoop(tr); err != nil {
+			return err
+		}
+	} else if err = r.runTaskCmds(tr); err != nil {
+		return err
+	}
+
+	// save sources fingerprint on run success
+	if len(st.Sources) > 0 && !ctx.DryRun {
+		if fingerprint == "" {
+			fingerprint, err = r.buildFingerprint(st, tr)
+		}
+		if err == nil {
+			err = r.saveFingerprint(st, tr, fingerprint)
+		}
+		if err != nil {
+			ccolor.Warnf("WARN: task %s save sources fingerprint error: %v\n", st.Name, err)
+		}
+	}
+
+	ccolor.Infof(" ✅  Task %s: all task commands done. Take time: %s\n", st.Name, timex.Now().Diff(startTime))
+	return nil
+}
 
-	// exec each command
-	for idx, tc := range st.Cmds {
+// exec each command of the task
+func (r *Runner) runTaskCmds(tr *taskRun) error {
+	showIndex := len(tr.st.Cmds) > 1 && !tr.ctx.Silent
+
+	for idx, tc := range tr.st.Cmds {
 		if len(tc.Run) == 0 {
 			continue
 		}
 
-		// redirect runs another task
-		if tc.isRef {
-			name := tc.Run
-			ccolor.Magentaln("Run Refer Task:", name)
-			osi, err1 := r.LoadScriptTaskInfo(name)
-			if err1 != nil {
-				return err1
-			}
-			if osi == nil {
-				return errorx.Rawf("task %q: reference script task %q not found", st.Name, name)
+		if showIndex && !tc.isRef {
+			fmt.Printf("--------------------------- task command #%d ---------------------------\n", idx+1)
+		}
+		if err := r.runTaskCmd(tc, tr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run the task commands for each item of the ScriptTask.For
+func (r *Runner) runTaskForLoop(tr *taskRun) error {
+	st, ctx := tr.st, tr.ctx
+	items, err := r.resolveForItems(st.For, tr)
+	if err != nil {
+		return errorx.Rf("task %s: resolve for items error: %v", st.Name, err)
+	}
+
+	varName := strutil.OrElse(st.For.Var, "item")
+	results := make([]error, len(items))
+	durations := make([]time.Duration, len(items))
+
+	for i, item := range items {
+		if !ctx.Silent {
+			ccolor.Magentaf("------------------ task %s loop #%d: %s=%s ------------------\n", st.Name, i+1, varName, item)
+		}
+
+		// each iteration use a copy of the vars
+		itr := *tr
+		itr.cmds = nil
+		itr.vars = maputil.Merge1level(tr.vars, map[string]any{
+			varName:     item,
+			"for_index": i,
+		})
+
+		start := time.Now()
+		results[i] = r.runTaskCmds(&itr)
+		durations[i] = time.Since(start)
+	}
+
+	// print summary for each iteration
+	var failNum int
+	ccolor.Magentaf("Task %s loop summary(total %d):\n", st.Name, len(items))
+	for i, item := range items {
+		if results[i] == nil {
+			ccolor.Printf("  #%d %s=%s <green>OK</> (%s)\n", i+1, varName, item, durations[i])
+			continue
+		}
+
+		failNum++
+		ccolor.Printf("  #%d %s=%s <red>FAIL</> (%s) %v\n", i+1, varName, item, durations[i], results[i])
+	}
+
+	if failNum > 0 {
+		return errorx.Rawf("task %s: %d of %d loop iterations failed", st.Name, failNum, len(items))
+	}
+	return nil
+}
+
+// resolve the items for loop. items from static list or dynamic source.
+func (r *Runner) resolveForItems(tf *TaskFor, tr *taskRun) (items []string, err error) {
+	for _, item := range tf.Items {
+		items = append(items, r.renderTaskVars(item, tr.vars, tr.ctx))
+	}
+	if tf.Source == "" {
+		return items, nil
+	}
+
+	typ, line := tf.SourceType()
+	if !tf.IsValidSourceType(typ) {
+		return nil, errorx.Rawf("task %s: invalid for source type %q", tr.st.Name, typ)
+	}
+	line = r.renderTaskVars(line, tr.vars, tr.ctx)
+
+	switch typ {
+	case "glob":
+		pattern := line
+		if tr.workdir != "" && !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(tr.workdir, pattern)
+		}
+
+		matches, err1 := filepath.Glob(pattern)
+		if err1 != nil {
+			return nil, err1
+		}
+		for _, match := range matches {
+			if tr.workdir != "" && !filepath.IsAbs(line) {
+				match, _ = filepath.Rel(tr.workdir, match)
 			}
+			items = append(items, match)
+		}
+	case "split":
+		items = append(items, strutil.SplitTrimmed(line, strutil.OrElse(tf.Sep, ","))...)
+	default: // shell command, each line as an item
+		shell := strutil.OrElse(typ, tr.shell)
+		out, err1 := newShellCmd(tr.runCtx, strutil.OrElse(shell, "sh"), line).
+			WorkDirOnNE(tr.workdir).
+			AppendEnv(tr.envMap).
+			Output()
+		if err1 != nil {
+			return nil, err1
+		}
 
-			// 递归执行依赖任务
-			if err2 := r.runScriptTask(osi, inArgs, ctx); err2 != nil {
-				return err2
+		for _, ln := range strings.Split(out, "\n") {
+			if ln = strings.TrimSpace(ln); ln != "" {
+				items = append(items, ln)
 			}
-			continue
 		}
+	}
+	return items, nil
+}
+
+// taskRun context data for run one script task commands
+type taskRun struct {
+	st  *ScriptTask
+	ctx *RunCtx
+	// vars for render command line
+	vars    map[string]any
+	shell   string
+	workdir string
+	envMap  map[string]string
+	inArgs  []string
+	// runCtx for control the task timeout
+	runCtx context.Context
+	// collected command results. access: ${cmds.<name>.result}
+	cmds map[string]any
+	// output writers for run commands
+	stdout, stderr io.Writer
+}
+
+// add collected command result to vars
+func (tr *taskRun) addCmdResult(tc *TaskCmd) {
+	if tr.cmds == nil {
+		tr.cmds = make(map[string]any)
+		tr.vars["cmds"] = tr.cmds
+	}
 
-		// 加载 command 独有的变量
-		if err1 := tc.appendVars(vars); err1 != nil {
-			return err1
+	tr.cmds[tc.Name] = map[string]any{
+		"output": tc.Result.Text(),
+		"result": tc.Result.Value(),
+	}
+}
+
+// run one command of the script task
+func (r *Runner) runTaskCmd(tc *TaskCmd, tr *taskRun) error {
+	st, ctx, vars := tr.st, tr.ctx, tr.vars
+
+	// redirect runs another task
+	if tc.isRef {
+		name := tc.Run
+		ccolor.Magentaln("Run Refer Task:", name)
+		osi, err := r.LoadScriptTaskInfo(name)
+		if err != nil {
+			return err
+		}
+		if osi == nil {
+			return errorx.Rawf("task %q: reference script task %q not found", st.Name, name)
 		}
 
-		line := r.renderTaskVars(tc.Run, vars, ctx)
-		// workdir for cmd
-		cmdDir := strutil.OrElse(tc.Workdir, workdir)
-		if strutil.ContainsByte(cmdDir, '$') {
-			cmdDir = r.renderTaskVars(cmdDir, vars, ctx)
-		} else {
-			cmdDir = sysutil.ExpandHome(cmdDir)
+		// 递归执行依赖任务. const vars of the command will pass to the refer task
+		var callVars map[string]string
+		for key, v := range tc.Vars {
+			if !v.IsDynamic() {
+				if callVars == nil {
+					callVars = make(map[string]string, len(tc.Vars))
+				}
+				callVars[key] = r.renderTaskVars(v.Value, vars, ctx)
+			}
 		}
-		vars["workdir"] = cmdDir
-		vars["dirname"] = fsutil.Name(cmdDir)
+		// NOTE: explicit refer always run, eg: in the for loop or refer same task multi times.
+		// only deps of the task will run at most once per invocation.
+		refCtx := ctx.forkWithVars(name, callVars)
+		if refCtx.graph == nil {
+			return r.runScriptTask(osi, tr.inArgs, refCtx)
+		}
+		return r.execScriptTask(osi, tr.inArgs, refCtx)
+	}
 
-		var cmd *cmdr.Cmd
-		if shell != "" {
-			cmd = cmdr.NewCmd(shell, "-c", line)
-		} else {
-			cmd = cmdr.NewCmdline(line)
+	// 加载 command 独有的变量
+	tc.appendVars(vars, ctx.lazyVars)
+
+	// workdir for cmd
+	cmdDir := strutil.OrElse(tc.Workdir, tr.workdir)
+	if strutil.ContainsByte(cmdDir, '$') {
+		cmdDir = r.renderTaskVars(cmdDir, vars, ctx)
+	} else {
+		cmdDir = sysutil.ExpandHome(cmdDir)
+	}
+	vars["workdir"] = cmdDir
+	vars["dirname"] = fsutil.Name(cmdDir)
+
+	// check the command if condition
+	if tc.If != "" {
+		ok, err := r.checkIfCond(tc.If, vars, cmdDir, tr.envMap, ctx)
+		if err != nil {
+			return errorx.Rf("task %s command#%d: %v", st.Name, tc.index, err)
 		}
+		if !ok {
+			ccolor.Cyanf("SKIP: task %s command#%d, the if condition %q is false\n", st.Name, tc.index, tc.If)
+			return nil
+		}
+	}
 
-		if showIndex {
-			fmt.Printf("--------------------------- task command #%d ---------------------------\n", idx+1)
+	// command timeout control
+	cmdCtx := tr.runCtx
+	timeout := tc.Timeout
+	if timeout <= 0 {
+		timeout = st.CmdTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(cmdCtx, timeout)
+		defer cancel()
+	}
+
+	line := r.renderTaskVars(tc.Run, vars, ctx)
+	if err := ctx.lazyVars.Err(); err != nil {
+		return errorx.Rf("task %s command#%d: %v", st.Name, tc.index, err)
+	}
+	shell := strutil.OrElse(tc.Type, tr.shell)
+	cmd := newShellCmd(cmdCtx, shell, line).WorkDirOnNE(cmdDir).WithDryRun(ctx.DryRun).AppendEnv(tr.envMap)
+	if !tc.Silent {
+		cmd.PrintCmdline2()
+	}
+
+	var outBuf *bytes.Buffer
+	switch tc.Output {
+	case OutputCollect:
+		outBuf = new(bytes.Buffer)
+		cmd.WithOutput(outBuf, tr.stderr)
+	case OutputDiscard:
+		cmd.WithOutput(io.Discard, io.Discard)
+	case OutputStderr:
+		cmd.WithOutput(tr.stderr, tr.stderr)
+	case OutputBoth:
+		cmd.WithOutput(tr.stdout, tr.stdout)
+	default:
+		cmd.WithOutput(tr.stdout, tr.stderr)
+	}
+
+	// limit the max parallel running commands
+	ctx.graph.acquire()
+	err := cmd.Run()
+	ctx.graph.release()
+	flushWriters(tr.stdout, tr.stderr)
+	if err != nil && cmdCtx.Err() == context.DeadlineExceeded {
+		err = errorx.Rf("task %s command#%d: run timeout, %v", st.Name, tc.index, err)
+	}
+
+	// collect output to result and can be used by later commands
+	if outBuf != nil {
+		if err1 := tc.Result.setOutput(outBuf.String(), tc.ResultType); err1 != nil && err == nil && !ctx.DryRun {
+			err = errorx.Rf("task %s command %s: %v", st.Name, tc.Name, err1)
 		}
-		err2 := cmd.WorkDirOnNE(cmdDir).WithDryRun(ctx.DryRun).AppendEnv(envMap).PrintCmdline2().FlushRun()
-		if err2 != nil {
-			return err2
+		tr.addCmdResult(tc)
+		if ctx.Verbose {
+			ccolor.Cyanf("Command %s result(%s): %v\n", tc.Name, tc.Result.Type, tc.Result.Value())
 		}
 	}
+	if err == nil {
+		return nil
+	}
 
-	ccolor.Infof(" ✅  Task %s: all task commands done. Take time: %s\n", st.Name, timex.Now().Diff(startTime))
-	return nil
+	if tc.FailMsg != "" {
+		ccolor.Errorln(r.renderTaskVars(tc.FailMsg, vars, ctx))
+	}
+	if tc.IgnoreErr {
+		ccolor.Warnf("WARN: task %s command#%d run fail, ignored. error: %v\n", st.Name, tc.index, err)
+		return nil
+	}
+	return err
+}
+
+// check the if condition for task or command.
+//
+//   - shell command condition, exit code 0 is true. eg: "sh:test -f .env"
+//   - expr-lang expression condition. eg: `os == "linux"`
+func (r *Runner) checkIfCond(cond string, vars map[string]any, dir string, envMap map[string]string, ctx *RunCtx) (bool, error) {
+	cond = strings.TrimSpace(cond)
+	if pos := strings.IndexByte(cond, ':'); pos > 1 {
+		typ := strings.TrimPrefix(cond[:pos], "@")
+		if arrutil.StringsContains(AllowTypes, typ) {
+			line := r.renderTaskVars(strings.TrimSpace(cond[pos+1:]), vars, ctx)
+			err := cmdr.NewCmd(typ, "-c", line).WorkDirOnNE(dir).AppendEnv(envMap).Run()
+			return err == nil, nil
+		}
+	}
+
+	ok, err := evalBoolExpr(cond, ctx.lazyVars.DataFor(cond, vars))
+	if err == nil {
+		err = ctx.lazyVars.Err()
+	}
+	return ok, err
+}
+
+// newShellCmd create command with context. if shell is empty, will direct run the command line.
+func newShellCmd(cmdCtx context.Context, shell, line string) *cmdr.Cmd {
+	if shell != "" {
+		return cmdr.CmdWithCtx(cmdCtx, shell, "-c", line)
+	}
+
+	bin, args := cmdline.NewParser(line).WithParseEnv().BinAndArgs()
+	return cmdr.CmdWithCtx(cmdCtx, bin, args...)
+}
+
+// ResolveTaskVars resolve all vars of the script task, includes dynamic vars.
+// use for show the task information before run it.
+func (r *Runner) ResolveTaskVars(st *ScriptTask, ctx *RunCtx) (map[string]any, error) {
+	ctx = EnsureCtx(ctx).WithName(st.Name)
+	vars, err := r.buildTaskRenderVars(st, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.MergeEnv(r.taskSettings.Env, st.Env)
+	workdir := strutil.OrElse(ctx.Workdir, st.Workdir)
+	if strutil.ContainsByte(workdir, '$') {
+		workdir = r.renderTaskVars(workdir, vars, ctx)
+	}
+	ctx.lazyVars.workdir = workdir
+
+	values, err := ctx.lazyVars.ResolveAll()
+	result := make(map[string]any, len(st.Vars))
+	for name := range st.Vars {
+		if val, ok := values[name]; ok {
+			result[name] = val
+		} else {
+			result[name] = vars[name]
+		}
+	}
+	return result, err
 }
 
 func (r *Runner) buildTaskRenderVars(st *ScriptTask, ctx *RunCtx) (map[string]any, error) {
@@ -248,18 +628,25 @@ func (r *Runner) buildTaskRenderVars(st *ScriptTask, ctx *RunCtx) (map[string]an
 		"dirname": "",
 	}
 
-	// st.Vars 需要支持动态变量
-	stVars, err := st.resolveDynVars(st.Vars)
-	if err != nil {
-		return nil, err
+	// st.Vars 支持动态变量, 在被引用时才会解析并缓存结果
+	vr := newVarResolver(r, ctx, data)
+	dynVars := make(map[string]*Variable)
+	for name, v := range st.Vars {
+		if v.IsDynamic() {
+			dynVars[name] = v
+		} else {
+			data[name] = v.Value
+		}
 	}
 
-	// 当前task配置的和ctx输入变量，放在顶级直接访问
-	topVars := maputil.MergeStrMap(ctx.Vars, stVars)
-	for k, v := range topVars {
+	// ctx输入变量 优先级更高，放在顶级直接访问
+	for k, v := range ctx.Vars {
 		data[k] = v
+		delete(dynVars, k)
 	}
-	// dump.P(ctx, topVars)
+
+	vr.add(dynVars)
+	ctx.lazyVars = vr
 
 	// 内置扩展变量
 	tn := time.Now()
@@ -282,16 +669,19 @@ func (r *Runner) buildTaskRenderVars(st *ScriptTask, ctx *RunCtx) (map[string]an
 	return data, nil
 }
 
-// 使用简单的模板渲染，支持链式语法变量替换，环境变量，默认值等 - 无法同时支持 $var ${var_name}
-// var rpl = textutil.NewVarReplacer("$").WithParseEnv().WithParseDefault()
-// 专门实现的类似 php, shell 的字符串表达式处理
-var rpl = textutil.NewStrVarRenderer()
-
-// process vars and env
+// process vars and env.
+//
+// 使用专门实现的类似 php, shell 的字符串表达式处理. 每次新建实例，允许并行执行任务
 func (r *Runner) renderTaskVars(line string, vars map[string]any, ctx *RunCtx) string {
 	envs := ctx.FullEnv()
+	rpl := textutil.NewStrVarRenderer()
 
 	rpl.SetGetter(func(name string) (val string, ok bool) {
+		// dynamic variable, resolve on referenced
+		if val, ok = ctx.lazyVars.Get(name); ok {
+			return val, true
+		}
+
 		// eg: $SHELL -> name=SHELL
 		if r.ParseEnv && strutil.IsEnvName(name) {
 			if val, ok = envs[name]; ok {
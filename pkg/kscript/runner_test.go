@@ -1,14 +1,385 @@
 package kscript
 
-import "testing"
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
 
-func TestIfExpr(t *testing.T) {
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/testutil/assert"
+)
 
+func TestIfExpr(t *testing.T) {
 	st := &ScriptTask{
 		If: "",
 	}
 
-	st.resolveIfExpr(map[string]any{
+	ok, err := st.resolveIfExpr(map[string]any{
 		"make": true,
 	})
+	assert.NoErr(t, err)
+	assert.True(t, ok)
+
+	st.If = `make && env == "dev"`
+	ok, err = st.resolveIfExpr(map[string]any{"make": true, "env": "prod"})
+	assert.NoErr(t, err)
+	assert.False(t, ok)
+
+	st.If = "make &&"
+	_, err = st.resolveIfExpr(map[string]any{"make": true})
+	assert.Err(t, err)
+}
+
+func TestRunner_runScriptTask_cmdOptions(t *testing.T) {
+	r := NewRunner(func(kr *Runner) {
+		kr.Scripts = map[string]any{
+			"demo": map[string]any{
+				"type": "sh",
+				"vars": map[string]any{"env": "dev"},
+				"cmds": []any{
+					map[string]any{"run": "exit 1", "ignore_err": true, "fail_msg": "fail on $env"},
+					map[string]any{"run": "exit 2", "if": `env == "prod"`},
+					"@exit 3",
+					map[string]any{"run": "sleep 1", "timeout": "50ms", "ignore_err": true},
+				},
+			},
+			"fail": map[string]any{
+				"type": "sh",
+				"cmds": []any{
+					map[string]any{"run": "sleep 1", "timeout": "50ms"},
+				},
+			},
+		}
+		kr.taskLoaded = true
+		kr.fileLoaded = true
+		kr.appLoaded = true
+	})
+
+	err := r.RunScriptTask("demo", nil, &RunCtx{Silent: true})
+	assert.NoErr(t, err)
+
+	err = r.RunScriptTask("fail", nil, &RunCtx{Silent: true})
+	assert.Err(t, err)
+	assert.ErrSubMsg(t, err, "timeout")
+}
+
+func TestRunner_runScriptTask_collectOutput(t *testing.T) {
+	r := NewRunner(func(kr *Runner) {
+		kr.Scripts = map[string]any{
+			"release": map[string]any{
+				"type": "sh",
+				"cmds": []any{
+					map[string]any{"name": "ver", "run": `echo '{"version": "1.2.3"}'`, "output": "collect", "result_type": "json"},
+					map[string]any{"name": "tag", "run": "echo v1.2.3", "output": "collect"},
+					`test "${cmds.ver.result.version}" = "1.2.3"`,
+					`test "${cmds.tag.result}" = "v1.2.3"`,
+				},
+			},
+		}
+		kr.taskLoaded = true
+		kr.fileLoaded = true
+		kr.appLoaded = true
+	})
+
+	err := r.RunScriptTask("release", nil, &RunCtx{Silent: true})
+	assert.NoErr(t, err)
+
+	cr := &CmdResult{}
+	assert.NoErr(t, cr.setOutput("name,age\ntom,23\n", "csv"))
+	rows := cr.Value().([]map[string]any)
+	assert.Eq(t, "tom", rows[0]["name"])
+	assert.Err(t, cr.setOutput("{invalid", "json"))
+}
+
+func TestRunner_runScriptTask_forLoop(t *testing.T) {
+	r := NewRunner(func(kr *Runner) {
+		kr.Scripts = map[string]any{
+			"loop": map[string]any{
+				"type": "sh",
+				"for":  map[string]any{"var": "svc", "items": []any{"api", "worker"}},
+				"cmds": `test "$svc" = api -o "$svc" = worker`,
+			},
+			"loop-split": map[string]any{
+				"type": "sh",
+				"vars": map[string]any{"names": "a;b;c"},
+				"for":  map[string]any{"items": "@split: ${names}", "sep": ";"},
+				"cmds": `test "$item" != b`,
+			},
+			"loop-sh": map[string]any{
+				"type": "sh",
+				"for":  "@sh: printf 'x\\ny\\n'",
+				"cmds": `test "$item" = x -o "$item" = y`,
+			},
+		}
+		kr.taskLoaded = true
+		kr.fileLoaded = true
+		kr.appLoaded = true
+	})
+
+	err := r.RunScriptTask("loop", nil, &RunCtx{Silent: true})
+	assert.NoErr(t, err)
+
+	err = r.RunScriptTask("loop-split", nil, &RunCtx{Silent: true})
+	assert.Err(t, err)
+	assert.ErrSubMsg(t, err, "1 of 3 loop iterations failed")
+
+	err = r.RunScriptTask("loop-sh", nil, &RunCtx{Silent: true})
+	assert.NoErr(t, err)
+
+	// unknown source type
+	r.Scripts["loop-bad"] = map[string]any{"for": "@foo: bar", "cmds": "echo $item"}
+	err = r.RunScriptTask("loop-bad", nil, &RunCtx{Silent: true})
+	assert.ErrSubMsg(t, err, `invalid for source type "foo"`)
+}
+
+func TestRunner_runScriptTask_depsGraph(t *testing.T) {
+	logFile := t.TempDir() + "/run.log"
+	r := NewRunner(func(kr *Runner) {
+		kr.Scripts = map[string]any{
+			"app": map[string]any{
+				"type": "sh",
+				"deps": []any{"api", "web"},
+				"cmds": "echo app >> " + logFile,
+			},
+			"api":   map[string]any{"type": "sh", "deps": "gen", "cmds": "echo api >> " + logFile},
+			"web":   map[string]any{"type": "sh", "deps": "gen", "cmds": []any{"echo web >> " + logFile, "@task: gen"}},
+			"gen":   map[string]any{"type": "sh", "cmds": "echo gen >> " + logFile},
+			"loop":  map[string]any{"deps": "loop2", "cmds": "echo loop"},
+			"loop2": map[string]any{"cmds": []any{"echo loop2", "@task: loop"}},
+		}
+		kr.taskLoaded = true
+		kr.fileLoaded = true
+		kr.appLoaded = true
+	})
+
+	for _, jobs := range []int{1, 4} {
+		assert.NoErr(t, os.WriteFile(logFile, nil, 0644))
+		err := r.RunScriptTask("app", nil, &RunCtx{Silent: true, Jobs: jobs})
+		assert.NoErr(t, err)
+
+		// gen: run once as deps, and run again by "@task: gen" in web
+		lines := strings.Fields(string(fsutil.MustReadFile(logFile)))
+		assert.Len(t, lines, 5)
+		assert.Eq(t, "gen", lines[0])
+		assert.Eq(t, 2, strings.Count(string(fsutil.MustReadFile(logFile)), "gen"))
+		assert.Eq(t, "app", lines[4])
+	}
+
+	err := r.RunScriptTask("loop", nil, &RunCtx{Silent: true})
+	assert.Err(t, err)
+	assert.ErrSubMsg(t, err, "cycle detected: loop -> loop2 -> loop")
+}
+
+func TestRunner_runScriptTask_refInLoop(t *testing.T) {
+	logFile := t.TempDir() + "/run.log"
+	r := NewRunner(func(kr *Runner) {
+		kr.Scripts = map[string]any{
+			"each": map[string]any{
+				"type": "sh",
+				"for":  map[string]any{"var": "svc", "items": []any{"api", "worker", "web"}},
+				"cmds": "@task: fmt",
+			},
+			"twice": map[string]any{"type": "sh", "cmds": []any{"@task: fmt", "@task: fmt"}},
+			"fmt":   map[string]any{"type": "sh", "cmds": "echo fmt >> " + logFile},
+		}
+		kr.taskLoaded = true
+		kr.fileLoaded = true
+		kr.appLoaded = true
+	})
+
+	runTimes := func() int {
+		return strings.Count(string(fsutil.MustReadFile(logFile)), "fmt")
+	}
+
+	assert.NoErr(t, os.WriteFile(logFile, nil, 0644))
+	assert.NoErr(t, r.RunScriptTask("each", nil, &RunCtx{Silent: true}))
+	assert.Eq(t, 3, runTimes())
+
+	assert.NoErr(t, os.WriteFile(logFile, nil, 0644))
+	assert.NoErr(t, r.RunScriptTask("twice", nil, &RunCtx{Silent: true}))
+	assert.Eq(t, 2, runTimes())
+}
+
+func TestRunner_runScriptTask_dynamicVars(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoErr(t, os.WriteFile(dir+"/package.json", []byte(`{"version": "1.2.3", "deps": [{"name": "gookit"}]}`), 0644))
+	assert.NoErr(t, os.WriteFile(dir+"/VERSION", []byte("v1.2.3\n"), 0644))
+	markFile := dir + "/resolved.log"
+
+	r := NewRunner(func(kr *Runner) {
+		kr.Scripts = map[string]any{
+			"demo": map[string]any{
+				"type":    "sh",
+				"workdir": dir,
+				"vars": map[string]any{
+					"name":    "kite",
+					"unused":  "@sh: echo unused >> " + markFile,
+					"ver":     "@json: package.json#version",
+					"dep":     map[string]any{"type": "json", "expr": "package.json", "path": "deps.0.name"},
+					"tag":     map[string]any{"file": "VERSION"},
+					"count":   map[string]any{"sh": "echo 1 >> " + markFile + "; echo 3"},
+					"label":   map[string]any{"tpl": "{{.name}}-{{.ver}}"},
+					"is_big":  map[string]any{"expr": `int(count) > 2`},
+					"my_home": "@env: HOME",
+				},
+				"cmds": []any{
+					`test "$ver" = 1.2.3 -a "$dep" = gookit -a "$tag" = v1.2.3`,
+					`test "$count" = 3 -a "${count}" = 3`,
+					`test "$label" = kite-1.2.3 -a "$is_big" = true`,
+					map[string]any{"run": `test "$my_home" = "$HOME"`, "if": "is_big == 'true'"},
+				},
+			},
+		}
+		kr.taskLoaded = true
+		kr.fileLoaded = true
+		kr.appLoaded = true
+	})
+
+	err := r.RunScriptTask("demo", nil, &RunCtx{Silent: true})
+	assert.NoErr(t, err)
+	// count resolved once, unused never resolved
+	assert.Eq(t, "1\n", string(fsutil.MustReadFile(markFile)))
+
+	st, err := r.LoadScriptTaskInfo("demo")
+	assert.NoErr(t, err)
+	vars, err := r.ResolveTaskVars(st, nil)
+	assert.NoErr(t, err)
+	assert.Eq(t, "1.2.3", vars["ver"])
+	assert.Eq(t, "kite-1.2.3", vars["label"])
+}
+
+func TestRunner_runScriptTask_upToDate(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoErr(t, os.MkdirAll(dir+"/src/sub", 0755))
+	assert.NoErr(t, os.WriteFile(dir+"/src/sub/a.txt", []byte("a"), 0644))
+	logFile := dir + "/run.log"
+
+	r := NewRunner(func(kr *Runner) {
+		kr.StateDir = dir + "/states"
+		kr.Scripts = map[string]any{
+			"gen": map[string]any{
+				"type":      "sh",
+				"workdir":   dir,
+				"sources":   []any{"src/**/*.txt"},
+				"generates": []any{"out.txt"},
+				"cmds":      "echo gen >> " + logFile + " && cat src/sub/a.txt > out.txt",
+			},
+			"status": map[string]any{
+				"type":    "sh",
+				"workdir": dir,
+				"status":  []any{"test -f out.txt"},
+				"cmds":    "echo status >> " + logFile,
+			},
+			"both": map[string]any{
+				"type":    "sh",
+				"workdir": dir,
+				"status":  []any{"test -f out.txt"},
+				"sources": []any{"src/**/*.txt"},
+				"cmds":    "echo both >> " + logFile,
+			},
+			"gen-n": map[string]any{
+				"type":    "sh",
+				"workdir": dir,
+				"sources": []any{"src/**/*.txt"},
+				"cmds":    "echo gen-n-${N} >> " + logFile,
+			},
+			"call-a": map[string]any{"deps": []any{map[string]any{"task": "gen-n", "vars": map[string]any{"N": "a"}}}, "cmds": "echo call-a"},
+			"call-b": map[string]any{"deps": []any{map[string]any{"task": "gen-n", "vars": map[string]any{"N": "b"}}}, "cmds": "echo call-b"},
+		}
+		kr.taskLoaded = true
+		kr.fileLoaded = true
+		kr.appLoaded = true
+	})
+
+	runTimes := func() int {
+		return strings.Count(string(fsutil.MustReadFile(logFile)), "gen")
+	}
+
+	assert.NoErr(t, r.RunScriptTask("gen", nil, &RunCtx{Silent: true}))
+	assert.Eq(t, 1, runTimes())
+	// not changed, skip run
+	assert.NoErr(t, r.RunScriptTask("gen", nil, &RunCtx{Silent: true}))
+	assert.Eq(t, 1, runTimes())
+	// force run
+	assert.NoErr(t, r.RunScriptTask("gen", nil, &RunCtx{Silent: true, Force: true}))
+	assert.Eq(t, 2, runTimes())
+	// source changed
+	assert.NoErr(t, os.WriteFile(dir+"/src/sub/a.txt", []byte("b"), 0644))
+	assert.NoErr(t, r.RunScriptTask("gen", nil, &RunCtx{Silent: true}))
+	assert.Eq(t, 3, runTimes())
+	// generates removed
+	assert.NoErr(t, os.Remove(dir+"/out.txt"))
+	assert.NoErr(t, r.RunScriptTask("gen", nil, &RunCtx{Silent: true}))
+	assert.Eq(t, 4, runTimes())
+
+	// status check passed, skip run
+	assert.NoErr(t, r.RunScriptTask("status", nil, &RunCtx{Silent: true}))
+	assert.NotContains(t, string(fsutil.MustReadFile(logFile)), "status")
+
+	// both status and sources are set, must all passed
+	bothTimes := func() int {
+		return strings.Count(string(fsutil.MustReadFile(logFile)), "both")
+	}
+	assert.NoErr(t, r.RunScriptTask("both", nil, &RunCtx{Silent: true}))
+	assert.Eq(t, 1, bothTimes())
+	assert.NoErr(t, r.RunScriptTask("both", nil, &RunCtx{Silent: true}))
+	assert.Eq(t, 1, bothTimes())
+	assert.NoErr(t, os.WriteFile(dir+"/src/sub/a.txt", []byte("c"), 0644))
+	assert.NoErr(t, r.RunScriptTask("both", nil, &RunCtx{Silent: true}))
+	assert.Eq(t, 2, bothTimes())
+
+	// the state is keyed by the task name and caller vars
+	genNTimes := func(n string) int {
+		return strings.Count(string(fsutil.MustReadFile(logFile)), "gen-n-"+n)
+	}
+	assert.NoErr(t, r.RunScriptTask("call-a", nil, &RunCtx{Silent: true}))
+	assert.NoErr(t, r.RunScriptTask("call-b", nil, &RunCtx{Silent: true}))
+	assert.NoErr(t, r.RunScriptTask("call-a", nil, &RunCtx{Silent: true}))
+	assert.Eq(t, 1, genNTimes("a"))
+	assert.Eq(t, 1, genNTimes("b"))
+
+	// state file is keyed by the task workdir, not the current dir
+	sum := md5.Sum([]byte(dir))
+	assert.FileExists(t, dir+"/states/"+hex.EncodeToString(sum[:])+".json")
+}
+
+func TestLoadTaskfile(t *testing.T) {
+	tf, err := LoadTaskfile("testdata/taskfile/Taskfile.yml")
+	assert.NoErr(t, err)
+	assert.Contains(t, tf.Tasks, "docs:build")
+	assert.Contains(t, tf.Tasks, "d:build")
+	assert.Contains(t, tf.Tasks, "echo")
+	assert.Contains(t, tf.Unsupported, "tasks.main-task.prompt")
+	assert.Contains(t, tf.Unsupported, "tasks.main-task.cmds.3(template {{.CLI_ARGS | upper}})")
+
+	logFile := t.TempDir() + "/run.log"
+	r := NewRunner(func(kr *Runner) {
+		kr.Scripts = tf.Tasks
+		kr.taskLoaded = true
+		kr.fileLoaded = true
+		kr.appLoaded = true
+	})
+
+	ctx := &RunCtx{Silent: true, Vars: map[string]string{"LOG_FILE": logFile}}
+	assert.NoErr(t, r.RunScriptTask("default", nil, ctx))
+	lines := strings.Split(strings.TrimSpace(string(fsutil.MustReadFile(logFile))), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines, "before 1")
+	assert.Contains(t, lines, "before 2")
+	assert.Eq(t, "after hello dev kite-app", lines[2])
+
+	assert.NoErr(t, os.WriteFile(logFile, nil, 0644))
+	assert.NoErr(t, r.RunScriptTask("main-task", nil, ctx))
+	contents := string(fsutil.MustReadFile(logFile))
+	assert.StrContains(t, contents, "call from main\ndocs gen\ndocs build kite docs\nmain-task done")
+	assert.NotContains(t, contents, "never run")
+
+	if runtime.GOOS != "windows" {
+		assert.NoErr(t, os.WriteFile(logFile, nil, 0644))
+		assert.NoErr(t, r.RunScriptTask("windows-only", nil, ctx))
+		assert.Empty(t, string(fsutil.MustReadFile(logFile)))
+	}
 }
@@ -0,0 +1,236 @@
+package kscript
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/x/ccolor"
+	"github.com/gookit/slog"
+)
+
+// taskGraph state for run a script task and its deps in one invocation.
+//
+//   - each task will run at most once
+//   - independent deps can run in parallel, limit by jobs
+type taskGraph struct {
+	mu sync.Mutex
+	// task run states. key: task name
+	states map[string]*taskState
+	// jobs max parallel running commands
+	jobs int
+	sem  chan struct{}
+	// outMu lock for write prefixed output lines
+	outMu sync.Mutex
+}
+
+type taskState struct {
+	done chan struct{}
+	err  error
+}
+
+func newTaskGraph(jobs int) *taskGraph {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	return &taskGraph{
+		jobs:   jobs,
+		sem:    make(chan struct{}, jobs),
+		states: make(map[string]*taskState),
+	}
+}
+
+func (g *taskGraph) parallel() bool { return g != nil && g.jobs > 1 }
+
+func (g *taskGraph) acquire() {
+	if g != nil {
+		g.sem <- struct{}{}
+	}
+}
+
+func (g *taskGraph) release() {
+	if g != nil {
+		<-g.sem
+	}
+}
+
+// runOnce run the task by name. if the task is running or done, will wait and return its result.
+func (g *taskGraph) runOnce(name string, fn func() error) error {
+	g.mu.Lock()
+	if ts, ok := g.states[name]; ok {
+		g.mu.Unlock()
+		slog.Debugf("kscript: task %q has been run, wait and reuse the result", name)
+		<-ts.done
+		return ts.err
+	}
+
+	ts := &taskState{done: make(chan struct{})}
+	g.states[name] = ts
+	g.mu.Unlock()
+
+	ts.err = fn()
+	close(ts.done)
+	return ts.err
+}
+
+// checkCycle check the dependency cycle of the task by DFS. edges from deps and @task refs.
+func (g *taskGraph) checkCycle(r *Runner, st *ScriptTask) error {
+	var path []string
+	visiting := make(map[string]bool)
+	visited := make(map[string]bool)
+
+	var visit func(st *ScriptTask) error
+	visit = func(st *ScriptTask) error {
+		name := st.Name
+		if visiting[name] {
+			idx := 0
+			for i, n := range path {
+				if n == name {
+					idx = i
+					break
+				}
+			}
+			cycle := append(path[idx:], name)
+			return errorx.Rawf("task dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+		if visited[name] {
+			return nil
+		}
+
+		visiting[name] = true
+		path = append(path, name)
+
+		for _, depName := range st.DepTaskNames() {
+			dst, err := r.LoadScriptTaskInfo(depName)
+			if err != nil {
+				return errorx.Rf("task %s: load dep task %q info fail: %v", name, depName, err)
+			}
+			if dst == nil {
+				return errorx.Rawf("task %s: the dep task %q not found", name, depName)
+			}
+			if err = visit(dst); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		visiting[name] = false
+		visited[name] = true
+		return nil
+	}
+
+	return visit(st)
+}
+
+// run the deps of the task. on parallel mode, each dep will run in a goroutine.
+//
+// the vars of each dep will be rendered by the vars of current task.
+func (r *Runner) runTaskDeps(st *ScriptTask, inArgs []string, vars map[string]any, ctx *RunCtx) error {
+	deps := make([]*ScriptTask, 0, len(st.Deps))
+	depCtxs := make([]*RunCtx, 0, len(st.Deps))
+	for _, dep := range st.Deps {
+		dst, err := r.LoadScriptTaskInfo(dep.Name)
+		if err != nil {
+			return errorx.Rf("task %s: load dep task %q info fail: %v", st.Name, dep.Name, err)
+		}
+		if dst == nil {
+			return errorx.Rawf("task %s: the dep task %q not found", st.Name, dep.Name)
+		}
+
+		var callVars map[string]string
+		if len(dep.Vars) > 0 {
+			callVars = make(map[string]string, len(dep.Vars))
+			for key, val := range dep.Vars {
+				callVars[key] = r.renderTaskVars(val, vars, ctx)
+			}
+		}
+
+		deps = append(deps, dst)
+		depCtxs = append(depCtxs, ctx.forkWithVars(dst.Name, callVars))
+	}
+
+	if !ctx.graph.parallel() {
+		for i, dst := range deps {
+			ccolor.Magentaln("Run Depends Task:", dst.Name)
+			if err := r.runScriptTask(dst, inArgs, depCtxs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(deps))
+	for i, dst := range deps {
+		ccolor.Magentaln("Run Depends Task:", dst.Name)
+
+		wg.Add(1)
+		go func(i int, dst *ScriptTask) {
+			defer wg.Done()
+			errs[i] = r.runScriptTask(dst, inArgs, depCtxs[i])
+		}(i, dst)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// prefixWriter add prefix for each output line. use for parallel run tasks.
+type prefixWriter struct {
+	mu  *sync.Mutex
+	w   io.Writer
+	buf []byte
+	// prefix for each line. eg: "[task-name] "
+	prefix []byte
+}
+
+func (g *taskGraph) newPrefixWriter(w io.Writer, name string) *prefixWriter {
+	return &prefixWriter{
+		w:      w,
+		mu:     &g.outMu,
+		prefix: []byte(ccolor.Sprintf("<cyan>[%s]</> ", name)),
+	}
+}
+
+// Write data, will write out each complete line with prefix
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	pw.buf = append(pw.buf, p...)
+
+	for {
+		idx := bytes.IndexByte(pw.buf, '\n')
+		if idx < 0 {
+			break
+		}
+
+		pw.writeLine(pw.buf[:idx+1])
+		pw.buf = pw.buf[idx+1:]
+	}
+	return len(p), nil
+}
+
+// Flush the remaining data without newline
+func (pw *prefixWriter) Flush() {
+	if len(pw.buf) > 0 {
+		pw.writeLine(append(pw.buf, '\n'))
+		pw.buf = nil
+	}
+}
+
+func (pw *prefixWriter) writeLine(line []byte) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	_, _ = pw.w.Write(pw.prefix)
+	_, _ = pw.w.Write(line)
+}
+
+func flushWriters(ws ...io.Writer) {
+	for _, w := range ws {
+		if pw, ok := w.(*prefixWriter); ok {
+			pw.Flush()
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package kscript
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/strutil"
+	"github.com/gookit/goutil/sysutil"
+	"github.com/gookit/goutil/sysutil/cmdr"
+	"github.com/gookit/slog"
+)
+
+// fingerprint method for check task sources changed. see ScriptTask.Method
+const (
+	MethodChecksum  = "checksum"
+	MethodTimestamp = "timestamp"
+)
+
+// TaskStateItem saved state for a script task
+type TaskStateItem struct {
+	// Fingerprint of the task sources on last success run
+	Fingerprint string `json:"fingerprint"`
+	// Method for build fingerprint
+	Method string `json:"method"`
+	// RunAt last success run time
+	RunAt time.Time `json:"run_at"`
+}
+
+// taskStates per-project task state file.
+type taskStates struct {
+	mu    sync.Mutex
+	file  string
+	items map[string]*TaskStateItem
+}
+
+// loadTaskStates from the state dir. each task workdir has a state file, named by md5 of the dir path.
+//
+// workdir is the resolved task workdir, will use current dir on it is empty.
+func (r *Runner) loadTaskStates(workdir string) (*taskStates, error) {
+	workdir, err := filepath.Abs(strutil.OrElse(workdir, sysutil.Workdir()))
+	if err != nil {
+		return nil, err
+	}
+
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	if ts, ok := r.states[workdir]; ok {
+		return ts, nil
+	}
+
+	stateDir := r.PathResolver(r.StateDir)
+	sum := md5.Sum([]byte(workdir))
+	stateFile := filepath.Join(stateDir, hex.EncodeToString(sum[:])+".json")
+
+	ts := &taskStates{file: stateFile, items: make(map[string]*TaskStateItem)}
+	if fsutil.IsFile(stateFile) {
+		bs, err := os.ReadFile(stateFile)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(bs, &ts.items); err != nil {
+			return nil, errorx.Rf("decode task state file %s error: %v", stateFile, err)
+		}
+	}
+
+	if r.states == nil {
+		r.states = make(map[string]*taskStates)
+	}
+	r.states[workdir] = ts
+	return ts, nil
+}
+
+func (ts *taskStates) get(name string) *TaskStateItem {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.items[name]
+}
+
+// save the task state to file
+func (ts *taskStates) save(name string, item *TaskStateItem) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.items[name] = item
+
+	bs, err := json.MarshalIndent(ts.items, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(ts.file, bs, fsutil.DefaultFilePerm)
+}
+
+// check the task is up-to-date. returns fingerprint for save on task run success.
+//
+//   - has Status commands: all commands exit with 0 is up-to-date
+//   - has Sources: fingerprint not changed and all Generates exists is up-to-date
+//
+// if both Status and Sources are set, must all checks passed.
+func (r *Runner) checkUpToDate(st *ScriptTask, tr *taskRun) (upToDate bool, fingerprint string, err error) {
+	if len(st.Status) > 0 {
+		for _, line := range st.Status {
+			line = r.renderTaskVars(line, tr.vars, tr.ctx)
+			shell := tr.shell
+			if shell == "" {
+				shell = "sh"
+			}
+
+			err1 := cmdr.NewCmd(shell, "-c", line).WorkDirOnNE(tr.workdir).AppendEnv(tr.envMap).Run()
+			if err1 != nil {
+				return false, "", nil
+			}
+		}
+
+		if len(st.Sources) == 0 {
+			return true, "", nil
+		}
+	} else if len(st.Sources) == 0 {
+		return false, "", nil
+	}
+
+	fingerprint, err = r.buildFingerprint(st, tr)
+	if err != nil {
+		return false, "", err
+	}
+
+	states, err := r.loadTaskStates(tr.workdir)
+	if err != nil {
+		return false, "", err
+	}
+
+	// same task with different caller vars has its own state
+	last := states.get(tr.ctx.runKey(st.Name))
+	if last == nil || last.Fingerprint != fingerprint {
+		return false, fingerprint, nil
+	}
+
+	// check generates files exists
+	for _, pattern := range st.Generates {
+		pattern = r.renderTaskVars(pattern, tr.vars, tr.ctx)
+		files, err1 := globFiles(tr.workdir, pattern)
+		if err1 != nil {
+			return false, "", err1
+		}
+		if len(files) == 0 {
+			return false, fingerprint, nil
+		}
+	}
+	return true, fingerprint, nil
+}
+
+// save fingerprint of the task on run success
+func (r *Runner) saveFingerprint(st *ScriptTask, tr *taskRun, fingerprint string) error {
+	states, err := r.loadTaskStates(tr.workdir)
+	if err != nil {
+		return err
+	}
+
+	return states.save(tr.ctx.runKey(st.Name), &TaskStateItem{
+		Method:      st.FingerprintMethod(),
+		RunAt:       time.Now(),
+		Fingerprint: fingerprint,
+	})
+}
+
+// build fingerprint by the task sources files and generates patterns
+func (r *Runner) buildFingerprint(st *ScriptTask, tr *taskRun) (string, error) {
+	var files []string
+	for _, pattern := range st.Sources {
+		pattern = r.renderTaskVars(pattern, tr.vars, tr.ctx)
+		matched, err := globFiles(tr.workdir, pattern)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, matched...)
+	}
+
+	sort.Strings(files)
+	method := st.FingerprintMethod()
+	hash := sha256.New()
+	// sources or generates patterns changed, should re-run
+	_, _ = fmt.Fprintln(hash, strings.Join(st.Sources, ","), strings.Join(st.Generates, ","))
+
+	var last string
+	for _, fPath := range files {
+		if fPath == last {
+			continue
+		}
+		last = fPath
+
+		fi, err := os.Stat(fPath)
+		if err != nil {
+			return "", err
+		}
+
+		if method == MethodTimestamp {
+			_, _ = fmt.Fprintln(hash, fPath, fi.Size(), fi.ModTime().UnixNano())
+			continue
+		}
+
+		if err = writeFileHash(hash, fPath); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writeFileHash(w io.Writer, fPath string) error {
+	fh, err := os.Open(fPath)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	fileHash := sha256.New()
+	if _, err = io.Copy(fileHash, fh); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, fPath, hex.EncodeToString(fileHash.Sum(nil)))
+	return err
+}
+
+// globFiles find files by glob pattern in the dir. pattern support "**" for match any level dirs.
+//
+// eg: "*.go", "src/**/*.ts", "go.mod"
+func globFiles(dir, pattern string) ([]string, error) {
+	if dir != "" && !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		files := matches[:0]
+		for _, match := range matches {
+			if fsutil.IsFile(match) {
+				files = append(files, match)
+			}
+		}
+		return files, nil
+	}
+
+	// eg: src/**/*.ts => base: src, subPattern: *.ts
+	base, subPattern, _ := strings.Cut(pattern, "**")
+	base = filepath.Clean(base)
+	subPattern = strings.TrimLeft(subPattern, `/\`)
+	if subPattern == "" {
+		subPattern = "*"
+	}
+
+	var files []string
+	err := filepath.WalkDir(base, func(fPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// match by name or relative path
+		rel, _ := filepath.Rel(base, fPath)
+		if ok, _ := filepath.Match(subPattern, d.Name()); ok {
+			files = append(files, fPath)
+		} else if ok, _ = filepath.Match(subPattern, filepath.ToSlash(rel)); ok {
+			files = append(files, fPath)
+		}
+		return nil
+	})
+
+	if os.IsNotExist(err) {
+		slog.Debugf("kscript: glob base dir %q not exists", base)
+		return nil, nil
+	}
+	return files, err
+}
@@ -0,0 +1,576 @@
+package kscript
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/gookit/goutil/arrutil"
+	"github.com/gookit/goutil/envutil"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/strutil"
+	"github.com/gookit/slog"
+)
+
+// TaskfileNames Taskfile.dev file names, will auto find them on not found kite task files.
+//
+// see https://taskfile.dev
+var TaskfileNames = []string{
+	"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml",
+	"Taskfile.dist.yml", "taskfile.dist.yml", "Taskfile.dist.yaml", "taskfile.dist.yaml",
+}
+
+// IsTaskfile check the file is a Taskfile.dev file by name
+func IsTaskfile(fPath string) bool {
+	return arrutil.StringsContains(TaskfileNames, filepath.Base(fPath))
+}
+
+// Taskfile loaded from a Taskfile.dev file. tasks are converted to kscript task definitions.
+//
+// Supported:
+//
+//   - includes with namespace. eg: docs:build, and include dir, vars, aliases, optional, flatten
+//   - vars, env, dotenv on the Taskfile and task level
+//   - deps and cmds with task call and vars. eg: {task: echo, vars: {TEXT: hi}}
+//   - sources, generates, status, method, platforms, aliases, dir, silent, ignore_error
+//   - template vars {{.VAR}} will convert to ${VAR}
+//
+// Other keys will be ignored and recorded to Unsupported.
+type Taskfile struct {
+	// File path of the root Taskfile
+	File string
+	// Tasks converted kscript task definitions. included task name with namespace. eg: docs:build
+	Tasks map[string]any
+	// Unsupported keys or templates on convert, they are ignored. eg: tasks.build.prompt
+	Unsupported []string
+}
+
+// LoadTaskfile load Taskfile.dev file and convert tasks to kscript task definitions.
+func LoadTaskfile(fPath string) (*Taskfile, error) {
+	fPath, err := filepath.Abs(fPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tl := &taskfileLoader{
+		rootDir: filepath.Dir(fPath),
+		tf:      &Taskfile{File: fPath, Tasks: make(map[string]any)},
+	}
+
+	err = tl.load(&tfScope{file: fPath, dir: tl.rootDir}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(tl.tf.Unsupported)
+	return tl.tf, nil
+}
+
+var (
+	taskfileTopKeys  = []string{"version", "vars", "env", "dotenv", "includes", "tasks", "silent", "method"}
+	taskfileTaskKeys = []string{
+		"desc", "summary", "cmds", "cmd", "deps", "dir", "vars", "env", "dotenv", "sources", "generates",
+		"status", "method", "platforms", "aliases", "silent", "ignore_error",
+	}
+	taskfileCmdKeys = []string{"cmd", "task", "vars", "silent", "ignore_error", "platforms"}
+	taskfileIncKeys = []string{"taskfile", "dir", "optional", "vars", "aliases", "flatten"}
+)
+
+// tfScope context for convert tasks in a Taskfile or an included Taskfile
+type tfScope struct {
+	// file path of the Taskfile
+	file string
+	// ns namespace of included Taskfile. eg: docs
+	ns string
+	// dir base workdir for run the tasks
+	dir string
+	// vars from include setting, will override the Taskfile vars
+	incVars map[string]any
+	// aliases of the namespace
+	nsAliases []string
+}
+
+type taskfileLoader struct {
+	tf      *Taskfile
+	rootDir string
+}
+
+func (tl *taskfileLoader) unsupported(format string, args ...any) {
+	tl.tf.Unsupported = append(tl.tf.Unsupported, fmt.Sprintf(format, args...))
+}
+
+func (tl *taskfileLoader) checkKeys(mp map[string]any, allowed []string, prefix string) {
+	for key := range mp {
+		if !arrutil.StringsContains(allowed, key) {
+			tl.unsupported("%s%s", prefix, key)
+		}
+	}
+}
+
+func (tl *taskfileLoader) load(sc *tfScope, parents []string) error {
+	if arrutil.StringsContains(parents, sc.file) {
+		return errorx.Rawf("Taskfile include cycle detected: %s -> %s", strings.Join(parents, " -> "), sc.file)
+	}
+
+	bs, err := os.ReadFile(sc.file)
+	if err != nil {
+		return err
+	}
+
+	var mp map[string]any
+	if err = yaml.Unmarshal(bs, &mp); err != nil {
+		return errorx.Rf("decode Taskfile %s error: %v", sc.file, err)
+	}
+	slog.Debugf("kscript: load Taskfile %q, namespace=%q", sc.file, sc.ns)
+
+	data := maputil.Data(mp)
+	prefix := sc.keyPrefix()
+	tl.checkKeys(mp, taskfileTopKeys, prefix)
+	if ver := data.Str("version"); ver != "" && !strings.HasPrefix(ver, "3") {
+		tl.unsupported("%sversion=%s (only support version 3)", prefix, ver)
+	}
+
+	// global vars and env for all tasks. include vars has higher priority
+	vars := make(map[string]any)
+	maps.Copy(vars, tl.convVars(data.Get("vars"), sc, prefix+"vars"))
+	maps.Copy(vars, sc.incVars)
+	env := tl.loadDotenv(sc.dir, data.Strings("dotenv"))
+	maps.Copy(env, tl.convEnv(data.Get("env"), sc, prefix+"env"))
+
+	fs := &tfFileSetting{
+		vars:   vars,
+		env:    env,
+		silent: data.Bool("silent"),
+		method: data.Str("method"),
+	}
+
+	if tasks, ok := data.Get("tasks").(map[string]any); ok {
+		for name, info := range tasks {
+			tl.convTask(name, info, sc, fs)
+		}
+	}
+
+	// load include files
+	includes, ok := data.Get("includes").(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	parents = append(parents, sc.file)
+	for ns, info := range includes {
+		incSc, optional, err := tl.includeScope(ns, info, sc)
+		if err != nil {
+			return err
+		}
+
+		if !fsutil.IsFile(incSc.file) {
+			if optional {
+				continue
+			}
+			return errorx.Rawf("Taskfile %s: the include %q file %s not exists", sc.file, ns, incSc.file)
+		}
+
+		if err = tl.load(incSc, parents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// build scope for included Taskfile. info allow: path string or map setting.
+func (tl *taskfileLoader) includeScope(ns string, info any, sc *tfScope) (incSc *tfScope, optional bool, err error) {
+	incSc = &tfScope{ns: sc.joinName(ns), dir: sc.dir}
+
+	var incFile string
+	switch typVal := info.(type) {
+	case string:
+		incFile = typVal
+	case map[string]any:
+		data := maputil.Data(typVal)
+		tl.checkKeys(typVal, taskfileIncKeys, sc.keyPrefix()+"includes."+ns+".")
+
+		incFile = data.Str("taskfile")
+		optional = data.Bool("optional")
+		incSc.incVars = tl.convVars(data.Get("vars"), sc, "includes."+ns+".vars")
+		for _, alias := range data.Strings("aliases") {
+			incSc.nsAliases = append(incSc.nsAliases, sc.joinName(alias))
+		}
+		if data.Bool("flatten") {
+			incSc.ns = sc.ns
+		}
+		if dir := data.Str("dir"); dir != "" {
+			incSc.dir = joinDir(filepath.Dir(sc.file), dir)
+		}
+	default:
+		return nil, false, errorx.Rawf("Taskfile %s: invalid include %q config", sc.file, ns)
+	}
+
+	if incFile == "" {
+		return nil, false, errorx.Rawf("Taskfile %s: include %q must set the taskfile path", sc.file, ns)
+	}
+
+	// path is a dir, find Taskfile in it.
+	incFile = joinDir(filepath.Dir(sc.file), incFile)
+	if fsutil.IsDir(incFile) {
+		for _, name := range TaskfileNames {
+			if fPath := filepath.Join(incFile, name); fsutil.IsFile(fPath) {
+				incFile = fPath
+				break
+			}
+		}
+	}
+
+	incSc.file = incFile
+	return incSc, optional, nil
+}
+
+// tfFileSetting global settings in a Taskfile
+type tfFileSetting struct {
+	vars   map[string]any
+	env    map[string]string
+	silent bool
+	method string
+}
+
+// convert a Taskfile task to kscript task definition
+func (tl *taskfileLoader) convTask(name string, info any, sc *tfScope, fs *tfFileSetting) {
+	fullName := sc.joinName(name)
+	prefix := sc.keyPrefix() + "tasks." + name + "."
+
+	var data maputil.Data
+	switch typVal := info.(type) {
+	case map[string]any:
+		data = typVal
+		tl.checkKeys(typVal, taskfileTaskKeys, prefix)
+	case string, []any: // short format
+		data = maputil.Data{"cmds": typVal}
+	default:
+		tl.unsupported("%s(invalid task config)", strings.TrimSuffix(prefix, "."))
+		return
+	}
+
+	cv := &tfConv{tl: tl, sc: sc, taskName: fullName}
+	st := map[string]any{
+		"desc": cv.tpl(data.StrOne("desc", "summary"), prefix+"desc"),
+		"dir":  sc.dir,
+	}
+	if runtime.GOOS != "windows" {
+		st["type"] = "sh"
+	}
+	if dir := data.Str("dir"); dir != "" {
+		st["dir"] = joinDir(sc.dir, cv.tpl(dir, prefix+"dir"))
+	}
+
+	// vars: task vars has higher priority than global vars
+	vars := make(map[string]any)
+	maps.Copy(vars, fs.vars)
+	maps.Copy(vars, tl.convVars(data.Get("vars"), sc, prefix+"vars"))
+	if len(vars) > 0 {
+		st["vars"] = vars
+	}
+
+	// env: task dotenv < global env < task env
+	env := tl.loadDotenv(sc.dir, data.Strings("dotenv"))
+	maps.Copy(env, fs.env)
+	maps.Copy(env, tl.convEnv(data.Get("env"), sc, prefix+"env"))
+	if len(env) > 0 {
+		st["env"] = env
+	}
+
+	for _, key := range []string{"sources", "generates", "status"} {
+		if ss := data.Strings(key); len(ss) > 0 {
+			for i, s := range ss {
+				ss[i] = cv.tpl(s, prefix+key)
+			}
+			st[key] = ss
+		}
+	}
+	if method := strutil.OrElse(data.Str("method"), fs.method); method != "" {
+		if method == "none" {
+			delete(st, "sources")
+		} else {
+			st["method"] = method
+		}
+	}
+	if platforms := data.Strings("platforms"); len(platforms) > 0 {
+		st["platforms"] = platforms
+	}
+
+	// deps
+	if deps := data.Get("deps"); deps != nil {
+		st["deps"] = cv.deps(deps, prefix+"deps")
+	}
+
+	// cmds
+	cmdOpts := map[string]bool{
+		"silent":     data.Bool("silent") || fs.silent,
+		"ignore_err": data.Bool("ignore_error"),
+	}
+	st["cmds"] = cv.cmds(data.One("cmds", "cmd"), cmdOpts, prefix+"cmds")
+
+	tl.addTask(fullName, st)
+
+	// task aliases, add as refer task
+	for _, alias := range data.Strings("aliases") {
+		tl.addTask(sc.joinName(alias), "@task: "+fullName)
+	}
+	// namespace aliases. eg: back:build -> backend:build
+	for _, nsAlias := range sc.nsAliases {
+		aliasName := strings.TrimPrefix(fullName, sc.ns)
+		tl.addTask(nsAlias+aliasName, "@task: "+fullName)
+	}
+}
+
+func (tl *taskfileLoader) addTask(name string, info any) {
+	if _, ok := tl.tf.Tasks[name]; ok {
+		tl.unsupported("tasks.%s(duplicate task name)", name)
+		return
+	}
+	tl.tf.Tasks[name] = info
+}
+
+// convert Taskfile vars. allow value: scalar, {sh: cmd}
+func (tl *taskfileLoader) convVars(val any, sc *tfScope, key string) map[string]any {
+	mp, ok := val.(map[string]any)
+	if !ok || len(mp) == 0 {
+		return nil
+	}
+
+	cv := &tfConv{tl: tl, sc: sc}
+	vars := make(map[string]any, len(mp))
+	for name, v := range mp {
+		switch typVal := v.(type) {
+		case map[string]any:
+			if cmd, ok := typVal["sh"].(string); ok && len(typVal) == 1 {
+				vars[name] = map[string]any{"sh": cv.tpl(cmd, key+"."+name)}
+			} else {
+				tl.unsupported("%s.%s(only support {sh: cmd} for dynamic var)", key, name)
+			}
+		case []any:
+			tl.unsupported("%s.%s(list value)", key, name)
+		case nil:
+			vars[name] = ""
+		default:
+			vars[name] = cv.tpl(strutil.SafeString(typVal), key+"."+name)
+		}
+	}
+	return vars
+}
+
+// convert Taskfile env. only support static value
+func (tl *taskfileLoader) convEnv(val any, sc *tfScope, key string) map[string]string {
+	mp, ok := val.(map[string]any)
+	if !ok || len(mp) == 0 {
+		return nil
+	}
+
+	cv := &tfConv{tl: tl, sc: sc}
+	env := make(map[string]string, len(mp))
+	for name, v := range mp {
+		if _, ok := v.(map[string]any); ok {
+			tl.unsupported("%s.%s(dynamic env value)", key, name)
+			continue
+		}
+		env[name] = cv.tpl(strutil.SafeString(v), key+"."+name)
+	}
+	return env
+}
+
+// load dotenv files, not exists file will be ignored.
+func (tl *taskfileLoader) loadDotenv(dir string, files []string) map[string]string {
+	env := make(map[string]string)
+	for _, file := range files {
+		fPath := joinDir(dir, file)
+		bs, err := os.ReadFile(fPath)
+		if err != nil {
+			slog.Debugf("kscript: skip load dotenv file %q, error: %v", fPath, err)
+			continue
+		}
+
+		// first loaded file has higher priority
+		for k, v := range envutil.SplitText2map(string(bs)) {
+			if _, ok := env[k]; !ok {
+				env[k] = v
+			}
+		}
+	}
+	return env
+}
+
+// tfConv converter for a task
+type tfConv struct {
+	tl *taskfileLoader
+	sc *tfScope
+	// taskName full name of the task
+	taskName string
+}
+
+var (
+	tfVarReg = regexp.MustCompile(`\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
+	tfTplReg = regexp.MustCompile(`\{\{.*?}}`)
+)
+
+// convert Taskfile template vars to kscript vars. eg: {{.NAME}} -> ${NAME}
+func (cv *tfConv) tpl(s, key string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+
+	s = tfVarReg.ReplaceAllStringFunc(s, func(m string) string {
+		name := tfVarReg.FindStringSubmatch(m)[1]
+		switch name {
+		case "TASK":
+			return cv.taskName
+		case "ROOT_DIR":
+			return cv.tl.rootDir
+		case "TASKFILE":
+			return cv.sc.file
+		case "TASKFILE_DIR":
+			return filepath.Dir(cv.sc.file)
+		case "USER_WORKING_DIR":
+			return "${cur_dir}"
+		case "CLI_ARGS":
+			return "$@"
+		}
+		return "${" + name + "}"
+	})
+
+	for _, m := range tfTplReg.FindAllString(s, -1) {
+		cv.tl.unsupported("%s(template %s)", key, m)
+	}
+	return s
+}
+
+// convert Taskfile task name for call. ":name" is root task, others are in the namespace.
+func (cv *tfConv) callName(name string) string {
+	if strings.HasPrefix(name, ":") {
+		return name[1:]
+	}
+	return cv.sc.joinName(name)
+}
+
+// convert call vars. eg: {task: echo, vars: {TEXT: hi}}
+func (cv *tfConv) callVars(val any, key string) map[string]string {
+	vars := cv.tl.convVars(val, cv.sc, key)
+	if len(vars) == 0 {
+		return nil
+	}
+
+	ss := make(map[string]string, len(vars))
+	for name, v := range vars {
+		if str, ok := v.(string); ok {
+			ss[name] = str
+		} else {
+			cv.tl.unsupported("%s.%s(dynamic var on task call)", key, name)
+		}
+	}
+	return ss
+}
+
+func (cv *tfConv) deps(val any, key string) []any {
+	var items []any
+	switch typVal := val.(type) {
+	case string:
+		items = []any{typVal}
+	case []any:
+		items = typVal
+	}
+
+	deps := make([]any, 0, len(items))
+	for i, item := range items {
+		switch typVal := item.(type) {
+		case map[string]any:
+			data := maputil.Data(typVal)
+			dep := map[string]any{"task": cv.callName(data.Str("task"))}
+			if vars := cv.callVars(data.Get("vars"), fmt.Sprintf("%s.%d.vars", key, i)); len(vars) > 0 {
+				dep["vars"] = vars
+			}
+			deps = append(deps, dep)
+		default:
+			deps = append(deps, cv.callName(strutil.SafeString(item)))
+		}
+	}
+	return deps
+}
+
+func (cv *tfConv) cmds(val any, opts map[string]bool, key string) []any {
+	var items []any
+	switch typVal := val.(type) {
+	case string:
+		items = []any{typVal}
+	case []any:
+		items = typVal
+	}
+
+	cmds := make([]any, 0, len(items))
+	for i, item := range items {
+		itemKey := fmt.Sprintf("%s.%d", key, i)
+		tc := map[string]any{
+			"silent":     opts["silent"],
+			"ignore_err": opts["ignore_err"],
+		}
+
+		switch typVal := item.(type) {
+		case map[string]any:
+			data := maputil.Data(typVal)
+			cv.tl.checkKeys(typVal, taskfileCmdKeys, itemKey+".")
+			if platforms := data.Strings("platforms"); len(platforms) > 0 && !matchPlatforms(platforms) {
+				continue
+			}
+
+			if data.Has("silent") {
+				tc["silent"] = data.Bool("silent")
+			}
+			if data.Has("ignore_error") {
+				tc["ignore_err"] = data.Bool("ignore_error")
+			}
+
+			if task := data.Str("task"); task != "" {
+				tc["task"] = cv.callName(task)
+				if vars := cv.callVars(data.Get("vars"), itemKey+".vars"); len(vars) > 0 {
+					tc["vars"] = maputil.ToAnyMap(vars)
+				}
+			} else if cmd := data.Str("cmd"); cmd != "" {
+				tc["run"] = cv.tpl(cmd, itemKey)
+			} else {
+				continue
+			}
+		default:
+			tc["run"] = cv.tpl(strutil.SafeString(item), itemKey)
+		}
+		cmds = append(cmds, tc)
+	}
+	return cmds
+}
+
+// joinName with namespace. eg: docs:build
+func (sc *tfScope) joinName(name string) string {
+	if sc.ns == "" {
+		return name
+	}
+	return sc.ns + ":" + name
+}
+
+// keyPrefix for report unsupported keys. eg: "includes(docs)."
+func (sc *tfScope) keyPrefix() string {
+	if sc.ns == "" {
+		return ""
+	}
+	return "includes(" + sc.ns + ")."
+}
+
+func joinDir(baseDir, path string) string {
+	path = fsutil.ExpandHome(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
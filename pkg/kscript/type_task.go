@@ -1,6 +1,8 @@
 package kscript
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"runtime"
@@ -9,14 +11,14 @@ import (
 	"time"
 
 	"github.com/expr-lang/expr"
+	"github.com/goccy/go-yaml"
 	"github.com/gookit/goutil/arrutil"
 	"github.com/gookit/goutil/comdef"
 	"github.com/gookit/goutil/errorx"
 	"github.com/gookit/goutil/maputil"
 	"github.com/gookit/goutil/strutil"
-	"github.com/gookit/goutil/sysutil"
 	"github.com/gookit/goutil/timex"
-	"github.com/gookit/slog"
+	"github.com/yosuke-furukawa/json5/encoding/json5"
 )
 
 //
@@ -24,14 +26,6 @@ import (
 // region T: TaskSettings
 //
 
-// Variable dynamic variable definition. 动态变量 TODO
-type Variable struct {
-	// Type of variable, allow: sh, bash, zsh, go or empty.
-	Type  string
-	Expr  string
-	Value string
-}
-
 // TaskSettings 可以通过 script task 文件中的 "__settings" 调整设置
 type TaskSettings struct {
 	// Env append set ENV for all script tasks
@@ -118,9 +112,119 @@ type ScriptTasks struct {
 	settings TaskSettings
 }
 
+// TaskFor loop setting for run task commands with each item.
+//
+// eg:
+//
+//	for: {var: svc, items: [api, worker]}
+//	for: {var: file, items: "@glob: ./cmd/*"}
 type TaskFor struct {
-	Var   string
+	// Var name for access the item value. default is: item
+	Var string
+	// Items static item list.
 	Items []string
+	// Source for dynamic items. format: "@type: expr"
+	//  - @sh: git tag -l    each line of the command output as item. also allow: bash, zsh, ...
+	//  - @glob: ./cmd/*     each matched path as item
+	//  - @split: ${services} split the value by Sep
+	Source string
+	// Sep for split the source value. default is: ","
+	Sep string
+}
+
+// SourceType parse the Source and returns type and expr.
+func (tf *TaskFor) SourceType() (typ, line string) {
+	line = strings.TrimSpace(tf.Source)
+	if pos := strings.IndexByte(line, ':'); pos > 1 && line[0] == '@' {
+		return line[1:pos], strings.TrimSpace(line[pos+1:])
+	}
+	return "", line
+}
+
+func parseTaskFor(val any) (*TaskFor, error) {
+	tf := &TaskFor{}
+	switch typVal := val.(type) {
+	case string: // as source
+		tf.Source = typVal
+	case []any: // as items
+		tf.Items = arrutil.SliceToStrings(typVal)
+	case []string:
+		tf.Items = typVal
+	case map[string]any:
+		data := maputil.Data(typVal)
+		tf.Var = data.Str("var")
+		tf.Sep = data.Str("sep")
+		tf.Source = data.Str("source")
+
+		if str, ok := data.Get("items").(string); ok {
+			tf.Source = str
+		} else {
+			tf.Items = data.Strings("items")
+		}
+	default:
+		return nil, errorx.Rawf("invalid for config: %v", val)
+	}
+
+	if tf.Source == "" && len(tf.Items) == 0 {
+		return nil, errorx.Raw("for config must set items or source")
+	}
+	if typ, _ := tf.SourceType(); !tf.IsValidSourceType(typ) {
+		return nil, errorx.Rawf("invalid for source type %q, allow: glob, split, %s", typ, strings.Join(AllowTypes, ", "))
+	}
+	return tf, nil
+}
+
+// IsValidSourceType check the source type. empty is run as shell command.
+func (tf *TaskFor) IsValidSourceType(typ string) bool {
+	return typ == "" || typ == "glob" || typ == "split" || arrutil.StringsContains(AllowTypes, typ)
+}
+
+// TaskDep dependency task of a script task.
+//
+// eg:
+//
+//	deps: [build, test]
+//	deps: [{task: echo, vars: {TEXT: hello}}]
+type TaskDep struct {
+	// Name of the dep task
+	Name string
+	// Vars for run the dep task, will override the vars of the dep task.
+	//  - allow reference vars of current task. eg: ${name}
+	Vars map[string]string
+}
+
+func parseTaskDeps(val any) ([]*TaskDep, error) {
+	var items []any
+	switch typVal := val.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		items = []any{typVal}
+	case []string:
+		items = arrutil.StringsToAnys(typVal)
+	case []any:
+		items = typVal
+	default:
+		return nil, errorx.Rawf("invalid deps config: %v", val)
+	}
+
+	deps := make([]*TaskDep, 0, len(items))
+	for _, item := range items {
+		switch typVal := item.(type) {
+		case map[string]any:
+			data := maputil.Data(typVal)
+			td := &TaskDep{Name: data.StrOne("task", "name"), Vars: data.StringMap("vars")}
+			if td.Name == "" {
+				return nil, errorx.Rawf("dep task name is required, config: %v", item)
+			}
+			deps = append(deps, td)
+		default:
+			if name := strutil.SafeString(item); name != "" {
+				deps = append(deps, &TaskDep{Name: name})
+			}
+		}
+	}
+	return deps, nil
 }
 
 // ScriptTask for one script task.
@@ -147,13 +251,23 @@ type ScriptTask struct {
 
 	// Output target. default is stdout
 	Output string
-	// Vars map[string]Variable // TODO
-	// Vars for run script task.
+	// Vars for run script task. see Variable for allowed format.
 	//  - task配置中访问: $name
-	//  - allow dynamic var: "@sh: git log -1" TODO
-	Vars map[string]string `json:"vars"`
-	// Deps task name list. 当前任务依赖的任务名称列表
-	Deps []string `json:"deps"`
+	//  - allow dynamic var: "@sh: git log -1", {type: json, expr: package.json, path: version}
+	//  - dynamic var will be resolved on referenced, and cached on the task run.
+	Vars map[string]*Variable `json:"vars"`
+	// Deps task list. 当前任务依赖的任务列表, 可以为每个依赖设置 vars
+	Deps []*TaskDep `json:"deps"`
+
+	// Sources file glob patterns for check the task is up-to-date. eg: src/**/*.go, go.mod
+	//  - task will be skipped on the sources not changed since last success run.
+	Sources []string `json:"sources"`
+	// Generates file glob patterns of the task generated. will re-run on any pattern not matched.
+	Generates []string `json:"generates"`
+	// Status check commands. if all commands exit with 0, the task is up-to-date and will be skipped.
+	Status []string `json:"status"`
+	// Method for build sources fingerprint. allow: checksum(default), timestamp
+	Method string `json:"method"`
 
 	// Cmds exec commands list.
 	Cmds []*TaskCmd
@@ -162,7 +276,8 @@ type ScriptTask struct {
 	// CmdTimeout for run each command, default is 0.
 	CmdTimeout time.Duration
 
-	// Platform limit exec. allow: windows, linux, darwin
+	// Platform limit exec. allow: windows, linux, darwin, amd64, linux/arm64
+	//  - the task will be skipped on current platform not matched.
 	Platform []string
 	// PlatformSet 当前系统平台的设置，可以覆盖设置 Type, Cmds
 	PlatformSet map[string]any
@@ -172,8 +287,8 @@ type ScriptTask struct {
 	// If condition check for run command. eg: sh:test -f .env
 	// or see github.com/expr-lang/expr
 	If string
-	// For loop for run command. eg: for i in 1 2 3; do echo $i; done
-	//  - var: 可以引用变量
+	// For loop for run commands with each item. eg: {var: svc, items: [api, worker]}
+	//  - items 支持引用变量，或使用动态来源: @sh, @glob, @split
 	For *TaskFor
 }
 
@@ -213,6 +328,7 @@ func (st *ScriptTask) LoadFromMap(mp map[string]any) error {
 	st.Scope = data.Str("scope")
 	st.Workdir = data.StrOne("dir", "workdir")
 	st.Desc = data.StrOne("desc", "description")
+	st.If = data.Str("if")
 	// task alias
 	st.Aliases = data.StringsOne("alias", "aliases")
 
@@ -231,7 +347,7 @@ func (st *ScriptTask) LoadFromMap(mp map[string]any) error {
 		if err != nil {
 			return errorx.Ef("invalid cmd timeout of the task %q, cmd_timeout=%s", st.Name, cmdTimeout)
 		}
-		st.Timeout = cmdDur
+		st.CmdTimeout = cmdDur
 	}
 
 	err := st.loadArgsDefine(data.Get("args"))
@@ -239,9 +355,29 @@ func (st *ScriptTask) LoadFromMap(mp map[string]any) error {
 		return err
 	}
 
+	// for loop setting
+	if forVal := data.Get("for"); forVal != nil {
+		st.For, err = parseTaskFor(forVal)
+		if err != nil {
+			return errorx.Rf("task %q: %v", st.Name, err)
+		}
+	}
+
 	// st.Vars 支持动态变量
-	st.Vars = data.StringMap("vars")
-	st.Deps = data.StringsOne("deps", "depends")
+	st.Vars, err = ParseVariables(data.Get("vars"))
+	if err != nil {
+		return errorx.Rf("task %q: %v", st.Name, err)
+	}
+	st.Deps, err = parseTaskDeps(data.One("deps", "depends"))
+	if err != nil {
+		return errorx.Rf("task %q: %v", st.Name, err)
+	}
+	st.Platform = data.StringsOne("platforms", "platform")
+	// up-to-date check settings
+	st.Sources = data.Strings("sources")
+	st.Generates = data.Strings("generates")
+	st.Status = data.Strings("status")
+	st.Method = data.Str("method")
 	// st.Cmds = data.StringsOne("run", "cmd", "cmds")
 	cmds := data.One(runKeys...)
 
@@ -312,6 +448,52 @@ func (st *ScriptTask) loadCmdsByAnySlice(anySlice []any) error {
 	return nil
 }
 
+// FingerprintMethod for build sources fingerprint. default is checksum
+func (st *ScriptTask) FingerprintMethod() string {
+	if st.Method == MethodTimestamp {
+		return MethodTimestamp
+	}
+	return MethodChecksum
+}
+
+// DepTaskNames returns the dep task names and refer task names in commands.
+func (st *ScriptTask) DepTaskNames() []string {
+	names := make([]string, 0, len(st.Deps))
+	for _, dep := range st.Deps {
+		names = append(names, dep.Name)
+	}
+	for _, tc := range st.Cmds {
+		if tc.isRef && tc.Run != "" {
+			names = append(names, tc.Run)
+		}
+	}
+	return arrutil.Unique(names)
+}
+
+// MatchPlatform check current platform is matched the Platform setting. returns true on not set.
+//
+// allow item: OS, ARCH or OS/ARCH. eg: windows, amd64, linux/arm64
+func (st *ScriptTask) MatchPlatform() bool {
+	if len(st.Platform) == 0 {
+		return true
+	}
+	return matchPlatforms(st.Platform)
+}
+
+func matchPlatforms(platforms []string) bool {
+	for _, item := range platforms {
+		osName, arch, hasArch := strings.Cut(item, "/")
+		if hasArch {
+			if osName == runtime.GOOS && arch == runtime.GOARCH {
+				return true
+			}
+		} else if item == runtime.GOOS || item == runtime.GOARCH {
+			return true
+		}
+	}
+	return false
+}
+
 var argReg = regexp.MustCompile(`\$\d{1,2}`)
 
 // ParseArgs on commands
@@ -347,60 +529,30 @@ func (st *ScriptTask) CmdsToString(sep ...string) string {
 	return strings.Join(ss, sepStr)
 }
 
-func (st *ScriptTask) resolveIfExpr(vars map[string]any) (ok bool) {
-	program, err := expr.Compile(st.If, expr.Env(vars))
-	if err != nil {
-		panic(err) // TODO
-	}
-
-	output, err := expr.Run(program, vars)
-	if err != nil {
-		panic(err)
-	}
-
-	fmt.Println(output)
-	return true
+// resolveIfExpr check the If condition of the task. returns true on If is empty.
+func (st *ScriptTask) resolveIfExpr(vars map[string]any) (bool, error) {
+	return evalBoolExpr(st.If, vars)
 }
 
-func (st *ScriptTask) resolveDynVars(vars map[string]string) (smp map[string]string, err error) {
-	if len(vars) == 0 {
-		return
+// evalBoolExpr evaluate the expr-lang expression and returns bool result.
+//
+// see github.com/expr-lang/expr
+func evalBoolExpr(exprStr string, vars map[string]any) (bool, error) {
+	exprStr = strings.TrimSpace(exprStr)
+	if exprStr == "" {
+		return true, nil
 	}
 
-	smp = make(map[string]string, len(vars))
-
-	// 解析动态变量值
-	for key, val := range vars {
-		// eg: @sh:test -v app.go
-		if pos := strings.Index(val, ":"); pos > 1 && val[0] == '@' {
-			typ := val[1:pos]
-			line := strings.TrimSpace(val[pos+1:])
-			slog.Debugf("task %s resolveDynVars: %s type=%s, expr=%q", st.Name, key, typ, line)
-
-			switch typ {
-			case "sh", "bash", "zsh", "cmd", "pwsh":
-				str, err1 := sysutil.ShellExec(line, typ)
-				if err1 != nil {
-					return nil, err1
-				}
-				smp[key] = strings.TrimSpace(str)
-			case "exec":
-				str, err1 := sysutil.ExecLine(line)
-				if err1 != nil {
-					return nil, err1
-				}
-				smp[key] = strings.TrimSpace(str)
-			default: // as normal string.
-				smp[key] = val
-			}
-			continue
-		}
-
-		// normal const value
-		smp[key] = val
+	program, err := expr.Compile(exprStr, expr.Env(vars), expr.AllowUndefinedVariables(), expr.AsBool())
+	if err != nil {
+		return false, errorx.Rf("compile if expr %q error: %v", exprStr, err)
 	}
 
-	return
+	output, err := expr.Run(program, vars)
+	if err != nil {
+		return false, errorx.Rf("run if expr %q error: %v", exprStr, err)
+	}
+	return output.(bool), nil
 }
 
 // WithFallbackType on not setting.
@@ -437,14 +589,104 @@ func (st *ScriptTask) loadArgsDefine(args any) error {
 // region T: task command
 //
 
+// output mode for run task command. see TaskCmd.Output
+const (
+	OutputStdout  = "stdout"
+	OutputStderr  = "stderr"
+	OutputBoth    = "both"
+	OutputDiscard = "discard"
+	OutputCollect = "collect"
+)
+
+// CmdResult collected output result of a task command
 type CmdResult struct {
 	val any
+	raw string
 	// Type for the command result, default is text.
 	//  - Allow: text, json, xml, html, csv, yaml, toml, jsonl, json5, jsonc
+	//  - xml, html, toml will keep as text.
 	Type string
 }
 
-// TaskCmd of the task TODO
+// Text of the collected output, will trim spaces
+func (cr *CmdResult) Text() string { return strings.TrimSpace(cr.raw) }
+
+// Bytes of the collected raw output
+func (cr *CmdResult) Bytes() []byte { return []byte(cr.raw) }
+
+// Value of the decoded result. if Type is text, returns Text()
+func (cr *CmdResult) Value() any {
+	if cr.val == nil {
+		return cr.Text()
+	}
+	return cr.val
+}
+
+// set collected output and decode it by the result type.
+func (cr *CmdResult) setOutput(out, typ string) (err error) {
+	cr.raw = out
+	cr.Type = strutil.OrElse(typ, "text")
+	cr.val = nil
+
+	text := cr.Text()
+	if text == "" {
+		return nil
+	}
+
+	switch cr.Type {
+	case "json":
+		err = json.Unmarshal([]byte(text), &cr.val)
+	case "json5", "jsonc":
+		err = json5.Unmarshal([]byte(text), &cr.val)
+	case "jsonl":
+		var list []any
+		for _, line := range strings.Split(text, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+
+			var item any
+			if err = json.Unmarshal([]byte(line), &item); err != nil {
+				break
+			}
+			list = append(list, item)
+		}
+		cr.val = list
+	case "yaml", "yml":
+		err = yaml.Unmarshal([]byte(text), &cr.val)
+	case "csv":
+		cr.val, err = decodeCsvRows(text)
+	}
+
+	if err != nil {
+		cr.val = nil
+		return errorx.Rf("decode %s result error: %v", cr.Type, err)
+	}
+	return nil
+}
+
+// decode csv text to row maps, first row is header.
+func decodeCsvRows(text string) ([]map[string]any, error) {
+	records, err := csv.NewReader(strings.NewReader(text)).ReadAll()
+	if err != nil || len(records) < 2 {
+		return nil, err
+	}
+
+	header := records[0]
+	rows := make([]map[string]any, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[name] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// TaskCmd of the task
 type TaskCmd struct {
 	st *ScriptTask
 	// is reference another task. eg: @task:another_task
@@ -455,10 +697,10 @@ type TaskCmd struct {
 	Name string
 	// Workdir for run command
 	Workdir string
-	// Vars for run cmd.
+	// Vars for run cmd. see Variable for allowed format.
 	//  - task配置中访问: $name
 	//  - allow dynamic var: "@sh: git log -1"
-	Vars map[string]string
+	Vars map[string]*Variable
 	// Env append ENV setting for run
 	Env map[string]string
 	// Run command line expr for run. eg: go run main.go
@@ -467,22 +709,27 @@ type TaskCmd struct {
 	Task string
 	// Type wrap for run. Allow: sh, bash, zsh
 	Type string
-	// If condition expr for run, return true or false
+	// If condition expr for run, return true or false. skip run on false.
+	//  - expr-lang expression. eg: os == "linux" && name != ""
+	//  - shell command, exit code 0 is true. eg: sh:test -f .env
 	If string
 	// FailMsg custom message on run fail
 	FailMsg string
 	// Silent mode, dont print exec command line.
 	Silent bool `json:"silent"`
 	// Timeout for run the command, default is 0.
+	//  - fallback use ScriptTask.CmdTimeout
 	Timeout time.Duration
 	// IgnoreErr safed run command. ignore run error, continue next command.
 	IgnoreErr bool `json:"ignore_err"`
 
-	// ------ 执行结果处理 ------ TODO
+	// ------ 执行结果处理 ------
 
 	// Output for run the command, default is stdout.
 	//  - allow: stdout, stderr, both, discard, collect
+	//  - stderr: stdout also write to stderr. both: stderr also write to stdout.
 	//  - collect: collect command output to Result. and can use Result.Text() or Result.Bytes() to get output.
+	//    later commands can access the result by: ${cmds.<name>.result}, ${cmds.<name>.output}
 	Output string
 	// ResultType for run the command, default is text. see CmdResult.Type
 	//
@@ -510,10 +757,17 @@ func (tc *TaskCmd) loadFromMap(mp map[string]any) error {
 	tc.Name = data.Str("name")
 	tc.Type = data.Str("type")
 	tc.Task = data.Str("task")
-	tc.Vars = data.StringMap("vars")
+	vs, err := ParseVariables(data.Get("vars"))
+	if err != nil {
+		return errorx.Rf("task %q command#%d: %v", tc.st.Name, tc.index, err)
+	}
+	tc.Vars = vs
 	tc.Env = data.StringMap("env")
 	// more setting
 	tc.Silent = data.Bool("silent")
+	tc.If = data.Str("if")
+	tc.Output = data.Str("output")
+	tc.ResultType = data.StrOne("result_type", "result")
 	tc.FailMsg = data.Str("fail_msg")
 	tc.Workdir = data.StrOne("workdir", "dir")
 	tc.IgnoreErr = data.BoolOne("ignore_err", "safe_run")
@@ -533,7 +787,7 @@ func (tc *TaskCmd) loadFromMap(mp map[string]any) error {
 
 func (tc *TaskCmd) loadRun(run string) {
 	run = strings.TrimSpace(run)
-	if run == "" {
+	if run == "" && tc.Task == "" {
 		return // TODO return errorx.Ef("invalid run of the task %q command#%d, run=%s", tc.st.Name, tc.index, run)
 	}
 
@@ -573,20 +827,23 @@ func (tc *TaskCmd) loadRun(run string) {
 
 }
 
-func (tc *TaskCmd) appendVars(vars map[string]any) error {
+// append command vars to vars. dynamic vars will be added to the resolver.
+func (tc *TaskCmd) appendVars(vars map[string]any, vr *varResolver) {
 	if len(tc.Vars) == 0 {
-		return nil
+		return
 	}
 
-	// parse dynamic vars
-	tcVars, err := tc.st.resolveDynVars(tc.Vars)
-	if err != nil {
-		return errorx.Rf("task command#%d: %v", tc.index, err)
+	dynVars := make(map[string]*Variable)
+	for name, v := range tc.Vars {
+		if v.IsDynamic() {
+			dynVars[name] = v
+			delete(vars, name)
+		} else {
+			vars[name] = v.Value
+		}
 	}
 
-	// set vars
-	for k, v := range tcVars {
-		vars[k] = v
+	if len(dynVars) > 0 && vr != nil {
+		vr.add(dynVars)
 	}
-	return nil
 }
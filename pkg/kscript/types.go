@@ -1,7 +1,10 @@
 package kscript
 
 import (
+	"maps"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gookit/goutil/cliutil"
@@ -134,6 +137,8 @@ type RunCtx struct {
 	Verbose bool
 	// DryRun script
 	DryRun bool
+	// Force run the task, ignore the up-to-date check by Sources or Status.
+	Force bool
 	// Workdir for run a script
 	Workdir string
 	// Vars for run cmd. access: $ctx.var_name
@@ -150,8 +155,16 @@ type RunCtx struct {
 	BeforeFn func(si any, ctx *RunCtx)
 	// AppendVarsFn hook for run task. eg: gvs, paths, kite
 	AppendVarsFn func(data map[string]any) map[string]any
+	// Jobs max parallel jobs for run task deps. default is 1
+	Jobs int
 
 	fullEnv map[string]string
+	// graph state for run task and deps in one invocation
+	graph *taskGraph
+	// lazy resolve dynamic variables for current task
+	lazyVars *varResolver
+	// callVars vars set by the caller task. eg: deps: [{task: x, vars: {k: v}}]
+	callVars map[string]string
 }
 
 // EnsureCtx to
@@ -180,6 +193,41 @@ func (c *RunCtx) WithNameArgs(name string, args []string) *RunCtx {
 	return c
 }
 
+// fork a new context for run dep or refer task.
+func (c *RunCtx) fork(name string) *RunCtx {
+	nc := *c
+	nc.Name = name
+	nc.Env = maps.Clone(c.Env)
+	nc.fullEnv = nil
+	nc.lazyVars = nil
+	nc.callVars = nil
+	return &nc
+}
+
+// forkWithVars fork a new context for run dep or refer task, with vars set by caller.
+func (c *RunCtx) forkWithVars(name string, vars map[string]string) *RunCtx {
+	nc := c.fork(name)
+	if len(vars) > 0 {
+		nc.callVars = vars
+		nc.Vars = maputil.MergeStrMap(vars, maps.Clone(c.Vars))
+	}
+	return nc
+}
+
+// runKey for run the task once in a graph. same task with different caller vars will run again.
+func (c *RunCtx) runKey(name string) string {
+	if len(c.callVars) == 0 {
+		return name
+	}
+
+	keys := maputil.Keys(c.callVars)
+	sort.Strings(keys)
+	for i, key := range keys {
+		keys[i] = key + "=" + c.callVars[key]
+	}
+	return name + "(" + strings.Join(keys, ",") + ")"
+}
+
 // MergeEnv and returns
 func (c *RunCtx) MergeEnv(mps ...map[string]string) {
 	if len(c.Env) > 0 {
@@ -213,15 +261,15 @@ func (c *RunCtx) AppendArgsToVars(vars map[string]any) {
 
 }
 
-// 专门实现的类似 php, shell 的字符串表达式处理
-var svRender = textutil.NewStrVarRenderer()
-
 func (c *RunCtx) ParseVarInEnv(envPaths []string, vars map[string]any) map[string]string {
 	// merge env
 	envMap := c.Env
+	// 专门实现的类似 php, shell 的字符串表达式处理
+	svRender := textutil.NewStrVarRenderer()
 
 	// parse env expression value
 	if len(envMap) > 0 && len(vars) > 0 {
+		svRender.SetGetter(c.lazyVars.Get)
 		for k, v := range envMap {
 			if strutil.ContainsByte(v, '$') {
 				envMap[k] = svRender.Render(v, vars)
@@ -0,0 +1,385 @@
+package kscript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/expr-lang/expr"
+	"github.com/goccy/go-yaml"
+	"github.com/gookit/goutil/arrutil"
+	"github.com/gookit/goutil/cliutil/cmdline"
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/maputil"
+	"github.com/gookit/goutil/strutil"
+	"github.com/gookit/goutil/sysutil/cmdr"
+	"github.com/gookit/slog"
+)
+
+// VarTypes allowed dynamic variable types. shell types see AllowTypes
+var VarTypes = []string{"exec", "tpl", "expr", "env", "file", "json"}
+
+// Variable dynamic variable definition. 动态变量
+//
+// Allow config format:
+//
+//	name: value                      // const value
+//	name: "@sh: git log -1"          // dynamic var by string. format: "@type: expr"
+//	name: "@json: package.json#version"
+//	name: {type: sh, expr: "git log -1"}
+//	name: {sh: "git log -1"}         // short map format
+//	name: {type: json, expr: package.json, path: version}
+//
+// Dynamic types:
+//   - sh, bash, zsh, cmd, pwsh: run shell command and use output
+//   - exec: direct run command line and use output
+//   - tpl: render Go text template. eg: {{.name}}-{{.version}}
+//   - expr: eval expr-lang expression. see github.com/expr-lang/expr
+//   - env: read ENV value by name
+//   - file: read file contents
+//   - json: read value by path from JSON/YAML file. eg: package.json#version
+type Variable struct {
+	// Name of the variable
+	Name string
+	// Type of variable, allow: sh, bash, zsh, exec, tpl, expr, env, file, json or empty for const
+	Type string
+	// Expr for resolve value. eg: command line, template, expression, env name, file path
+	Expr string
+	// Path key path for type json. eg: version, deps.0.name
+	Path string
+	// Value const value or the resolved value.
+	Value string
+}
+
+// IsDynamic variable
+func (v *Variable) IsDynamic() bool { return v.Type != "" }
+
+// String get variable info
+func (v *Variable) String() string {
+	if v.Type == "" {
+		return v.Value
+	}
+	if v.Path != "" {
+		return fmt.Sprintf("@%s: %s#%s", v.Type, v.Expr, v.Path)
+	}
+	return fmt.Sprintf("@%s: %s", v.Type, v.Expr)
+}
+
+// NewConstVar create a const value variable
+func NewConstVar(name, value string) *Variable {
+	return &Variable{Name: name, Value: value}
+}
+
+// ParseVariable by config value. see Variable for allowed format.
+func ParseVariable(name string, val any) (*Variable, error) {
+	v := &Variable{Name: name}
+
+	switch typVal := val.(type) {
+	case map[string]any:
+		data := maputil.Data(typVal)
+		v.Type = data.Str("type")
+		v.Expr = data.StrOne("expr", "file")
+		v.Path = data.Str("path")
+		v.Value = data.Str("value")
+
+		// short format. eg: {sh: "git log -1"}
+		if v.Type == "" {
+			for key := range typVal {
+				if isVarType(key) {
+					v.Type = key
+					v.Expr = data.Str(key)
+					break
+				}
+			}
+		}
+
+		if v.Type != "" && !isVarType(v.Type) {
+			return nil, errorx.Rawf("invalid type %q of the variable %q", v.Type, name)
+		}
+	default:
+		str := strutil.SafeString(val)
+		v.Value = str
+
+		// eg: @sh: test -v app.go
+		if pos := strings.IndexByte(str, ':'); pos > 1 && str[0] == '@' {
+			if typ := str[1:pos]; isVarType(typ) {
+				v.Type = typ
+				v.Value = ""
+				v.Expr = strings.TrimSpace(str[pos+1:])
+			}
+		}
+	}
+
+	// json path. eg: package.json#version
+	if v.Type == "json" && v.Path == "" {
+		v.Expr, v.Path = strutil.QuietCut(v.Expr, "#")
+	}
+	return v, nil
+}
+
+// ParseVariables by config map data.
+func ParseVariables(val any) (map[string]*Variable, error) {
+	mp, ok := val.(map[string]any)
+	if !ok || len(mp) == 0 {
+		return nil, nil
+	}
+
+	vs := make(map[string]*Variable, len(mp))
+	for name, v := range mp {
+		variable, err := ParseVariable(name, v)
+		if err != nil {
+			return nil, err
+		}
+		vs[name] = variable
+	}
+	return vs, nil
+}
+
+func isVarType(typ string) bool {
+	return arrutil.StringsContains(AllowTypes, typ) || arrutil.StringsContains(VarTypes, typ)
+}
+
+// varResolver lazy resolve dynamic variables on referenced, the result will be cached.
+type varResolver struct {
+	mu sync.Mutex
+	r  *Runner
+	// ctx for get env, workdir
+	ctx *RunCtx
+	// dynamic variable definitions
+	defs map[string]*Variable
+	// resolved values cache
+	cache map[string]string
+	// marks the resolving variables, for check cycle reference.
+	resolving map[string]bool
+	// first resolve error
+	err error
+	// workdir for resolve the variable
+	workdir string
+	// vars for render tpl, expr type variable
+	vars map[string]any
+}
+
+func newVarResolver(r *Runner, ctx *RunCtx, vars map[string]any) *varResolver {
+	return &varResolver{
+		r:    r,
+		ctx:  ctx,
+		vars: vars,
+		defs: make(map[string]*Variable),
+		// cache resolved values
+		cache:     make(map[string]string),
+		resolving: make(map[string]bool),
+	}
+}
+
+// add dynamic variables. will override exists and clear cached value.
+func (vr *varResolver) add(vs map[string]*Variable) {
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+
+	for name, v := range vs {
+		vr.defs[name] = v
+		delete(vr.cache, name)
+	}
+}
+
+// has dynamic variable by name
+func (vr *varResolver) has(name string) bool {
+	if vr == nil {
+		return false
+	}
+
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+	_, ok := vr.defs[name]
+	return ok
+}
+
+// Err get the first resolve error and reset it.
+func (vr *varResolver) Err() error {
+	if vr == nil {
+		return nil
+	}
+
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+	err := vr.err
+	vr.err = nil
+	return err
+}
+
+// Get value of the dynamic variable. will resolve it on first access.
+func (vr *varResolver) Get(name string) (string, bool) {
+	if vr == nil {
+		return "", false
+	}
+
+	vr.mu.Lock()
+	v, ok := vr.defs[name]
+	if !ok {
+		vr.mu.Unlock()
+		return "", false
+	}
+	if val, ok := vr.cache[name]; ok {
+		vr.mu.Unlock()
+		return val, true
+	}
+	if vr.resolving[name] {
+		vr.setErr(errorx.Rawf("variable %q has cycle reference", name))
+		vr.mu.Unlock()
+		return "", false
+	}
+	vr.resolving[name] = true
+	vr.mu.Unlock()
+
+	val, err := vr.resolve(v)
+
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+	delete(vr.resolving, name)
+	if err != nil {
+		vr.setErr(errorx.Rf("resolve variable %q error: %v", name, err))
+		return "", false
+	}
+
+	slog.Debugf("kscript: resolved dynamic variable %s=%q", name, val)
+	vr.cache[name] = val
+	return val, true
+}
+
+func (vr *varResolver) setErr(err error) {
+	if vr.err == nil {
+		vr.err = err
+	}
+}
+
+// ResolveAll dynamic variables and returns values.
+func (vr *varResolver) ResolveAll() (map[string]string, error) {
+	vr.mu.Lock()
+	names := make([]string, 0, len(vr.defs))
+	for name := range vr.defs {
+		names = append(names, name)
+	}
+	vr.mu.Unlock()
+
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		if val, ok := vr.Get(name); ok {
+			values[name] = val
+		}
+	}
+	return values, vr.Err()
+}
+
+var wordReg = regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_]*`)
+
+// DataFor returns vars data with the dynamic variables referenced by the expr text.
+func (vr *varResolver) DataFor(text string, vars map[string]any) map[string]any {
+	if vr == nil {
+		return vars
+	}
+
+	var refs map[string]any
+	for _, word := range arrutil.Unique(wordReg.FindAllString(text, -1)) {
+		if _, ok := vars[word]; ok || !vr.has(word) {
+			continue
+		}
+
+		if val, ok := vr.Get(word); ok {
+			if refs == nil {
+				refs = make(map[string]any)
+			}
+			refs[word] = val
+		}
+	}
+
+	if len(refs) == 0 {
+		return vars
+	}
+	return maputil.Merge1level(vars, refs)
+}
+
+func (vr *varResolver) resolve(v *Variable) (string, error) {
+	line := v.Expr
+	if v.Type != "tpl" && v.Type != "expr" && strutil.ContainsByte(line, '$') {
+		line = vr.r.renderTaskVars(line, vr.vars, vr.ctx)
+	}
+
+	switch v.Type {
+	case "exec":
+		bin, args := cmdline.NewParser(line).WithParseEnv().BinAndArgs()
+		out, err := cmdr.NewCmd(bin, args...).WorkDirOnNE(vr.workdir).AppendEnv(vr.ctx.Env).Output()
+		return strings.TrimSpace(out), err
+	case "tpl":
+		tpl, err := template.New(v.Name).Parse(line)
+		if err != nil {
+			return "", err
+		}
+
+		buf := new(bytes.Buffer)
+		err = tpl.Execute(buf, vr.DataFor(line, vr.vars))
+		return buf.String(), err
+	case "expr":
+		out, err := expr.Eval(line, vr.DataFor(line, vr.vars))
+		if err != nil {
+			return "", err
+		}
+		return strutil.SafeString(out), nil
+	case "env":
+		return vr.ctx.FullEnv()[line], nil
+	case "file":
+		bs, err := os.ReadFile(vr.resolvePath(line))
+		return strings.TrimSpace(string(bs)), err
+	case "json":
+		return vr.readPathValue(vr.resolvePath(line), v.Path)
+	default: // shell types
+		out, err := cmdr.NewCmd(v.Type, "-c", line).WorkDirOnNE(vr.workdir).AppendEnv(vr.ctx.Env).Output()
+		return strings.TrimSpace(out), err
+	}
+}
+
+func (vr *varResolver) resolvePath(fPath string) string {
+	fPath = fsutil.ExpandHome(fPath)
+	if vr.workdir != "" && !filepath.IsAbs(fPath) {
+		return filepath.Join(vr.workdir, fPath)
+	}
+	return fPath
+}
+
+// read value by key path from JSON or YAML file
+func (vr *varResolver) readPathValue(fPath, keyPath string) (string, error) {
+	bs, err := os.ReadFile(fPath)
+	if err != nil {
+		return "", err
+	}
+
+	var data map[string]any
+	switch fsutil.FileExt(fPath) {
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(bs, &data)
+	default:
+		err = json.Unmarshal(bs, &data)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	keyPath = strings.TrimPrefix(strings.TrimPrefix(keyPath, "$"), ".")
+	val, ok := maputil.GetByPath(keyPath, data)
+	if !ok {
+		return "", errorx.Rawf("path %q not found in file %s", keyPath, fPath)
+	}
+
+	switch typVal := val.(type) {
+	case map[string]any, []any:
+		bs, err = json.Marshal(typVal)
+		return string(bs), err
+	}
+	return strutil.SafeString(val), nil
+}
@@ -0,0 +1,267 @@
+package kvstore
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/netutil/httpctype"
+	"github.com/gookit/goutil/x/ccolor"
+	"github.com/inhere/kite-go/pkg/util/httputil"
+)
+
+// MaxValueSize max size of a JSON or text value
+var MaxValueSize int64 = 1 << 20
+
+// Server of the store, implemented http.Handler
+//
+// Routes:
+//
+//	GET    /kv                       list namespaces with key count
+//	GET    /kv/{ns}?prefix=build.    list keys in the namespace
+//	DELETE /kv/{ns}                  clear the namespace
+//	PUT    /kv/{ns}/{key}?ttl=10m    set value. JSON body as JSON value, text or form body as string, others as blob file.
+//	GET    /kv/{ns}/{key}            get value. query: meta=1 for get the entry info
+//	POST   /kv/{ns}/{key}?incr=1     increase the number value, returns the new value
+//	DELETE /kv/{ns}/{key}            delete the key
+type Server struct {
+	*Store
+	// Token for auth, empty for disable. allow: Authorization: Bearer TOKEN, X-Token header or token query.
+	Token string
+	// MaxBlobSize max size of the blob file. default is 100MB
+	MaxBlobSize int64
+	// Quiet mode, dont print request logs
+	Quiet bool
+
+	mux *http.ServeMux
+}
+
+// NewServer create store server
+func NewServer(s *Store, token string) *Server {
+	srv := &Server{Store: s, Token: token, MaxBlobSize: 100 << 20}
+
+	srv.mux = http.NewServeMux()
+	srv.mux.HandleFunc("GET /kv", srv.handleNamespaces)
+	srv.mux.HandleFunc("GET /kv/{ns}", srv.handleList)
+	srv.mux.HandleFunc("DELETE /kv/{ns}", srv.handleClear)
+	srv.mux.HandleFunc("GET /kv/{ns}/{key...}", srv.handleGet)
+	srv.mux.HandleFunc("PUT /kv/{ns}/{key...}", srv.handleSet)
+	srv.mux.HandleFunc("POST /kv/{ns}/{key...}", srv.handleSet)
+	srv.mux.HandleFunc("DELETE /kv/{ns}/{key...}", srv.handleDelete)
+	return srv
+}
+
+// ServeHTTP check auth and handle the request
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !srv.Quiet {
+		ccolor.Printf("%s <cyan>%s</> from %s\n", r.Method, r.URL.RequestURI(), r.RemoteAddr)
+	}
+
+	if srv.Token != "" && !srv.checkToken(r) {
+		writeError(w, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+	srv.mux.ServeHTTP(w, r)
+}
+
+func (srv *Server) checkToken(r *http.Request) bool {
+	token := r.Header.Get("X-Token")
+	if token == "" {
+		token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(srv.Token)) == 1
+}
+
+// StartCleaner start a goroutine for purge expired keys by interval. returns the stop func.
+func (srv *Server) StartCleaner(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if n, err := srv.Purge(); err != nil {
+					ccolor.Warnf("purge expired keys error: %v\n", err)
+				} else if n > 0 && !srv.Quiet {
+					ccolor.Infof("purged %d expired keys\n", n)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (srv *Server) handleNamespaces(w http.ResponseWriter, _ *http.Request) {
+	httputil.WriteJSON(w, http.StatusOK, srv.Namespaces())
+}
+
+func (srv *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	list := srv.List(r.PathValue("ns"), r.URL.Query().Get("prefix"))
+	if list == nil {
+		list = []*KeyInfo{}
+	}
+	httputil.WriteJSON(w, http.StatusOK, list)
+}
+
+func (srv *Server) handleClear(w http.ResponseWriter, r *http.Request) {
+	ns := r.PathValue("ns")
+	if err := CheckNamespace(ns); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	n, err := srv.Clear(ns)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"deleted": n})
+}
+
+func (srv *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	ns, key := r.PathValue("ns"), r.PathValue("key")
+	e, err := srv.Get(ns, key)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("meta") == "1" {
+		httputil.WriteJSON(w, http.StatusOK, &KeyInfo{Key: key, Entry: e})
+		return
+	}
+
+	if e.Blob {
+		f, err := srv.OpenBlob(ns, key)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer f.Close()
+
+		if e.Type != "" {
+			w.Header().Set("Content-Type", e.Type)
+		}
+		http.ServeContent(w, r, "", time.Unix(e.Updated, 0), f)
+		return
+	}
+
+	// string value as plain text, for easy use in shell
+	if str, ok := e.Value.(string); ok {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, str)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, e.Value)
+}
+
+func (srv *Server) handleSet(w http.ResponseWriter, r *http.Request) {
+	ns, key := r.PathValue("ns"), r.PathValue("key")
+	if err := CheckKey(ns, key); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	q := r.URL.Query()
+	if r.Method == http.MethodPost {
+		if q.Get("incr") == "" {
+			writeError(w, http.StatusMethodNotAllowed, "use PUT for set value, POST only for ?incr=N")
+			return
+		}
+
+		by, err := strconv.ParseInt(q.Get("incr"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid incr number: "+q.Get("incr"))
+			return
+		}
+
+		num, err := srv.Incr(ns, key, by)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		httputil.WriteJSON(w, http.StatusOK, num)
+		return
+	}
+
+	var ttl time.Duration
+	if s := q.Get("ttl"); s != "" {
+		var err error
+		if ttl, err = parseTTL(s); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid ttl: "+s)
+			return
+		}
+	}
+
+	var e *Entry
+	var err error
+	cType := r.Header.Get(httpctype.Key)
+	switch kind := httpctype.ToKind(cType, ""); {
+	case q.Get("blob") != "1" && (kind == httpctype.KindJSON || kind == httpctype.KindForm || strings.HasPrefix(cType, "text/")):
+		bs, err1 := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxValueSize))
+		if err1 != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, err1.Error())
+			return
+		}
+
+		var val any = string(bs)
+		if kind == httpctype.KindJSON {
+			if err1 = json.Unmarshal(bs, &val); err1 != nil {
+				writeError(w, http.StatusBadRequest, "invalid JSON value: "+err1.Error())
+				return
+			}
+		}
+		e, err = srv.Set(ns, key, val, ttl)
+	default:
+		e, err = srv.SetBlob(ns, key, cType, http.MaxBytesReader(w, r.Body, srv.MaxBlobSize), ttl)
+	}
+
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, &KeyInfo{Key: key, Entry: e})
+}
+
+func (srv *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if err := srv.Delete(r.PathValue("ns"), r.PathValue("key")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"deleted": 1})
+}
+
+// parse ttl by duration string or seconds. eg: 30s, 10m, 1h, 60
+func parseTTL(s string) (time.Duration, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrNotFound:
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	case ErrNotNumber:
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	httputil.WriteJSON(w, status, map[string]any{"error": msg})
+}
@@ -0,0 +1,350 @@
+// Package kvstore provides a simple key-value and blob store, for share small state between machines by HTTP.
+//
+// The values are saved in a JSON file by lcstorage.FileStorage, and the blobs(files) are saved in a directory.
+package kvstore
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/inhere/kite-go/pkg/tools/lcstorage"
+)
+
+// ErrNotFound error for key not exists or expired
+var ErrNotFound = errorx.Raw("key not found")
+
+// ErrNotNumber error for incr a not number value
+var ErrNotNumber = errorx.Raw("the value is not a number")
+
+var nsReg = regexp.MustCompile(`^\w[\w.-]*$`)
+
+// MaxKeyLen max length of the key
+const MaxKeyLen = 256
+
+// Entry of a key in the store
+type Entry struct {
+	// Value of the key. is nil on Blob=true
+	Value any `json:"value,omitempty"`
+	// Blob the value is a file, saved in the blob dir.
+	Blob bool `json:"blob,omitempty"`
+	// Size of the blob file
+	Size int64 `json:"size,omitempty"`
+	// Type content type of the blob file
+	Type string `json:"type,omitempty"`
+	// Expire unix time of the key, 0 for never expire.
+	Expire int64 `json:"expire,omitempty"`
+	// Updated unix time of the key
+	Updated int64 `json:"updated"`
+}
+
+// Expired check the entry is expired
+func (e *Entry) Expired(now time.Time) bool {
+	return e.Expire > 0 && now.Unix() >= e.Expire
+}
+
+// KeyInfo for list keys
+type KeyInfo struct {
+	Key string `json:"key"`
+	*Entry
+}
+
+// Store of key-value and blob, the key is under a namespace.
+type Store struct {
+	mu sync.Mutex
+	// values storage. key: {ns}/{key}
+	db *lcstorage.FileStorage
+	// blobDir for save the blob files
+	blobDir string
+}
+
+// Open the store in the data dir. values file: {dir}/kvstore.json, blobs dir: {dir}/blobs
+func Open(dir string) (*Store, error) {
+	blobDir := filepath.Join(dir, "blobs")
+	if err := fsutil.MkdirQuick(blobDir); err != nil {
+		return nil, err
+	}
+
+	db := lcstorage.NewFileStorage(filepath.Join(dir, "kvstore.json"))
+	if err := db.Restore(); err != nil {
+		return nil, err
+	}
+	return &Store{db: db, blobDir: blobDir}, nil
+}
+
+// CheckNamespace check the namespace name is valid
+func CheckNamespace(ns string) error {
+	if !nsReg.MatchString(ns) {
+		return errorx.Rawf("invalid namespace %q, allow chars: a-zA-Z0-9_.-", ns)
+	}
+	return nil
+}
+
+// CheckKey check the namespace and key is valid
+func CheckKey(ns, key string) error {
+	if err := CheckNamespace(ns); err != nil {
+		return err
+	}
+	if key == "" || len(key) > MaxKeyLen {
+		return errorx.Rawf("the key must be 1-%d chars", MaxKeyLen)
+	}
+	return nil
+}
+
+func dbKey(ns, key string) string { return ns + "/" + key }
+
+func (s *Store) blobFile(ns, key string) string {
+	sum := sha1.Sum([]byte(dbKey(ns, key)))
+	return filepath.Join(s.blobDir, ns, hex.EncodeToString(sum[:]))
+}
+
+// Get entry by key. returns ErrNotFound on not exists or expired.
+func (s *Store) Get(ns, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(ns, key)
+}
+
+func (s *Store) get(ns, key string) (*Entry, error) {
+	val, err := s.db.Get(dbKey(ns, key))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	e, err := toEntry(val)
+	if err != nil {
+		return nil, err
+	}
+	if e.Expired(time.Now()) {
+		_ = s.delete(ns, key, e)
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+// OpenBlob open the blob file of the key
+func (s *Store) OpenBlob(ns, key string) (*os.File, error) {
+	return os.Open(s.blobFile(ns, key))
+}
+
+// Set value for the key. ttl <= 0 for never expire.
+func (s *Store) Set(ns, key string, val any, ttl time.Duration) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// remove old blob file
+	if old, err := s.get(ns, key); err == nil && old.Blob {
+		_ = os.Remove(s.blobFile(ns, key))
+	}
+
+	e := newEntry(ttl)
+	e.Value = val
+	return e, s.db.Set(dbKey(ns, key), e)
+}
+
+// SetBlob save the reader content as blob file for the key.
+func (s *Store) SetBlob(ns, key, cType string, r io.Reader, ttl time.Duration) (*Entry, error) {
+	fPath := s.blobFile(ns, key)
+	if err := fsutil.MkParentDir(fPath); err != nil {
+		return nil, err
+	}
+
+	// write to temp file first, avoid block other keys
+	tmpFile := fPath + ".tmp" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := io.Copy(f, r)
+	_ = f.Close()
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = os.Rename(tmpFile, fPath); err != nil {
+		_ = os.Remove(tmpFile)
+		return nil, err
+	}
+
+	e := newEntry(ttl)
+	e.Blob, e.Size, e.Type = true, size, cType
+	return e, s.db.Set(dbKey(ns, key), e)
+}
+
+// Incr the number value of the key, not exists key will start from 0. returns the new value.
+func (s *Store) Incr(ns, key string, by int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var num int64
+	e, err := s.get(ns, key)
+	if err == nil {
+		if num, err = toInt(e.Value); err != nil {
+			return 0, ErrNotNumber
+		}
+	} else if err != ErrNotFound {
+		return 0, err
+	} else {
+		e = newEntry(0)
+	}
+
+	num += by
+	e.Value = num
+	e.Updated = time.Now().Unix()
+	return num, s.db.Set(dbKey(ns, key), e)
+}
+
+// Delete the key. returns ErrNotFound on not exists.
+func (s *Store) Delete(ns, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.get(ns, key)
+	if err != nil {
+		return err
+	}
+	return s.delete(ns, key, e)
+}
+
+func (s *Store) delete(ns, key string, e *Entry) error {
+	if e.Blob {
+		_ = os.Remove(s.blobFile(ns, key))
+	}
+	return s.db.Delete(dbKey(ns, key))
+}
+
+// List keys in the namespace by prefix, sorted by key.
+func (s *Store) List(ns, prefix string) []*KeyInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []*KeyInfo
+	nsPfx := dbKey(ns, "")
+	for _, k := range s.db.Keys() {
+		key, ok := strings.CutPrefix(k, nsPfx)
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if e, err := s.get(ns, key); err == nil {
+			list = append(list, &KeyInfo{Key: key, Entry: e})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
+// Namespaces list with key count
+func (s *Store) Namespaces() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nsMap := make(map[string]int)
+	for _, k := range s.db.Keys() {
+		if ns, _, ok := strings.Cut(k, "/"); ok {
+			nsMap[ns]++
+		}
+	}
+	return nsMap
+}
+
+// Clear all keys in the namespace. returns the deleted number.
+func (s *Store) Clear(ns string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	nsPfx := dbKey(ns, "")
+	for _, k := range s.db.Keys() {
+		if !strings.HasPrefix(k, nsPfx) {
+			continue
+		}
+		if err := s.db.Delete(k); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, os.RemoveAll(filepath.Join(s.blobDir, ns))
+}
+
+// Purge the expired keys. returns the deleted number.
+func (s *Store) Purge() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	now := time.Now()
+	for _, k := range s.db.Keys() {
+		val, err := s.db.Get(k)
+		if err != nil {
+			continue
+		}
+
+		e, err := toEntry(val)
+		if err != nil || !e.Expired(now) {
+			continue
+		}
+
+		ns, key, _ := strings.Cut(k, "/")
+		if err = s.delete(ns, key, e); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func newEntry(ttl time.Duration) *Entry {
+	now := time.Now()
+	e := &Entry{Updated: now.Unix()}
+	if ttl > 0 {
+		e.Expire = now.Add(ttl).Unix()
+	}
+	return e
+}
+
+// the restored value from file is map[string]any
+func toEntry(val any) (*Entry, error) {
+	if e, ok := val.(*Entry); ok {
+		return e, nil
+	}
+
+	bs, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Entry{}
+	if err = json.Unmarshal(bs, e); err != nil {
+		return nil, fmt.Errorf("invalid store entry: %w", err)
+	}
+	return e, nil
+}
+
+func toInt(val any) (int64, error) {
+	switch v := val.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	}
+	return 0, errorx.Raw("not a number")
+}
@@ -0,0 +1,108 @@
+package kvstore
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func TestServer_ServeHTTP(t *testing.T) {
+	dir := t.TempDir()
+	st, err := Open(dir)
+	assert.NoErr(t, err)
+
+	srv := NewServer(st, "tk")
+	srv.Quiet = true
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	send := func(method, uri, cType, body string) (int, string) {
+		req, err := http.NewRequest(method, ts.URL+uri, strings.NewReader(body))
+		assert.NoErr(t, err)
+		req.Header.Set("Authorization", "Bearer tk")
+		if cType != "" {
+			req.Header.Set("Content-Type", cType)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoErr(t, err)
+		defer resp.Body.Close()
+		bs, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, strings.TrimSpace(string(bs))
+	}
+
+	// auth
+	resp, err := http.Get(ts.URL + "/kv")
+	assert.NoErr(t, err)
+	_ = resp.Body.Close()
+	assert.Eq(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// string, JSON and blob values
+	code, _ := send("PUT", "/kv/build/app.token", "application/x-www-form-urlencoded", "abc123")
+	assert.Eq(t, http.StatusOK, code)
+	code, _ = send("PUT", "/kv/build/app.info", "application/json", `{"ver": "1.2.0"}`)
+	assert.Eq(t, http.StatusOK, code)
+	code, _ = send("PUT", "/kv/build/dist/app.tgz", "", "binary data")
+	assert.Eq(t, http.StatusOK, code)
+
+	code, body := send("GET", "/kv/build/app.token", "", "")
+	assert.Eq(t, http.StatusOK, code)
+	assert.Eq(t, "abc123", body)
+	_, body = send("GET", "/kv/build/app.info", "", "")
+	assert.Eq(t, `{"ver":"1.2.0"}`, body)
+	_, body = send("GET", "/kv/build/dist/app.tgz", "", "")
+	assert.Eq(t, "binary data", body)
+	_, body = send("GET", "/kv/build/dist/app.tgz?meta=1", "", "")
+	assert.StrContains(t, body, `"blob":true`)
+
+	// incr
+	_, body = send("POST", "/kv/build/number?incr=1", "", "")
+	assert.Eq(t, "1", body)
+	_, body = send("POST", "/kv/build/number?incr=5", "", "")
+	assert.Eq(t, "6", body)
+	code, _ = send("POST", "/kv/build/app.token?incr=1", "", "")
+	assert.Eq(t, http.StatusBadRequest, code)
+
+	// list by prefix
+	_, body = send("GET", "/kv/build?prefix=app.", "", "")
+	assert.StrContains(t, body, `"key":"app.info"`)
+	assert.NotContains(t, body, "number")
+	_, body = send("GET", "/kv", "", "")
+	assert.Eq(t, `{"build":4}`, body)
+
+	// ttl
+	_, err = st.Set("tmp", "lock", "on", time.Second)
+	assert.NoErr(t, err)
+	e, err := st.Get("tmp", "lock")
+	assert.NoErr(t, err)
+	e.Expire = time.Now().Unix() - 1
+	n, err := st.Purge()
+	assert.NoErr(t, err)
+	assert.Eq(t, 1, n)
+	code, _ = send("GET", "/kv/tmp/lock", "", "")
+	assert.Eq(t, http.StatusNotFound, code)
+
+	// delete and reload from file
+	code, _ = send("DELETE", "/kv/build/app.token", "", "")
+	assert.Eq(t, http.StatusOK, code)
+
+	st2, err := Open(dir)
+	assert.NoErr(t, err)
+	num, err := st2.Incr("build", "number", 1)
+	assert.NoErr(t, err)
+	assert.Eq(t, int64(7), num)
+	assert.Len(t, st2.List("build", ""), 3)
+	_, err = st2.Get("build", "app.token")
+	assert.Eq(t, ErrNotFound, err)
+
+	// clear namespace
+	_, body = send("DELETE", "/kv/build", "", "")
+	assert.Eq(t, `{"deleted":3}`, body)
+	code, _ = send("PUT", "/kv/-bad/x", "text/plain", "v")
+	assert.Eq(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,271 @@
+package proxysrv
+
+import (
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/strutil"
+)
+
+// Matched rules for a request. key is protocol name.
+type Matched map[string]*MatchedRule
+
+// MatchedRule info
+type MatchedRule struct {
+	*ProxyRule
+	// Rest path after matched pattern path
+	Rest string
+}
+
+// Match rules for the request info. the first matched rule of each protocol will be used.
+func (s *ProxySrv) Match(scheme, host, reqPath string) Matched {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ms := make(Matched)
+	for _, rule := range s.Rules {
+		if _, ok := ms[rule.Protocol]; ok {
+			continue
+		}
+		if rest, ok := rule.Match(scheme, host, reqPath); ok {
+			ms[rule.Protocol] = &MatchedRule{ProxyRule: rule, Rest: rest}
+		}
+	}
+	return ms
+}
+
+// forward target info
+func (ms Matched) target(u *url.URL, host string) (target *url.URL, hostHeader string) {
+	target = &url.URL{Scheme: u.Scheme, Host: host, Path: u.Path, RawQuery: u.RawQuery}
+	if target.Scheme == "" {
+		target.Scheme = "http"
+	}
+
+	// forward to the URL
+	m := ms[ProtoHTTP]
+	if m == nil {
+		m = ms[ProtoHTTPS]
+	}
+	if m != nil {
+		to, _ := url.Parse(m.Operate)
+		target.Scheme, target.Host = to.Scheme, to.Host
+		if m.Rest != "" {
+			target.Path = strings.TrimSuffix(to.Path, "/") + m.Rest
+		} else {
+			target.Path = strutil.OrElse(to.Path, "/")
+		}
+		if to.RawQuery != "" {
+			target.RawQuery = strings.TrimPrefix(target.RawQuery+"&"+to.RawQuery, "&")
+		}
+		return target, to.Host
+	}
+
+	// map to the host, keep the Host header
+	if m = ms[ProtoHost]; m != nil {
+		target.Host = withPort(m.Operate, host)
+	}
+	return target, host
+}
+
+// ServeHTTP implements the http.Handler
+func (s *ProxySrv) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		s.handleConnect(w, r)
+		return
+	}
+
+	start := time.Now()
+	scheme, host := strutil.OrElse(r.URL.Scheme, "http"), strutil.OrElse(r.URL.Host, r.Host)
+	ms := s.Match(scheme, host, r.URL.Path)
+
+	rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	dist := "-"
+	defer func() {
+		s.logf("%s %s://%s%s -> %s <cyan>%d</> %s\n", r.Method, scheme, host, r.URL.RequestURI(), dist, rw.status, time.Since(start).Round(time.Millisecond))
+	}()
+
+	if m := ms[ProtoReqDelay]; m != nil {
+		sleepMs(m.Operate)
+	}
+	if m := ms[ProtoReqHeaders]; m != nil {
+		for k, v := range m.Config {
+			r.Header.Set(k, v)
+		}
+	}
+
+	switch {
+	case ms[ProtoRedirect] != nil:
+		dist = "redirect://" + ms[ProtoRedirect].Operate
+		ms.beforeWrite(rw.Header())
+		http.Redirect(rw, r, ms[ProtoRedirect].Operate, http.StatusFound)
+	case ms[ProtoStatus] != nil:
+		dist = "statusCode://" + ms[ProtoStatus].Operate
+		ms.beforeWrite(rw.Header())
+		code, err := strconv.Atoi(ms[ProtoStatus].Operate)
+		if err != nil || code < 100 {
+			code = http.StatusInternalServerError
+		}
+		rw.WriteHeader(code)
+	case ms[ProtoFile] != nil:
+		m := ms[ProtoFile]
+		fPath := m.resolvePath(m.Operate)
+		if fsInfo, err := os.Stat(fPath); err == nil && fsInfo.IsDir() {
+			fPath = filepath.Join(fPath, filepath.FromSlash(cleanRelPath(m.Rest)))
+		}
+
+		dist = "file://" + fPath
+		ms.beforeWrite(rw.Header())
+		serveFile(rw, fPath)
+	default:
+		// not a proxy request and no forward rule, avoid loop request self.
+		if !r.URL.IsAbs() && ms[ProtoHost] == nil && ms[ProtoHTTP] == nil && ms[ProtoHTTPS] == nil {
+			dist = "self"
+			http.Error(rw, "kite proxy server: not a proxy request", http.StatusBadRequest)
+			return
+		}
+
+		target, hostHeader := ms.target(r.URL, host)
+		dist = target.String()
+		s.forward(rw, r, ms, target, hostHeader)
+	}
+}
+
+func (s *ProxySrv) forward(w http.ResponseWriter, r *http.Request, ms Matched, target *url.URL, hostHeader string) {
+	rp := &httputil.ReverseProxy{
+		Transport: s.transport,
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL = target
+			pr.Out.Host = hostHeader
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if m := ms[ProtoReplaceStatus]; m != nil {
+				if code, err := strconv.Atoi(m.Operate); err == nil {
+					resp.StatusCode = code
+					resp.Status = strconv.Itoa(code) + " " + http.StatusText(code)
+				}
+			}
+			ms.beforeWrite(resp.Header)
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			s.logf("<red>ERROR</> forward to %s: %v\n", target, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	rp.ServeHTTP(w, r)
+}
+
+// apply response headers and delay before write response
+func (ms Matched) beforeWrite(h http.Header) {
+	if m := ms[ProtoResHeaders]; m != nil {
+		for k, v := range m.Config {
+			h.Set(k, v)
+		}
+	}
+	if m := ms[ProtoResDelay]; m != nil {
+		sleepMs(m.Operate)
+	}
+}
+
+// handle https proxy by tunnel. only the host and http(s) rules can be applied.
+func (s *ProxySrv) handleConnect(w http.ResponseWriter, r *http.Request) {
+	addr := r.Host
+	ms := s.Match("https", r.Host, "/")
+	if m := ms[ProtoHTTPS]; m != nil {
+		if to, err := url.Parse(m.Operate); err == nil {
+			addr = to.Host
+			if to.Port() == "" {
+				addr = net.JoinHostPort(to.Hostname(), strutil.OrCond(to.Scheme == "http", "80", "443"))
+			}
+		}
+	} else if m = ms[ProtoHost]; m != nil {
+		addr = withPort(m.Operate, r.Host)
+	}
+	s.logf("CONNECT %s -> %s\n", r.Host, addr)
+
+	dst, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	src, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		_ = dst.Close()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = src.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+
+	go tunnel(dst, src)
+	go tunnel(src, dst)
+}
+
+func tunnel(dst, src net.Conn) {
+	defer dst.Close()
+	defer src.Close()
+	_, _ = io.Copy(dst, src)
+}
+
+func serveFile(w http.ResponseWriter, fPath string) {
+	bs, err := os.ReadFile(fPath)
+	if err != nil {
+		http.Error(w, "kite proxy server: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		if typ := mime.TypeByExtension(filepath.Ext(fPath)); typ != "" {
+			w.Header().Set("Content-Type", typ)
+		} else {
+			w.Header().Set("Content-Type", http.DetectContentType(bs))
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(bs)
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap for http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// add port from the origin host on the new host not contains port
+func withPort(newHost, origin string) string {
+	if _, _, err := net.SplitHostPort(newHost); err == nil {
+		return newHost
+	}
+	if _, port, err := net.SplitHostPort(origin); err == nil {
+		return net.JoinHostPort(newHost, port)
+	}
+	return newHost
+}
+
+func cleanRelPath(p string) string {
+	// clean with root, avoid access parent dir
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+func sleepMs(ms string) {
+	if n, err := strconv.Atoi(ms); err == nil && n > 0 {
+		time.Sleep(time.Duration(n) * time.Millisecond)
+	}
+}
@@ -1,13 +1,40 @@
 // Package proxysrv provides a proxy server for Kite.
 package proxysrv
 
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/config/v2"
+	"github.com/gookit/config/v2/yaml"
+	"github.com/gookit/goutil/x/ccolor"
+)
+
+// RuleFileExts allowed rule file extensions on load from RuleDirs
+var RuleFileExts = []string{".txt", ".rules", ".whistle"}
+
 // Config struct
 type Config struct {
-	Port       int
-	Rules      []string
-	RuleFiles  []string
-	RuleDirs   []string
-	GlobalVars map[string]string
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// Rules inline rule lines. see ParseRules for the rule syntax.
+	Rules []string `json:"rules"`
+	// RuleFiles rule file paths
+	RuleFiles []string `json:"rule_files"`
+	// RuleDirs load rule files from the dirs. see RuleFileExts
+	RuleDirs []string `json:"rule_dirs"`
+	// GlobalVars for render rule lines. usage: ${name}
+	GlobalVars map[string]string `json:"global_vars"`
+	// Quiet mode, dont print request logs
+	Quiet bool `json:"quiet"`
 }
 
 type ConfigFn func(c *Config)
@@ -20,6 +47,10 @@ type ConfigFn func(c *Config)
 type ProxySrv struct {
 	*Config
 	Rules []*ProxyRule
+
+	mu sync.RWMutex
+	// transport for forward requests
+	transport *http.Transport
 }
 
 // NewProxySrv create a new ProxySrv instance
@@ -28,6 +59,13 @@ func NewProxySrv(fns ...ConfigFn) *ProxySrv {
 		Config: &Config{
 			Port: 8090,
 		},
+		transport: &http.Transport{
+			Proxy:                 nil, // dont use env proxy
+			DialContext:           (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			ResponseHeaderTimeout: 60 * time.Second,
+		},
 	}
 
 	for _, fn := range fns {
@@ -36,24 +74,90 @@ func NewProxySrv(fns ...ConfigFn) *ProxySrv {
 	return ps
 }
 
-func (s *ProxySrv) Start() error {
+// LoadConfigFile load config from YAML or JSON file
+func (s *ProxySrv) LoadConfigFile(cfgFile string) error {
+	cfg := config.NewGeneric("proxy-config", config.WithTagName("json"))
+	cfg.AddDriver(yaml.Driver)
+
+	if err := cfg.LoadFiles(cfgFile); err != nil {
+		return fmt.Errorf("failed to load config file %s: %w", cfgFile, err)
+	}
+	if err := cfg.Decode(s.Config); err != nil {
+		return fmt.Errorf("failed to decode proxy config: %w", err)
+	}
+	return nil
+}
+
+// AddRule parse and add rule lines
+func (s *ProxySrv) AddRule(lines ...string) error {
+	rules, err := ParseRules(strings.Join(lines, "\n"), "inline", s.GlobalVars)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.Rules = append(s.Rules, rules...)
+	s.mu.Unlock()
 	return nil
 }
 
-// ProxyRule definition
-type ProxyRule struct {
-	Name  string // id name
-	Group string
-	Index int // index in rules
-	Sort  int // sort number
+// LoadRules load rules from Config.Rules, RuleFiles and RuleDirs. will reset old rules.
+func (s *ProxySrv) LoadRules() error {
+	rules, err := ParseRules(strings.Join(s.Config.Rules, "\n"), "inline", s.GlobalVars)
+	if err != nil {
+		return err
+	}
 
-	// Protocol name of rule. http, https, ws, wss, file, ...
-	Protocol string
+	files := append([]string{}, s.RuleFiles...)
+	for _, dir := range s.RuleDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
 
-	// Pattern from host or path
-	Pattern string
-	// Operate dist host or path
-	Operate string
-	// Config for the rule
-	Config map[string]string
+		for _, ent := range entries {
+			if !ent.IsDir() && slices.Contains(RuleFileExts, filepath.Ext(ent.Name())) {
+				files = append(files, filepath.Join(dir, ent.Name()))
+			}
+		}
+	}
+
+	for _, fPath := range files {
+		fRules, err := ParseRuleFile(fPath, s.GlobalVars)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, fRules...)
+	}
+
+	s.mu.Lock()
+	s.Rules = rules
+	s.mu.Unlock()
+	return nil
+}
+
+// Addr get listen address
+func (s *ProxySrv) Addr() string {
+	host := s.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return host + ":" + strconv.Itoa(s.Port)
+}
+
+// Start the proxy server. will block until the server stopped.
+func (s *ProxySrv) Start() error {
+	if err := s.LoadRules(); err != nil {
+		return err
+	}
+
+	ccolor.Infof("Proxy server listening on http://%s, loaded %d rules\n", s.Addr(), len(s.Rules))
+	ccolor.Printf("Usage: <cyan>export http_proxy=http://%s https_proxy=http://%s</>\n", s.Addr(), s.Addr())
+	return http.ListenAndServe(s.Addr(), s)
+}
+
+func (s *ProxySrv) logf(format string, args ...any) {
+	if !s.Quiet {
+		ccolor.Printf(format, args...)
+	}
 }
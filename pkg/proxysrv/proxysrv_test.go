@@ -0,0 +1,124 @@
+package proxysrv
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(`
+# comments
+api.example.com ${local}
+/v1/users ./mock.json resHeaders://X-Mock=1&X-Env=dev
+www.example.com/old redirect://https://www.example.com/new
+`, "test", map[string]string{"local": "127.0.0.1:8080"})
+	assert.NoErr(t, err)
+	assert.Len(t, rules, 4)
+
+	assert.Eq(t, ProtoHost, rules[0].Protocol)
+	assert.Eq(t, "127.0.0.1:8080", rules[0].Operate)
+	assert.Eq(t, ProtoFile, rules[1].Protocol)
+	assert.Eq(t, "1", rules[2].Config["X-Mock"])
+	assert.Eq(t, ProtoRedirect, rules[3].Protocol)
+	assert.Eq(t, "https://www.example.com/new", rules[3].Operate)
+	assert.Eq(t, "test#4", rules[1].Name)
+
+	rest, ok := rules[1].Match("http", "any.host:80", "/v1/users/12")
+	assert.True(t, ok)
+	assert.Eq(t, "/12", rest)
+	_, ok = rules[1].Match("http", "any.host", "/v1/usersx")
+	assert.False(t, ok)
+	_, ok = rules[0].Match("https", "api.example.com:443", "/")
+	assert.True(t, ok)
+
+	_, err = ParseRules("api.example.com unknown://val", "test", nil)
+	assert.ErrSubMsg(t, err, "not supported rule protocol")
+}
+
+func newTestProxy(t *testing.T, rules ...string) *http.Client {
+	ps := NewProxySrv(func(c *Config) {
+		c.Quiet = true
+	})
+	assert.NoErr(t, ps.AddRule(rules...))
+
+	srv := httptest.NewServer(ps)
+	t.Cleanup(srv.Close)
+
+	proxyURL, _ := url.Parse(srv.URL)
+	return &http.Client{
+		Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	defer resp.Body.Close()
+	bs, err := io.ReadAll(resp.Body)
+	assert.NoErr(t, err)
+	return string(bs)
+}
+
+func TestProxySrv_forward(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Env", r.Header.Get("X-Env"))
+		_, _ = io.WriteString(w, r.Host+" "+r.URL.RequestURI())
+	}))
+	defer upstream.Close()
+	upAddr := upstream.Listener.Addr().String()
+
+	cli := newTestProxy(t,
+		"api.example.com "+upAddr+" reqHeaders://X-Env=dev",
+		"api.example.com/v2 http://"+upAddr+"/api/v2 replaceStatus://201 resHeaders://X-Mock=1",
+	)
+
+	resp, err := cli.Get("http://api.example.com/users?id=1")
+	assert.NoErr(t, err)
+	assert.Eq(t, "dev", resp.Header.Get("X-Env"))
+	assert.Eq(t, "api.example.com /users?id=1", readBody(t, resp))
+
+	resp, err = cli.Get("http://api.example.com/v2/users")
+	assert.NoErr(t, err)
+	assert.Eq(t, 201, resp.StatusCode)
+	assert.Eq(t, "1", resp.Header.Get("X-Mock"))
+	assert.Eq(t, upAddr+" /api/v2/users", readBody(t, resp))
+}
+
+func TestProxySrv_local(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoErr(t, os.WriteFile(filepath.Join(dir, "mock.json"), []byte(`{"name": "mock"}`), 0644))
+
+	cli := newTestProxy(t,
+		"/v1/users file://"+filepath.Join(dir, "mock.json"),
+		"/static "+dir,
+		"api.example.com/login statusCode://403 resDelay://10",
+		"www.example.com/old redirect://https://www.example.com/new",
+	)
+
+	resp, err := cli.Get("http://api.example.com/v1/users")
+	assert.NoErr(t, err)
+	assert.Eq(t, 200, resp.StatusCode)
+	assert.Eq(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Eq(t, `{"name": "mock"}`, readBody(t, resp))
+
+	resp, err = cli.Get("http://any.host/static/mock.json")
+	assert.NoErr(t, err)
+	assert.Eq(t, `{"name": "mock"}`, readBody(t, resp))
+
+	resp, err = cli.Get("http://api.example.com/login")
+	assert.NoErr(t, err)
+	assert.Eq(t, 403, resp.StatusCode)
+
+	resp, err = cli.Get("http://www.example.com/old")
+	assert.NoErr(t, err)
+	assert.Eq(t, 302, resp.StatusCode)
+	assert.Eq(t, "https://www.example.com/new", resp.Header.Get("Location"))
+}
@@ -0,0 +1,272 @@
+package proxysrv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gookit/goutil/errorx"
+)
+
+// rule protocols
+const (
+	// ProtoHost map request to the host. eg: host://127.0.0.1:8080
+	ProtoHost = "host"
+	// ProtoHTTP forward request to the http URL. eg: http://127.0.0.1:8080/api
+	ProtoHTTP = "http"
+	// ProtoHTTPS forward request to the https URL
+	ProtoHTTPS = "https"
+	// ProtoFile response local file or file in dir. eg: file://./mock.json
+	ProtoFile = "file"
+	// ProtoStatus response the status code directly. eg: statusCode://404
+	ProtoStatus = "statusCode"
+	// ProtoReplaceStatus replace the upstream response status. eg: replaceStatus://500
+	ProtoReplaceStatus = "replaceStatus"
+	// ProtoRedirect response 302 redirect to the URL
+	ProtoRedirect = "redirect"
+	// ProtoReqHeaders set request headers. eg: reqHeaders://X-Env=dev&X-Debug=1
+	ProtoReqHeaders = "reqHeaders"
+	// ProtoResHeaders set response headers. value format like reqHeaders, or JSON object
+	ProtoResHeaders = "resHeaders"
+	// ProtoReqDelay delay milliseconds before forward request
+	ProtoReqDelay = "reqDelay"
+	// ProtoResDelay delay milliseconds before write response
+	ProtoResDelay = "resDelay"
+)
+
+// AllowProtocols list
+var AllowProtocols = []string{
+	ProtoHost, ProtoHTTP, ProtoHTTPS, ProtoFile, ProtoStatus, ProtoReplaceStatus,
+	ProtoRedirect, ProtoReqHeaders, ProtoResHeaders, ProtoReqDelay, ProtoResDelay,
+}
+
+// ProxyRule definition
+type ProxyRule struct {
+	Name  string // id name
+	Group string
+	Index int // index in rules
+	Sort  int // sort number
+
+	// Protocol name of rule. http, https, ws, wss, file, ...
+	Protocol string
+
+	// Pattern from host or path
+	Pattern string
+	// Operate dist host or path
+	Operate string
+	// Config for the rule. eg: headers for reqHeaders, resHeaders
+	Config map[string]string
+
+	// parsed pattern
+	scheme, host, path string
+	// base dir for relative file path
+	baseDir string
+}
+
+// Match the request scheme, host and path. returns the rest path after matched pattern path.
+//
+// Pattern formats:
+//
+//	api.example.com           match host, any path
+//	api.example.com:8080/v1   match host with port and path prefix
+//	*.example.com             wildcard host
+//	/v1/users                 match path prefix on any host
+//	/static/*                 wildcard path prefix
+//	https://api.example.com   match scheme and host
+func (r *ProxyRule) Match(scheme, host, reqPath string) (rest string, ok bool) {
+	if r.scheme != "" && r.scheme != scheme {
+		return "", false
+	}
+
+	if r.host != "" {
+		hostname := host
+		if !strings.Contains(r.host, ":") {
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				hostname = h
+			}
+		}
+		if ok, _ = path.Match(r.host, strings.ToLower(hostname)); !ok {
+			return "", false
+		}
+	}
+
+	if prefix, isWild := strings.CutSuffix(r.path, "*"); isWild {
+		if strings.HasPrefix(reqPath, prefix) {
+			return reqPath[len(prefix):], true
+		}
+		return "", false
+	}
+
+	prefix := strings.TrimSuffix(r.path, "/")
+	if prefix == "" || reqPath == prefix || strings.HasPrefix(reqPath, prefix+"/") {
+		return reqPath[len(prefix):], true
+	}
+	return "", false
+}
+
+// String rule to string
+func (r *ProxyRule) String() string {
+	return fmt.Sprintf("%s %s://%s", r.Pattern, r.Protocol, r.Operate)
+}
+
+func (r *ProxyRule) parsePattern() {
+	pattern := r.Pattern
+	if scheme, rest, ok := strings.Cut(pattern, "://"); ok {
+		r.scheme, pattern = strings.ToLower(scheme), rest
+	}
+
+	if strings.HasPrefix(pattern, "/") {
+		r.path = pattern
+		return
+	}
+
+	host, rest, _ := strings.Cut(pattern, "/")
+	r.host = strings.ToLower(host)
+	if rest != "" {
+		r.path = "/" + rest
+	}
+}
+
+// resolve relative file path by rule base dir
+func (r *ProxyRule) resolvePath(fPath string) string {
+	if strings.HasPrefix(fPath, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			fPath = home + fPath[1:]
+		}
+	}
+	if r.baseDir == "" || filepath.IsAbs(fPath) {
+		return fPath
+	}
+	return filepath.Join(r.baseDir, fPath)
+}
+
+// ParseRuleFile parse rules from a rule file. relative file path in rules is based on the file dir.
+func ParseRuleFile(fPath string, vars map[string]string) ([]*ProxyRule, error) {
+	bs, err := os.ReadFile(fPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ParseRules(string(bs), filepath.Base(fPath), vars)
+	if err != nil {
+		return nil, errorx.Rf("parse rule file %s error: %v", fPath, err)
+	}
+
+	for _, rule := range rules {
+		rule.baseDir = filepath.Dir(fPath)
+	}
+	return rules, nil
+}
+
+var varReg = regexp.MustCompile(`\$\{(\w+)}`)
+
+// ParseRules parse rules text. one rule per line, format: pattern operation [operation ...]
+//
+// Examples:
+//
+//	# comments line
+//	api.example.com 127.0.0.1:8080
+//	api.example.com/v2 http://127.0.0.1:8081/api reqHeaders://X-Env=dev
+//	/v1/users file://./mock.json resHeaders://Content-Type=application/json
+//	/slow reqDelay://1000
+//	www.example.com/old redirect://https://www.example.com/new
+//	api.example.com/v1/login statusCode://403
+//	${api_host} host://${local_addr}
+//
+// Operation without protocol: a path like /, ./, ~ as file, otherwise as host.
+func ParseRules(text, group string, vars map[string]string) ([]*ProxyRule, error) {
+	var rules []*ProxyRule
+	for lineNo, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if len(vars) > 0 {
+			line = varReg.ReplaceAllStringFunc(line, func(s string) string {
+				if val, ok := vars[s[2:len(s)-1]]; ok {
+					return val
+				}
+				return s
+			})
+		}
+
+		nodes := strings.Fields(line)
+		if len(nodes) < 2 {
+			return nil, errorx.Rf("line %d: invalid rule %q, must be: pattern operation", lineNo+1, line)
+		}
+
+		for _, op := range nodes[1:] {
+			rule, err := newRule(nodes[0], op)
+			if err != nil {
+				return nil, errorx.Rf("line %d: %v", lineNo+1, err)
+			}
+
+			rule.Group = group
+			rule.Index = len(rules)
+			rule.Name = fmt.Sprintf("%s#%d", group, lineNo+1)
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func newRule(pattern, op string) (*ProxyRule, error) {
+	rule := &ProxyRule{Pattern: pattern}
+	rule.parsePattern()
+
+	if proto, val, ok := strings.Cut(op, "://"); ok {
+		rule.Protocol, rule.Operate = proto, val
+	} else if strings.HasPrefix(op, "/") || strings.HasPrefix(op, ".") || strings.HasPrefix(op, "~") {
+		rule.Protocol, rule.Operate = ProtoFile, op
+	} else {
+		rule.Protocol, rule.Operate = ProtoHost, op
+	}
+
+	switch rule.Protocol {
+	case ProtoHTTP, ProtoHTTPS:
+		// keep full URL for forward
+		rule.Operate = op
+		if _, err := url.Parse(op); err != nil {
+			return nil, err
+		}
+	case ProtoReqHeaders, ProtoResHeaders:
+		headers, err := parseHeaders(rule.Operate)
+		if err != nil {
+			return nil, errorx.Rf("invalid %s value %q: %v", rule.Protocol, rule.Operate, err)
+		}
+		rule.Config = headers
+	case ProtoHost, ProtoFile, ProtoStatus, ProtoReplaceStatus, ProtoRedirect, ProtoReqDelay, ProtoResDelay:
+	default:
+		return nil, errorx.Rf("not supported rule protocol %q", rule.Protocol)
+	}
+
+	if rule.Operate == "" {
+		return nil, errorx.Rf("empty operate value for rule %q", op)
+	}
+	return rule, nil
+}
+
+// parse headers value. format: key=value&key2=value2 or JSON object
+func parseHeaders(val string) (map[string]string, error) {
+	headers := make(map[string]string)
+	if strings.HasPrefix(val, "{") {
+		err := json.Unmarshal([]byte(val), &headers)
+		return headers, err
+	}
+
+	q, err := url.ParseQuery(val)
+	if err != nil {
+		return nil, err
+	}
+	for k := range q {
+		headers[k] = q.Get(k)
+	}
+	return headers, nil
+}
@@ -0,0 +1,64 @@
+package reqrec
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/errorx"
+)
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// FetchList get record summaries from a running server. eg: http://127.0.0.1:10425
+//
+// token is required on the server set Recorder.Token
+func FetchList(server, token string, limit int) ([]*Summary, error) {
+	var list []*Summary
+	err := fetchJSON(serverURL(server)+"?limit="+strconv.Itoa(limit), token, &list)
+	return list, err
+}
+
+// Fetch record by ID from a running server. id can be "last".
+func Fetch(server, token, id string) (*Record, error) {
+	rec := &Record{}
+	if err := fetchJSON(serverURL(server)+"/"+id, token, rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func serverURL(server string) string {
+	if !strings.Contains(server, "://") {
+		server = "http://" + server
+	}
+	return strings.TrimSuffix(server, "/") + PathPrefix
+}
+
+func fetchJSON(apiURL, token string, ptr any) error {
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set(TokenHeader, token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errorx.Rawf("request %s error, status: %d, body: %s", apiURL, resp.StatusCode, bs)
+	}
+	return json.Unmarshal(bs, ptr)
+}
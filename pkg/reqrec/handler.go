@@ -0,0 +1,108 @@
+package reqrec
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/inhere/kite-go/pkg/util/httputil"
+)
+
+// PathPrefix for query recorded requests. requests under the path will not be recorded.
+//
+// Routes:
+//
+//	GET    /_requests       list record summaries, newest first. query: limit=20
+//	GET    /_requests/last  get the last record
+//	GET    /_requests/{id}  get record by ID
+//	DELETE /_requests       clear records in buffer
+const PathPrefix = "/_requests"
+
+// TokenHeader for query recorded requests on Recorder.Token is set. also allow use query "token".
+const TokenHeader = "X-Record-Token"
+
+// IsQueryPath check the path is for query recorded requests
+func IsQueryPath(path string) bool {
+	return path == PathPrefix || strings.HasPrefix(path, PathPrefix+"/")
+}
+
+// Skip check the request path should not be recorded. eg: query path, SkipPaths
+func (rc *Recorder) Skip(path string) bool {
+	if IsQueryPath(path) {
+		return true
+	}
+	for _, sp := range rc.SkipPaths {
+		if sp == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware record requests before call the next handler.
+func (rc *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsQueryPath(r.URL.Path) {
+			rc.ServeHTTP(w, r)
+			return
+		}
+		if rc.Skip(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, err := rc.Record(r); err != nil {
+			http.Error(w, "record request error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ServeHTTP handle the query routes, see PathPrefix. will check the token on Recorder.Token is set.
+func (rc *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rc.Token != "" {
+		token := r.Header.Get(TokenHeader)
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(rc.Token)) != 1 {
+			httputil.WriteJSONIndent(w, http.StatusUnauthorized, map[string]any{"error": "invalid record token"})
+			return
+		}
+	}
+
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, PathPrefix), "/")
+	if name == "" {
+		switch r.Method {
+		case http.MethodGet:
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			list := rc.List(limit)
+			sums := make([]*Summary, len(list))
+			for i, rec := range list {
+				sums[i] = rec.Summary()
+			}
+			httputil.WriteJSONIndent(w, http.StatusOK, sums)
+		case http.MethodDelete:
+			rc.Clear()
+			httputil.WriteJSONIndent(w, http.StatusOK, map[string]any{"cleared": true})
+		default:
+			httputil.WriteJSONIndent(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		}
+		return
+	}
+
+	var rec *Record
+	if name == "last" {
+		rec = rc.Last()
+	} else if id, err := strconv.ParseInt(name, 10, 64); err == nil {
+		rec = rc.Get(id)
+	}
+
+	if rec == nil {
+		httputil.WriteJSONIndent(w, http.StatusNotFound, map[string]any{"error": "record not found: " + name})
+		return
+	}
+	httputil.WriteJSONIndent(w, http.StatusOK, rec)
+}
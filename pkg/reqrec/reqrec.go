@@ -0,0 +1,352 @@
+// Package reqrec record received http requests, for debug webhook payloads and replay them.
+package reqrec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/goutil/errorx"
+	"github.com/gookit/goutil/fsutil"
+)
+
+// DefaultSize of the ring buffer
+var DefaultSize = 100
+
+// MaxBodySize max recorded body size, the exceeded part will be dropped.
+var MaxBodySize int64 = 10 << 20
+
+// RedactedValue for replace the sensitive header values
+const RedactedValue = "[REDACTED]"
+
+// sensitive headers will be redacted on record. also will redact the X-*-Token headers.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// IsSensitiveHeader check the header maybe contains credentials. eg: Authorization, X-Gitlab-Token
+func IsSensitiveHeader(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	if strings.HasPrefix(name, "X-") && strings.HasSuffix(name, "-Token") {
+		return true
+	}
+
+	for _, sh := range sensitiveHeaders {
+		if sh == name {
+			return true
+		}
+	}
+	return false
+}
+
+// redact the sensitive header values
+func redactHeader(h http.Header) http.Header {
+	for name, vals := range h {
+		if IsSensitiveHeader(name) {
+			for i := range vals {
+				vals[i] = RedactedValue
+			}
+		}
+	}
+	return h
+}
+
+// Record of a received request
+type Record struct {
+	ID     int64       `json:"id"`
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method"`
+	Host   string      `json:"host"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+	// RemoteAddr of the client
+	RemoteAddr string `json:"remote_addr"`
+}
+
+// URI path with query
+func (r *Record) URI() string {
+	if r.Query == "" {
+		return r.Path
+	}
+	return r.Path + "?" + r.Query
+}
+
+// Summary line of the record
+func (r *Record) Summary() *Summary {
+	return &Summary{
+		ID:     r.ID,
+		Time:   r.Time,
+		Method: r.Method,
+		URI:    r.URI(),
+		Size:   len(r.Body),
+		Type:   r.Header.Get("Content-Type"),
+		Event:  EventName(r.Header),
+	}
+}
+
+// hop-by-hop and auto set headers, will not copy on replay.
+var skipHeaders = []string{"Host", "Content-Length", "Connection", "Accept-Encoding", "Keep-Alive", "Transfer-Encoding"}
+
+// BuildRequest for replay the record to target URL.
+//
+// target without path will append the recorded path and query. eg:
+//
+//	http://localhost:8080          => http://localhost:8080/webhook/gitlab?a=b
+//	http://localhost:8080/api/hook => http://localhost:8080/api/hook
+func (r *Record) BuildRequest(target string) (*http.Request, error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+
+	// only scheme and host, append the recorded path
+	if i := strings.Index(target, "://"); !strings.Contains(strings.TrimSuffix(target[i+3:], "/"), "/") {
+		target = strings.TrimSuffix(target, "/") + r.URI()
+	}
+
+	req, err := http.NewRequest(r.Method, target, strings.NewReader(r.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, vals := range r.Header {
+		if !isSkipHeader(name) {
+			req.Header[name] = vals
+		}
+	}
+	return req, nil
+}
+
+func isSkipHeader(name string) bool {
+	for _, skip := range skipHeaders {
+		if strings.EqualFold(skip, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary of a record
+type Summary struct {
+	ID     int64     `json:"id"`
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	URI    string    `json:"uri"`
+	Size   int       `json:"size"`
+	Type   string    `json:"type,omitempty"`
+	// Event name of the webhook. eg: push, Merge Request Hook
+	Event string `json:"event,omitempty"`
+}
+
+// EventName of the webhook request, from GitHub, GitLab or Gitee event header.
+func EventName(h http.Header) string {
+	for _, name := range []string{"X-GitHub-Event", "X-Gitlab-Event", "X-Gitee-Event"} {
+		if val := h.Get(name); val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+// Recorder store received requests in a ring buffer, and append to a JSONL file if set.
+type Recorder struct {
+	// Token for query the records, see TokenHeader. no check on empty.
+	Token string
+	// SkipPaths the request paths will not be recorded
+	SkipPaths []string
+
+	mu   sync.RWMutex
+	size int
+	seq  int64
+	// ring buffer
+	buf  []*Record
+	next int
+	full bool
+	// optional JSONL file for persistent
+	file *os.File
+}
+
+// New recorder with buffer size, size <= 0 will use DefaultSize.
+func New(size int) *Recorder {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Recorder{size: size, buf: make([]*Record, size)}
+}
+
+// OpenFile for append records as JSONL. will load the last records in the file to buffer.
+func (rc *Recorder) OpenFile(fpath string) error {
+	if fsutil.IsFile(fpath) {
+		list, err := ReadFile(fpath)
+		if err != nil {
+			return err
+		}
+		for _, r := range list {
+			rc.add(r)
+			rc.seq = max(rc.seq, r.ID)
+		}
+	}
+
+	f, err := fsutil.OpenAppendFile(fpath)
+	if err != nil {
+		return err
+	}
+
+	rc.file = f
+	return nil
+}
+
+// Record the request, the body will be restored for the next handlers.
+//
+// NOTE: only the first MaxBodySize bytes of body are recorded, the sensitive headers are redacted.
+func (rc *Recorder) Record(r *http.Request) (*Record, error) {
+	rec := &Record{
+		Time:       time.Now(),
+		Method:     r.Method,
+		Host:       r.Host,
+		Path:       r.URL.Path,
+		Query:      r.URL.RawQuery,
+		Header:     redactHeader(r.Header.Clone()),
+		RemoteAddr: r.RemoteAddr,
+	}
+
+	if r.Body != nil {
+		bs, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize))
+		if err != nil {
+			return nil, err
+		}
+
+		// the next handlers can still read the full body
+		rec.Body = string(bs)
+		r.Body = &readCloser{Reader: io.MultiReader(bytes.NewReader(bs), r.Body), Closer: r.Body}
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.seq++
+	rec.ID = rc.seq
+	rc.add(rec)
+
+	if rc.file != nil {
+		bs, err := json.Marshal(rec)
+		if err != nil {
+			return rec, err
+		}
+		if _, err = rc.file.Write(append(bs, '\n')); err != nil {
+			return rec, err
+		}
+	}
+	return rec, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// add to ring buffer
+func (rc *Recorder) add(rec *Record) {
+	rc.buf[rc.next] = rec
+	rc.next = (rc.next + 1) % rc.size
+	if rc.next == 0 {
+		rc.full = true
+	}
+}
+
+// Len of the recorded in buffer
+func (rc *Recorder) Len() int {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	if rc.full {
+		return rc.size
+	}
+	return rc.next
+}
+
+// List records in buffer, the newest first. limit <= 0 for all.
+func (rc *Recorder) List(limit int) []*Record {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	n := rc.next
+	if rc.full {
+		n = rc.size
+	}
+	if limit > 0 && limit < n {
+		n = limit
+	}
+
+	list := make([]*Record, 0, n)
+	for i := 1; i <= n; i++ {
+		list = append(list, rc.buf[(rc.next-i+rc.size)%rc.size])
+	}
+	return list
+}
+
+// Get record by ID
+func (rc *Recorder) Get(id int64) *Record {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	for _, rec := range rc.buf {
+		if rec != nil && rec.ID == id {
+			return rec
+		}
+	}
+	return nil
+}
+
+// Last record, return nil on no records.
+func (rc *Recorder) Last() *Record {
+	if list := rc.List(1); len(list) > 0 {
+		return list[0]
+	}
+	return nil
+}
+
+// Clear records in buffer, will not clear the file.
+func (rc *Recorder) Clear() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.buf = make([]*Record, rc.size)
+	rc.next, rc.full = 0, false
+}
+
+// Close the file
+func (rc *Recorder) Close() error {
+	if rc.file != nil {
+		return rc.file.Close()
+	}
+	return nil
+}
+
+// ReadFile read records from the JSONL file
+func ReadFile(fpath string) ([]*Record, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var list []*Record
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 0, 64*1024), int(MaxBodySize)*2)
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		rec := &Record{}
+		if err = json.Unmarshal(line, rec); err != nil {
+			return nil, errorx.Rf("invalid record line %d in %s: %v", len(list)+1, fpath, err)
+		}
+		list = append(list, rec)
+	}
+	return list, s.Err()
+}
@@ -0,0 +1,115 @@
+package reqrec
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func TestRecorder_record_query_replay(t *testing.T) {
+	rc := New(2)
+	recFile := filepath.Join(t.TempDir(), "requests.jsonl")
+	assert.NoErr(t, rc.OpenFile(recFile))
+
+	var gotBody string
+	srv := httptest.NewServer(rc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, _ := io.ReadAll(r.Body)
+		gotBody = string(bs)
+		_, _ = io.WriteString(w, "ok")
+	})))
+	defer srv.Close()
+
+	for _, body := range []string{`{"n": 1}`, `{"n": 2}`, `{"n": 3}`} {
+		req, err := http.NewRequest("POST", srv.URL+"/webhook/gitlab?from=test", strings.NewReader(body))
+		assert.NoErr(t, err)
+		req.Header.Set("X-Gitlab-Event", "Push Hook")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoErr(t, err)
+		_ = resp.Body.Close()
+	}
+
+	// body is restored for next handler
+	assert.Eq(t, `{"n": 3}`, gotBody)
+	// ring buffer keep the newest 2
+	assert.Eq(t, 2, rc.Len())
+	assert.Nil(t, rc.Get(1))
+	assert.Eq(t, int64(3), rc.Last().ID)
+
+	// query by the http api
+	list, err := FetchList(srv.URL, "", 0)
+	assert.NoErr(t, err)
+	assert.Len(t, list, 2)
+	assert.Eq(t, "/webhook/gitlab?from=test", list[0].URI)
+	assert.Eq(t, "Push Hook", list[0].Event)
+
+	rec, err := Fetch(srv.URL, "", "2")
+	assert.NoErr(t, err)
+	assert.Eq(t, `{"n": 2}`, rec.Body)
+	_, err = Fetch(srv.URL, "", "1")
+	assert.Err(t, err)
+
+	// require token, skip paths are not recorded
+	rc.Token = "tk"
+	rc.SkipPaths = []string{"/_hook-runs"}
+	_, err = Fetch(srv.URL, "", "last")
+	assert.ErrSubMsg(t, err, "status: 401")
+	resp, err := http.Get(srv.URL + "/_hook-runs")
+	assert.NoErr(t, err)
+	_ = resp.Body.Close()
+	last, err := Fetch(srv.URL, "tk", "last")
+	assert.NoErr(t, err)
+	assert.Eq(t, int64(3), last.ID)
+
+	// reload from the JSONL file
+	assert.NoErr(t, rc.Close())
+	all, err := ReadFile(recFile)
+	assert.NoErr(t, err)
+	assert.Len(t, all, 3)
+
+	rc2 := New(10)
+	assert.NoErr(t, rc2.OpenFile(recFile))
+	defer rc2.Close()
+	assert.Eq(t, 3, rc2.Len())
+	assert.Eq(t, `{"n": 1}`, rc2.Get(1).Body)
+
+	// replay to another URL
+	req, err := rec.BuildRequest("localhost:8080")
+	assert.NoErr(t, err)
+	assert.Eq(t, "http://localhost:8080/webhook/gitlab?from=test", req.URL.String())
+	assert.Eq(t, "Push Hook", req.Header.Get("X-Gitlab-Event"))
+	assert.Eq(t, "", req.Header.Get("Content-Length"))
+
+	req, err = rec.BuildRequest("http://localhost:8080/api/hook")
+	assert.NoErr(t, err)
+	assert.Eq(t, "/api/hook", req.URL.Path)
+}
+
+func TestRecorder_Record_limitBody_redact(t *testing.T) {
+	old := MaxBodySize
+	MaxBodySize = 5
+	defer func() { MaxBodySize = old }()
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader("0123456789"))
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Gitlab-Token", "s3cret")
+	req.Header.Set("X-Gitlab-Event", "Push Hook")
+
+	rec, err := New(2).Record(req)
+	assert.NoErr(t, err)
+	assert.Eq(t, "01234", rec.Body)
+	assert.Eq(t, RedactedValue, rec.Header.Get("Authorization"))
+	assert.Eq(t, RedactedValue, rec.Header.Get("X-Gitlab-Token"))
+	assert.Eq(t, "Push Hook", rec.Header.Get("X-Gitlab-Event"))
+
+	// the next handler can read the full body and raw headers
+	bs, err := io.ReadAll(req.Body)
+	assert.NoErr(t, err)
+	assert.Eq(t, "0123456789", string(bs))
+	assert.Eq(t, "s3cret", req.Header.Get("X-Gitlab-Token"))
+	assert.NoErr(t, req.Body.Close())
+}
@@ -0,0 +1,151 @@
+package sharesrv
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/goutil/mathutil"
+	"github.com/inhere/kite-go/pkg/util/httputil"
+)
+
+// FileInfo of the uploaded file
+type FileInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	// MIME type of the file
+	MIME string `json:"mime"`
+	// Preview type for the page. allow: image, text, video, audio. empty for not support.
+	Preview string `json:"preview,omitempty"`
+
+	path string
+}
+
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	// limit the body size, keep 1MB for other form data
+	r.Body = http.MaxBytesReader(w, r.Body, s.MaxSize+1<<20)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "read upload file error: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	if header.Size > s.MaxSize {
+		writeError(w, http.StatusRequestEntityTooLarge, "the file size exceeds the limit "+mathutil.DataSize(uint64(s.MaxSize)))
+		return
+	}
+
+	name := filepath.Base(header.Filename)
+	if !s.AllowExt(name) {
+		writeError(w, http.StatusUnsupportedMediaType, "not allowed file type: "+filepath.Ext(name))
+		return
+	}
+
+	fi, err := s.saveFile(name, file)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "save file error: "+err.Error())
+		return
+	}
+
+	user := strings.TrimSpace(r.FormValue("user"))
+	if user == "" {
+		user = "user"
+	}
+	s.logf("<cyan>[share]</> user %q uploaded file %s(%s)\n", user, fi.Name, mathutil.DataSize(uint64(fi.Size)))
+
+	s.hub.broadcast(&Message{Type: TypeFile, User: user, File: fi})
+	httputil.WriteJSON(w, http.StatusOK, fi)
+}
+
+func (s *Server) saveFile(name string, src io.Reader) (*FileInfo, error) {
+	s.mu.Lock()
+	s.seq++
+	id := strconv.FormatInt(s.seq, 10)
+	s.mu.Unlock()
+
+	// save as {id}_{name} for avoid name conflict
+	fPath := filepath.Join(s.FileDir, id+"_"+name)
+	dst, err := os.Create(fPath)
+	if err != nil {
+		return nil, err
+	}
+	defer dst.Close()
+
+	size, err := io.Copy(dst, src)
+	if err != nil {
+		_ = os.Remove(fPath)
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	fi := &FileInfo{
+		ID:   id,
+		Name: name,
+		Size: size,
+		MIME: mime.TypeByExtension(ext),
+		path: fPath,
+	}
+	if fi.MIME == "" {
+		fi.MIME = "application/octet-stream"
+	}
+
+	switch {
+	case textExts[ext]:
+		fi.Preview = "text"
+	case strings.HasPrefix(fi.MIME, "image/"):
+		fi.Preview = "image"
+	case strings.HasPrefix(fi.MIME, "video/"):
+		fi.Preview = "video"
+	case strings.HasPrefix(fi.MIME, "audio/"):
+		fi.Preview = "audio"
+	}
+
+	s.mu.Lock()
+	s.files[id] = fi
+	s.mu.Unlock()
+	return fi, nil
+}
+
+// File get uploaded file info by ID
+func (s *Server) File(id string) *FileInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.files[id]
+}
+
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	fi := s.File(r.PathValue("id"))
+	if fi == nil {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+
+	// only inline the preview files. the svg image maybe contains scripts, so always download it.
+	disposition := "inline"
+	if r.URL.Query().Get("dl") == "1" || fi.Preview == "" || fi.MIME == "image/svg+xml" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": fi.Name}))
+
+	// text file as plain text for preview, avoid run the html content.
+	if fi.Preview == "text" || strings.HasPrefix(fi.MIME, "text/html") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", fi.MIME)
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// disallow run scripts on open the file directly
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	http.ServeFile(w, r, fi.path)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	httputil.WriteJSON(w, status, map[string]any{"error": msg})
+}
@@ -0,0 +1,234 @@
+package sharesrv
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// MaxTextSize max size of a websocket text message
+var MaxTextSize = 1 << 20
+
+// message types
+const (
+	TypeText   = "text"
+	TypeFile   = "file"
+	TypeJoin   = "join"
+	TypeLeave  = "leave"
+	TypeRename = "rename"
+	TypeUsers  = "users"
+	TypeError  = "error"
+)
+
+// Message of the chat room
+type Message struct {
+	ID   int64  `json:"id,omitempty"`
+	Type string `json:"type"`
+	User string `json:"user,omitempty"`
+	Text string `json:"text,omitempty"`
+	// File info on Type=file
+	File *FileInfo `json:"file,omitempty"`
+	// Users online user names on Type=users
+	Users []string `json:"users,omitempty"`
+	// Time unix milliseconds
+	Time int64 `json:"time,omitempty"`
+}
+
+type client struct {
+	name string
+	conn *websocket.Conn
+	send chan *Message
+}
+
+// hub of the online clients, broadcast messages to all clients.
+type hub struct {
+	mu      sync.Mutex
+	seq     int64
+	clients map[*client]bool
+	// recent text and file messages
+	history []*Message
+	maxSize int
+}
+
+func newHub(history int) *hub {
+	return &hub{clients: make(map[*client]bool), maxSize: history}
+}
+
+// join add client, send the history messages to it.
+func (h *hub) join(c *client) {
+	h.mu.Lock()
+	c.name = h.uniqueName(c.name, nil)
+	h.clients[c] = true
+	for _, m := range h.history {
+		c.send <- m
+	}
+	h.mu.Unlock()
+
+	// tell the client its final name
+	h.sendTo(c, &Message{Type: TypeRename, User: c.name})
+	h.broadcast(&Message{Type: TypeJoin, User: c.name})
+	h.broadcastUsers()
+}
+
+func (h *hub) leave(c *client) {
+	h.mu.Lock()
+	if !h.clients[c] {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.clients, c)
+	close(c.send)
+	h.mu.Unlock()
+
+	h.broadcast(&Message{Type: TypeLeave, User: c.name})
+	h.broadcastUsers()
+}
+
+func (h *hub) rename(c *client, name string) {
+	h.mu.Lock()
+	old := c.name
+	c.name = h.uniqueName(name, c)
+	h.mu.Unlock()
+
+	h.sendTo(c, &Message{Type: TypeRename, User: c.name})
+	if old != c.name {
+		h.broadcast(&Message{Type: TypeRename, User: c.name, Text: old})
+		h.broadcastUsers()
+	}
+}
+
+// uniqueName append number suffix on the name exists. must be called under lock.
+func (h *hub) uniqueName(name string, self *client) string {
+	name = strings.TrimSpace(name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	if name == "" {
+		name = "user"
+	}
+
+	exists := func(n string) bool {
+		for c := range h.clients {
+			if c != self && c.name == n {
+				return true
+			}
+		}
+		return false
+	}
+
+	newName := name
+	for i := 2; exists(newName); i++ {
+		newName = name + "-" + strconv.Itoa(i)
+	}
+	return newName
+}
+
+// broadcast message to all clients. text and file messages will be kept in history.
+func (h *hub) broadcast(m *Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	m.ID = h.seq
+	m.Time = time.Now().UnixMilli()
+	if m.Type == TypeText || m.Type == TypeFile {
+		h.history = append(h.history, m)
+		if len(h.history) > h.maxSize {
+			h.history = h.history[len(h.history)-h.maxSize:]
+		}
+	}
+
+	for c := range h.clients {
+		h.push(c, m)
+	}
+}
+
+func (h *hub) broadcastUsers() {
+	h.mu.Lock()
+	users := make([]string, 0, len(h.clients))
+	for c := range h.clients {
+		users = append(users, c.name)
+	}
+	h.mu.Unlock()
+
+	h.broadcast(&Message{Type: TypeUsers, Users: users})
+}
+
+func (h *hub) sendTo(c *client, m *Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[c] {
+		h.push(c, m)
+	}
+}
+
+// push message to client, will drop the slow client. must be called under lock.
+func (h *hub) push(c *client, m *Message) {
+	select {
+	case c.send <- m:
+	default:
+		delete(h.clients, c)
+		close(c.send)
+		_ = c.conn.Close()
+	}
+}
+
+func (h *hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		delete(h.clients, c)
+		close(c.send)
+		_ = c.conn.Close()
+	}
+}
+
+func (s *Server) wsHandler() websocket.Server {
+	// no Handshake func: allow any origin, it's a LAN tool.
+	return websocket.Server{Handler: func(conn *websocket.Conn) {
+		conn.MaxPayloadBytes = MaxTextSize
+		c := &client{
+			name: conn.Request().URL.Query().Get("name"),
+			conn: conn,
+			send: make(chan *Message, 256+s.History),
+		}
+
+		// writer
+		go func() {
+			for m := range c.send {
+				if err := websocket.JSON.Send(conn, m); err != nil {
+					_ = conn.Close()
+				}
+			}
+		}()
+
+		s.hub.join(c)
+		s.logf("<cyan>[share]</> user %q joined from %s\n", c.name, conn.Request().RemoteAddr)
+		defer func() {
+			s.hub.leave(c)
+			_ = conn.Close()
+			s.logf("<cyan>[share]</> user %q left\n", c.name)
+		}()
+
+		for {
+			var in Message
+			if err := websocket.JSON.Receive(conn, &in); err != nil {
+				return
+			}
+
+			switch in.Type {
+			case TypeText:
+				if strings.TrimSpace(in.Text) != "" {
+					s.hub.broadcast(&Message{Type: TypeText, User: c.name, Text: in.Text})
+				}
+			case TypeRename:
+				s.hub.rename(c, in.User)
+			default:
+				s.hub.sendTo(c, &Message{Type: TypeError, Text: "unknown message type: " + in.Type})
+			}
+		}
+	}}
+}
@@ -0,0 +1,201 @@
+// Package sharesrv provides a browser based LAN chat room, for share text snippets and files between machines.
+//
+// Routes:
+//
+//	GET  /             the chat page
+//	GET  /ws?name=tom  websocket connection for chat messages
+//	POST /upload       upload a file by multipart field "file", will broadcast a file message
+//	GET  /files/{id}   get the uploaded file. query: dl=1 for download
+package sharesrv
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gookit/goutil/fsutil"
+	"github.com/gookit/goutil/netutil"
+	"github.com/gookit/goutil/x/ccolor"
+)
+
+//go:embed assets/index.html
+var indexHTML []byte
+
+// DefaultMaxSize of the upload file: 50MB
+const DefaultMaxSize int64 = 50 << 20
+
+// AllowExts default allowed upload file extensions: documents, images, videos, audios and archives.
+var AllowExts = []string{
+	// documents
+	".txt", ".md", ".log", ".csv", ".json", ".yml", ".yaml", ".xml", ".toml", ".ini", ".sql", ".html",
+	".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx",
+	// images
+	".png", ".jpg", ".jpeg", ".gif", ".webp", ".bmp", ".svg", ".ico",
+	// videos
+	".mp4", ".webm", ".mov", ".avi", ".mkv",
+	// audios
+	".mp3", ".wav", ".ogg", ".flac", ".m4a",
+	// archives
+	".zip", ".tar", ".gz", ".tgz", ".bz2", ".xz", ".7z", ".rar",
+}
+
+// text file extensions for preview on page
+var textExts = map[string]bool{
+	".txt": true, ".md": true, ".log": true, ".csv": true, ".json": true, ".yml": true, ".yaml": true,
+	".xml": true, ".toml": true, ".ini": true, ".sql": true,
+}
+
+// Config for the share server
+type Config struct {
+	Host string `json:"host"`
+	Port uint   `json:"port"`
+	// MaxSize of the upload file, unit: byte. default is DefaultMaxSize
+	MaxSize int64 `json:"max_size"`
+	// AllowExts allowed upload file extensions, default is AllowExts. "*" for allow all.
+	AllowExts []string `json:"allow_exts"`
+	// History max number of recent messages for send to new user. default is 100
+	History int `json:"history"`
+	// FileDir for save the uploaded files. default will create a temp dir and remove it on exit.
+	FileDir string `json:"file_dir"`
+	// Quiet mode, dont print logs
+	Quiet bool `json:"quiet"`
+}
+
+// Addr of the server listen
+func (c *Config) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.FormatUint(uint64(c.Port), 10))
+}
+
+// Server of share chat room. implemented http.Handler
+type Server struct {
+	*Config
+	hub *hub
+	mux *http.ServeMux
+	// tempDir created on FileDir is empty. will remove on Close
+	tempDir string
+
+	mu    sync.RWMutex
+	seq   int64
+	files map[string]*FileInfo
+}
+
+// New share server
+func New(c *Config) *Server {
+	if c.MaxSize <= 0 {
+		c.MaxSize = DefaultMaxSize
+	}
+	if len(c.AllowExts) == 0 {
+		c.AllowExts = AllowExts
+	}
+	if c.History <= 0 {
+		c.History = 100
+	}
+
+	s := &Server{
+		Config: c,
+		hub:    newHub(c.History),
+		files:  make(map[string]*FileInfo),
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.Handle("GET /ws", s.wsHandler())
+	s.mux.HandleFunc("POST /upload", s.handleUpload)
+	s.mux.HandleFunc("GET /files/{id}", s.handleFile)
+	return s
+}
+
+// Init the file dir. will create a temp dir on FileDir is empty.
+func (s *Server) Init() error {
+	if s.FileDir != "" {
+		return fsutil.MkdirQuick(s.FileDir)
+	}
+
+	dir, err := os.MkdirTemp("", "kite-share-*")
+	if err != nil {
+		return err
+	}
+	s.FileDir, s.tempDir = dir, dir
+	return nil
+}
+
+// Close the server, remove the temp file dir.
+func (s *Server) Close() error {
+	s.hub.closeAll()
+	if s.tempDir != "" {
+		return os.RemoveAll(s.tempDir)
+	}
+	return nil
+}
+
+// Start the server, will stop and clean files on receive exit signal.
+func (s *Server) Start() error {
+	if err := s.Init(); err != nil {
+		return err
+	}
+	defer s.Close()
+
+	srv := &http.Server{Addr: s.Addr(), Handler: s}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		sdCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sdCtx)
+	}()
+
+	ccolor.Infof("Share server listening on http://%s\n", s.Addr())
+	if s.Host == "" || s.Host == "0.0.0.0" {
+		ips, _ := netutil.AllLocalIPv4()
+		for _, ip := range ips {
+			ccolor.Printf(" - open <cyan>http://%s:%d</> on other machines\n", ip, s.Port)
+		}
+	}
+	ccolor.Printf(" - files are saved in %s\n", s.FileDir)
+
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		ccolor.Infoln("Share server stopped, clean the files")
+		return nil
+	}
+	return err
+}
+
+// ServeHTTP handle the requests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(indexHTML)
+}
+
+// AllowExt check the file extension is allowed for upload
+func (c *Config) AllowExt(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, allow := range c.AllowExts {
+		if allow == "*" || strings.ToLower(allow) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if !s.Quiet {
+		ccolor.Printf(format, args...)
+	}
+}
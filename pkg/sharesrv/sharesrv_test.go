@@ -0,0 +1,120 @@
+package sharesrv
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"golang.org/x/net/websocket"
+)
+
+func TestServer_chat_upload(t *testing.T) {
+	s := New(&Config{MaxSize: 100, Quiet: true})
+	assert.NoErr(t, s.Init())
+	dir := s.FileDir
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?name="
+	dial := func(name string) *websocket.Conn {
+		conn, err := websocket.Dial(wsURL+name, "", srv.URL)
+		assert.NoErr(t, err)
+		return conn
+	}
+	// receive until the message type matched
+	recv := func(conn *websocket.Conn, typ string) *Message {
+		assert.NoErr(t, conn.SetReadDeadline(time.Now().Add(3*time.Second)))
+		for {
+			m := &Message{}
+			assert.NoErr(t, websocket.JSON.Receive(conn, m))
+			if m.Type == typ {
+				return m
+			}
+		}
+	}
+
+	c1 := dial("tom")
+	defer c1.Close()
+	assert.Eq(t, "tom", recv(c1, TypeRename).User)
+	assert.Eq(t, "tom", recv(c1, TypeJoin).User)
+
+	// same name will add suffix
+	c2 := dial("tom")
+	defer c2.Close()
+	assert.Eq(t, "tom-2", recv(c2, TypeRename).User)
+	assert.Eq(t, "tom-2", recv(c1, TypeJoin).User)
+
+	assert.NoErr(t, websocket.JSON.Send(c2, &Message{Type: TypeText, Text: "hello\nworld"}))
+	m := recv(c1, TypeText)
+	assert.Eq(t, "tom-2", m.User)
+	assert.Eq(t, "hello\nworld", m.Text)
+
+	upload := func(name, content string) *http.Response {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		_ = mw.WriteField("user", "tom")
+		fw, err := mw.CreateFormFile("file", name)
+		assert.NoErr(t, err)
+		_, _ = io.WriteString(fw, content)
+		assert.NoErr(t, mw.Close())
+
+		resp, err := http.Post(srv.URL+"/upload", mw.FormDataContentType(), &buf)
+		assert.NoErr(t, err)
+		return resp
+	}
+
+	resp := upload("notes.txt", "some notes")
+	_ = resp.Body.Close()
+	assert.Eq(t, http.StatusOK, resp.StatusCode)
+
+	m = recv(c2, TypeFile)
+	assert.Eq(t, "notes.txt", m.File.Name)
+	assert.Eq(t, "text", m.File.Preview)
+
+	resp, err := http.Get(srv.URL + "/files/" + m.File.ID + "?dl=1")
+	assert.NoErr(t, err)
+	bs, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Eq(t, "some notes", string(bs))
+	assert.StrContains(t, resp.Header.Get("Content-Disposition"), "attachment")
+
+	// svg image maybe contains scripts, should not inline
+	resp = upload("x.svg", `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)
+	_ = resp.Body.Close()
+	assert.Eq(t, http.StatusOK, resp.StatusCode)
+	m = recv(c2, TypeFile)
+	assert.Eq(t, "image", m.File.Preview)
+
+	resp, err = http.Get(srv.URL + "/files/" + m.File.ID)
+	assert.NoErr(t, err)
+	_ = resp.Body.Close()
+	assert.StrContains(t, resp.Header.Get("Content-Disposition"), "attachment")
+	assert.Eq(t, "sandbox", resp.Header.Get("Content-Security-Policy"))
+
+	// not allowed type and too large
+	resp = upload("run.exe", "bin")
+	_ = resp.Body.Close()
+	assert.Eq(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	resp = upload("big.txt", strings.Repeat("a", 101))
+	_ = resp.Body.Close()
+	assert.Eq(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	// new user will receive the history
+	c3 := dial("")
+	defer c3.Close()
+	assert.Eq(t, "hello\nworld", recv(c3, TypeText).Text)
+	assert.Eq(t, "user", recv(c3, TypeRename).User)
+
+	// clean the temp dir
+	assert.NoErr(t, s.Close())
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
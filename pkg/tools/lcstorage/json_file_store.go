@@ -68,7 +68,7 @@ func (s *FileStorage) doStore() error {
 	}
 
 	if err1 := os.WriteFile(s.filePath, dataBytes, 0644); err1 != nil {
-		return fmt.Errorf("failed to write storage file: %w", err)
+		return fmt.Errorf("failed to write storage file: %w", err1)
 	}
 	return nil
 }
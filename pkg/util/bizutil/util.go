@@ -57,3 +57,55 @@ func NewTxtRender(engine, varFmt string) (RenderFn, error) {
 		return nil, errorx.Errf("invalid engine name %q", engine)
 	}
 }
+
+// ShellQuote quote string by single quotes for use in shell command.
+// the inner single quote will be closed, escaped and reopened.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// cmdMetaChars the special chars of Windows cmd.exe, need escape by ^
+const cmdMetaChars = `()%!^"<>&|`
+
+// CmdQuote quote string for use in Windows cmd.exe command line(eg: cmd /C LINE).
+//
+// the string is quoted by the C runtime argv rules first, then all cmd meta chars are escaped by "^".
+func CmdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\v\""+cmdMetaChars) {
+		return s
+	}
+
+	// quote by the C runtime argv rules
+	var sb strings.Builder
+	sb.WriteByte('"')
+	var slashes int
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			slashes++
+		case '"':
+			// escape the backslashes before quote and the quote self
+			sb.WriteString(strings.Repeat(`\`, slashes*2+1))
+			sb.WriteByte(c)
+			slashes = 0
+		default:
+			sb.WriteString(strings.Repeat(`\`, slashes))
+			sb.WriteByte(c)
+			slashes = 0
+		}
+	}
+	// the backslashes before the end quote need escape
+	sb.WriteString(strings.Repeat(`\`, slashes*2))
+	sb.WriteByte('"')
+
+	// escape the cmd meta chars, include the quotes
+	quoted := sb.String()
+	sb.Reset()
+	for i := 0; i < len(quoted); i++ {
+		if strings.IndexByte(cmdMetaChars, quoted[i]) >= 0 {
+			sb.WriteByte('^')
+		}
+		sb.WriteByte(quoted[i])
+	}
+	return sb.String()
+}
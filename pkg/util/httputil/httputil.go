@@ -0,0 +1,27 @@
+package httputil
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// WriteJSON write the data as JSON response with the status code.
+func WriteJSON(w http.ResponseWriter, status int, data any) {
+	writeJSON(w, status, data, false)
+}
+
+// WriteJSONIndent write the data as indented JSON response with the status code.
+func WriteJSONIndent(w http.ResponseWriter, status int, data any) {
+	writeJSON(w, status, data, true)
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any, indent bool) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	_ = enc.Encode(data)
+}